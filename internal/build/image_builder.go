@@ -230,24 +230,10 @@ func (d *dockerImageBuilder) PushImage(ctx context.Context, ref reference.NamedT
 	span, ctx := opentracing.StartSpanFromContext(ctx, "daemon-PushImage")
 	defer span.Finish()
 
-	repoInfo, err := registry.ParseRepositoryInfo(ref)
-	if err != nil {
-		return nil, errors.Wrap(err, "PushImage#ParseRepositoryInfo")
-	}
-
 	l.Infof("%sconnecting to repository", prefix)
-	cli := command.NewDockerCli(nil, writer, writer, true)
-
-	err = cli.Initialize(cliflags.NewClientOptions())
+	encodedAuth, requestPrivilege, err := registryAuth(ctx, ref, writer, "push")
 	if err != nil {
-		return nil, errors.Wrap(err, "PushImage#InitializeCLI")
-	}
-	authConfig := command.ResolveAuthConfig(ctx, cli, repoInfo.Index)
-	requestPrivilege := command.RegistryAuthenticationPrivilegedFunc(cli, repoInfo.Index, "push")
-
-	encodedAuth, err := command.EncodeAuthToBase64(authConfig)
-	if err != nil {
-		return nil, errors.Wrap(err, "PushImage#EncodeAuthToBase64")
+		return nil, errors.Wrap(err, "PushImage")
 	}
 
 	options := types.ImagePushOptions{
@@ -283,6 +269,29 @@ func (d *dockerImageBuilder) PushImage(ctx context.Context, ref reference.NamedT
 	return ref, nil
 }
 
+// Resolve the credentials for the registry that hosts the given image,
+// the same way the docker CLI does.
+func registryAuth(ctx context.Context, ref reference.Named, writer io.Writer, cmdName string) (string, types.RequestPrivilegeFunc, error) {
+	repoInfo, err := registry.ParseRepositoryInfo(ref)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "ParseRepositoryInfo")
+	}
+
+	cli := command.NewDockerCli(nil, writer, writer, true)
+	err = cli.Initialize(cliflags.NewClientOptions())
+	if err != nil {
+		return "", nil, errors.Wrap(err, "InitializeCLI")
+	}
+	authConfig := command.ResolveAuthConfig(ctx, cli, repoInfo.Index)
+	requestPrivilege := command.RegistryAuthenticationPrivilegedFunc(cli, repoInfo.Index, cmdName)
+
+	encodedAuth, err := command.EncodeAuthToBase64(authConfig)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "EncodeAuthToBase64")
+	}
+	return encodedAuth, requestPrivilege, nil
+}
+
 func (d *dockerImageBuilder) buildFromDf(ctx context.Context, ps *PipelineState, df dockerfile.Dockerfile, paths []PathMapping, filter model.PathMatcher, ref reference.Named, buildArgs model.DockerBuildArgs) (reference.NamedTagged, error) {
 	logger.Get(ctx).Infof("Building Dockerfile:\n%s\n", indent(df.String(), "  "))
 	span, ctx := opentracing.StartSpanFromContext(ctx, "daemon-buildFromDf")
@@ -0,0 +1,242 @@
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"github.com/windmilleng/wmclient/pkg/dirs"
+
+	"github.com/windmilleng/tilt/internal/container"
+	"github.com/windmilleng/tilt/internal/docker"
+	"github.com/windmilleng/tilt/internal/ignore"
+	"github.com/windmilleng/tilt/internal/logger"
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+// The state file, relative to the windmill dir.
+const imageCacheFile = "tilt/build_cache.json"
+
+type imageCacheEntry struct {
+	// A content hash of everything that goes into the image.
+	Hash string
+
+	// The image we built from those contents.
+	Ref string
+}
+
+// Remembers the last image we built for each image target, across Tilt sessions,
+// so that we don't rebuild images that haven't changed since the last `tilt up`.
+type ImageCache struct {
+	dCli docker.Client
+	dir  *dirs.WindmillDir
+	mu   sync.Mutex
+}
+
+func NewImageCache(dCli docker.Client, dir *dirs.WindmillDir) *ImageCache {
+	return &ImageCache{
+		dCli: dCli,
+		dir:  dir,
+	}
+}
+
+// Returns the image we built for this target from the same contents in an earlier
+// session, if it still exists. If checkRegistry is set, we'll also look for the image
+// in its registry, and inRegistry reports whether we found it there (so that it doesn't
+// need to be pushed again).
+func (c *ImageCache) Lookup(ctx context.Context, id model.TargetID, hash string, checkRegistry bool) (ref reference.NamedTagged, inRegistry bool, err error) {
+	if hash == "" {
+		return nil, false, nil
+	}
+
+	entries, err := c.read()
+	if err != nil {
+		return nil, false, err
+	}
+
+	entry, ok := entries[id.String()]
+	if !ok || entry.Hash != hash {
+		return nil, false, nil
+	}
+
+	ref, err = container.ParseNamedTagged(entry.Ref)
+	if err != nil {
+		// A corrupt entry just means we need to build again.
+		logger.Get(ctx).Debugf("Ignoring cached image %q: %v", entry.Ref, err)
+		return nil, false, nil
+	}
+
+	if checkRegistry && reference.Domain(ref) != "" {
+		encodedAuth, _, err := registryAuth(ctx, ref, ioutil.Discard, "pull")
+		if err == nil {
+			_, err = c.dCli.DistributionInspect(ctx, ref.String(), encodedAuth)
+		}
+		if err == nil {
+			return ref, true, nil
+		}
+		logger.Get(ctx).Debugf("Cached image %s not found in registry: %v", ref, err)
+	}
+
+	_, _, err = c.dCli.ImageInspectWithRaw(ctx, ref.String())
+	if err == nil {
+		return ref, false, nil
+	} else if !client.IsErrNotFound(err) {
+		return nil, false, errors.Wrap(err, "ImageCache#Lookup")
+	}
+	return nil, false, nil
+}
+
+// Record that we built this image target from contents with the given hash.
+func (c *ImageCache) Save(id model.TargetID, hash string, ref reference.NamedTagged) error {
+	if hash == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.readLocked()
+	if err != nil {
+		// If the file is corrupt, start over.
+		entries = make(map[string]imageCacheEntry)
+	}
+
+	entries[id.String()] = imageCacheEntry{Hash: hash, Ref: ref.String()}
+	contents, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "ImageCache#Save")
+	}
+
+	err = c.dir.WriteFile(imageCacheFile, string(contents))
+	if err != nil {
+		return errors.Wrap(err, "ImageCache#Save")
+	}
+	return nil
+}
+
+func (c *ImageCache) read() (map[string]imageCacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.readLocked()
+}
+
+func (c *ImageCache) readLocked() (map[string]imageCacheEntry, error) {
+	entries := make(map[string]imageCacheEntry)
+	contents, err := c.dir.ReadFile(imageCacheFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, errors.Wrap(err, "ImageCache")
+	}
+
+	err = json.Unmarshal([]byte(contents), &entries)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ImageCache: parsing %s", imageCacheFile)
+	}
+	return entries, nil
+}
+
+// Computes a content hash of everything that goes into building the image:
+// the Dockerfile, the build args, and the files in the build context.
+//
+// Returns an empty hash for images that we can't hash (e.g., custom builds,
+// where we don't know what the command reads).
+func ImageTargetHash(iTarget model.ImageTarget) (string, error) {
+	h := sha256.New()
+	filter := ignore.CreateBuildContextFilter(iTarget)
+
+	switch bd := iTarget.BuildDetails.(type) {
+	case model.DockerBuild:
+		fmt.Fprintf(h, "dockerfile:%q\n", bd.Dockerfile)
+
+		keys := make([]string, 0, len(bd.BuildArgs))
+		for k := range bd.BuildArgs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(h, "arg:%q=%q\n", k, bd.BuildArgs[k])
+		}
+
+		err := hashPath(h, bd.BuildPath, filter)
+		if err != nil {
+			return "", err
+		}
+	case model.FastBuild:
+		fmt.Fprintf(h, "dockerfile:%q\n", bd.BaseDockerfile)
+		for _, sync := range bd.Syncs {
+			fmt.Fprintf(h, "sync:%q\n", sync.ContainerPath)
+			err := hashPath(h, sync.LocalPath, filter)
+			if err != nil {
+				return "", err
+			}
+		}
+		for _, run := range bd.Runs {
+			fmt.Fprintf(h, "run:%q:%q\n", run.Cmd.Argv, run.Triggers.Paths)
+		}
+		fmt.Fprintf(h, "entrypoint:%q\n", bd.Entrypoint.Argv)
+	default:
+		return "", nil
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// Hash the names, modes and contents of all the files under root that
+// end up in the build context.
+func hashPath(h hash.Hash, root string, filter model.PathMatcher) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "error walking to %s", path)
+		}
+
+		matches, err := filter.Matches(path, info.IsDir())
+		if err != nil {
+			return err
+		}
+		if matches {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "file:%q:%s\n", filepath.ToSlash(rel), info.Mode())
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			linkname, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "link:%q\n", linkname)
+		case info.Mode().IsRegular():
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(h, f)
+			_ = f.Close()
+			if err != nil {
+				return errors.Wrapf(err, "reading %s", path)
+			}
+		}
+		return nil
+	})
+}
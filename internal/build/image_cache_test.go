@@ -0,0 +1,131 @@
+package build
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/windmilleng/wmclient/pkg/dirs"
+
+	"github.com/windmilleng/tilt/internal/container"
+	"github.com/windmilleng/tilt/internal/docker"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/testutils/output"
+	"github.com/windmilleng/tilt/internal/testutils/tempdir"
+)
+
+func TestImageTargetHashChangesWithContents(t *testing.T) {
+	f := newImageCacheFixture(t)
+	defer f.TearDown()
+
+	f.WriteFile("src/main.go", "package main")
+	iTarget := f.dockerTarget(nil)
+	h1 := f.hash(iTarget)
+	assert.Equal(t, h1, f.hash(iTarget))
+
+	f.WriteFile("src/main.go", "package main\n\nfunc main() {}")
+	h2 := f.hash(iTarget)
+	assert.NotEqual(t, h1, h2)
+
+	f.WriteFile("src/other.go", "package main")
+	h3 := f.hash(iTarget)
+	assert.NotEqual(t, h2, h3)
+
+	h4 := f.hash(f.dockerTarget(model.DockerBuildArgs{"VERSION": "2"}))
+	assert.NotEqual(t, h3, h4)
+}
+
+func TestImageTargetHashIgnoresDockerignoredFiles(t *testing.T) {
+	f := newImageCacheFixture(t)
+	defer f.TearDown()
+
+	f.WriteFile("src/main.go", "package main")
+	iTarget := f.dockerTarget(nil).WithDockerignores([]model.Dockerignore{
+		{LocalPath: f.JoinPath("src"), Contents: "*.log"},
+	})
+	h1 := f.hash(iTarget)
+
+	f.WriteFile("src/debug.log", "hello")
+	assert.Equal(t, h1, f.hash(iTarget))
+}
+
+func TestImageTargetHashCustomBuild(t *testing.T) {
+	iTarget := model.NewImageTarget(container.MustParseSelector("gcr.io/foo")).
+		WithBuildDetails(model.CustomBuild{Command: "make", Deps: []string{"."}})
+	h, err := ImageTargetHash(iTarget)
+	assert.NoError(t, err)
+	assert.Equal(t, "", h)
+}
+
+func TestImageCacheLookup(t *testing.T) {
+	f := newImageCacheFixture(t)
+	defer f.TearDown()
+
+	id := model.TargetID{Type: model.TargetTypeImage, Name: "gcr.io/foo"}
+	ref := container.MustParseNamedTagged("gcr.io/foo:tilt-1234")
+	err := f.cache.Save(id, "abc", ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Not in the local daemon or the registry.
+	f.assertLookup(id, "abc", true, nil, false)
+
+	f.dCli.Images[ref.String()] = types.ImageInspect{}
+	f.assertLookup(id, "abc", true, ref, false)
+	f.assertLookup(id, "def", true, nil, false)
+
+	// A new cache, so that we read from the state file.
+	f.cache = NewImageCache(f.dCli, f.dir)
+	f.assertLookup(id, "abc", false, ref, false)
+}
+
+type imageCacheFixture struct {
+	*tempdir.TempDirFixture
+	ctx   context.Context
+	dCli  *docker.FakeClient
+	dir   *dirs.WindmillDir
+	cache *ImageCache
+}
+
+func newImageCacheFixture(t *testing.T) *imageCacheFixture {
+	f := tempdir.NewTempDirFixture(t)
+	dCli := docker.NewFakeClient()
+	dir := dirs.NewWindmillDirAt(f.JoinPath(".windmill"))
+	return &imageCacheFixture{
+		TempDirFixture: f,
+		ctx:            output.CtxForTest(),
+		dCli:           dCli,
+		dir:            dir,
+		cache:          NewImageCache(dCli, dir),
+	}
+}
+
+func (f *imageCacheFixture) dockerTarget(args model.DockerBuildArgs) model.ImageTarget {
+	return model.NewImageTarget(container.MustParseSelector("gcr.io/foo")).
+		WithBuildDetails(model.DockerBuild{
+			Dockerfile: "FROM alpine",
+			BuildPath:  f.JoinPath("src"),
+			BuildArgs:  args,
+		})
+}
+
+func (f *imageCacheFixture) hash(iTarget model.ImageTarget) string {
+	h, err := ImageTargetHash(iTarget)
+	if err != nil {
+		f.T().Fatal(err)
+	}
+	return h
+}
+
+func (f *imageCacheFixture) assertLookup(id model.TargetID, hash string, checkRegistry bool,
+	expectedRef reference.NamedTagged, expectedInRegistry bool) {
+	ref, inRegistry, err := f.cache.Lookup(f.ctx, id, hash, checkRegistry)
+	if err != nil {
+		f.T().Fatal(err)
+	}
+	assert.Equal(f.T(), expectedRef, ref)
+	assert.Equal(f.T(), expectedInRegistry, inRegistry)
+}
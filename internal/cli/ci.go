@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+
+	"github.com/windmilleng/tilt/internal/engine"
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/logger"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/tiltfile"
+)
+
+const DefaultCITimeout = 30 * time.Minute
+
+type ciCmd struct {
+	fileName          string
+	timeout           time.Duration
+	maxParallelBuilds int
+	namespace         string
+
+	tiltfileArgs
+}
+
+func (c *ciCmd) register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci [<name>] [<name2>] [...] [-- <Tiltfile args>]",
+		Short: "build and deploy resources once, wait until they're ready, then exit",
+		Long: `Builds and deploys every resource once, then waits until they're all ready.
+
+Exits with status 0 when all resources are ready, or with a non-zero status as
+soon as the Tiltfile, a build, a deploy or a running resource fails, or when
+the timeout expires.`,
+	}
+
+	cmd.Flags().StringVar(&c.fileName, "file", tiltfile.FileName, "Path to Tiltfile")
+	cmd.Flags().DurationVar(&c.timeout, "timeout", DefaultCITimeout, "How long to wait for all resources to be ready before failing")
+	cmd.Flags().IntVar(&c.maxParallelBuilds, "max-parallel-builds", 0, "Maximum number of resources to build at once. If 0, uses the Tiltfile setting (default 1)")
+	cmd.Flags().StringVar(&c.namespace, "namespace", "", "Deploy all Kubernetes objects to this namespace, creating it if needed. Overrides the Tiltfile's namespace()")
+	cmd.Flags().BoolVar(&logActionsFlag, "logactions", false, "log all actions and state changes")
+	cmd.Flags().Lookup("logactions").Hidden = true
+
+	return cmd
+}
+
+func (c *ciCmd) run(ctx context.Context, args []string) error {
+	analyticsService.Incr("cmd.ci", map[string]string{
+		"count": fmt.Sprintf("%d", len(args)),
+	})
+	defer analyticsService.Flush(time.Second)
+
+	if c.namespace != "" {
+		err := k8s.ValidateNamespace(c.namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	threads, err := wireThreads(ctx)
+	if err != nil {
+		return err
+	}
+
+	upper := threads.upper
+
+	// There's no HUD in CI, so print the log stream as we go.
+	l := engine.NewLogActionLogger(ctx, upper.Dispatch)
+	ctx = logger.WithLogger(ctx, l)
+	ctx = logger.CtxWithForkedOutput(ctx, os.Stdout)
+
+	log.SetOutput(logger.Get(ctx).Writer(logger.InfoLvl))
+	klog.SetOutput(logger.Get(ctx).Writer(logger.InfoLvl))
+
+	logOutput(fmt.Sprintf("Starting Tilt in CI mode (%s)…", buildStamp()))
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	err = upper.Start(ctx, engine.StartOptions{
+		ResourceNames:     args,
+		TiltfileArgs:      c.tiltfileArgs,
+		TiltfilePath:      c.fileName,
+		TriggerMode:       model.TriggerAuto,
+		MaxParallelBuilds: c.maxParallelBuilds,
+		Namespace:         k8s.Namespace(c.namespace),
+		EngineMode:        store.EngineModeCI,
+	})
+	if err == context.DeadlineExceeded {
+		err = fmt.Errorf("Timed out after %s waiting for resources to be ready", c.timeout)
+	}
+
+	state := threads.store.RLockState()
+	printCISummary(os.Stdout, state)
+	threads.store.RUnlockState()
+
+	return err
+}
+
+func printCISummary(w io.Writer, state store.EngineState) {
+	targets := state.Targets()
+	if len(targets) == 0 {
+		return
+	}
+
+	width := 0
+	for _, mt := range targets {
+		if n := len(mt.Manifest.Name); n > width {
+			width = n
+		}
+	}
+
+	_, _ = fmt.Fprintln(w, "\nResources:")
+	for _, mt := range targets {
+		status := "not ready"
+		ready, err := mt.CIStatus()
+		if err != nil {
+			status = err.Error()
+		} else if ready {
+			status = "ready"
+		}
+		_, _ = fmt.Fprintf(w, "  %-*s  %s\n", width, mt.Manifest.Name, status)
+	}
+}
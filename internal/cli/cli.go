@@ -45,10 +45,13 @@ func Execute() {
 	}
 
 	addCommand(rootCmd, &upCmd{})
+	addCommand(rootCmd, &ciCmd{})
 	addCommand(rootCmd, &doctorCmd{})
 	addCommand(rootCmd, &downCmd{})
+	addCommand(rootCmd, &logsCmd{})
 	addCommand(rootCmd, &demoCmd{})
 	addCommand(rootCmd, &versionCmd{})
+	rootCmd.AddCommand(newSailCmd())
 
 	globalFlags := rootCmd.PersistentFlags()
 	globalFlags.BoolVarP(&debug, "debug", "d", false, "Enable debug logging")
@@ -72,6 +75,18 @@ type tiltCmd interface {
 	run(ctx context.Context, args []string) error
 }
 
+// A command that passes the args after `--` to the Tiltfile,
+// e.g., `tilt up frontend -- --env=staging`.
+type tiltfileArgsCmd interface {
+	setTiltfileArgs(args []string)
+}
+
+type tiltfileArgs []string
+
+func (a *tiltfileArgs) setTiltfileArgs(args []string) {
+	*a = args
+}
+
 func preCommand(ctx context.Context) (context.Context, func() error) {
 	cleanup := func() error { return nil }
 	initKlog()
@@ -115,9 +130,16 @@ func preCommand(ctx context.Context) (context.Context, func() error) {
 
 func addCommand(parent *cobra.Command, child tiltCmd) {
 	cobraChild := child.register()
-	cobraChild.Run = func(_ *cobra.Command, args []string) {
+	cobraChild.Run = func(cmd *cobra.Command, args []string) {
 		ctx, cleanup := preCommand(context.Background())
 
+		if tac, ok := child.(tiltfileArgsCmd); ok {
+			if n := cmd.ArgsLenAtDash(); n >= 0 {
+				tac.setTiltfileArgs(args[n:])
+				args = args[:n]
+			}
+		}
+
 		err := child.run(ctx, args)
 
 		err2 := cleanup()
@@ -3,28 +3,44 @@ package cli
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/windmilleng/tilt/internal/build"
 	"github.com/windmilleng/tilt/internal/engine"
 	"github.com/windmilleng/tilt/internal/k8s"
 	"github.com/windmilleng/tilt/internal/logger"
+	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/tiltfile"
 )
 
 type downCmd struct {
-	fileName string
+	fileName         string
+	deleteNamespaces bool
+	pruneImages      bool
+	namespace        string
+
+	tiltfileArgs
 }
 
 func (c *downCmd) register() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "down",
-		Short: "delete kubernetes resources",
+		Use:   "down [<name>] [<name2>] [...] [-- <Tiltfile args>]",
+		Short: "delete resources created by 'tilt up'",
+		Long: `Deletes the Kubernetes objects and docker-compose services in the Tiltfile.
+
+If resource names are given, only those resources are deleted. Otherwise,
+everything in the Tiltfile is deleted, including YAML that doesn't belong to
+any resource.`,
 	}
 
 	cmd.Flags().StringVar(&c.fileName, "file", tiltfile.FileName, "Path to Tiltfile")
+	cmd.Flags().BoolVar(&c.deleteNamespaces, "delete-namespaces", false, "Also delete Namespaces declared in the Tiltfile (and everything in them)")
+	cmd.Flags().StringVar(&c.namespace, "namespace", "", "The namespace passed to 'tilt up --namespace', if any. Overrides the Tiltfile's namespace()")
+	cmd.Flags().BoolVar(&c.pruneImages, "prune-images", false, "Also remove the images that Tilt built for these resources")
 
 	return cmd
 }
@@ -40,45 +56,133 @@ func (c *downCmd) run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	tlr, err := downDeps.tfl.Load(ctx, c.fileName, nil)
+	return c.down(ctx, downDeps, args)
+}
+
+func (c *downCmd) down(ctx context.Context, downDeps DownDeps, args []string) error {
+	var matching map[string]bool
+	if len(args) > 0 {
+		matching = make(map[string]bool, len(args))
+		for _, arg := range args {
+			matching[arg] = true
+		}
+	}
+
+	tlr, err := downDeps.tfl.Load(ctx, c.fileName, matching, c.tiltfileArgs)
 	if err != nil {
 		return err
 	}
+	downDeps.contextGuard.Allow(tlr.AllowedK8sContexts)
+
+	// When the user names resources, we leave the global YAML alone,
+	// because it may be shared by resources they didn't name.
+	all := len(matching) == 0
 
 	entities, err := engine.ParseYAMLFromManifests(tlr.Manifests...)
 	if err != nil {
 		return errors.Wrap(err, "Parsing manifest YAML")
 	}
-	gyamlEntities, err := k8s.ParseYAMLFromString(tlr.Global.K8sTarget().YAML)
-	if err != nil {
-		return errors.Wrap(err, "Parsing global YAML")
+	if all {
+		gyamlEntities, err := k8s.ParseYAMLFromString(tlr.Global.K8sTarget().YAML)
+		if err != nil {
+			return errors.Wrap(err, "Parsing global YAML")
+		}
+		entities = append(entities, gyamlEntities...)
 	}
-	entities = append(entities, gyamlEntities...)
 
-	err = downDeps.kClient.Delete(ctx, entities)
-	if err != nil {
-		logger.Get(ctx).Infof("error deleting k8s entities: %v", err)
+	namespace := tlr.K8sNamespace
+	if c.namespace != "" {
+		namespace = k8s.Namespace(c.namespace)
+	}
+	if namespace != "" {
+		entities, err = k8s.InjectNamespaceAll(entities, namespace)
+		if err != nil {
+			return errors.Wrap(err, "Injecting namespace")
+		}
+		if c.deleteNamespaces {
+			entities = append(entities, k8s.NewNamespaceEntity(namespace))
+		}
+	}
+
+	if !c.deleteNamespaces {
+		entities = withoutNamespaces(entities)
 	}
 
-	var dcConfigPath string
+	l := logger.Get(ctx)
+	if len(entities) > 0 {
+		err = downDeps.kClient.Delete(ctx, entities)
+		if err != nil {
+			l.Infof("error deleting k8s entities: %v", err)
+		}
+	}
+
+	// Group the services by config file, in the order we first see them.
+	var dcConfigPaths []string
+	dcServices := make(map[string][]model.TargetName)
 	for _, m := range tlr.Manifests {
-		if m.IsDC() {
-			// TODO(maia): when we support up-ing from multiple docker-compose files, we'll
-			// need to support down-ing as well. For now, we `down` the first one we find.
-			dcConfigPath = m.DockerComposeTarget().ConfigPath
-			break
+		if !m.IsDC() {
+			continue
 		}
+		dcTarget := m.DockerComposeTarget()
+		if _, ok := dcServices[dcTarget.ConfigPath]; !ok {
+			dcConfigPaths = append(dcConfigPaths, dcTarget.ConfigPath)
+		}
+		dcServices[dcTarget.ConfigPath] = append(dcServices[dcTarget.ConfigPath], dcTarget.Name)
 	}
 
-	if dcConfigPath != "" {
-		// TODO(maia): when we support up-ing from multiple docker-compose files, we'll need to support down-ing as well
-		// TODO(maia): a way to `down` specific services?
+	dcc := downDeps.dcClient
+	out := l.Writer(logger.InfoLvl)
+	for _, configPath := range dcConfigPaths {
+		if all {
+			err = dcc.Down(ctx, configPath, out, out)
+			if err != nil {
+				l.Infof("error running `docker-compose down`: %v", err)
+			}
+		} else {
+			err = dcc.Rm(ctx, configPath, dcServices[configPath], out, out)
+			if err != nil {
+				l.Infof("error running `docker-compose rm`: %v", err)
+			}
+		}
+	}
 
-		dcc := downDeps.dcClient
-		err = dcc.Down(ctx, dcConfigPath, logger.Get(ctx).Writer(logger.InfoLvl), logger.Get(ctx).Writer(logger.InfoLvl))
-		if err != nil {
-			logger.Get(ctx).Infof("error running `docker-compose down`: %v", err)
+	// `tilt up` records the serve_cmd processes that it starts, in case
+	// it dies without stopping them.
+	tiltfilePath, err := filepath.Abs(c.fileName)
+	if err != nil {
+		return err
+	}
+	var names []model.ManifestName
+	for _, arg := range args {
+		names = append(names, model.ManifestName(arg))
+	}
+	err = engine.KillLocalProcesses(ctx, downDeps.dir, tiltfilePath, names)
+	if err != nil {
+		l.Infof("error stopping local processes: %v", err)
+	}
+
+	if c.pruneImages {
+		for _, m := range tlr.Manifests {
+			for _, iTarget := range m.ImageTargets {
+				err = downDeps.reaper.RemoveTiltImages(ctx, time.Now(), true, build.FilterByRefName(iTarget.DeploymentRef))
+				if err != nil {
+					l.Infof("error removing images for %s: %v", m.Name, err)
+				}
+			}
 		}
 	}
 	return nil
 }
+
+// Deleting a namespace deletes everything in it, including objects
+// that Tilt didn't create, so we only do it when asked.
+func withoutNamespaces(entities []k8s.K8sEntity) []k8s.K8sEntity {
+	result := make([]k8s.K8sEntity, 0, len(entities))
+	for _, e := range entities {
+		if e.HasKind("Namespace") {
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
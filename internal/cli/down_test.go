@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/windmilleng/wmclient/pkg/dirs"
+
+	"github.com/windmilleng/tilt/internal/build"
+	"github.com/windmilleng/tilt/internal/container"
+	"github.com/windmilleng/tilt/internal/docker"
+	"github.com/windmilleng/tilt/internal/dockercompose"
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/k8s/testyaml"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/testutils/output"
+	"github.com/windmilleng/tilt/internal/testutils/tempdir"
+	"github.com/windmilleng/tilt/internal/tiltfile"
+)
+
+func TestDownAll(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	f.tfl.Manifests = []model.Manifest{
+		f.k8sManifest("sancho", testyaml.SanchoYAML),
+		f.dcManifest("redis", "/src/docker-compose.yml"),
+		f.dcManifest("web", "/src/docker-compose.yml"),
+		f.dcManifest("db", "/src/db/docker-compose.yml"),
+	}
+	f.tfl.Global = f.k8sManifest("global", testyaml.MyNamespaceYAML+"---\n"+testyaml.SecretYaml)
+
+	f.down(&downCmd{})
+
+	assert.Contains(t, f.kCli.DeletedYaml, "name: sancho")
+	assert.Contains(t, f.kCli.DeletedYaml, "kind: Secret")
+	assert.NotContains(t, f.kCli.DeletedYaml, "kind: Namespace")
+	assert.Equal(t, []string{"/src/docker-compose.yml", "/src/db/docker-compose.yml"}, f.dcCli.DownCalls)
+	assert.Empty(t, f.dcCli.RmCalls)
+	assert.Empty(t, f.dCli.RemovedImageIDs())
+}
+
+func TestDownDeleteNamespaces(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	f.tfl.Global = f.k8sManifest("global", testyaml.MyNamespaceYAML)
+
+	f.down(&downCmd{deleteNamespaces: true})
+
+	assert.Contains(t, f.kCli.DeletedYaml, "kind: Namespace")
+}
+
+func TestDownNamespaceFromTiltfile(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	f.tfl.Manifests = []model.Manifest{
+		f.k8sManifest("sancho", testyaml.SanchoYAML),
+	}
+	f.tfl.K8sNamespace = "dev-jane"
+
+	f.down(&downCmd{})
+
+	assert.Contains(t, f.kCli.DeletedYaml, "namespace: dev-jane")
+	assert.NotContains(t, f.kCli.DeletedYaml, "namespace: sancho-ns")
+	assert.NotContains(t, f.kCli.DeletedYaml, "kind: Namespace")
+}
+
+func TestDownNamespaceFlagDeleteNamespaces(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	f.tfl.Manifests = []model.Manifest{
+		f.k8sManifest("sancho", testyaml.SanchoYAML),
+	}
+	f.tfl.K8sNamespace = "dev-jane"
+
+	f.down(&downCmd{namespace: "dev-bob", deleteNamespaces: true})
+
+	assert.Contains(t, f.kCli.DeletedYaml, "namespace: dev-bob")
+	assert.Contains(t, f.kCli.DeletedYaml, "kind: Namespace")
+	assert.Contains(t, f.kCli.DeletedYaml, "name: dev-bob")
+}
+
+func TestDownNamedResources(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	// The loader only returns the resources that were asked for.
+	f.tfl.Manifests = []model.Manifest{
+		f.k8sManifest("sancho", testyaml.SanchoYAML),
+		f.dcManifest("web", "/src/docker-compose.yml"),
+	}
+	f.tfl.Global = f.k8sManifest("global", testyaml.SecretYaml)
+
+	f.down(&downCmd{}, "sancho", "web")
+
+	assert.Contains(t, f.kCli.DeletedYaml, "name: sancho")
+	assert.NotContains(t, f.kCli.DeletedYaml, "kind: Secret")
+	assert.Empty(t, f.dcCli.DownCalls)
+	assert.Equal(t, []dockercompose.RmCall{
+		{PathToConfig: "/src/docker-compose.yml", ServiceNames: []model.TargetName{"web"}},
+	}, f.dcCli.RmCalls)
+}
+
+func TestDownPruneImages(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	iTarget := model.NewImageTarget(container.MustParseSelector("gcr.io/some-project-162817/sancho"))
+	f.tfl.Manifests = []model.Manifest{
+		f.k8sManifest("sancho", testyaml.SanchoYAML).WithImageTarget(iTarget),
+	}
+	f.dCli.BuildCount = 2
+
+	f.down(&downCmd{pruneImages: true})
+
+	assert.Equal(t, []string{"build-id-0", "build-id-1"}, f.dCli.RemovedImageIDs())
+}
+
+func TestDownKillsLocalProcesses(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	cmd := exec.Command("sh", "-c", "sleep 60")
+	cmd.Env = append(os.Environ(), "TILT_LOCAL_PROCESS_ID=down-test")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	err := cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan error)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	defer func() {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}()
+
+	tiltfilePath := f.JoinPath("Tiltfile")
+	f.WriteFile("windmill/tilt/local_processes.json", fmt.Sprintf(`[{
+  "TiltfilePath": %q,
+  "ManifestName": "server",
+  "OwnerPID": %d,
+  "PGID": %d,
+  "ID": "down-test"
+}]`, tiltfilePath, os.Getpid(), cmd.Process.Pid))
+
+	f.down(&downCmd{fileName: tiltfilePath})
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the local process to be killed")
+	}
+	contents, err := ioutil.ReadFile(f.JoinPath("windmill/tilt/local_processes.json"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "[]", string(contents))
+	}
+}
+
+type downFixture struct {
+	*tempdir.TempDirFixture
+	t     *testing.T
+	ctx   context.Context
+	tfl   *tiltfile.FakeTiltfileLoader
+	dcCli *dockercompose.FakeDCClient
+	kCli  *k8s.FakeK8sClient
+	dCli  *docker.FakeClient
+}
+
+func newDownFixture(t *testing.T) *downFixture {
+	ctx := output.CtxForTest()
+	return &downFixture{
+		TempDirFixture: tempdir.NewTempDirFixture(t),
+		t:              t,
+		ctx:            ctx,
+		tfl:            tiltfile.NewFakeTiltfileLoader(),
+		dcCli:          dockercompose.NewFakeDockerComposeClient(t, ctx),
+		kCli:           k8s.NewFakeK8sClient(),
+		dCli:           docker.NewFakeClient(),
+	}
+}
+
+func (f *downFixture) k8sManifest(name string, yaml string) model.Manifest {
+	return model.Manifest{Name: model.ManifestName(name)}.WithDeployTarget(model.K8sTarget{YAML: yaml})
+}
+
+func (f *downFixture) dcManifest(name string, configPath string) model.Manifest {
+	return model.Manifest{Name: model.ManifestName(name)}.WithDeployTarget(model.DockerComposeTarget{
+		Name:       model.TargetName(name),
+		ConfigPath: configPath,
+	})
+}
+
+func (f *downFixture) down(c *downCmd, args ...string) {
+	deps := ProvideDownDeps(f.tfl, f.dcCli, f.kCli, build.NewImageReaper(f.dCli), dirs.NewWindmillDirAt(f.JoinPath("windmill")), k8s.ProvideContextGuard(k8s.EnvDockerDesktop, "docker-for-desktop"))
+	err := c.down(f.ctx, deps, args)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/windmilleng/tilt/internal/store/logstore"
+)
+
+// How often we ask 'tilt up' for new lines in follow mode.
+const logsPollInterval = 500 * time.Millisecond
+
+// How many lines we ask 'tilt up' for at a time, so that a long
+// history comes back in pages instead of one huge response.
+const logsPageLines = 1000
+
+type logsCmd struct {
+	port       int
+	follow     bool
+	since      time.Duration
+	timestamps bool
+	json       bool
+
+	out    io.Writer
+	errOut io.Writer
+}
+
+func (c *logsCmd) register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs [resource...]",
+		Short: "print the logs of a running 'tilt up'",
+		Long: `Print the logs of a running 'tilt up'.
+
+Without any resources, prints Tilt's own log, which has the output of every
+resource, the way the terminal shows it. With resources, prints only
+the logs of those resources.`,
+	}
+
+	cmd.Flags().IntVar(&c.port, "port", DefaultWebPort, "Port of the HTTP server of the running 'tilt up'")
+	cmd.Flags().BoolVarP(&c.follow, "follow", "f", false, "Keep printing new logs as they come in")
+	cmd.Flags().DurationVar(&c.since, "since", 0, "Only print logs newer than a relative duration, like 30s or 5m")
+	cmd.Flags().BoolVarP(&c.timestamps, "timestamps", "t", false, "Print the time of each line")
+	cmd.Flags().BoolVar(&c.json, "json", false, "Print each line as a JSON object, with metadata about where it came from")
+	return cmd
+}
+
+func (c *logsCmd) run(ctx context.Context, args []string) error {
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	if c.errOut == nil {
+		c.errOut = os.Stderr
+	}
+
+	params := url.Values{}
+	if len(args) == 0 {
+		params.Add("name", "")
+	}
+	for _, name := range args {
+		params.Add("name", name)
+	}
+	if c.since > 0 {
+		params.Set("since", time.Now().Add(-c.since).Format(time.RFC3339))
+	}
+
+	// Prefix each line with its resource, so that you can tell them apart.
+	// Tilt's log already tells them apart the way the terminal does.
+	tiltLog := len(args) == 0
+	showNames := len(args) > 1
+
+	params.Set("limit", strconv.Itoa(logsPageLines))
+
+	checkpoint := 0
+	for {
+		params.Set("from", strconv.Itoa(checkpoint))
+		result, err := c.getLogs(ctx, params)
+		if ctx.Err() != nil {
+			// The user hit Ctrl-C.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if result.Truncated && checkpoint == 0 {
+			_, _ = fmt.Fprintln(c.errOut, "Some older lines are missing, because Tilt couldn't save them to disk.")
+		}
+
+		for _, line := range result.Lines {
+			err := c.printLine(line, tiltLog, showNames)
+			if err != nil {
+				return err
+			}
+		}
+		checkpoint = result.Checkpoint
+
+		if result.More {
+			// Read the rest of the history before we wait for new lines.
+			continue
+		}
+		if !c.follow {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(logsPollInterval):
+		}
+	}
+}
+
+func (c *logsCmd) getLogs(ctx context.Context, params url.Values) (logstore.QueryResult, error) {
+	endpoint := fmt.Sprintf("http://localhost:%d/api/logs?%s", c.port, params.Encode())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return logstore.QueryResult{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return logstore.QueryResult{}, fmt.Errorf("Could not reach 'tilt up' on port %d. Is it running?\n%v", c.port, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return logstore.QueryResult{}, fmt.Errorf("%s", strings.TrimSpace(string(body)))
+	}
+
+	var result logstore.QueryResult
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return logstore.QueryResult{}, fmt.Errorf("reading logs: %v", err)
+	}
+	return result, nil
+}
+
+func (c *logsCmd) printLine(line logstore.Line, tiltLog, showName bool) error {
+	if c.json {
+		return json.NewEncoder(c.out).Encode(line)
+	}
+
+	text := line.Text
+	if tiltLog {
+		text = line.TiltLogText()
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if showName {
+		text = fmt.Sprintf("[%s] %s", line.ManifestName, text)
+	}
+	if c.timestamps {
+		text = fmt.Sprintf("%s %s", line.Time.Format("2006/01/02 15:04:05"), text)
+	}
+
+	_, err := io.WriteString(c.out, text)
+	return err
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store/logstore"
+	"github.com/windmilleng/tilt/internal/testutils/output"
+)
+
+func TestLogsTiltLog(t *testing.T) {
+	var names []string
+	server, port := startFakeTiltServer(t, func(w http.ResponseWriter, req *http.Request) {
+		names = req.URL.Query()["name"]
+		writeLogs(t, w, 3, logLine(0, "", "Starting Tilt\n"), logLine(1, "fe", "hello\n"), logLine(2, "", "[Tiltfile] cut off"))
+	})
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	cmd := &logsCmd{port: port, out: out}
+	err := cmd.run(output.CtxForTest(), nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{""}, names)
+		assert.Equal(t, "Starting Tilt\nfe          ┊ hello\n[Tiltfile] cut off\n", out.String())
+	}
+}
+
+func TestLogsResourcesWithTimestamps(t *testing.T) {
+	var names []string
+	server, port := startFakeTiltServer(t, func(w http.ResponseWriter, req *http.Request) {
+		names = req.URL.Query()["name"]
+		writeLogs(t, w, 2, logLine(0, "fe", "hello\n"), logLine(1, "be", "hi\n"))
+	})
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	cmd := &logsCmd{port: port, timestamps: true, out: out}
+	err := cmd.run(output.CtxForTest(), []string{"fe", "be"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"fe", "be"}, names)
+		assert.Equal(t, "2019/06/01 12:00:00 [fe] hello\n2019/06/01 12:00:00 [be] hi\n", out.String())
+	}
+}
+
+func TestLogsJSON(t *testing.T) {
+	server, port := startFakeTiltServer(t, func(w http.ResponseWriter, req *http.Request) {
+		writeLogs(t, w, 1, logLine(0, "fe", "hello\n"))
+	})
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	cmd := &logsCmd{port: port, json: true, out: out}
+	err := cmd.run(output.CtxForTest(), []string{"fe"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var line logstore.Line
+	err = json.Unmarshal(out.Bytes(), &line)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.ManifestName("fe"), line.ManifestName)
+		assert.Equal(t, logstore.SourcePod, line.Source)
+		assert.Equal(t, "hello\n", line.Text)
+	}
+}
+
+func TestLogsFollow(t *testing.T) {
+	ctx, cancel := context.WithCancel(output.CtxForTest())
+	defer cancel()
+
+	var froms []string
+	server, port := startFakeTiltServer(t, func(w http.ResponseWriter, req *http.Request) {
+		from := req.URL.Query().Get("from")
+		froms = append(froms, from)
+		switch from {
+		case "0":
+			writeLogs(t, w, 1, logLine(0, "fe", "line 0\n"))
+		case "1":
+			writeLogs(t, w, 3, logLine(1, "fe", "line 1\n"), logLine(2, "fe", "line 2\n"))
+		default:
+			cancel()
+			writeLogs(t, w, 3)
+		}
+	})
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	cmd := &logsCmd{port: port, follow: true, out: out}
+	err := cmd.run(ctx, []string{"fe"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"0", "1", "3"}, froms)
+		assert.Equal(t, "line 0\nline 1\nline 2\n", out.String())
+	}
+}
+
+func TestLogsPages(t *testing.T) {
+	var froms []string
+	var limit string
+	server, port := startFakeTiltServer(t, func(w http.ResponseWriter, req *http.Request) {
+		from := req.URL.Query().Get("from")
+		froms = append(froms, from)
+		limit = req.URL.Query().Get("limit")
+		switch from {
+		case "0":
+			writePage(t, w, 2, true, logLine(0, "fe", "line 0\n"), logLine(1, "fe", "line 1\n"))
+		default:
+			writeLogs(t, w, 3, logLine(2, "fe", "line 2\n"))
+		}
+	})
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	cmd := &logsCmd{port: port, out: out}
+	err := cmd.run(output.CtxForTest(), []string{"fe"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"0", "2"}, froms)
+		assert.Equal(t, "1000", limit)
+		assert.Equal(t, "line 0\nline 1\nline 2\n", out.String())
+	}
+}
+
+func TestLogsSince(t *testing.T) {
+	var since time.Time
+	server, port := startFakeTiltServer(t, func(w http.ResponseWriter, req *http.Request) {
+		var err error
+		since, err = time.Parse(time.RFC3339, req.URL.Query().Get("since"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeLogs(t, w, 0)
+	})
+	defer server.Close()
+
+	cmd := &logsCmd{port: port, since: 5 * time.Minute, out: &bytes.Buffer{}}
+	err := cmd.run(output.CtxForTest(), nil)
+	if assert.NoError(t, err) {
+		assert.WithinDuration(t, time.Now().Add(-5*time.Minute), since, 5*time.Second)
+	}
+}
+
+func TestLogsError(t *testing.T) {
+	server, port := startFakeTiltServer(t, func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, `no resource found with name "fe"`, http.StatusNotFound)
+	})
+	defer server.Close()
+
+	cmd := &logsCmd{port: port, out: &bytes.Buffer{}}
+	err := cmd.run(output.CtxForTest(), []string{"fe"})
+	if assert.Error(t, err) {
+		assert.Equal(t, `no resource found with name "fe"`, err.Error())
+	}
+}
+
+func logLine(seq int, mn model.ManifestName, text string) logstore.Line {
+	return logstore.Line{
+		Meta: logstore.Meta{
+			Time:         time.Date(2019, 6, 1, 12, 0, 0, 0, time.Local),
+			ManifestName: mn,
+			Source:       logstore.SourcePod,
+			Level:        logstore.LevelInfo,
+		},
+		Seq:  seq,
+		Text: text,
+	}
+}
+
+func writeLogs(t *testing.T, w http.ResponseWriter, checkpoint int, lines ...logstore.Line) {
+	writePage(t, w, checkpoint, false, lines...)
+}
+
+func writePage(t *testing.T, w http.ResponseWriter, checkpoint int, more bool, lines ...logstore.Line) {
+	err := json.NewEncoder(w).Encode(logstore.QueryResult{Lines: lines, Checkpoint: checkpoint, More: more})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Commands for managing the Sail room that a running `tilt up` shares its session in.
+func newSailCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "sail",
+		Short:  "manage the Sail room that a running 'tilt up' is shared in",
+		Hidden: true,
+	}
+
+	addCommand(cmd, &sailRoomCmd{action: "revoke", short: "disconnect everyone following this Tilt session, and change the follow URL"})
+	addCommand(cmd, &sailRoomCmd{action: "close", short: "stop sharing this Tilt session, and disconnect everyone following it"})
+	return cmd
+}
+
+type sailRoomCmd struct {
+	action string
+	short  string
+	port   int
+}
+
+func (c *sailRoomCmd) register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   c.action,
+		Short: c.short,
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.Flags().IntVar(&c.port, "port", DefaultWebPort, "Port of the HTTP server of the running 'tilt up'")
+	return cmd
+}
+
+// Asks the running `tilt up` to manage its room, because only it
+// knows the room's secret token.
+func (c *sailRoomCmd) run(ctx context.Context, args []string) error {
+	url := fmt.Sprintf("http://localhost:%d/api/sail/%s", c.port, c.action)
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("Could not reach 'tilt up' on port %d. Is it running?\n%v", c.port, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s", strings.TrimSpace(string(body)))
+	}
+
+	switch c.action {
+	case "revoke":
+		fmt.Println("Revoked all followers. 'tilt up' logs the new follow URL.")
+	case "close":
+		fmt.Println("Stopped sharing this Tilt session.")
+	}
+	return nil
+}
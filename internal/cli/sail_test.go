@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/testutils/output"
+)
+
+func TestSailRevoke(t *testing.T) {
+	var path, contentType string
+	server, port := startFakeTiltServer(t, func(w http.ResponseWriter, req *http.Request) {
+		path = req.URL.Path
+		contentType = req.Header.Get("Content-Type")
+	})
+	defer server.Close()
+
+	cmd := &sailRoomCmd{action: "revoke", port: port}
+	err := cmd.run(output.CtxForTest(), nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/api/sail/revoke", path)
+		assert.Equal(t, "application/json", contentType)
+	}
+}
+
+func TestSailCloseError(t *testing.T) {
+	server, port := startFakeTiltServer(t, func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "error closing room: This Tilt session isn't shared in a Sail room", http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	cmd := &sailRoomCmd{action: "close", port: port}
+	err := cmd.run(output.CtxForTest(), nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, "error closing room: This Tilt session isn't shared in a Sail room", err.Error())
+	}
+}
+
+// Starts a fake of the HTTP server of 'tilt up' on a random port.
+func startFakeTiltServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, int) {
+	server := httptest.NewServer(handler)
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, port
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/windmilleng/tilt/internal/build"
 	"github.com/windmilleng/tilt/internal/engine"
 	"github.com/windmilleng/tilt/internal/hud"
+	"github.com/windmilleng/tilt/internal/k8s"
 	"github.com/windmilleng/tilt/internal/logger"
 	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/output"
@@ -41,12 +42,22 @@ type upCmd struct {
 	hud         bool
 	autoDeploy  bool
 	fileName    string
+
+	maxParallelBuilds int
+	namespace         string
+
+	tiltfileArgs
 }
 
 func (c *upCmd) register() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "up [<name>] [<name2>] [...]",
+		Use:   "up [<name>] [<name2>] [...] [-- <Tiltfile args>]",
 		Short: "stand up one or more manifests",
+		Long: `Starts Tilt and builds and deploys the resources in the Tiltfile.
+
+If resource names are given, only those resources are started.
+
+Args after -- are passed to the Tiltfile, which reads them with config.parse().`,
 	}
 
 	cmd.Flags().BoolVar(&c.watch, "watch", true, "If true, services will be automatically rebuilt and redeployed when files change. Otherwise, each service will be started once.")
@@ -65,6 +76,8 @@ func (c *upCmd) register() *cobra.Command {
 	cmd.Flags().BoolVar(&enableSail, "enable-sail", false, "Open a connection to the sail server on startup")
 	cmd.Flags().Lookup("logactions").Hidden = true
 	cmd.Flags().StringVar(&c.fileName, "file", tiltfile.FileName, "Path to Tiltfile")
+	cmd.Flags().IntVar(&c.maxParallelBuilds, "max-parallel-builds", 0, "Maximum number of resources to build at once. If 0, uses the Tiltfile setting (default 1)")
+	cmd.Flags().StringVar(&c.namespace, "namespace", "", "Deploy all Kubernetes objects to this namespace, creating it if needed. Overrides the Tiltfile's namespace()")
 	err := cmd.Flags().MarkHidden("image-tag-prefix")
 	if err != nil {
 		panic(err)
@@ -140,6 +153,13 @@ func (c *upCmd) run(ctx context.Context, args []string) error {
 		})
 	}
 
+	if c.namespace != "" {
+		err := k8s.ValidateNamespace(c.namespace)
+		if err != nil {
+			return err
+		}
+	}
+
 	triggerMode := model.TriggerAuto
 	if !c.autoDeploy {
 		triggerMode = model.TriggerManual
@@ -147,7 +167,17 @@ func (c *upCmd) run(ctx context.Context, args []string) error {
 
 	g.Go(func() error {
 		defer cancel()
-		return upper.Start(ctx, args, c.watch, triggerMode, c.fileName, c.hud)
+		return upper.Start(ctx, engine.StartOptions{
+			ResourceNames:     args,
+			TiltfileArgs:      c.tiltfileArgs,
+			TiltfilePath:      c.fileName,
+			Watch:             c.watch,
+			TriggerMode:       triggerMode,
+			MaxParallelBuilds: c.maxParallelBuilds,
+			Namespace:         k8s.Namespace(c.namespace),
+			UseActionWriter:   c.hud,
+			EngineMode:        store.EngineModeUp,
+		})
 	})
 
 	err = g.Wait()
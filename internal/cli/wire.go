@@ -9,6 +9,7 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/google/wire"
+	"github.com/windmilleng/wmclient/pkg/dirs"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/tools/clientcmd/api"
 
@@ -39,6 +40,7 @@ var K8sWireSet = wire.NewSet(
 	k8s.ProvideKubectlRunner,
 	k8s.ProvideContainerRuntime,
 	k8s.ProvideServerVersion,
+	k8s.ProvideContextGuard,
 	k8s.ProvideK8sClient)
 
 var BaseWireSet = wire.NewSet(
@@ -53,6 +55,8 @@ var BaseWireSet = wire.NewSet(
 
 	build.NewImageReaper,
 
+	dirs.UseWindmillDir,
+
 	tiltfile.ProvideTiltfileLoader,
 
 	engine.DeployerWireSet,
@@ -61,10 +65,16 @@ var BaseWireSet = wire.NewSet(
 	engine.NewBuildController,
 	engine.NewPodWatcher,
 	engine.NewServiceWatcher,
+	engine.NewEventWatchManager,
+	engine.NewJobWatcher,
 	engine.NewImageController,
+	engine.NewKubernetesPruner,
 	engine.NewConfigsController,
 	engine.NewDockerComposeEventWatcher,
 	engine.NewDockerComposeLogManager,
+	engine.NewLocalProcessManager,
+	engine.NewProbeController,
+	engine.NewResourceRestarter,
 	engine.NewProfilerManager,
 
 	provideClock,
@@ -114,10 +124,11 @@ func wireThreads(ctx context.Context) (Threads, error) {
 type Threads struct {
 	hud   hud.HeadsUpDisplay
 	upper engine.Upper
+	store *store.Store
 }
 
-func provideThreads(h hud.HeadsUpDisplay, upper engine.Upper) Threads {
-	return Threads{h, upper}
+func provideThreads(h hud.HeadsUpDisplay, upper engine.Upper, st *store.Store) Threads {
+	return Threads{h, upper, st}
 }
 
 func wireK8sClient(ctx context.Context) (k8s.Client, error) {
@@ -174,16 +185,26 @@ type DownDeps struct {
 	tfl      tiltfile.TiltfileLoader
 	dcClient dockercompose.DockerComposeClient
 	kClient  k8s.Client
+	reaper   build.ImageReaper
+	dir      *dirs.WindmillDir
+
+	contextGuard *k8s.ContextGuard
 }
 
 func ProvideDownDeps(
 	tfl tiltfile.TiltfileLoader,
 	dcClient dockercompose.DockerComposeClient,
-	kClient k8s.Client) DownDeps {
+	kClient k8s.Client,
+	reaper build.ImageReaper,
+	dir *dirs.WindmillDir,
+	contextGuard *k8s.ContextGuard) DownDeps {
 	return DownDeps{
-		tfl:      tfl,
-		dcClient: dcClient,
-		kClient:  kClient,
+		tfl:          tfl,
+		dcClient:     dcClient,
+		kClient:      kClient,
+		reaper:       reaper,
+		dir:          dir,
+		contextGuard: contextGuard,
 	}
 }
 
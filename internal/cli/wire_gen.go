@@ -23,6 +23,7 @@ import (
 	"github.com/windmilleng/tilt/internal/sail/client"
 	"github.com/windmilleng/tilt/internal/store"
 	"github.com/windmilleng/tilt/internal/tiltfile"
+	"github.com/windmilleng/wmclient/pkg/dirs"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/tools/clientcmd/api"
 	"time"
@@ -62,13 +63,16 @@ func wireDemo(ctx context.Context, branch demo.RepoBranch) (demo.Script, error)
 		return demo.Script{}, err
 	}
 	kubectlRunner := k8s.ProvideKubectlRunner(kubeContext)
-	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig)
+	contextGuard := k8s.ProvideContextGuard(env, kubeContext)
+	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig, contextGuard)
 	podWatcher := engine.NewPodWatcher(k8sClient)
 	nodeIP, err := k8s.DetectNodeIP(ctx, env)
 	if err != nil {
 		return demo.Script{}, err
 	}
 	serviceWatcher := engine.NewServiceWatcher(k8sClient, nodeIP)
+	eventWatchManager := engine.NewEventWatchManager(k8sClient, namespace)
+	jobWatcher := engine.NewJobWatcher(k8sClient)
 	podLogManager := engine.NewPodLogManager(k8sClient)
 	portForwardController := engine.NewPortForwardController(k8sClient)
 	fsWatcherMaker := engine.ProvideFsWatcherMaker()
@@ -108,20 +112,31 @@ func wireDemo(ctx context.Context, branch demo.RepoBranch) (demo.Script, error)
 	clock := build.ProvideClock()
 	execCustomBuilder := build.NewExecCustomBuilder(cli, dockerEnv, clock)
 	kindPusher := engine.NewKINDPusher()
-	imageBuildAndDeployer := engine.NewImageBuildAndDeployer(imageBuilder, cacheBuilder, execCustomBuilder, k8sClient, env, analytics, updateMode, clock, runtime, kindPusher)
+	windmillDir, err := dirs.UseWindmillDir()
+	if err != nil {
+		return demo.Script{}, err
+	}
+	imageCache := build.NewImageCache(cli, windmillDir)
+	k8sNamespaceEnsurer := engine.NewK8sNamespaceEnsurer(k8sClient)
+	imageBuildAndDeployer := engine.NewImageBuildAndDeployer(imageBuilder, cacheBuilder, execCustomBuilder, k8sClient, env, analytics, updateMode, clock, runtime, kindPusher, imageCache, k8sNamespaceEnsurer)
 	dockerComposeClient := dockercompose.NewDockerComposeClient(dockerEnv)
 	imageAndCacheBuilder := engine.NewImageAndCacheBuilder(imageBuilder, cacheBuilder, execCustomBuilder, updateMode)
 	dockerComposeBuildAndDeployer := engine.NewDockerComposeBuildAndDeployer(dockerComposeClient, cli, imageAndCacheBuilder, clock)
-	buildOrder := engine.DefaultBuildOrder(syncletBuildAndDeployer, localContainerBuildAndDeployer, imageBuildAndDeployer, dockerComposeBuildAndDeployer, env, updateMode, runtime)
+	localTargetBuildAndDeployer := engine.NewLocalTargetBuildAndDeployer(clock)
+	buildOrder := engine.DefaultBuildOrder(syncletBuildAndDeployer, localContainerBuildAndDeployer, imageBuildAndDeployer, dockerComposeBuildAndDeployer, localTargetBuildAndDeployer, env, updateMode, runtime)
 	compositeBuildAndDeployer := engine.NewCompositeBuildAndDeployer(buildOrder)
 	buildController := engine.NewBuildController(compositeBuildAndDeployer)
 	imageReaper := build.NewImageReaper(cli)
 	imageController := engine.NewImageController(imageReaper)
-	globalYAMLBuildController := engine.NewGlobalYAMLBuildController(k8sClient)
-	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics, dockerComposeClient)
-	configsController := engine.NewConfigsController(tiltfileLoader)
+	globalYAMLBuildController := engine.NewGlobalYAMLBuildController(k8sClient, k8sNamespaceEnsurer)
+	kubernetesPruner := engine.NewKubernetesPruner(k8sClient, namespace, windmillDir)
+	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics, dockerComposeClient, kubeContext, env)
+	configsController := engine.NewConfigsController(tiltfileLoader, contextGuard)
 	dockerComposeEventWatcher := engine.NewDockerComposeEventWatcher(dockerComposeClient)
 	dockerComposeLogManager := engine.NewDockerComposeLogManager(dockerComposeClient)
+	localProcessManager := engine.NewLocalProcessManager(windmillDir)
+	probeController := engine.NewProbeController()
+	resourceRestarter := engine.NewResourceRestarter(k8sClient, dockerComposeClient)
 	profilerManager := engine.NewProfilerManager()
 	analyticsReporter := engine.ProvideAnalyticsReporter(analytics, storeStore)
 	cliBuildInfo := provideBuildInfo()
@@ -135,16 +150,16 @@ func wireDemo(ctx context.Context, branch demo.RepoBranch) (demo.Script, error)
 	if err != nil {
 		return demo.Script{}, err
 	}
-	headsUpServer := server.ProvideHeadsUpServer(storeStore, assetServer, analytics)
-	headsUpServerController := server.ProvideHeadsUpServerController(modelWebPort, headsUpServer, assetServer)
 	sailDialer := client.ProvideSailDialer()
 	sailURL, err := provideSailURL()
 	if err != nil {
 		return demo.Script{}, err
 	}
 	sailClient := client.ProvideSailClient(sailDialer, sailURL)
-	v2 := engine.ProvideSubscribers(headsUpDisplay, podWatcher, serviceWatcher, podLogManager, portForwardController, watchManager, buildController, imageController, globalYAMLBuildController, configsController, dockerComposeEventWatcher, dockerComposeLogManager, profilerManager, syncletManager, analyticsReporter, headsUpServerController, sailClient)
-	upper := engine.NewUpper(ctx, storeStore, v2)
+	headsUpServer := server.ProvideHeadsUpServer(storeStore, assetServer, analytics, sailClient)
+	headsUpServerController := server.ProvideHeadsUpServerController(modelWebPort, headsUpServer, assetServer)
+	v2 := engine.ProvideSubscribers(headsUpDisplay, podWatcher, serviceWatcher, eventWatchManager, jobWatcher, podLogManager, portForwardController, watchManager, buildController, imageController, globalYAMLBuildController, kubernetesPruner, configsController, dockerComposeEventWatcher, dockerComposeLogManager, localProcessManager, probeController, resourceRestarter, profilerManager, syncletManager, analyticsReporter, headsUpServerController, sailClient)
+	upper := engine.NewUpper(ctx, storeStore, v2, kubeContext)
 	script := demo.NewScript(upper, headsUpDisplay, k8sClient, env, storeStore, branch, runtime, tiltfileLoader)
 	return script, nil
 }
@@ -186,13 +201,16 @@ func wireThreads(ctx context.Context) (Threads, error) {
 		return Threads{}, err
 	}
 	kubectlRunner := k8s.ProvideKubectlRunner(kubeContext)
-	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig)
+	contextGuard := k8s.ProvideContextGuard(env, kubeContext)
+	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig, contextGuard)
 	podWatcher := engine.NewPodWatcher(k8sClient)
 	nodeIP, err := k8s.DetectNodeIP(ctx, env)
 	if err != nil {
 		return Threads{}, err
 	}
 	serviceWatcher := engine.NewServiceWatcher(k8sClient, nodeIP)
+	eventWatchManager := engine.NewEventWatchManager(k8sClient, namespace)
+	jobWatcher := engine.NewJobWatcher(k8sClient)
 	podLogManager := engine.NewPodLogManager(k8sClient)
 	portForwardController := engine.NewPortForwardController(k8sClient)
 	fsWatcherMaker := engine.ProvideFsWatcherMaker()
@@ -232,20 +250,31 @@ func wireThreads(ctx context.Context) (Threads, error) {
 	clock := build.ProvideClock()
 	execCustomBuilder := build.NewExecCustomBuilder(cli, dockerEnv, clock)
 	kindPusher := engine.NewKINDPusher()
-	imageBuildAndDeployer := engine.NewImageBuildAndDeployer(imageBuilder, cacheBuilder, execCustomBuilder, k8sClient, env, analytics, updateMode, clock, runtime, kindPusher)
+	windmillDir, err := dirs.UseWindmillDir()
+	if err != nil {
+		return Threads{}, err
+	}
+	imageCache := build.NewImageCache(cli, windmillDir)
+	k8sNamespaceEnsurer := engine.NewK8sNamespaceEnsurer(k8sClient)
+	imageBuildAndDeployer := engine.NewImageBuildAndDeployer(imageBuilder, cacheBuilder, execCustomBuilder, k8sClient, env, analytics, updateMode, clock, runtime, kindPusher, imageCache, k8sNamespaceEnsurer)
 	dockerComposeClient := dockercompose.NewDockerComposeClient(dockerEnv)
 	imageAndCacheBuilder := engine.NewImageAndCacheBuilder(imageBuilder, cacheBuilder, execCustomBuilder, updateMode)
 	dockerComposeBuildAndDeployer := engine.NewDockerComposeBuildAndDeployer(dockerComposeClient, cli, imageAndCacheBuilder, clock)
-	buildOrder := engine.DefaultBuildOrder(syncletBuildAndDeployer, localContainerBuildAndDeployer, imageBuildAndDeployer, dockerComposeBuildAndDeployer, env, updateMode, runtime)
+	localTargetBuildAndDeployer := engine.NewLocalTargetBuildAndDeployer(clock)
+	buildOrder := engine.DefaultBuildOrder(syncletBuildAndDeployer, localContainerBuildAndDeployer, imageBuildAndDeployer, dockerComposeBuildAndDeployer, localTargetBuildAndDeployer, env, updateMode, runtime)
 	compositeBuildAndDeployer := engine.NewCompositeBuildAndDeployer(buildOrder)
 	buildController := engine.NewBuildController(compositeBuildAndDeployer)
 	imageReaper := build.NewImageReaper(cli)
 	imageController := engine.NewImageController(imageReaper)
-	globalYAMLBuildController := engine.NewGlobalYAMLBuildController(k8sClient)
-	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics, dockerComposeClient)
-	configsController := engine.NewConfigsController(tiltfileLoader)
+	globalYAMLBuildController := engine.NewGlobalYAMLBuildController(k8sClient, k8sNamespaceEnsurer)
+	kubernetesPruner := engine.NewKubernetesPruner(k8sClient, namespace, windmillDir)
+	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics, dockerComposeClient, kubeContext, env)
+	configsController := engine.NewConfigsController(tiltfileLoader, contextGuard)
 	dockerComposeEventWatcher := engine.NewDockerComposeEventWatcher(dockerComposeClient)
 	dockerComposeLogManager := engine.NewDockerComposeLogManager(dockerComposeClient)
+	localProcessManager := engine.NewLocalProcessManager(windmillDir)
+	probeController := engine.NewProbeController()
+	resourceRestarter := engine.NewResourceRestarter(k8sClient, dockerComposeClient)
 	profilerManager := engine.NewProfilerManager()
 	analyticsReporter := engine.ProvideAnalyticsReporter(analytics, storeStore)
 	cliBuildInfo := provideBuildInfo()
@@ -259,17 +288,17 @@ func wireThreads(ctx context.Context) (Threads, error) {
 	if err != nil {
 		return Threads{}, err
 	}
-	headsUpServer := server.ProvideHeadsUpServer(storeStore, assetServer, analytics)
-	headsUpServerController := server.ProvideHeadsUpServerController(modelWebPort, headsUpServer, assetServer)
 	sailDialer := client.ProvideSailDialer()
 	sailURL, err := provideSailURL()
 	if err != nil {
 		return Threads{}, err
 	}
 	sailClient := client.ProvideSailClient(sailDialer, sailURL)
-	v2 := engine.ProvideSubscribers(headsUpDisplay, podWatcher, serviceWatcher, podLogManager, portForwardController, watchManager, buildController, imageController, globalYAMLBuildController, configsController, dockerComposeEventWatcher, dockerComposeLogManager, profilerManager, syncletManager, analyticsReporter, headsUpServerController, sailClient)
-	upper := engine.NewUpper(ctx, storeStore, v2)
-	threads := provideThreads(headsUpDisplay, upper)
+	headsUpServer := server.ProvideHeadsUpServer(storeStore, assetServer, analytics, sailClient)
+	headsUpServerController := server.ProvideHeadsUpServerController(modelWebPort, headsUpServer, assetServer)
+	v2 := engine.ProvideSubscribers(headsUpDisplay, podWatcher, serviceWatcher, eventWatchManager, jobWatcher, podLogManager, portForwardController, watchManager, buildController, imageController, globalYAMLBuildController, kubernetesPruner, configsController, dockerComposeEventWatcher, dockerComposeLogManager, localProcessManager, probeController, resourceRestarter, profilerManager, syncletManager, analyticsReporter, headsUpServerController, sailClient)
+	upper := engine.NewUpper(ctx, storeStore, v2, kubeContext)
+	threads := provideThreads(headsUpDisplay, upper, storeStore)
 	return threads, nil
 }
 
@@ -287,7 +316,8 @@ func wireK8sClient(ctx context.Context) (k8s.Client, error) {
 		return nil, err
 	}
 	kubectlRunner := k8s.ProvideKubectlRunner(kubeContext)
-	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig)
+	contextGuard := k8s.ProvideContextGuard(env, kubeContext)
+	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig, contextGuard)
 	return k8sClient, nil
 }
 
@@ -343,7 +373,8 @@ func wireRuntime(ctx context.Context) (container.Runtime, error) {
 		return "", err
 	}
 	kubectlRunner := k8s.ProvideKubectlRunner(kubeContext)
-	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig)
+	contextGuard := k8s.ProvideContextGuard(env, kubeContext)
+	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig, contextGuard)
 	runtime := k8s.ProvideContainerRuntime(ctx, k8sClient)
 	return runtime, nil
 }
@@ -379,7 +410,8 @@ func wireDockerVersion(ctx context.Context) (types.Version, error) {
 		return types.Version{}, err
 	}
 	kubectlRunner := k8s.ProvideKubectlRunner(kubeContext)
-	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig)
+	contextGuard := k8s.ProvideContextGuard(env, kubeContext)
+	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig, contextGuard)
 	runtime := k8s.ProvideContainerRuntime(ctx, k8sClient)
 	minikubeClient := minikube.ProvideMinikubeClient()
 	dockerEnv, err := docker.ProvideEnv(ctx, env, runtime, minikubeClient)
@@ -411,7 +443,8 @@ func wireDockerEnv(ctx context.Context) (docker.Env, error) {
 		return docker.Env{}, err
 	}
 	kubectlRunner := k8s.ProvideKubectlRunner(kubeContext)
-	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig)
+	contextGuard := k8s.ProvideContextGuard(env, kubeContext)
+	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig, contextGuard)
 	runtime := k8s.ProvideContainerRuntime(ctx, k8sClient)
 	minikubeClient := minikube.ProvideMinikubeClient()
 	dockerEnv, err := docker.ProvideEnv(ctx, env, runtime, minikubeClient)
@@ -439,7 +472,8 @@ func wireDownDeps(ctx context.Context) (DownDeps, error) {
 		return DownDeps{}, err
 	}
 	kubectlRunner := k8s.ProvideKubectlRunner(kubeContext)
-	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig)
+	contextGuard := k8s.ProvideContextGuard(env, kubeContext)
+	k8sClient := k8s.ProvideK8sClient(ctx, env, portForwarder, namespace, kubectlRunner, clientConfig, contextGuard)
 	runtime := k8s.ProvideContainerRuntime(ctx, k8sClient)
 	minikubeClient := minikube.ProvideMinikubeClient()
 	dockerEnv, err := docker.ProvideEnv(ctx, env, runtime, minikubeClient)
@@ -447,17 +481,34 @@ func wireDownDeps(ctx context.Context) (DownDeps, error) {
 		return DownDeps{}, err
 	}
 	dockerComposeClient := dockercompose.NewDockerComposeClient(dockerEnv)
-	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics, dockerComposeClient)
-	downDeps := ProvideDownDeps(tiltfileLoader, dockerComposeClient, k8sClient)
+	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics, dockerComposeClient, kubeContext, env)
+	clientClient, err := docker.ProvideDockerClient(ctx, dockerEnv)
+	if err != nil {
+		return DownDeps{}, err
+	}
+	version, err := docker.ProvideDockerVersion(ctx, clientClient)
+	if err != nil {
+		return DownDeps{}, err
+	}
+	cli, err := docker.DefaultClient(ctx, clientClient, version)
+	if err != nil {
+		return DownDeps{}, err
+	}
+	imageReaper := build.NewImageReaper(cli)
+	windmillDir, err := dirs.UseWindmillDir()
+	if err != nil {
+		return DownDeps{}, err
+	}
+	downDeps := ProvideDownDeps(tiltfileLoader, dockerComposeClient, k8sClient, imageReaper, windmillDir, contextGuard)
 	return downDeps, nil
 }
 
 // wire.go:
 
-var K8sWireSet = wire.NewSet(k8s.ProvideEnv, k8s.DetectNodeIP, k8s.ProvideKubeContext, k8s.ProvideKubeConfig, k8s.ProvideClientConfig, k8s.ProvideClientSet, k8s.ProvideRESTConfig, k8s.ProvidePortForwarder, k8s.ProvideConfigNamespace, k8s.ProvideKubectlRunner, k8s.ProvideContainerRuntime, k8s.ProvideServerVersion, k8s.ProvideK8sClient)
+var K8sWireSet = wire.NewSet(k8s.ProvideEnv, k8s.DetectNodeIP, k8s.ProvideKubeContext, k8s.ProvideKubeConfig, k8s.ProvideClientConfig, k8s.ProvideClientSet, k8s.ProvideRESTConfig, k8s.ProvidePortForwarder, k8s.ProvideConfigNamespace, k8s.ProvideKubectlRunner, k8s.ProvideContainerRuntime, k8s.ProvideServerVersion, k8s.ProvideContextGuard, k8s.ProvideK8sClient)
 
 var BaseWireSet = wire.NewSet(
-	K8sWireSet, docker.ProvideDockerClient, docker.ProvideDockerVersion, docker.DefaultClient, wire.Bind(new(docker.Client), new(docker.Cli)), dockercompose.NewDockerComposeClient, build.NewImageReaper, tiltfile.ProvideTiltfileLoader, engine.DeployerWireSet, engine.NewPodLogManager, engine.NewPortForwardController, engine.NewBuildController, engine.NewPodWatcher, engine.NewServiceWatcher, engine.NewImageController, engine.NewConfigsController, engine.NewDockerComposeEventWatcher, engine.NewDockerComposeLogManager, engine.NewProfilerManager, provideClock, hud.NewRenderer, hud.NewDefaultHeadsUpDisplay, provideLogActions, store.NewStore, wire.Bind(new(store.RStore), new(store.Store)), provideBuildInfo, engine.ProvideSubscribers, engine.NewUpper, provideAnalytics, engine.ProvideAnalyticsReporter, provideUpdateModeFlag, engine.NewWatchManager, engine.ProvideFsWatcherMaker, engine.ProvideTimerMaker, provideWebVersion,
+	K8sWireSet, docker.ProvideDockerClient, docker.ProvideDockerVersion, docker.DefaultClient, wire.Bind(new(docker.Client), new(docker.Cli)), dockercompose.NewDockerComposeClient, build.NewImageReaper, dirs.UseWindmillDir, tiltfile.ProvideTiltfileLoader, engine.DeployerWireSet, engine.NewPodLogManager, engine.NewPortForwardController, engine.NewBuildController, engine.NewPodWatcher, engine.NewServiceWatcher, engine.NewEventWatchManager, engine.NewJobWatcher, engine.NewImageController, engine.NewKubernetesPruner, engine.NewConfigsController, engine.NewDockerComposeEventWatcher, engine.NewDockerComposeLogManager, engine.NewLocalProcessManager, engine.NewProbeController, engine.NewResourceRestarter, engine.NewProfilerManager, provideClock, hud.NewRenderer, hud.NewDefaultHeadsUpDisplay, provideLogActions, store.NewStore, wire.Bind(new(store.RStore), new(store.Store)), provideBuildInfo, engine.ProvideSubscribers, engine.NewUpper, provideAnalytics, engine.ProvideAnalyticsReporter, provideUpdateModeFlag, engine.NewWatchManager, engine.ProvideFsWatcherMaker, engine.ProvideTimerMaker, provideWebVersion,
 	provideWebMode,
 	provideWebURL,
 	provideWebPort,
@@ -467,26 +518,37 @@ var BaseWireSet = wire.NewSet(
 type Threads struct {
 	hud   hud.HeadsUpDisplay
 	upper engine.Upper
+	store *store.Store
 }
 
-func provideThreads(h hud.HeadsUpDisplay, upper engine.Upper) Threads {
-	return Threads{h, upper}
+func provideThreads(h hud.HeadsUpDisplay, upper engine.Upper, st *store.Store) Threads {
+	return Threads{h, upper, st}
 }
 
 type DownDeps struct {
 	tfl      tiltfile.TiltfileLoader
 	dcClient dockercompose.DockerComposeClient
 	kClient  k8s.Client
+	reaper   build.ImageReaper
+	dir      *dirs.WindmillDir
+
+	contextGuard *k8s.ContextGuard
 }
 
 func ProvideDownDeps(
 	tfl tiltfile.TiltfileLoader,
 	dcClient dockercompose.DockerComposeClient,
-	kClient k8s.Client) DownDeps {
+	kClient k8s.Client,
+	reaper build.ImageReaper,
+	dir *dirs.WindmillDir,
+	contextGuard *k8s.ContextGuard) DownDeps {
 	return DownDeps{
-		tfl:      tfl,
-		dcClient: dcClient,
-		kClient:  kClient,
+		tfl:          tfl,
+		dcClient:     dcClient,
+		kClient:      kClient,
+		reaper:       reaper,
+		dir:          dir,
+		contextGuard: contextGuard,
 	}
 }
 
@@ -83,7 +83,7 @@ func (m *podMonitor) OnChange(ctx context.Context, st store.RStore) {
 		m.healthy = false
 	}
 
-	if state.CurrentlyBuilding != "" {
+	if state.IsBuilding() {
 		m.healthy = false
 	}
 
@@ -186,7 +186,7 @@ func (s Script) Run(ctx context.Context) error {
 
 		tfPath := filepath.Join(dir, tiltfile.FileName)
 		// TODO(dmiller): not this?
-		tlr, err := s.tfl.Load(ctx, tfPath, nil)
+		tlr, err := s.tfl.Load(ctx, tfPath, nil, nil)
 		if err != nil {
 			return err
 		}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/registry"
 	"github.com/windmilleng/tilt/internal/container"
 	"github.com/windmilleng/tilt/internal/model"
 )
@@ -90,9 +92,15 @@ type FakeClient struct {
 	ExecErrorToThrow error // next call to Exec will throw this err (after which we clear the error)
 
 	RestartsByContainer map[string]int
-	RemovedImageIDs     []string
+
+	// Images are removed concurrently, so this is guarded by mu.
+	mu              sync.Mutex
+	removedImageIDs []string
 
 	Images map[string]types.ImageInspect
+
+	// Images that can be found in the registry, keyed by ref.
+	RegistryImages map[string]registry.DistributionInspect
 }
 
 func NewFakeClient() *FakeClient {
@@ -102,6 +110,7 @@ func NewFakeClient() *FakeClient {
 		ContainerListOutput: make(map[string][]types.Container),
 		RestartsByContainer: make(map[string]int),
 		Images:              make(map[string]types.ImageInspect),
+		RegistryImages:      make(map[string]registry.DistributionInspect),
 	}
 }
 
@@ -203,11 +212,28 @@ func (c *FakeClient) ImageList(ctx context.Context, options types.ImageListOptio
 }
 
 func (c *FakeClient) ImageRemove(ctx context.Context, imageID string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error) {
-	c.RemovedImageIDs = append(c.RemovedImageIDs, imageID)
-	sort.Strings(c.RemovedImageIDs)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removedImageIDs = append(c.removedImageIDs, imageID)
+	sort.Strings(c.removedImageIDs)
 	return nil, nil
 }
 
+// The IDs of the images removed so far, sorted.
+func (c *FakeClient) RemovedImageIDs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.removedImageIDs...)
+}
+
+func (c *FakeClient) DistributionInspect(ctx context.Context, image, encodedRegistryAuth string) (registry.DistributionInspect, error) {
+	result, ok := c.RegistryImages[image]
+	if ok {
+		return result, nil
+	}
+	return registry.DistributionInspect{}, newNotFoundErrorf("fakeClient.RegistryImages key: %s", image)
+}
+
 var _ Client = &FakeClient{}
 
 type fakeDockerResponse struct {
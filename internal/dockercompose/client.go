@@ -20,6 +20,8 @@ import (
 type DockerComposeClient interface {
 	Up(ctx context.Context, configPath string, serviceName model.TargetName, shouldBuild bool, stdout, stderr io.Writer) error
 	Down(ctx context.Context, configPath string, stdout, stderr io.Writer) error
+	Rm(ctx context.Context, configPath string, serviceNames []model.TargetName, stdout, stderr io.Writer) error
+	Restart(ctx context.Context, configPath string, serviceName model.TargetName, stdout, stderr io.Writer) error
 	StreamLogs(ctx context.Context, configPath string, serviceName model.TargetName) (io.ReadCloser, error)
 	StreamEvents(ctx context.Context, configPath string) (<-chan string, error)
 	Config(ctx context.Context, configPath string) (string, error)
@@ -90,6 +92,37 @@ func (c *cmdDCClient) Down(ctx context.Context, configPath string, stdout, stder
 	return nil
 }
 
+// Stops and removes the containers of the given services, leaving the
+// rest of the project (and its networks) alone.
+func (c *cmdDCClient) Rm(ctx context.Context, configPath string, serviceNames []model.TargetName, stdout, stderr io.Writer) error {
+	var args []string
+	if logger.Get(ctx).Level() >= logger.VerboseLvl {
+		args = []string{"--verbose"}
+	}
+	args = append(args, "-f", configPath, "rm", "--stop", "--force")
+	for _, name := range serviceNames {
+		args = append(args, name.String())
+	}
+	cmd := c.dcCommand(ctx, args)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	return FormatError(cmd, nil, cmd.Run())
+}
+
+func (c *cmdDCClient) Restart(ctx context.Context, configPath string, serviceName model.TargetName, stdout, stderr io.Writer) error {
+	var args []string
+	if logger.Get(ctx).Level() >= logger.VerboseLvl {
+		args = []string{"--verbose"}
+	}
+	args = append(args, "-f", configPath, "restart", serviceName.String())
+	cmd := c.dcCommand(ctx, args)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	return FormatError(cmd, nil, cmd.Run())
+}
+
 func (c *cmdDCClient) StreamLogs(ctx context.Context, configPath string, serviceName model.TargetName) (io.ReadCloser, error) {
 	// TODO(maia): --since time
 	// (may need to implement with `docker log <cID>` instead since `d-c log` doesn't support `--since`
@@ -21,7 +21,10 @@ type FakeDCClient struct {
 	ConfigOutput      string
 	ServicesOutput    string
 
-	UpCalls []UpCall
+	UpCalls      []UpCall
+	DownCalls    []string
+	RmCalls      []RmCall
+	RestartCalls []RestartCall
 }
 
 // Represents a single call to Up
@@ -31,6 +34,18 @@ type UpCall struct {
 	ShouldBuild  bool
 }
 
+// Represents a single call to Rm
+type RmCall struct {
+	PathToConfig string
+	ServiceNames []model.TargetName
+}
+
+// Represents a single call to Restart
+type RestartCall struct {
+	PathToConfig string
+	ServiceName  model.TargetName
+}
+
 func NewFakeDockerComposeClient(t *testing.T, ctx context.Context) *FakeDCClient {
 	return &FakeDCClient{
 		t:            t,
@@ -47,6 +62,17 @@ func (c *FakeDCClient) Up(ctx context.Context, pathToConfig string, serviceName
 }
 
 func (c *FakeDCClient) Down(ctx context.Context, pathToConfig string, stdout, stderr io.Writer) error {
+	c.DownCalls = append(c.DownCalls, pathToConfig)
+	return nil
+}
+
+func (c *FakeDCClient) Rm(ctx context.Context, pathToConfig string, serviceNames []model.TargetName, stdout, stderr io.Writer) error {
+	c.RmCalls = append(c.RmCalls, RmCall{pathToConfig, serviceNames})
+	return nil
+}
+
+func (c *FakeDCClient) Restart(ctx context.Context, pathToConfig string, serviceName model.TargetName, stdout, stderr io.Writer) error {
+	c.RestartCalls = append(c.RestartCalls, RestartCall{pathToConfig, serviceName})
 	return nil
 }
 
@@ -4,10 +4,13 @@ import (
 	"net/url"
 	"time"
 
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 
+	"github.com/windmilleng/tilt/internal/container"
 	"github.com/windmilleng/tilt/internal/dockercompose"
 	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/logger"
 	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/store"
 )
@@ -37,6 +40,27 @@ func NewServiceChangeAction(service *v1.Service, url *url.URL) ServiceChangeActi
 	return ServiceChangeAction{Service: service, URL: url}
 }
 
+type JobChangeAction struct {
+	Job *batchv1.Job
+}
+
+func (JobChangeAction) Action() {}
+
+func NewJobChangeAction(job *batchv1.Job) JobChangeAction {
+	return JobChangeAction{Job: job}
+}
+
+type K8sEventAction struct {
+	Event        *v1.Event
+	ManifestName model.ManifestName
+}
+
+func (K8sEventAction) Action() {}
+
+func NewK8sEventAction(event *v1.Event, manifestName model.ManifestName) K8sEventAction {
+	return K8sEventAction{Event: event, ManifestName: manifestName}
+}
+
 type BuildLogAction struct {
 	logEvent
 	ManifestName model.ManifestName
@@ -48,12 +72,14 @@ type PodLogAction struct {
 	logEvent
 	ManifestName model.ManifestName
 	PodID        k8s.PodID
+	Container    container.Name
 }
 
 func (PodLogAction) Action() {}
 
 type LogAction struct {
 	logEvent
+	Level logger.Level
 }
 
 func (LogAction) Action() {}
@@ -84,16 +110,18 @@ func NewDeployIDActionsForTargets(ids []model.TargetID, dID model.DeployID) []De
 }
 
 type BuildCompleteAction struct {
-	Result store.BuildResultSet
-	Error  error
+	ManifestName model.ManifestName
+	Result       store.BuildResultSet
+	Error        error
 }
 
 func (BuildCompleteAction) Action() {}
 
-func NewBuildCompleteAction(result store.BuildResultSet, err error) BuildCompleteAction {
+func NewBuildCompleteAction(mn model.ManifestName, result store.BuildResultSet, err error) BuildCompleteAction {
 	return BuildCompleteAction{
-		Result: result,
-		Error:  err,
+		ManifestName: mn,
+		Result:       result,
+		Error:        err,
 	}
 }
 
@@ -104,7 +132,12 @@ type InitAction struct {
 	TiltfilePath       string
 	ConfigFiles        []string
 	InitManifests      []model.ManifestName
+	TiltfileArgs       []string
 	TriggerMode        model.TriggerMode
+	MaxParallelBuilds  int
+	K8sNamespace       k8s.Namespace
+	KubeContext        k8s.KubeContext
+	EngineMode         store.EngineMode
 
 	StartTime  time.Time
 	FinishTime time.Time
@@ -165,6 +198,9 @@ type ConfigsReloadedAction struct {
 	GlobalYAML         model.Manifest
 	TiltIgnoreContents string
 	ConfigFiles        []string
+	MaxParallelBuilds  int
+	K8sNamespace       k8s.Namespace
+	UnmatchedManifests []model.Manifest
 
 	StartTime  time.Time
 	FinishTime time.Time
@@ -187,6 +223,40 @@ type DockerComposeLogAction struct {
 
 func (DockerComposeLogAction) Action() {}
 
+type LocalProcessStartedAction struct {
+	ManifestName model.ManifestName
+	PID          int
+	StartTime    time.Time
+}
+
+func (LocalProcessStartedAction) Action() {}
+
+type LocalProcessExitedAction struct {
+	ManifestName model.ManifestName
+	PID          int
+	ExitCode     int
+}
+
+func (LocalProcessExitedAction) Action() {}
+
+type LocalProcessLogAction struct {
+	logEvent
+	ManifestName model.ManifestName
+	PID          int
+}
+
+func (LocalProcessLogAction) Action() {}
+
+type ProbeResultAction struct {
+	ManifestName model.ManifestName
+	DeployTime   time.Time // the deploy that was probed
+	Ready        bool
+	Message      string
+	Time         time.Time
+}
+
+func (ProbeResultAction) Action() {}
+
 type TiltfileLogAction struct {
 	logEvent
 }
@@ -87,34 +87,34 @@ func (composite *CompositeBuildAndDeployer) BuildAndDeploy(ctx context.Context,
 	return store.BuildResultSet{}, lastErr
 }
 
-func DefaultBuildOrder(sbad *SyncletBuildAndDeployer, cbad *LocalContainerBuildAndDeployer, ibad *ImageBuildAndDeployer, dcbad *DockerComposeBuildAndDeployer, env k8s.Env, updMode UpdateMode, runtime container.Runtime) BuildOrder {
+func DefaultBuildOrder(sbad *SyncletBuildAndDeployer, cbad *LocalContainerBuildAndDeployer, ibad *ImageBuildAndDeployer, dcbad *DockerComposeBuildAndDeployer, ltbad *LocalTargetBuildAndDeployer, env k8s.Env, updMode UpdateMode, runtime container.Runtime) BuildOrder {
 
 	if updMode == UpdateModeImage || updMode == UpdateModeNaive {
-		return BuildOrder{dcbad, ibad}
+		return BuildOrder{dcbad, ltbad, ibad}
 	}
 
 	if updMode == UpdateModeKubectlExec {
-		return BuildOrder{sbad, dcbad, ibad}
+		return BuildOrder{sbad, dcbad, ltbad, ibad}
 	}
 
 	if updMode == UpdateModeContainer {
-		return BuildOrder{cbad, dcbad, ibad}
+		return BuildOrder{cbad, dcbad, ltbad, ibad}
 	}
 
 	if updMode == UpdateModeSynclet {
 		if runtime == container.RuntimeDocker {
 			ibad.SetInjectSynclet(true)
 		}
-		return BuildOrder{sbad, dcbad, ibad}
+		return BuildOrder{sbad, dcbad, ltbad, ibad}
 	}
 
 	if env.IsLocalCluster() && runtime == container.RuntimeDocker {
-		return BuildOrder{cbad, dcbad, ibad}
+		return BuildOrder{cbad, dcbad, ltbad, ibad}
 	}
 
 	if runtime == container.RuntimeDocker {
 		ibad.SetInjectSynclet(true)
 	}
 
-	return BuildOrder{sbad, cbad, dcbad, ibad}
+	return BuildOrder{sbad, cbad, dcbad, ltbad, ibad}
 }
@@ -2,6 +2,7 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"time"
 
@@ -23,6 +24,10 @@ type buildEntry struct {
 	buildStateSet store.BuildStateSet
 	buildReason   model.BuildReason
 	firstBuild    bool
+
+	// When other builds may be running at the same time, we prefix
+	// this build's output in the global log with the manifest name.
+	prefixLog bool
 }
 
 func NewBuildController(b BuildAndDeployer) *BuildController {
@@ -40,9 +45,14 @@ func nextTargetToBuild(state store.EngineState) *store.ManifestTarget {
 		return nil
 	}
 
+	// Don't start any more builds if we're already at the limit.
+	if len(state.CurrentlyBuilding) >= state.MaxParallelBuilds() {
+		return nil
+	}
+
 	// put no-build manifests first since they're more likely to be
 	// 1. fast and 2. dependencies of other services (e.g., redis)
-	targets := append([]*store.ManifestTarget{}, state.Targets()...)
+	targets := buildableTargets(state)
 	sort.Sort(newNoBuildsManifestsFirst(targets))
 
 	// First, go through all the manifests in order.
@@ -68,25 +78,86 @@ func nextTargetToBuild(state store.EngineState) *store.ManifestTarget {
 		}
 	}
 
-	if state.TriggerMode == model.TriggerManual && len(state.TriggerQueue) > 0 {
-		mn := state.TriggerQueue[0]
-		mt, ok := state.ManifestTargets[mn]
+	// Manifests in manual mode only build when the user triggers them.
+	for _, mn := range state.TriggerQueue {
+		for _, mt := range targets {
+			if mt.Manifest.Name == mn {
+				return mt
+			}
+		}
+	}
+
+	for _, mt := range targets {
+		if state.ManifestTriggerMode(mt.Manifest.Name) != model.TriggerAuto {
+			continue
+		}
+
+		ok, newTime := mt.State.HasPendingChangesBefore(earliest)
 		if ok {
-			return mt
+			choice = mt
+			earliest = newTime
 		}
 	}
 
-	if state.TriggerMode == model.TriggerAuto {
-		for _, mt := range targets {
-			ok, newTime := mt.State.HasPendingChangesBefore(earliest)
-			if ok {
-				choice = mt
-				earliest = newTime
+	return choice
+}
+
+// Returns the manifests that we could start building right now, in a stable order.
+//
+// A manifest can't be built while it's already building, or while another manifest
+// that shares one of its images is building. A manifest won't start its first
+// build until all of its resource dependencies are ready.
+func buildableTargets(state store.EngineState) []*store.ManifestTarget {
+	busyImages := make(map[model.TargetID]bool)
+	for mn := range state.CurrentlyBuilding {
+		mt, ok := state.ManifestTargets[mn]
+		if !ok {
+			continue
+		}
+		for _, iTarget := range mt.Manifest.ImageTargets {
+			busyImages[iTarget.ID()] = true
+		}
+	}
+
+	result := make([]*store.ManifestTarget, 0, len(state.ManifestTargets))
+	for _, mt := range state.Targets() {
+		if state.CurrentlyBuilding[mt.Manifest.Name] {
+			continue
+		}
+
+		isBusy := false
+		for _, iTarget := range mt.Manifest.ImageTargets {
+			if busyImages[iTarget.ID()] {
+				isBusy = true
+				break
 			}
 		}
+		if isBusy {
+			continue
+		}
+
+		if !mt.State.StartedFirstBuild() && !resourceDepsReady(state, mt.Manifest) {
+			continue
+		}
+
+		result = append(result, mt)
 	}
+	return result
+}
 
-	return choice
+// Dependencies that aren't in the engine state (e.g., because they were
+// filtered out on the command line) are treated as ready.
+func resourceDepsReady(state store.EngineState, m model.Manifest) bool {
+	for _, dep := range m.ResourceDependencies {
+		depTarget, ok := state.ManifestTargets[dep]
+		if !ok {
+			continue
+		}
+		if !depTarget.IsReady() {
+			return false
+		}
+	}
+	return true
 }
 
 type noBuildManifestsFirst struct {
@@ -171,6 +242,7 @@ func (c *BuildController) needsBuild(ctx context.Context, st store.RStore) (buil
 		firstBuild:    firstBuild,
 		buildReason:   buildReason,
 		buildStateSet: buildStateSet,
+		prefixLog:     state.MaxParallelBuilds() > 1,
 	}, true
 }
 
@@ -188,6 +260,10 @@ func (c *BuildController) OnChange(ctx context.Context, st store.RStore) {
 	}
 
 	go func() {
+		if entry.prefixLog {
+			ctx = ctxWithPrefixedLogger(ctx, entry.name)
+		}
+
 		// Send the logs to both the EngineState and the normal log stream.
 		actionWriter := BuildLogActionWriter{
 			store:        st,
@@ -205,7 +281,7 @@ func (c *BuildController) OnChange(ctx context.Context, st store.RStore) {
 		c.logBuildEntry(ctx, entry, filesChanged)
 
 		result, err := c.buildAndDeploy(ctx, st, entry)
-		st.Dispatch(NewBuildCompleteAction(result, err))
+		st.Dispatch(NewBuildCompleteAction(entry.name, result, err))
 	}()
 }
 
@@ -249,6 +325,15 @@ func (c *BuildController) logBuildEntry(ctx context.Context, entry buildEntry, c
 	}
 }
 
+// Prefix each line of the build output with the manifest name, so that
+// we can tell apart the output of builds that run at the same time.
+func ctxWithPrefixedLogger(ctx context.Context, mn model.ManifestName) context.Context {
+	l := logger.Get(ctx)
+	prefix := fmt.Sprintf("[%s] ", mn)
+	prefixLogWriter := logger.NewPrefixedWriter(prefix, l.Writer(logger.InfoLvl))
+	return logger.WithLogger(ctx, logger.NewLogger(l.Level(), prefixLogWriter))
+}
+
 type BuildLogActionWriter struct {
 	store        store.RStore
 	manifestName model.ManifestName
@@ -274,6 +359,8 @@ func buildTargets(manifest model.Manifest) []model.TargetSpec {
 		result = append(result, manifest.DockerComposeTarget())
 	} else if manifest.IsK8s() {
 		result = append(result, manifest.K8sTarget())
+	} else if manifest.IsLocal() {
+		result = append(result, manifest.LocalTarget())
 	}
 
 	return result
@@ -285,7 +372,7 @@ func buildStateSet(manifest model.Manifest, specs []model.TargetSpec, ms *store.
 
 	for _, spec := range specs {
 		id := spec.ID()
-		if id.Type != model.TargetTypeImage && id.Type != model.TargetTypeDockerCompose {
+		if id.Type != model.TargetTypeImage && id.Type != model.TargetTypeDockerCompose && id.Type != model.TargetTypeLocal {
 			continue
 		}
 
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 
 	"github.com/windmilleng/tilt/internal/container"
 	"github.com/windmilleng/tilt/internal/hud/view"
@@ -85,6 +86,29 @@ func TestBuildControllerDockerCompose(t *testing.T) {
 	f.assertAllBuildsConsumed()
 }
 
+func TestBuildControllerLocalResource(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	dep := f.JoinPath("stuff.json")
+	lt := model.NewLocalTarget("yarn-add", model.ToShellCmd("echo beep boop"), f.Path(), []string{dep})
+	manifest := model.Manifest{Name: "yarn-add"}.WithDeployTarget(lt)
+	f.Start([]model.Manifest{manifest}, true)
+
+	call := f.nextCall()
+	assert.Equal(t, lt, call.local())
+
+	f.fsWatcher.events <- watch.FileEvent{Path: dep}
+
+	call = f.nextCall()
+	assert.Equal(t, lt, call.local())
+	assert.Equal(t, []string{dep}, call.oneState().FilesChanged())
+
+	err := f.Stop()
+	assert.NoError(t, err)
+	f.assertAllBuildsConsumed()
+}
+
 func TestBuildControllerWontContainerBuildWithTwoPods(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
@@ -230,3 +254,120 @@ func TestBuildControllerNoBuildManifestsFirst(t *testing.T) {
 	}
 	assert.Equal(t, expectedBuildOrder, observedBuildOrder)
 }
+
+func TestNextTargetToBuildRespectsMaxParallelBuilds(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	state := store.NewState()
+	for _, name := range []string{"fe", "be"} {
+		m := f.newManifest(name, nil)
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+	}
+	state.CurrentlyBuilding["fe"] = true
+
+	assert.Nil(t, nextTargetToBuild(*state))
+
+	state.MaxParallelBuildsFromFlag = 2
+	mt := nextTargetToBuild(*state)
+	if assert.NotNil(t, mt) {
+		assert.Equal(t, model.ManifestName("be"), mt.Manifest.Name)
+	}
+
+	state.CurrentlyBuilding["be"] = true
+	assert.Nil(t, nextTargetToBuild(*state))
+}
+
+func TestNextTargetToBuildSkipsSharedImage(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	ref := container.MustParseNamed("gcr.io/shared")
+	state := store.NewState()
+	state.MaxParallelBuildsFromTiltfile = 3
+	for _, name := range []string{"fe", "be"} {
+		m := f.newManifestWithRef(name, ref, nil)
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+	}
+	state.UpsertManifestTarget(store.NewManifestTarget(f.newManifest("other", nil)))
+	state.CurrentlyBuilding["fe"] = true
+
+	mt := nextTargetToBuild(*state)
+	if assert.NotNil(t, mt) {
+		assert.Equal(t, model.ManifestName("other"), mt.Manifest.Name)
+	}
+}
+
+func TestMaxParallelBuildsFlagOverridesTiltfile(t *testing.T) {
+	state := store.NewState()
+	assert.Equal(t, 1, state.MaxParallelBuilds())
+
+	state.MaxParallelBuildsFromTiltfile = 4
+	assert.Equal(t, 4, state.MaxParallelBuilds())
+
+	state.MaxParallelBuildsFromFlag = 2
+	assert.Equal(t, 2, state.MaxParallelBuilds())
+}
+
+func TestNextTargetToBuildWaitsForResourceDeps(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	db := f.newManifest("db", nil)
+	api := f.newManifest("api", nil).WithResourceDependencies([]model.ManifestName{"db"})
+
+	state := store.NewState()
+	state.MaxParallelBuildsFromFlag = 2
+	state.UpsertManifestTarget(store.NewManifestTarget(api))
+	state.UpsertManifestTarget(store.NewManifestTarget(db))
+
+	mt := nextTargetToBuild(*state)
+	if assert.NotNil(t, mt) {
+		assert.Equal(t, model.ManifestName("db"), mt.Manifest.Name)
+	}
+
+	// db has deployed, but its pod isn't ready yet.
+	dbState := state.ManifestTargets["db"].State
+	dbState.AddCompletedBuild(model.BuildRecord{StartTime: time.Now(), FinishTime: time.Now()})
+	assert.Nil(t, nextTargetToBuild(*state))
+
+	dbState.PodSet = store.NewPodSet(store.Pod{
+		PodID: "db-pod",
+		Phase: v1.PodRunning,
+		Ready: true,
+	})
+	mt = nextTargetToBuild(*state)
+	if assert.NotNil(t, mt) {
+		assert.Equal(t, model.ManifestName("api"), mt.Manifest.Name)
+	}
+}
+
+func TestNextTargetToBuildRespectsTriggerModeOverride(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	state := store.NewState()
+	for _, name := range []string{"fe", "be"} {
+		m := f.newManifest(name, nil)
+		mt := store.NewManifestTarget(m)
+		mt.State.AddCompletedBuild(model.BuildRecord{StartTime: time.Now(), FinishTime: time.Now()})
+		mt.State.MutableBuildStatus(m.ImageTargetAt(0).ID()).PendingFileChanges["main.go"] = time.Now()
+		state.UpsertManifestTarget(mt)
+	}
+
+	manual := model.TriggerManual
+	state.ManifestTargets["fe"].State.TriggerModeOverride = &manual
+	mt := nextTargetToBuild(*state)
+	if assert.NotNil(t, mt) {
+		assert.Equal(t, model.ManifestName("be"), mt.Manifest.Name)
+	}
+
+	state.ManifestTargets["be"].State.TriggerModeOverride = &manual
+	assert.Nil(t, nextTargetToBuild(*state))
+
+	state.TriggerQueue = []model.ManifestName{"fe"}
+	mt = nextTargetToBuild(*state)
+	if assert.NotNil(t, mt) {
+		assert.Equal(t, model.ManifestName("fe"), mt.Manifest.Name)
+	}
+}
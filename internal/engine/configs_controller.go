@@ -6,6 +6,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/windmilleng/tilt/internal/k8s"
 	"github.com/windmilleng/tilt/internal/logger"
 	"github.com/windmilleng/tilt/internal/store"
 	"github.com/windmilleng/tilt/internal/tiltfile"
@@ -14,14 +15,16 @@ import (
 type ConfigsController struct {
 	disabledForTesting bool
 	tfl                tiltfile.TiltfileLoader
+	contextGuard       *k8s.ContextGuard
 	clock              func() time.Time
 	activeBuild        bool
 }
 
-func NewConfigsController(tfl tiltfile.TiltfileLoader) *ConfigsController {
+func NewConfigsController(tfl tiltfile.TiltfileLoader, contextGuard *k8s.ContextGuard) *ConfigsController {
 	return &ConfigsController{
-		tfl:   tfl,
-		clock: time.Now,
+		tfl:          tfl,
+		contextGuard: contextGuard,
+		clock:        time.Now,
 	}
 }
 
@@ -58,6 +61,7 @@ func (cc *ConfigsController) OnChange(ctx context.Context, st store.RStore) {
 	defer st.RUnlockState()
 
 	initManifests := state.InitManifests
+	tiltfileArgs := state.TiltfileArgs
 	if !cc.shouldBuild(state) {
 		return
 	}
@@ -89,12 +93,15 @@ func (cc *ConfigsController) OnChange(ctx context.Context, st store.RStore) {
 
 		loadCtx := logger.WithLogger(ctx, logger.NewLogger(logger.Get(ctx).Level(), multiWriter))
 
-		tlr, err := cc.tfl.Load(loadCtx, tiltfilePath, matching)
+		tlr, err := cc.tfl.Load(loadCtx, tiltfilePath, matching, tiltfileArgs)
 		if err == nil && len(tlr.Manifests) == 0 && tlr.Global.Empty() {
 			err = fmt.Errorf("No resources found. Check out https://docs.tilt.dev/tutorial.html to get started!")
 		}
 		if err != nil {
 			logger.Get(loadCtx).Infof(err.Error())
+		} else {
+			// Let the k8s client change the cluster before anything deploys to it.
+			cc.contextGuard.Allow(tlr.AllowedK8sContexts)
 		}
 		st.Dispatch(ConfigsReloadedAction{
 			Manifests:          tlr.Manifests,
@@ -105,6 +112,9 @@ func (cc *ConfigsController) OnChange(ctx context.Context, st store.RStore) {
 			FinishTime:         cc.clock(),
 			Err:                err,
 			Warnings:           tlr.Warnings,
+			MaxParallelBuilds:  tlr.MaxParallelBuilds,
+			K8sNamespace:       tlr.K8sNamespace,
+			UnmatchedManifests: tlr.UnmatchedManifests,
 		})
 	}()
 }
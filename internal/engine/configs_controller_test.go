@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/windmilleng/tilt/internal/k8s"
 	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/store"
 	"github.com/windmilleng/tilt/internal/testutils"
@@ -40,6 +41,38 @@ func TestConfigsController(t *testing.T) {
 	assert.Equal(t, expected, a)
 }
 
+func TestConfigsControllerPassesTiltfileArgs(t *testing.T) {
+	f := newCCFixture(t)
+	defer f.TearDown()
+
+	state := f.st.LockMutableStateForTesting()
+	state.PendingConfigFileChanges["Tiltfile"] = time.Now()
+	state.TiltfilePath = f.JoinPath("Tiltfile")
+	state.TiltfileArgs = []string{"--env=prod"}
+	f.st.UnlockMutableState()
+
+	_ = f.run()
+
+	assert.Equal(t, []string{"--env=prod"}, f.tfl.LastArgs())
+}
+
+func TestConfigsControllerAllowsK8sContexts(t *testing.T) {
+	f := newCCFixture(t)
+	defer f.TearDown()
+
+	state := f.st.LockMutableStateForTesting()
+	state.PendingConfigFileChanges["Tiltfile"] = time.Now()
+	state.TiltfilePath = f.JoinPath("Tiltfile")
+	f.st.UnlockMutableState()
+
+	assert.Error(t, f.contextGuard.Check())
+
+	f.tfl.AllowedK8sContexts = []k8s.KubeContext{"gke_dev"}
+	_ = f.run()
+
+	assert.NoError(t, f.contextGuard.Check())
+}
+
 func (f *ccFixture) run() ConfigsReloadedAction {
 	// configs_controller uses state.RelativeTiltfilePath, which is relative to wd
 	// sometimes the original directory was invalid (e.g., it was another test's temp dir, which was deleted,
@@ -73,19 +106,21 @@ func (f *ccFixture) run() ConfigsReloadedAction {
 
 type ccFixture struct {
 	*tempdir.TempDirFixture
-	ctx        context.Context
-	cc         *ConfigsController
-	st         *store.Store
-	getActions func() []store.Action
-	tfl        *tiltfile.FakeTiltfileLoader
-	fc         *testutils.FakeClock
+	ctx          context.Context
+	cc           *ConfigsController
+	st           *store.Store
+	getActions   func() []store.Action
+	tfl          *tiltfile.FakeTiltfileLoader
+	contextGuard *k8s.ContextGuard
+	fc           *testutils.FakeClock
 }
 
 func newCCFixture(t *testing.T) *ccFixture {
 	f := tempdir.NewTempDirFixture(t)
 	st, getActions := store.NewStoreForTesting()
 	tfl := tiltfile.NewFakeTiltfileLoader()
-	cc := NewConfigsController(tfl)
+	contextGuard := k8s.ProvideContextGuard(k8s.EnvGKE, "gke_dev")
+	cc := NewConfigsController(tfl, contextGuard)
 	fc := testutils.NewRandomFakeClock()
 	cc.clock = fc.Clock()
 	ctx := output.CtxForTest()
@@ -98,6 +133,7 @@ func newCCFixture(t *testing.T) *ccFixture {
 		st:             st,
 		getActions:     getActions,
 		tfl:            tfl,
+		contextGuard:   contextGuard,
 		fc:             fc,
 	}
 }
@@ -23,7 +23,7 @@ func NewDockerComposeEventWatcher(dcc dockercompose.DockerComposeClient) *Docker
 func (w *DockerComposeEventWatcher) needsWatch(st store.RStore) bool {
 	state := st.RLockState()
 	defer st.RUnlockState()
-	return state.WatchFiles && !w.watching
+	return state.WatchRuntime() && !w.watching
 }
 
 func (w *DockerComposeEventWatcher) OnChange(ctx context.Context, st store.RStore) {
@@ -31,8 +31,9 @@ func (m *DockerComposeLogManager) diff(ctx context.Context, st store.RStore) (se
 	state := st.RLockState()
 	defer st.RUnlockState()
 
-	// If we're not watching the FS for changes, then don't bother watching logs.
-	if !state.WatchFiles {
+	// If we're not watching the FS for changes (or running in CI),
+	// then don't bother watching logs.
+	if !state.WatchRuntime() {
 		return nil, nil
 	}
 
@@ -0,0 +1,328 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/logger"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+)
+
+// How far up the owner chain (e.g., Pod -> ReplicaSet -> Deployment)
+// we look for the manifest that an object belongs to.
+const maxOwnerDepth = 4
+
+// Kubernetes deletes events an hour after they last happened (the default
+// --event-ttl), so we don't need to remember them for longer than that.
+const eventCacheTTL = time.Hour
+
+// Watches Kubernetes Events, and dispatches the Warnings about
+// objects that belong to one of our manifests.
+//
+// We only look at events in the namespaces that our manifests deploy to,
+// so that we don't look up objects all over the cluster.
+type EventWatchManager struct {
+	kCli            k8s.Client
+	configNamespace k8s.Namespace
+	now             func() time.Time
+
+	watching    bool
+	namespace   k8s.Namespace
+	cancelWatch context.CancelFunc
+
+	// The YAML that we last computed the namespaces from.
+	lastYAML []string
+
+	mu sync.Mutex
+
+	// The namespaces that our manifests deploy to.
+	namespaces map[k8s.Namespace]bool
+
+	// The manifest that each object belongs to. An empty name means
+	// the object doesn't belong to any manifest.
+	manifestsByUID map[types.UID]cachedManifest
+
+	// The last version of each event that we dispatched, so that the
+	// same warning doesn't show up twice when the watch re-lists.
+	lastSeen    map[types.UID]eventVersion
+	lastEvicted time.Time
+}
+
+type eventVersion struct {
+	count int32
+	time  time.Time
+}
+
+type cachedManifest struct {
+	name     model.ManifestName
+	lastUsed time.Time
+}
+
+func NewEventWatchManager(kCli k8s.Client, configNamespace k8s.Namespace) *EventWatchManager {
+	return &EventWatchManager{
+		kCli:            kCli,
+		configNamespace: configNamespace,
+		now:             time.Now,
+		namespaces:      make(map[k8s.Namespace]bool),
+		manifestsByUID:  make(map[types.UID]cachedManifest),
+		lastSeen:        make(map[types.UID]eventVersion),
+	}
+}
+
+type eventWatchEntry struct {
+	// The namespace to watch. Empty means all namespaces.
+	namespace k8s.Namespace
+
+	startTime  time.Time
+	yaml       []string
+	needsWatch bool
+}
+
+func (m *EventWatchManager) needsWatch(st store.RStore) eventWatchEntry {
+	state := st.RLockState()
+	defer st.RUnlockState()
+
+	var yaml []string
+	for _, manifest := range state.Manifests() {
+		if manifest.IsK8s() {
+			yaml = append(yaml, manifest.K8sTarget().YAML)
+		}
+	}
+	namespace := state.K8sNamespace()
+	needsWatch := !m.watching || namespace != m.namespace
+	return eventWatchEntry{
+		namespace:  namespace,
+		startTime:  state.TiltStartTime,
+		yaml:       yaml,
+		needsWatch: len(yaml) > 0 && state.WatchRuntime() && needsWatch,
+	}
+}
+
+func (m *EventWatchManager) OnChange(ctx context.Context, st store.RStore) {
+	entry := m.needsWatch(st)
+	m.updateNamespaces(ctx, entry)
+	if !entry.needsWatch {
+		return
+	}
+
+	if m.cancelWatch != nil {
+		m.cancelWatch()
+	}
+	m.watching = true
+	m.namespace = entry.namespace
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	m.cancelWatch = cancel
+	ch, err := m.kCli.WatchEvents(watchCtx, entry.namespace)
+	if err != nil {
+		err = errors.Wrap(err, "Error watching k8s events. Are you connected to kubernetes?\n")
+		st.Dispatch(NewErrorAction(err))
+		return
+	}
+
+	go m.dispatchEventsLoop(watchCtx, ch, st, entry.startTime)
+}
+
+// Keeps track of the namespaces that our manifests deploy to.
+func (m *EventWatchManager) updateNamespaces(ctx context.Context, entry eventWatchEntry) {
+	if entry.namespace == m.namespace && m.watching && stringSlicesEqual(entry.yaml, m.lastYAML) {
+		return
+	}
+	m.lastYAML = entry.yaml
+
+	namespaces := make(map[k8s.Namespace]bool)
+	if entry.namespace != "" {
+		namespaces[entry.namespace] = true
+	} else {
+		for _, y := range entry.yaml {
+			entities, err := k8s.ParseYAMLFromString(y)
+			if err != nil {
+				logger.Get(ctx).Debugf("Error parsing YAML for k8s events: %v", err)
+				continue
+			}
+			for _, e := range entities {
+				ns := k8s.Namespace(rawNamespace(e))
+				if ns == "" {
+					ns = m.configNamespace
+				}
+				if ns == "" {
+					ns = k8s.DefaultNamespace
+				}
+				namespaces[ns] = true
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.namespaces = namespaces
+}
+
+func (m *EventWatchManager) inNamespace(ns string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.namespaces[k8s.Namespace(ns)]
+}
+
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *EventWatchManager) dispatchEventsLoop(ctx context.Context, ch <-chan *v1.Event, st store.RStore, startTime time.Time) {
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			m.handleEvent(ctx, st, event, startTime)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *EventWatchManager) handleEvent(ctx context.Context, st store.RStore, event *v1.Event, startTime time.Time) {
+	if event.Type != v1.EventTypeWarning {
+		return
+	}
+
+	// Warnings from before we started are about some other session's deploy.
+	if k8s.EventTime(event).Before(startTime) {
+		return
+	}
+
+	// Events about objects in other namespaces aren't about our manifests,
+	// so don't bother looking up the objects.
+	if !m.inNamespace(event.InvolvedObject.Namespace) {
+		return
+	}
+
+	if !m.isNewVersion(event) {
+		return
+	}
+
+	mn := m.manifestFor(ctx, event.InvolvedObject)
+	if mn == "" || mn == model.GlobalYAMLManifestName {
+		return
+	}
+
+	st.Dispatch(NewK8sEventAction(event, mn))
+}
+
+// Kubernetes updates an Event in place when it repeats, bumping the count
+// and the timestamp. Anything else is a copy of an event we've already seen.
+func (m *EventWatchManager) isNewVersion(event *v1.Event) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.evictOldEntries()
+
+	v := eventVersion{count: event.Count, time: k8s.EventTime(event)}
+	last, ok := m.lastSeen[event.UID]
+	if ok && last.count == v.count && last.time.Equal(v.time) {
+		return false
+	}
+	m.lastSeen[event.UID] = v
+	return true
+}
+
+// Forgets events that Kubernetes has deleted by now, and objects that
+// we haven't seen events about in as long, so that the caches don't grow forever.
+// Must hold the lock.
+func (m *EventWatchManager) evictOldEntries() {
+	now := m.now()
+	if now.Sub(m.lastEvicted) < time.Minute {
+		return
+	}
+	m.lastEvicted = now
+
+	cutoff := now.Add(-eventCacheTTL)
+	for uid, v := range m.lastSeen {
+		if v.time.Before(cutoff) {
+			delete(m.lastSeen, uid)
+		}
+	}
+	for uid, cached := range m.manifestsByUID {
+		if cached.lastUsed.Before(cutoff) {
+			delete(m.manifestsByUID, uid)
+		}
+	}
+}
+
+// Finds the manifest that an object belongs to, from the manifest label
+// on the object or on one of its controllers.
+func (m *EventWatchManager) manifestFor(ctx context.Context, ref v1.ObjectReference) model.ManifestName {
+	var visited []types.UID
+	mn := model.ManifestName("")
+	for i := 0; i < maxOwnerDepth; i++ {
+		if cached, ok := m.cachedManifest(ref.UID); ok {
+			mn = cached
+			break
+		}
+		visited = append(visited, ref.UID)
+
+		entity, err := m.kCli.GetByReference(ctx, ref)
+		if err != nil {
+			// The object may have been deleted already. Don't cache this,
+			// so that we try again if the object shows up.
+			logger.Get(ctx).Debugf("Error looking up %s %s for k8s event: %v", ref.Kind, ref.Name, err)
+			return ""
+		}
+
+		mn = model.ManifestName(entity.Labels()[k8s.ManifestNameLabel])
+		owner := entity.ControllerRef()
+		if mn != "" || owner == nil {
+			break
+		}
+
+		ref = v1.ObjectReference{
+			APIVersion: owner.APIVersion,
+			Kind:       owner.Kind,
+			Name:       owner.Name,
+			Namespace:  ref.Namespace,
+			UID:        owner.UID,
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, uid := range visited {
+		if uid != "" {
+			m.manifestsByUID[uid] = cachedManifest{name: mn, lastUsed: m.now()}
+		}
+	}
+	return mn
+}
+
+func (m *EventWatchManager) cachedManifest(uid types.UID) (model.ManifestName, bool) {
+	if uid == "" {
+		return "", false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cached, ok := m.manifestsByUID[uid]
+	if !ok {
+		return "", false
+	}
+	cached.lastUsed = m.now()
+	m.manifestsByUID[uid] = cached
+	return cached.name, true
+}
@@ -0,0 +1,284 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/k8s/testyaml"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/testutils/output"
+)
+
+func TestEventWatchManagerLabeledObject(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	f.addObject("pod-abc", "Pod", "fe", nil)
+	f.ewm.OnChange(f.ctx, f.store)
+
+	evt := f.warnEvent("pod-abc", "Pod", 1)
+	f.kClient.EventsCh <- evt
+
+	f.assertActions(NewK8sEventAction(evt, "fe"))
+}
+
+func TestEventWatchManagerWatchesInCIMode(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	state := f.store.RLockState()
+	f.store.RUnlockState()
+	state.WatchFiles = false
+	state.EngineMode = store.EngineModeCI
+	f.store.SetState(state)
+
+	f.addObject("pod-abc", "Pod", "fe", nil)
+	f.ewm.OnChange(f.ctx, f.store)
+	if !assert.True(t, f.ewm.watching, "expected a watch in CI mode") {
+		return
+	}
+
+	evt := f.warnEvent("pod-abc", "Pod", 1)
+	f.kClient.EventsCh <- evt
+
+	f.assertActions(NewK8sEventAction(evt, "fe"))
+}
+
+func TestEventWatchManagerFollowsOwners(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	f.addObject("pod-abc", "Pod", "", &metav1.OwnerReference{Kind: "ReplicaSet", Name: "rs-abc", UID: "rs-abc"})
+	f.addObject("rs-abc", "ReplicaSet", "", &metav1.OwnerReference{Kind: "Deployment", Name: "dep", UID: "dep"})
+	f.addObject("dep", "Deployment", "fe", nil)
+	f.ewm.OnChange(f.ctx, f.store)
+
+	evt := f.warnEvent("pod-abc", "Pod", 1)
+	f.kClient.EventsCh <- evt
+
+	f.assertActions(NewK8sEventAction(evt, "fe"))
+}
+
+func TestEventWatchManagerIgnoresUnknownObjects(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	f.addObject("pod-abc", "Pod", "", nil)
+	f.ewm.OnChange(f.ctx, f.store)
+
+	f.kClient.EventsCh <- f.warnEvent("pod-abc", "Pod", 1)
+	f.kClient.EventsCh <- f.warnEvent("pod-xyz", "Pod", 1)
+
+	f.assertActions()
+}
+
+func TestEventWatchManagerIgnoresNormalAndOldEvents(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	f.addObject("pod-abc", "Pod", "fe", nil)
+	f.ewm.OnChange(f.ctx, f.store)
+
+	normal := f.warnEvent("pod-abc", "Pod", 1)
+	normal.Type = v1.EventTypeNormal
+	f.kClient.EventsCh <- normal
+
+	old := f.warnEvent("pod-abc", "Pod", 1)
+	old.LastTimestamp = metav1.NewTime(f.startTime.Add(-time.Minute))
+	f.kClient.EventsCh <- old
+
+	f.assertActions()
+}
+
+func TestEventWatchManagerDedupesByCountAndTimestamp(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	f.addObject("pod-abc", "Pod", "fe", nil)
+	f.ewm.OnChange(f.ctx, f.store)
+
+	evt1 := f.warnEvent("pod-abc", "Pod", 1)
+	f.kClient.EventsCh <- evt1
+	f.kClient.EventsCh <- evt1.DeepCopy()
+
+	evt2 := evt1.DeepCopy()
+	evt2.Count = 2
+	evt2.LastTimestamp = metav1.NewTime(evt1.LastTimestamp.Add(time.Second))
+	f.kClient.EventsCh <- evt2
+
+	f.assertActions(NewK8sEventAction(evt1, "fe"), NewK8sEventAction(evt2, "fe"))
+}
+
+func TestEventWatchManagerIgnoresOtherNamespaces(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	f.addObject("pod-abc", "Pod", "fe", nil)
+	f.ewm.OnChange(f.ctx, f.store)
+
+	evt := f.warnEvent("pod-abc", "Pod", 1)
+	evt.InvolvedObject.Namespace = "kube-system"
+	f.kClient.EventsCh <- evt
+
+	f.assertActions()
+	_, ok := f.ewm.cachedManifest("pod-abc")
+	assert.False(t, ok, "looked up an object in another namespace")
+}
+
+func TestEventWatchManagerNamespaceOverride(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	f.ewm.OnChange(f.ctx, f.store)
+	assert.True(t, f.ewm.inNamespace("sancho-ns"))
+
+	state := f.store.RLockState()
+	f.store.RUnlockState()
+	state.K8sNamespaceFromFlag = "my-ns"
+	f.store.SetState(state)
+
+	f.ewm.OnChange(f.ctx, f.store)
+	assert.True(t, f.ewm.inNamespace("my-ns"))
+	assert.False(t, f.ewm.inNamespace("sancho-ns"))
+}
+
+func TestEventWatchManagerEvictsOldEntries(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	now := f.startTime.Add(time.Second)
+	f.ewm.now = func() time.Time { return now }
+
+	f.addObject("pod-abc", "Pod", "fe", nil)
+	f.addObject("pod-def", "Pod", "fe", nil)
+	f.ewm.OnChange(f.ctx, f.store)
+
+	evt1 := f.warnEvent("pod-abc", "Pod", 1)
+	f.kClient.EventsCh <- evt1
+	f.assertActions(NewK8sEventAction(evt1, "fe"))
+
+	now = now.Add(eventCacheTTL + time.Minute)
+	evt2 := f.warnEvent("pod-def", "Pod", 1)
+	evt2.LastTimestamp = metav1.NewTime(now)
+	f.kClient.EventsCh <- evt2
+	f.assertActions(NewK8sEventAction(evt1, "fe"), NewK8sEventAction(evt2, "fe"))
+
+	f.ewm.mu.Lock()
+	defer f.ewm.mu.Unlock()
+	assert.Equal(t, []types.UID{"pod-def.1"}, uidsOf(f.ewm.lastSeen))
+	_, ok := f.ewm.manifestsByUID["pod-abc"]
+	assert.False(t, ok, "kept the manifest of an object without recent events")
+	_, ok = f.ewm.manifestsByUID["pod-def"]
+	assert.True(t, ok)
+}
+
+func uidsOf(m map[types.UID]eventVersion) []types.UID {
+	result := []types.UID{}
+	for uid := range m {
+		result = append(result, uid)
+	}
+	return result
+}
+
+type ewmFixture struct {
+	t         *testing.T
+	kClient   *k8s.FakeK8sClient
+	ewm       *EventWatchManager
+	ctx       context.Context
+	cancel    func()
+	store     *store.TestingStore
+	startTime time.Time
+}
+
+func newEWMFixture(t *testing.T) *ewmFixture {
+	kClient := k8s.NewFakeK8sClient()
+	kClient.EventsCh = make(chan *v1.Event)
+	kClient.GetByReferenceEntities = make(map[string]k8s.K8sEntity)
+
+	ctx, cancel := context.WithCancel(output.CtxForTest())
+
+	startTime := time.Now().Truncate(time.Second)
+	st := store.NewTestingStore()
+	state := store.NewState()
+	state.WatchFiles = true
+	state.TiltStartTime = startTime
+	m := model.Manifest{Name: "fe"}.WithDeployTarget(model.K8sTarget{YAML: testyaml.SanchoYAML})
+	state.UpsertManifestTarget(store.NewManifestTarget(m))
+	st.SetState(*state)
+
+	return &ewmFixture{
+		t:         t,
+		kClient:   kClient,
+		ewm:       NewEventWatchManager(kClient, ""),
+		ctx:       ctx,
+		cancel:    cancel,
+		store:     st,
+		startTime: startTime,
+	}
+}
+
+func (f *ewmFixture) TearDown() {
+	f.cancel()
+}
+
+func (f *ewmFixture) addObject(name, kind, manifestName string, owner *metav1.OwnerReference) {
+	meta := metav1.ObjectMeta{Name: name, UID: types.UID(name), Labels: map[string]string{}}
+	if manifestName != "" {
+		meta.Labels[k8s.ManifestNameLabel] = manifestName
+	}
+	if owner != nil {
+		controller := true
+		owner.Controller = &controller
+		meta.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+
+	gvk := v1.SchemeGroupVersion.WithKind(kind)
+	f.kClient.GetByReferenceEntities[name] = k8s.K8sEntity{
+		Obj:  &v1.Pod{ObjectMeta: meta},
+		Kind: &gvk,
+	}
+}
+
+func (f *ewmFixture) warnEvent(objName, objKind string, count int32) *v1.Event {
+	return &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: objName + ".1", UID: types.UID(objName + ".1")},
+		InvolvedObject: v1.ObjectReference{
+			Kind:      objKind,
+			Name:      objName,
+			Namespace: "sancho-ns",
+			UID:       types.UID(objName),
+		},
+		Type:          v1.EventTypeWarning,
+		Reason:        "FailedScheduling",
+		Message:       "0/1 nodes are available",
+		Count:         count,
+		LastTimestamp: metav1.NewTime(f.startTime.Add(time.Second)),
+	}
+}
+
+func (f *ewmFixture) assertActions(expected ...store.Action) {
+	start := time.Now()
+	for time.Since(start) < 200*time.Millisecond {
+		if len(f.store.ActionsSnapshot()) >= len(expected) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Give the loop a moment to dispatch anything unexpected.
+	time.Sleep(10 * time.Millisecond)
+	actions := f.store.ActionsSnapshot()
+	if len(expected) == 0 {
+		assert.Empty(f.t, actions)
+		return
+	}
+	assert.Equal(f.t, expected, actions)
+}
@@ -16,12 +16,15 @@ import (
 type GlobalYAMLBuildController struct {
 	disabledForTesting     bool
 	lastGlobalYAMLManifest model.Manifest
+	lastK8sNamespace       k8s.Namespace
 	k8sClient              k8s.Client
+	namespaces             *K8sNamespaceEnsurer
 }
 
-func NewGlobalYAMLBuildController(k8sClient k8s.Client) *GlobalYAMLBuildController {
+func NewGlobalYAMLBuildController(k8sClient k8s.Client, namespaces *K8sNamespaceEnsurer) *GlobalYAMLBuildController {
 	return &GlobalYAMLBuildController{
-		k8sClient: k8sClient,
+		k8sClient:  k8sClient,
+		namespaces: namespaces,
 	}
 }
 
@@ -32,13 +35,18 @@ func (c *GlobalYAMLBuildController) OnChange(ctx context.Context, st store.RStor
 
 	state := st.RLockState()
 	m := state.GlobalYAML
+	ownerLabel := k8s.TiltOwnerLabel(state.TiltfilePath)
+	namespace := state.K8sNamespace()
 	st.RUnlockState()
 
-	if m.K8sTarget().YAML != c.lastGlobalYAMLManifest.K8sTarget().YAML {
+	yamlChanged := m.K8sTarget().YAML != c.lastGlobalYAMLManifest.K8sTarget().YAML
+	namespaceChanged := namespace != c.lastK8sNamespace && m.K8sTarget().YAML != ""
+	if yamlChanged || namespaceChanged {
 		c.lastGlobalYAMLManifest = m
+		c.lastK8sNamespace = namespace
 		st.Dispatch(GlobalYAMLApplyStartedAction{})
 
-		err := handleGlobalYamlChange(ctx, m, c.k8sClient)
+		err := handleGlobalYamlChange(ctx, m, ownerLabel, namespace, c.k8sClient, c.namespaces)
 
 		if err != nil {
 			logger.Get(ctx).Infof(err.Error())
@@ -70,7 +78,7 @@ func (nf namespacesFirst) Swap(i, j int) {
 	nf[i], nf[j] = nf[j], nf[i]
 }
 
-func handleGlobalYamlChange(ctx context.Context, m model.Manifest, kCli k8s.Client) error {
+func handleGlobalYamlChange(ctx context.Context, m model.Manifest, ownerLabel model.LabelPair, namespace k8s.Namespace, kCli k8s.Client, namespaces *K8sNamespaceEnsurer) error {
 	entities, err := k8s.ParseYAMLFromString(m.K8sTarget().YAML)
 	if err != nil {
 		return errors.Wrap(err, "Error parsing k8s_yaml")
@@ -79,7 +87,7 @@ func handleGlobalYamlChange(ctx context.Context, m model.Manifest, kCli k8s.Clie
 	newK8sEntities := []k8s.K8sEntity{}
 
 	for _, e := range entities {
-		e, err = k8s.InjectLabels(e, []model.LabelPair{k8s.TiltRunLabel(), {Key: k8s.ManifestNameLabel, Value: m.ManifestName().String()}})
+		e, err = k8s.InjectLabels(e, []model.LabelPair{k8s.TiltRunLabel(), ownerLabel, {Key: k8s.ManifestNameLabel, Value: m.ManifestName().String()}})
 		if err != nil {
 			return errors.Wrap(err, "Error injecting labels in to k8s_yaml")
 		}
@@ -95,6 +103,11 @@ func handleGlobalYamlChange(ctx context.Context, m model.Manifest, kCli k8s.Clie
 		newK8sEntities = append(newK8sEntities, e)
 	}
 
+	newK8sEntities, err = namespaces.injectK8sNamespace(ctx, namespace, newK8sEntities)
+	if err != nil {
+		return err
+	}
+
 	// namespaces need to be created before the entities that go in them
 	sort.Sort(namespacesFirst(newK8sEntities))
 
@@ -134,6 +134,7 @@ func newGlobalYamlBuildControllerForTest(yaml string) *TestGlobalYAMLBuildContro
 		GlobalYAMLBuildController: GlobalYAMLBuildController{
 			lastGlobalYAMLManifest: k8s.NewK8sOnlyManifestForTesting(model.GlobalYAMLManifestName, yaml),
 			k8sClient:              kc,
+			namespaces:             NewK8sNamespaceEnsurer(kc),
 		},
 		k8sClient: kc,
 	}
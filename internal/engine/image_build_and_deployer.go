@@ -21,6 +21,7 @@ import (
 
 	"github.com/windmilleng/tilt/internal/build"
 	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/logger"
 	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/synclet/sidecar"
 )
@@ -48,6 +49,7 @@ func NewKINDPusher() KINDPusher {
 type ImageBuildAndDeployer struct {
 	ib            build.ImageBuilder
 	icb           *imageAndCacheBuilder
+	imageCache    *build.ImageCache
 	k8sClient     k8s.Client
 	env           k8s.Env
 	runtime       container.Runtime
@@ -55,6 +57,7 @@ type ImageBuildAndDeployer struct {
 	injectSynclet bool
 	clock         build.Clock
 	kp            KINDPusher
+	namespaces    *K8sNamespaceEnsurer
 }
 
 func NewImageBuildAndDeployer(
@@ -68,16 +71,20 @@ func NewImageBuildAndDeployer(
 	c build.Clock,
 	runtime container.Runtime,
 	kp KINDPusher,
+	imageCache *build.ImageCache,
+	namespaces *K8sNamespaceEnsurer,
 ) *ImageBuildAndDeployer {
 	return &ImageBuildAndDeployer{
-		ib:        b,
-		icb:       NewImageAndCacheBuilder(b, cacheBuilder, customBuilder, updMode),
-		k8sClient: k8sClient,
-		env:       env,
-		analytics: analytics,
-		clock:     c,
-		runtime:   runtime,
-		kp:        kp,
+		ib:         b,
+		icb:        NewImageAndCacheBuilder(b, cacheBuilder, customBuilder, updMode),
+		imageCache: imageCache,
+		k8sClient:  k8sClient,
+		env:        env,
+		analytics:  analytics,
+		clock:      c,
+		runtime:    runtime,
+		kp:         kp,
+		namespaces: namespaces,
 	}
 }
 
@@ -135,6 +142,29 @@ func (ibd *ImageBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.R
 			return store.BuildResult{}, err
 		}
 
+		anyInPlaceBuild = anyInPlaceBuild ||
+			iTarget.MaybeFastBuildInfo() != nil || iTarget.MaybeLiveUpdateInfo() != nil
+
+		// If this is our first build of the image, maybe we built it
+		// in an earlier session. Hashing walks the whole build context,
+		// so we only do it when the cache can help.
+		//
+		// Hash the contents before we build, so that we don't record
+		// changes made during the build as part of this image.
+		hash := ""
+		if state.LastResult.IsEmpty() {
+			hash, err = build.ImageTargetHash(iTarget)
+			if err != nil {
+				logger.Get(ctx).Debugf("Could not hash image %s: %v", iTarget.ConfigurationRef, err)
+				hash = ""
+			}
+
+			ref, ok := ibd.useCachedImage(ctx, ps, iTarget, kTargets, hash)
+			if ok {
+				return store.NewImageBuildResult(iTarget.ID(), ref), nil
+			}
+		}
+
 		ref, err := ibd.icb.Build(ctx, iTarget, state, ps)
 		if err != nil {
 			return store.BuildResult{}, err
@@ -145,8 +175,11 @@ func (ibd *ImageBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.R
 			return store.BuildResult{}, err
 		}
 
-		anyInPlaceBuild = anyInPlaceBuild ||
-			iTarget.MaybeFastBuildInfo() != nil || iTarget.MaybeLiveUpdateInfo() != nil
+		err = ibd.imageCache.Save(iTarget.ID(), hash, ref)
+		if err != nil {
+			logger.Get(ctx).Debugf("Could not save image %s to build cache: %v", ref, err)
+		}
+
 		return store.NewImageBuildResult(iTarget.ID(), ref), nil
 	})
 	if err != nil {
@@ -167,14 +200,7 @@ func (ibd *ImageBuildAndDeployer) push(ctx context.Context, ref reference.NamedT
 	ps.StartPipelineStep(ctx, "Pushing %s", ref.String())
 	defer ps.EndPipelineStep(ctx)
 
-	cbSkip := false
-	if iTarget.IsCustomBuild() {
-		cbSkip = iTarget.CustomBuildInfo().DisablePush
-	}
-
-	// We can also skip the push of the image if it isn't used
-	// in any k8s resources! (e.g., it's consumed by another image).
-	if ibd.canAlwaysSkipPush() || !isImageDeployedToK8s(iTarget, kTargets) || cbSkip {
+	if ibd.skipPush(iTarget, kTargets) {
 		ps.Printf(ctx, "Skipping push")
 		return ref, nil
 	}
@@ -197,6 +223,35 @@ func (ibd *ImageBuildAndDeployer) push(ctx context.Context, ref reference.NamedT
 	return ref, nil
 }
 
+// Look for an image that we built from the same contents in an earlier session,
+// and push it if necessary. Returns false if we need to build the image.
+func (ibd *ImageBuildAndDeployer) useCachedImage(ctx context.Context, ps *build.PipelineState, iTarget model.ImageTarget, kTargets []model.K8sTarget, hash string) (reference.NamedTagged, bool) {
+	needsPush := !ibd.skipPush(iTarget, kTargets) && ibd.env != k8s.EnvKIND
+	ref, inRegistry, err := ibd.imageCache.Lookup(ctx, iTarget.ID(), hash, needsPush)
+	if err != nil {
+		logger.Get(ctx).Debugf("Could not read build cache: %v", err)
+		return nil, false
+	}
+	if ref == nil {
+		return nil, false
+	}
+
+	ps.StartPipelineStep(ctx, "Using cached image: [%s]", iTarget.ConfigurationRef.String())
+	ps.Printf(ctx, "Image unchanged since last build: %s", ref.String())
+	ps.EndPipelineStep(ctx)
+
+	if inRegistry {
+		return ref, true
+	}
+
+	ref, err = ibd.push(ctx, ref, ps, iTarget, kTargets)
+	if err != nil {
+		logger.Get(ctx).Debugf("Could not push cached image %s: %v", ref, err)
+		return nil, false
+	}
+	return ref, true
+}
+
 // Returns: the entities deployed and the namespace of the pod with the given image name/tag.
 func (ibd *ImageBuildAndDeployer) deploy(ctx context.Context, st store.RStore, ps *build.PipelineState,
 	iTargetMap map[model.TargetID]model.ImageTarget, k8sTargets []model.K8sTarget, results store.BuildResultSet, needsSynclet bool) error {
@@ -210,6 +265,11 @@ func (ibd *ImageBuildAndDeployer) deploy(ctx context.Context, st store.RStore, p
 	deployID := model.NewDeployID()
 	deployLabel := k8s.TiltDeployLabel(deployID)
 
+	state := st.RLockState()
+	ownerLabel := k8s.TiltOwnerLabel(state.TiltfilePath)
+	namespace := state.K8sNamespace()
+	st.RUnlockState()
+
 	var targetIDs []model.TargetID
 
 	for _, k8sTarget := range k8sTargets {
@@ -223,7 +283,7 @@ func (ibd *ImageBuildAndDeployer) deploy(ctx context.Context, st store.RStore, p
 		depIDs := k8sTarget.DependencyIDs()
 		injectedDepIDs := map[model.TargetID]bool{}
 		for _, e := range entities {
-			e, err = k8s.InjectLabels(e, []model.LabelPair{k8s.TiltRunLabel(), {Key: k8s.ManifestNameLabel, Value: k8sTarget.Name.String()}, deployLabel})
+			e, err = k8s.InjectLabels(e, []model.LabelPair{k8s.TiltRunLabel(), ownerLabel, {Key: k8s.ManifestNameLabel, Value: k8sTarget.Name.String()}, deployLabel})
 			if err != nil {
 				return errors.Wrap(err, "deploy")
 			}
@@ -284,12 +344,47 @@ func (ibd *ImageBuildAndDeployer) deploy(ctx context.Context, st store.RStore, p
 		}
 	}
 
+	newK8sEntities, err := ibd.namespaces.injectK8sNamespace(ctx, namespace, newK8sEntities)
+	if err != nil {
+		return err
+	}
+
 	deployIDActions := NewDeployIDActionsForTargets(targetIDs, deployID)
 	for _, a := range deployIDActions {
 		st.Dispatch(a)
 	}
 
-	return ibd.k8sClient.Upsert(ctx, newK8sEntities)
+	// A Job can't be updated once it's created, and rebuilding it should
+	// run it again, so we always delete and re-create it.
+	jobs, rest, err := k8s.Filter(newK8sEntities, func(e k8s.K8sEntity) (bool, error) {
+		return e.HasKind("Job"), nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(jobs) > 0 {
+		err = ibd.k8sClient.Delete(ctx, jobs)
+		if err != nil {
+			return err
+		}
+		err = ibd.k8sClient.Create(ctx, jobs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ibd.k8sClient.Upsert(ctx, rest)
+}
+
+func (ibd *ImageBuildAndDeployer) skipPush(iTarget model.ImageTarget, kTargets []model.K8sTarget) bool {
+	cbSkip := false
+	if iTarget.IsCustomBuild() {
+		cbSkip = iTarget.CustomBuildInfo().DisablePush
+	}
+
+	// We can also skip the push of the image if it isn't used
+	// in any k8s resources! (e.g., it's consumed by another image).
+	return ibd.canAlwaysSkipPush() || !isImageDeployedToK8s(iTarget, kTargets) || cbSkip
 }
 
 // If we're using docker-for-desktop as our k8s backend,
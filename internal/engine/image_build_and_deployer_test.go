@@ -11,6 +11,7 @@ import (
 
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/registry"
 	"github.com/opencontainers/go-digest"
 	"github.com/stretchr/testify/assert"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/windmilleng/tilt/internal/testutils"
 	"github.com/windmilleng/tilt/internal/testutils/output"
 	"github.com/windmilleng/tilt/internal/testutils/tempdir"
+	"github.com/windmilleng/tilt/internal/yaml"
 )
 
 func TestDockerBuildWithCache(t *testing.T) {
@@ -174,6 +176,51 @@ func TestNoImageTargets(t *testing.T) {
 	expectedLabelStr := fmt.Sprintf("%s: %s", k8s.ManifestNameLabel, targName)
 	assert.Equalf(t, 1, strings.Count(f.k8s.Yaml, expectedLabelStr),
 		"Expected \"%s\"image to appear once in YAML: %s", expectedLabelStr, f.k8s.Yaml)
+
+	ownerLabel := k8s.TiltOwnerLabel("")
+	expectedOwnerStr := fmt.Sprintf("%s: %s", ownerLabel.Key, ownerLabel.Value)
+	assert.Containsf(t, f.k8s.Yaml, expectedOwnerStr, "Expected owner label in YAML: %s", f.k8s.Yaml)
+}
+
+func TestDeployToNamespaceOverride(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	state := store.NewState()
+	state.K8sNamespaceFromFlag = "dev-jane"
+	f.st.SetState(*state)
+
+	specs := []model.TargetSpec{
+		model.K8sTarget{Name: "sancho", YAML: testyaml.SanchoYAML},
+	}
+
+	_, err := f.ibd.BuildAndDeploy(f.ctx, f.st, specs, store.BuildStateSet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, f.k8s.Yaml, "namespace: dev-jane")
+	assert.NotContains(t, f.k8s.Yaml, "namespace: sancho-ns")
+}
+
+func TestDeployJobRecreates(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	specs := []model.TargetSpec{
+		model.K8sTarget{Name: "pi", YAML: yaml.ConcatYAML(testyaml.JobYAML, testyaml.SanchoYAML)},
+	}
+
+	_, err := f.ibd.BuildAndDeploy(f.ctx, f.st, specs, store.BuildStateSet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, f.k8s.DeletedYaml, "name: pi")
+	assert.Contains(t, f.k8s.CreatedYaml, "name: pi")
+	assert.NotContains(t, f.k8s.CreatedYaml, "name: sancho")
+	assert.Contains(t, f.k8s.Yaml, "name: sancho")
+	assert.NotContains(t, f.k8s.Yaml, "name: pi")
 }
 
 func TestMultiStageDockerBuild(t *testing.T) {
@@ -310,6 +357,92 @@ func TestKINDPush(t *testing.T) {
 	assert.Equal(t, 0, f.docker.PushCount)
 }
 
+func TestImageCachedFromEarlierSession(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	f.WriteFile("sancho/main.go", "package main")
+	manifest := NewSanchoDockerBuildManifestWithBuildPath(f.JoinPath("sancho"))
+	iTarget := manifest.ImageTargetAt(0)
+	result, err := f.ibd.BuildAndDeploy(f.ctx, f.st, buildTargets(manifest), store.BuildStateSet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, f.docker.BuildCount)
+	assert.Equal(t, 1, f.docker.PushCount)
+
+	// A new session, where the image is still in the registry.
+	ref := result[iTarget.ID()].Image
+	f.docker.RegistryImages[ref.String()] = registry.DistributionInspect{}
+	result, err = f.ibd.BuildAndDeploy(f.ctx, f.st, buildTargets(manifest), store.BuildStateSet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, f.docker.BuildCount)
+	assert.Equal(t, 1, f.docker.PushCount)
+	assert.Equal(t, ref.String(), result[iTarget.ID()].Image.String())
+}
+
+func TestImageCacheMissWhenFilesChange(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvDockerDesktop)
+	defer f.TearDown()
+
+	f.WriteFile("sancho/main.go", "package main")
+	manifest := NewSanchoDockerBuildManifestWithBuildPath(f.JoinPath("sancho"))
+	iTarget := manifest.ImageTargetAt(0)
+	result, err := f.ibd.BuildAndDeploy(f.ctx, f.st, buildTargets(manifest), store.BuildStateSet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.docker.Images[result[iTarget.ID()].Image.String()] = types.ImageInspect{}
+
+	f.WriteFile("sancho/main.go", "package main\n\nfunc main() {}")
+	_, err = f.ibd.BuildAndDeploy(f.ctx, f.st, buildTargets(manifest), store.BuildStateSet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, f.docker.BuildCount)
+
+	// Nothing changed since the last build, so we can use the local image.
+	_, err = f.ibd.BuildAndDeploy(f.ctx, f.st, buildTargets(manifest), store.BuildStateSet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, f.docker.BuildCount)
+}
+
+func TestImageCacheOnlyOnFirstBuild(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvDockerDesktop)
+	defer f.TearDown()
+
+	f.WriteFile("sancho/main.go", "package main")
+	manifest := NewSanchoDockerBuildManifestWithBuildPath(f.JoinPath("sancho"))
+	iTarget := manifest.ImageTargetAt(0)
+	result, err := f.ibd.BuildAndDeploy(f.ctx, f.st, buildTargets(manifest), store.BuildStateSet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.docker.Images[result[iTarget.ID()].Image.String()] = types.ImageInspect{}
+
+	// Later builds in the session don't hash the build context, so they
+	// don't consult or update the cache.
+	f.WriteFile("sancho/main.go", "package main\n\nfunc main() {}")
+	stateSet := store.BuildStateSet{iTarget.ID(): store.NewBuildState(result[iTarget.ID()], []string{f.JoinPath("sancho/main.go")})}
+	result, err = f.ibd.BuildAndDeploy(f.ctx, f.st, buildTargets(manifest), stateSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.docker.Images[result[iTarget.ID()].Image.String()] = types.ImageInspect{}
+	assert.Equal(t, 2, f.docker.BuildCount)
+
+	// So a new session with the same files builds again.
+	_, err = f.ibd.BuildAndDeploy(f.ctx, f.st, buildTargets(manifest), store.BuildStateSet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, f.docker.BuildCount)
+}
+
 func TestCustomBuildDisablePush(t *testing.T) {
 	f := newIBDFixture(t, k8s.EnvKIND)
 	defer f.TearDown()
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+)
+
+// Watches the Jobs we deploy, so that we know when they complete or fail.
+type JobWatcher struct {
+	kCli      k8s.Client
+	watching  bool
+	namespace k8s.Namespace
+
+	// Stops the current watch, when we need to watch a different namespace.
+	cancelWatch context.CancelFunc
+}
+
+func NewJobWatcher(kCli k8s.Client) *JobWatcher {
+	return &JobWatcher{
+		kCli: kCli,
+	}
+}
+
+// Returns the namespace to watch (empty means all namespaces),
+// and whether we need to start a new watch.
+func (w *JobWatcher) needsWatch(st store.RStore) (k8s.Namespace, bool) {
+	state := st.RLockState()
+	defer st.RUnlockState()
+
+	atLeastOneJob := false
+	for _, m := range state.Manifests() {
+		if m.IsK8s() && m.K8sTarget().IsJob {
+			atLeastOneJob = true
+		}
+	}
+	namespace := state.K8sNamespace()
+	needsWatch := !w.watching || namespace != w.namespace
+	return namespace, atLeastOneJob && state.WatchRuntime() && needsWatch
+}
+
+func (w *JobWatcher) OnChange(ctx context.Context, st store.RStore) {
+	namespace, ok := w.needsWatch(st)
+	if !ok {
+		return
+	}
+	if w.cancelWatch != nil {
+		w.cancelWatch()
+	}
+	w.watching = true
+	w.namespace = namespace
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	w.cancelWatch = cancel
+	ch, err := w.kCli.WatchJobs(watchCtx, namespace, []model.LabelPair{k8s.TiltRunLabel()})
+	if err != nil {
+		err = errors.Wrap(err, "Error watching jobs. Are you connected to kubernetes?\n")
+		st.Dispatch(NewErrorAction(err))
+		return
+	}
+
+	go w.dispatchJobChangesLoop(watchCtx, ch, st)
+}
+
+func (w *JobWatcher) dispatchJobChangesLoop(ctx context.Context, ch <-chan *batchv1.Job, st store.RStore) {
+	for {
+		select {
+		case job, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			st.Dispatch(NewJobChangeAction(job))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Whether the job has finished, according to its conditions.
+func jobStatus(job *batchv1.Job) store.K8sJobStatus {
+	for _, c := range job.Status.Conditions {
+		if c.Status != v1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return store.K8sJobStatusCompleted
+		case batchv1.JobFailed:
+			return store.K8sJobStatusFailed
+		}
+	}
+	return ""
+}
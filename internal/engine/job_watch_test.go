@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/k8s/testyaml"
+	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/testutils/output"
+)
+
+func TestJobWatcherRestartsWatchOnNamespaceChange(t *testing.T) {
+	kCli := k8s.NewFakeK8sClient()
+	jw := NewJobWatcher(kCli)
+	st := store.NewTestingStore()
+	ctx, cancel := context.WithCancel(output.CtxForTest())
+	defer cancel()
+
+	state := store.NewState()
+	state.WatchFiles = true
+	m := k8s.NewK8sOnlyManifestForTesting("pi", testyaml.JobYAML)
+	kTarget := m.K8sTarget()
+	kTarget.IsJob = true
+	state.UpsertManifestTarget(store.NewManifestTarget(m.WithDeployTarget(kTarget)))
+	st.SetState(*state)
+
+	jw.OnChange(ctx, st)
+	assert.Equal(t, k8s.Namespace(""), kCli.WatchedJobsNamespace)
+	firstCtx := kCli.WatchedJobsCtx
+	if assert.NotNil(t, firstCtx) {
+		assert.NoError(t, firstCtx.Err())
+	}
+
+	state.K8sNamespaceFromTiltfile = "dev"
+	st.SetState(*state)
+	jw.OnChange(ctx, st)
+
+	assert.Equal(t, k8s.Namespace("dev"), kCli.WatchedJobsNamespace)
+	if assert.NotNil(t, kCli.WatchedJobsCtx) {
+		assert.NoError(t, kCli.WatchedJobsCtx.Err())
+	}
+	if firstCtx != nil {
+		assert.Equal(t, context.Canceled, firstCtx.Err(), "expected the old watch to be canceled")
+	}
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+)
+
+// Creates the namespace from `--namespace` or the Tiltfile's namespace().
+//
+// Every manifest deploys to the same namespace, so we only create it the first
+// time we deploy to it in a session, rather than on every deploy.
+type K8sNamespaceEnsurer struct {
+	kCli    k8s.Client
+	mu      sync.Mutex
+	ensured map[k8s.Namespace]bool
+}
+
+func NewK8sNamespaceEnsurer(kCli k8s.Client) *K8sNamespaceEnsurer {
+	return &K8sNamespaceEnsurer{
+		kCli:    kCli,
+		ensured: make(map[k8s.Namespace]bool),
+	}
+}
+
+// Moves the entities into the namespace, creating it if it doesn't exist.
+// If the namespace is empty, the entities stay in the namespaces from their YAML.
+func (e *K8sNamespaceEnsurer) injectK8sNamespace(ctx context.Context, ns k8s.Namespace, entities []k8s.K8sEntity) ([]k8s.K8sEntity, error) {
+	if ns == "" {
+		return entities, nil
+	}
+
+	err := e.ensure(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	return k8s.InjectNamespaceAll(entities, ns)
+}
+
+func (e *K8sNamespaceEnsurer) ensure(ctx context.Context, ns k8s.Namespace) error {
+	// Hold the lock while we create the namespace, so that builds
+	// running in parallel don't all try to create it.
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ensured[ns] {
+		return nil
+	}
+
+	// Create the namespace on its own, so that it exists before we `kubectl replace`
+	// any immutable entities, and so that falling back to `kubectl delete && apply`
+	// never deletes it.
+	err := e.kCli.Upsert(ctx, []k8s.K8sEntity{k8s.NewNamespaceEntity(ns)})
+	if err != nil {
+		return errors.Wrapf(err, "creating namespace %s", ns)
+	}
+
+	e.ensured[ns] = true
+	return nil
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/k8s/testyaml"
+	"github.com/windmilleng/tilt/internal/testutils/output"
+)
+
+func TestNamespaceCreatedOncePerSession(t *testing.T) {
+	kCli := k8s.NewFakeK8sClient()
+	ensurer := NewK8sNamespaceEnsurer(kCli)
+	ctx := output.CtxForTest()
+
+	entities, err := k8s.ParseYAMLFromString(testyaml.SanchoYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	injected, err := ensurer.injectK8sNamespace(ctx, "dev-jane", entities)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "dev-jane", injected[0].Namespace().String())
+	assert.Contains(t, kCli.Yaml, "kind: Namespace")
+	assert.Contains(t, kCli.Yaml, "name: dev-jane")
+
+	// Another manifest deploys to the same namespace.
+	kCli.Yaml = ""
+	injected, err = ensurer.injectK8sNamespace(ctx, "dev-jane", entities)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "dev-jane", injected[0].Namespace().String())
+	assert.Empty(t, kCli.Yaml, "expected the namespace to be created only once")
+
+	// A different namespace gets created.
+	_, err = ensurer.injectK8sNamespace(ctx, "dev-joe", entities)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, kCli.Yaml, "name: dev-joe")
+}
+
+func TestNamespaceCreatedAgainAfterError(t *testing.T) {
+	kCli := k8s.NewFakeK8sClient()
+	ensurer := NewK8sNamespaceEnsurer(kCli)
+	ctx := output.CtxForTest()
+
+	kCli.UpsertError = fmt.Errorf("connection refused")
+	_, err := ensurer.injectK8sNamespace(ctx, "dev-jane", nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "creating namespace dev-jane")
+	}
+
+	kCli.UpsertError = nil
+	_, err = ensurer.injectK8sNamespace(ctx, "dev-jane", nil)
+	assert.NoError(t, err)
+	assert.Contains(t, kCli.Yaml, "name: dev-jane")
+}
@@ -0,0 +1,320 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/windmilleng/wmclient/pkg/dirs"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/logger"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+)
+
+// Deletes the Kubernetes objects that Tilt deployed from this Tiltfile,
+// but that the Tiltfile no longer declares (e.g., because someone removed
+// a resource). Runs after every successful Tiltfile load, including the first,
+// so it also cleans up after earlier runs of Tilt.
+//
+// We only look for objects in the namespaces that the Tiltfile has declared
+// objects in, so that we never touch the rest of the cluster. We record those
+// namespaces in the windmill dir, so that a later session still looks in a
+// namespace after the Tiltfile stops declaring anything in it.
+type KubernetesPruner struct {
+	kCli             k8s.Client
+	configNamespace  k8s.Namespace
+	dir              *dirs.WindmillDir
+	lastTiltfileLoad time.Time
+	namespaces       map[k8s.Namespace]bool
+}
+
+func NewKubernetesPruner(kCli k8s.Client, configNamespace k8s.Namespace, dir *dirs.WindmillDir) *KubernetesPruner {
+	return &KubernetesPruner{
+		kCli:            kCli,
+		configNamespace: configNamespace,
+		dir:             dir,
+		namespaces:      make(map[k8s.Namespace]bool),
+	}
+}
+
+// The file, relative to the windmill dir, where we record the namespaces
+// that each owner has deployed objects to.
+const ownedNamespacesFile = "tilt/owned_namespaces.json"
+
+type pruneEntry struct {
+	ownerLabel model.LabelPair
+	declared   []model.Manifest
+
+	// The namespace that all objects are deployed to, if any.
+	namespace k8s.Namespace
+}
+
+func (p *KubernetesPruner) needsPrune(st store.RStore) (pruneEntry, bool) {
+	state := st.RLockState()
+	defer st.RUnlockState()
+
+	// If the Tiltfile didn't load, we don't know what it declares.
+	build := state.LastTiltfileBuild
+	if build.Empty() || build.Error != nil || !build.FinishTime.After(p.lastTiltfileLoad) {
+		return pruneEntry{}, false
+	}
+	p.lastTiltfileLoad = build.FinishTime
+
+	// The manifests we aren't running are still in the Tiltfile.
+	declared := append(state.Manifests(), state.UnmatchedManifests...)
+	declared = append(declared, state.GlobalYAML)
+
+	return pruneEntry{
+		ownerLabel: k8s.TiltOwnerLabel(state.TiltfilePath),
+		declared:   declared,
+		namespace:  state.K8sNamespace(),
+	}, true
+}
+
+func (p *KubernetesPruner) OnChange(ctx context.Context, st store.RStore) {
+	entry, ok := p.needsPrune(st)
+	if !ok {
+		return
+	}
+
+	err := p.prune(ctx, entry)
+	if err != nil {
+		logger.Get(ctx).Infof("Error pruning Kubernetes objects: %v", err)
+	}
+}
+
+func (p *KubernetesPruner) prune(ctx context.Context, entry pruneEntry) error {
+	declared, err := p.declaredKeys(entry.declared, entry.namespace)
+	if err != nil {
+		return err
+	}
+
+	owner := entry.ownerLabel.Value
+	recorded, err := p.readOwnedNamespaces(owner)
+	if err != nil {
+		logger.Get(ctx).Debugf("Error reading owned namespaces: %v", err)
+	}
+	for _, ns := range recorded {
+		p.namespaces[ns] = true
+	}
+
+	inUse := make(map[k8s.Namespace]bool)
+	for key := range declared {
+		if key.namespace != "" {
+			inUse[k8s.Namespace(key.namespace)] = true
+			p.namespaces[k8s.Namespace(key.namespace)] = true
+		}
+	}
+	namespaces := sortedNamespaces(p.namespaces)
+	p.writeOwnedNamespaces(ctx, owner, namespaces)
+
+	owned, err := p.kCli.ListByLabels(ctx, namespaces, []model.LabelPair{entry.ownerLabel})
+	if err != nil {
+		return err
+	}
+
+	var toPrune []k8s.K8sEntity
+	var names []string
+	seen := make(map[pruneKey]bool)
+	for _, e := range owned {
+		// kubectl lists an object once for each API group that serves it
+		// (e.g., deployments.apps and deployments.extensions).
+		key := newPruneKey(e, rawNamespace(e))
+		if declared[key] || seen[key] || !isPrunable(e) {
+			if key.namespace != "" {
+				inUse[k8s.Namespace(key.namespace)] = true
+			}
+			continue
+		}
+		seen[key] = true
+		toPrune = append(toPrune, e)
+		names = append(names, fmt.Sprintf("%s/%s", e.Kind.Kind, e.Name()))
+	}
+
+	if len(toPrune) > 0 {
+		logger.Get(ctx).Infof("Pruning Kubernetes objects that are no longer in the Tiltfile: %s", strings.Join(names, ", "))
+		err = p.kCli.Delete(ctx, toPrune)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Once we've cleaned out a namespace that the Tiltfile no longer declares
+	// anything in, we can stop looking there.
+	p.namespaces = inUse
+	p.writeOwnedNamespaces(ctx, owner, sortedNamespaces(inUse))
+	return nil
+}
+
+func sortedNamespaces(set map[k8s.Namespace]bool) []k8s.Namespace {
+	var result []k8s.Namespace
+	for ns := range set {
+		result = append(result, ns)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func (p *KubernetesPruner) readOwnedNamespacesFile() (map[string][]k8s.Namespace, error) {
+	contents, err := p.dir.ReadFile(ownedNamespacesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "reading owned namespaces")
+	}
+
+	var result map[string][]k8s.Namespace
+	err = json.Unmarshal([]byte(contents), &result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", ownedNamespacesFile)
+	}
+	return result, nil
+}
+
+func (p *KubernetesPruner) readOwnedNamespaces(owner string) ([]k8s.Namespace, error) {
+	if p.dir == nil {
+		return nil, nil
+	}
+
+	all, err := p.readOwnedNamespacesFile()
+	if err != nil {
+		return nil, err
+	}
+	return all[owner], nil
+}
+
+// We remember the namespaces in memory too, so failing to record them
+// only matters for later sessions.
+func (p *KubernetesPruner) writeOwnedNamespaces(ctx context.Context, owner string, namespaces []k8s.Namespace) {
+	if p.dir == nil {
+		return
+	}
+
+	err := p.writeOwnedNamespacesFile(owner, namespaces)
+	if err != nil {
+		logger.Get(ctx).Debugf("Error recording owned namespaces: %v", err)
+	}
+}
+
+func (p *KubernetesPruner) writeOwnedNamespacesFile(owner string, namespaces []k8s.Namespace) error {
+	all, err := p.readOwnedNamespacesFile()
+	if err != nil || all == nil {
+		// If the file is corrupt, start over.
+		all = make(map[string][]k8s.Namespace)
+	}
+
+	if len(namespaces) == 0 {
+		delete(all, owner)
+	} else {
+		all[owner] = namespaces
+	}
+
+	contents, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "writing owned namespaces")
+	}
+
+	err = p.dir.WriteFile(ownedNamespacesFile, string(contents))
+	if err != nil {
+		return errors.Wrap(err, "writing owned namespaces")
+	}
+	return nil
+}
+
+// The keys of all the objects the manifests declare.
+func (p *KubernetesPruner) declaredKeys(manifests []model.Manifest, override k8s.Namespace) (map[pruneKey]bool, error) {
+	result := make(map[pruneKey]bool)
+	for _, m := range manifests {
+		if !m.IsK8s() {
+			continue
+		}
+
+		entities, err := k8s.ParseYAMLFromString(m.K8sTarget().YAML)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entities {
+			namespace := rawNamespace(e)
+			if override != "" && !e.IsClusterScoped() {
+				// We moved the object to the override namespace, unless it's
+				// a cluster-scoped custom resource, which we can't tell from the YAML.
+				result[newPruneKey(e, override.String())] = true
+				result[newPruneKey(e, "")] = true
+				continue
+			}
+
+			if namespace != "" {
+				result[newPruneKey(e, namespace)] = true
+				continue
+			}
+
+			// Objects without a namespace go in the namespace of the kube context,
+			// unless they're cluster-scoped, which we can't tell from the YAML.
+			// So match them either way.
+			result[newPruneKey(e, p.defaultNamespace().String())] = true
+			result[newPruneKey(e, "")] = true
+		}
+	}
+	return result, nil
+}
+
+// The namespace that objects without one go in.
+func (p *KubernetesPruner) defaultNamespace() k8s.Namespace {
+	if p.configNamespace == "" {
+		return k8s.DefaultNamespace
+	}
+	return p.configNamespace
+}
+
+// Objects that another object controls (e.g., the pods of a deployment)
+// get our labels from their pod template, but they aren't ours to delete.
+//
+// We never prune namespaces, because deleting one deletes everything in it.
+// `tilt down --delete-namespaces` deletes them explicitly.
+func isPrunable(e k8s.K8sEntity) bool {
+	if e.Kind.Kind == "Namespace" {
+		return false
+	}
+
+	m, err := meta.Accessor(e.Obj)
+	if err != nil {
+		return false
+	}
+	return len(m.GetOwnerReferences()) == 0
+}
+
+// Identifies an object in the cluster. We leave out the API group, because the
+// cluster may serve an object under a different group than the YAML declares.
+type pruneKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+func newPruneKey(e k8s.K8sEntity, namespace string) pruneKey {
+	return pruneKey{
+		kind:      e.Kind.Kind,
+		namespace: namespace,
+		name:      e.Name(),
+	}
+}
+
+// The namespace in the object's metadata, which is empty for cluster-scoped objects
+// (unlike K8sEntity.Namespace(), which fills in the default).
+func rawNamespace(e k8s.K8sEntity) string {
+	m, err := meta.Accessor(e.Obj)
+	if err != nil {
+		return ""
+	}
+	return m.GetNamespace()
+}
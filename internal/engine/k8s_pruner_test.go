@@ -0,0 +1,345 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/windmilleng/wmclient/pkg/dirs"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/k8s/testyaml"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/testutils/output"
+	"github.com/windmilleng/tilt/internal/testutils/tempdir"
+)
+
+const prunerTiltfilePath = "/home/tilt/Tiltfile"
+
+func TestPruneRemovedObjects(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(nil,
+		k8s.NewK8sOnlyManifestForTesting("sancho", testyaml.SanchoYAML),
+		k8s.NewK8sOnlyManifestForTesting("doggos", testyaml.DoggosDeploymentYaml))
+	f.setListed(testyaml.SanchoYAML, testyaml.DoggosDeploymentYaml)
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+	f.assertDeleted()
+
+	// Remove doggos from the Tiltfile.
+	f.setState(nil, k8s.NewK8sOnlyManifestForTesting("sancho", testyaml.SanchoYAML))
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted("Deployment/doggos")
+}
+
+func TestPruneOnlyInDeclaredNamespaces(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(nil, k8s.NewK8sOnlyManifestForTesting("sancho", testyaml.SanchoYAML))
+
+	// Deployed by this Tiltfile to a namespace that it doesn't declare
+	// anything in anymore, and that we have no record of.
+	f.setListed(testyaml.SanchoYAML, testyaml.DoggosDeploymentYaml)
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted()
+}
+
+func TestPruneInNamespacesFromEarlierSessions(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(nil,
+		k8s.NewK8sOnlyManifestForTesting("sancho", testyaml.SanchoYAML),
+		k8s.NewK8sOnlyManifestForTesting("doggos", testyaml.DoggosDeploymentYaml))
+	f.setListed(testyaml.SanchoYAML, testyaml.DoggosDeploymentYaml)
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+	f.assertDeleted()
+
+	// Remove doggos, the only object in its namespace, between sessions.
+	f.restart()
+	f.setState(nil, k8s.NewK8sOnlyManifestForTesting("sancho", testyaml.SanchoYAML))
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted("Deployment/doggos")
+}
+
+func TestPruneForgetsEmptyNamespaces(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(nil,
+		k8s.NewK8sOnlyManifestForTesting("sancho", testyaml.SanchoYAML),
+		k8s.NewK8sOnlyManifestForTesting("doggos", testyaml.DoggosDeploymentYaml))
+	f.setListed(testyaml.SanchoYAML, testyaml.DoggosDeploymentYaml)
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.restart()
+	f.setState(nil, k8s.NewK8sOnlyManifestForTesting("sancho", testyaml.SanchoYAML))
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+	f.assertDeleted("Deployment/doggos")
+
+	// We pruned everything in the-dog-zone, so we stop looking there.
+	namespaces, err := f.pruner.readOwnedNamespaces(k8s.TiltOwnerLabel(prunerTiltfilePath).Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []k8s.Namespace{"sancho-ns"}, namespaces)
+}
+
+func TestPruneKeepsUnmatchedManifests(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+
+	// `tilt up sancho`
+	f.setState(nil, k8s.NewK8sOnlyManifestForTesting("sancho", testyaml.SanchoYAML))
+	state := f.st.RLockState()
+	f.st.RUnlockState()
+	state.UnmatchedManifests = []model.Manifest{
+		k8s.NewK8sOnlyManifestForTesting("doggos", testyaml.DoggosDeploymentYaml),
+	}
+	f.st.SetState(state)
+	f.pruner.namespaces["the-dog-zone"] = true
+	f.setListed(testyaml.SanchoYAML, testyaml.DoggosDeploymentYaml)
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted()
+}
+
+func TestPruneNothingWhenEverythingDeclared(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(nil,
+		k8s.NewK8sOnlyManifestForTesting("sancho", testyaml.SanchoYAML),
+		k8s.NewK8sOnlyManifestForTesting("doggos", testyaml.DoggosDeploymentYaml))
+	f.setListed(testyaml.SanchoYAML, testyaml.DoggosDeploymentYaml)
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted()
+}
+
+func TestPruneOnlyOwnedObjects(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(nil)
+	f.pruner.namespaces["sancho-ns"] = true
+	f.pruner.namespaces["the-dog-zone"] = true
+	f.setListed(testyaml.SanchoYAML)
+
+	// Deployed from a different Tiltfile
+	other, err := k8s.ParseYAMLFromString(testyaml.DoggosDeploymentYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other[0], err = k8s.InjectLabels(other[0], []model.LabelPair{k8s.TiltOwnerLabel("/somewhere/else/Tiltfile")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.kCli.ListedEntities = append(f.kCli.ListedEntities, other...)
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted("Deployment/sancho")
+}
+
+func TestPruneSkipsNamespacesAndControlledObjects(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(nil)
+	f.pruner.namespaces["default"] = true
+	f.setListed(testyaml.MyNamespaceYAML, testyaml.PodYAML)
+
+	pod := f.kCli.ListedEntities[1]
+	m, err := meta.Accessor(pod.Obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetOwnerReferences([]metav1.OwnerReference{{Kind: "ReplicaSet", Name: "sleep-1234"}})
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted()
+}
+
+func TestPruneMatchesDefaultNamespaceWithoutConfigNamespace(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+
+	f.setState(nil, k8s.NewK8sOnlyManifestForTesting("snack", testyaml.SnackYaml))
+	f.setListed(testyaml.SnackYaml)
+	m, err := meta.Accessor(f.kCli.ListedEntities[0].Obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetNamespace("default")
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted()
+}
+
+func TestPruneMatchesDefaultNamespace(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.pruner.configNamespace = "dev"
+
+	// Declared without a namespace, listed in the namespace of the kube context.
+	f.setState(nil, k8s.NewK8sOnlyManifestForTesting("snack", testyaml.SnackYaml))
+	f.setListed(testyaml.SnackYaml)
+	m, err := meta.Accessor(f.kCli.ListedEntities[0].Obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetNamespace("dev")
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted()
+}
+
+func TestPruneWithNamespaceOverride(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(nil, k8s.NewK8sOnlyManifestForTesting("sancho", testyaml.SanchoYAML))
+	state := f.st.RLockState()
+	f.st.RUnlockState()
+	state.K8sNamespaceFromTiltfile = "dev-jane"
+	f.st.SetState(state)
+
+	// Deployed to the override namespace, and left over in the namespace from the YAML
+	// by an earlier load without the override.
+	f.pruner.namespaces["sancho-ns"] = true
+	f.setListed(testyaml.SanchoYAML, testyaml.SanchoYAML)
+	m, err := meta.Accessor(f.kCli.ListedEntities[0].Obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetNamespace("dev-jane")
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted("Deployment/sancho")
+	entities, err := k8s.ParseYAMLFromString(f.kCli.DeletedYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "sancho-ns", entities[0].Namespace().String())
+}
+
+func TestPruneGlobalYAMLIsDeclared(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(nil)
+	state := f.st.RLockState()
+	f.st.RUnlockState()
+	state.GlobalYAML = k8s.NewK8sOnlyManifestForTesting(model.GlobalYAMLManifestName, testyaml.SecretYaml)
+	f.st.SetState(state)
+	f.setListed(testyaml.SecretYaml)
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted()
+}
+
+func TestNoPruneOnTiltfileError(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(fmt.Errorf("syntax error"))
+	f.setListed(testyaml.SanchoYAML)
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+
+	f.assertDeleted()
+}
+
+func TestPruneOncePerTiltfileLoad(t *testing.T) {
+	f := newPrunerFixture(t)
+	defer f.TearDown()
+	f.setState(nil)
+	f.pruner.namespaces["sancho-ns"] = true
+	f.setListed(testyaml.SanchoYAML)
+
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+	f.assertDeleted("Deployment/sancho")
+
+	f.kCli.DeletedYaml = ""
+	f.pruner.OnChange(output.CtxForTest(), f.st)
+	f.assertDeleted()
+}
+
+type prunerFixture struct {
+	*tempdir.TempDirFixture
+	t      *testing.T
+	st     *store.TestingStore
+	kCli   *k8s.FakeK8sClient
+	pruner *KubernetesPruner
+}
+
+func newPrunerFixture(t *testing.T) *prunerFixture {
+	f := tempdir.NewTempDirFixture(t)
+	kCli := k8s.NewFakeK8sClient()
+	return &prunerFixture{
+		TempDirFixture: f,
+		t:              t,
+		st:             store.NewTestingStore(),
+		kCli:           kCli,
+		pruner:         NewKubernetesPruner(kCli, "", dirs.NewWindmillDirAt(f.JoinPath("windmill"))),
+	}
+}
+
+// Simulates a new session of Tilt, with the same windmill dir.
+func (f *prunerFixture) restart() {
+	f.pruner = NewKubernetesPruner(f.kCli, "", f.pruner.dir)
+	f.kCli.DeletedYaml = ""
+}
+
+func (f *prunerFixture) setState(tiltfileErr error, manifests ...model.Manifest) {
+	state := store.NewState()
+	state.TiltfilePath = prunerTiltfilePath
+	state.LastTiltfileBuild = model.BuildRecord{
+		StartTime:  time.Now(),
+		FinishTime: time.Now(),
+		Error:      tiltfileErr,
+	}
+	for _, m := range manifests {
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+	}
+	f.st.SetState(*state)
+}
+
+// The objects in the cluster, each deployed by this Tiltfile.
+func (f *prunerFixture) setListed(yamls ...string) {
+	for _, y := range yamls {
+		entities, err := k8s.ParseYAMLFromString(y)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		for _, e := range entities {
+			e, err = k8s.InjectLabels(e, []model.LabelPair{k8s.TiltOwnerLabel(prunerTiltfilePath)})
+			if err != nil {
+				f.t.Fatal(err)
+			}
+			f.kCli.ListedEntities = append(f.kCli.ListedEntities, e)
+		}
+	}
+}
+
+func (f *prunerFixture) assertDeleted(expected ...string) {
+	var actual []string
+	if f.kCli.DeletedYaml != "" {
+		entities, err := k8s.ParseYAMLFromString(f.kCli.DeletedYaml)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		for _, e := range entities {
+			actual = append(actual, fmt.Sprintf("%s/%s", e.Kind.Kind, e.Name()))
+		}
+	}
+	assert.ElementsMatch(f.t, expected, actual)
+}
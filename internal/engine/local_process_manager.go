@@ -0,0 +1,286 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/windmilleng/wmclient/pkg/dirs"
+
+	"github.com/windmilleng/tilt/internal/logger"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/store/logstore"
+)
+
+// How long we give a process group to exit after SIGTERM before we SIGKILL it.
+const localProcessGracePeriod = 2 * time.Second
+
+// Runs the long-running processes (serve_cmd) of local resources.
+//
+// A process is started after the first successful build of its resource,
+// and restarted after every successful build after that (e.g., because
+// one of its deps changed), or when the user asks for a restart. When the
+// process goes away, so does its whole process group.
+//
+// Each process runs in its own process group, which doesn't go away if
+// tilt up dies without cleaning up. So we record the process groups in the
+// windmill dir, and kill the ones left behind by an earlier tilt up of the same
+// Tiltfile before we start anything. `tilt down` kills them too.
+type LocalProcessManager struct {
+	procs   map[model.ManifestName]*localProcess
+	records *localProcessRecords
+
+	tiltfilePath  string
+	killedOrphans bool
+}
+
+func NewLocalProcessManager(dir *dirs.WindmillDir) *LocalProcessManager {
+	return &LocalProcessManager{
+		procs:   make(map[model.ManifestName]*localProcess),
+		records: newLocalProcessRecords(dir),
+	}
+}
+
+type localProcess struct {
+	name        model.ManifestName
+	target      model.LocalTarget
+	buildTime   time.Time // the start time of the build that this process was started after
+	restartTime time.Time // the restart request that this process was started after, if any
+	cmd         *exec.Cmd
+	done        chan struct{}
+}
+
+// Diff the running processes against the state store, returning
+// the processes we need to stop and the ones we need to start.
+func (m *LocalProcessManager) diff(st store.RStore) (setup []*localProcess, teardown []*localProcess) {
+	state := st.RLockState()
+	defer st.RUnlockState()
+
+	m.tiltfilePath = state.TiltfilePath
+
+	inState := make(map[model.ManifestName]bool)
+	for _, mt := range state.Targets() {
+		manifest := mt.Manifest
+		if !manifest.IsLocal() || manifest.LocalTarget().ServeCmd.Empty() {
+			continue
+		}
+		inState[manifest.Name] = true
+
+		ms := mt.State
+		if !ms.CurrentBuild.Empty() {
+			// Don't restart until the build finishes.
+			continue
+		}
+
+		lastBuild := ms.LastBuild()
+		if lastBuild.StartTime.IsZero() || lastBuild.Error != nil {
+			continue
+		}
+
+		existing, ok := m.procs[manifest.Name]
+		if ok && existing.buildTime.Equal(lastBuild.StartTime) && !ms.RestartRequestTime.After(existing.restartTime) {
+			continue
+		}
+
+		if ok {
+			teardown = append(teardown, existing)
+		}
+
+		p := &localProcess{
+			name:        manifest.Name,
+			target:      manifest.LocalTarget(),
+			buildTime:   lastBuild.StartTime,
+			restartTime: ms.RestartRequestTime,
+			done:        make(chan struct{}),
+		}
+		m.procs[manifest.Name] = p
+		setup = append(setup, p)
+	}
+
+	for name, p := range m.procs {
+		if !inState[name] {
+			delete(m.procs, name)
+			teardown = append(teardown, p)
+		}
+	}
+
+	return setup, teardown
+}
+
+func (m *LocalProcessManager) OnChange(ctx context.Context, st store.RStore) {
+	setup, teardown := m.diff(st)
+
+	if !m.killedOrphans && m.tiltfilePath != "" {
+		m.killedOrphans = true
+		err := m.records.killOrphans(ctx, m.tiltfilePath)
+		if err != nil {
+			logger.Get(ctx).Debugf("Error cleaning up old local processes: %v", err)
+		}
+	}
+
+	for _, p := range teardown {
+		m.stop(ctx, p)
+	}
+
+	for _, p := range setup {
+		m.start(ctx, st, p)
+	}
+}
+
+func (m *LocalProcessManager) Teardown(ctx context.Context) {
+	for name, p := range m.procs {
+		m.stop(ctx, p)
+		delete(m.procs, name)
+	}
+}
+
+func (m *LocalProcessManager) start(ctx context.Context, st store.RStore, p *localProcess) {
+	argv := p.target.ServeCmd.Argv
+	l := logger.Get(ctx)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		l.Infof("Error starting %s: %v", p.name, err)
+		close(p.done)
+		return
+	}
+
+	id, err := newLocalProcessID()
+	if err != nil {
+		_ = r.Close()
+		_ = w.Close()
+		l.Infof("Error starting %s: %v", p.name, err)
+		close(p.done)
+		return
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = p.target.Workdir
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", localProcessIDEnv, id))
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	// The command may spawn subprocesses, so set a process group id
+	// so that we can kill them all.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	err = cmd.Start()
+	_ = w.Close()
+	if err != nil {
+		_ = r.Close()
+		l.Infof("Error starting %s: %v", p.name, err)
+		close(p.done)
+		return
+	}
+	p.cmd = cmd
+
+	pid := cmd.Process.Pid
+	err = m.records.add(localProcessRecord{
+		TiltfilePath: m.tiltfilePath,
+		ManifestName: p.name,
+		OwnerPID:     os.Getpid(),
+		PGID:         pid,
+		ID:           id,
+	})
+	if err != nil {
+		l.Debugf("Error recording %s: %v", p.name, err)
+	}
+
+	st.Dispatch(LocalProcessStartedAction{
+		ManifestName: p.name,
+		PID:          pid,
+		StartTime:    time.Now(),
+	})
+
+	go func() {
+		defer close(p.done)
+
+		logWriter := logger.NewPrefixedWriter(logstore.ManifestPrefix(p.name.String()), l.Writer(logger.InfoLvl))
+		actionWriter := LocalProcessLogActionWriter{
+			store:        st,
+			manifestName: p.name,
+			pid:          pid,
+		}
+		_, _ = io.Copy(io.MultiWriter(logWriter, actionWriter), r)
+		_ = r.Close()
+
+		exitCode := 0
+		err := cmd.Wait()
+		if err != nil {
+			exitCode = -1
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				exitCode = exitErr.ExitCode()
+			}
+		}
+
+		err = m.records.remove(pid)
+		if err != nil {
+			l.Debugf("Error recording %s: %v", p.name, err)
+		}
+
+		st.Dispatch(LocalProcessExitedAction{
+			ManifestName: p.name,
+			PID:          pid,
+			ExitCode:     exitCode,
+		})
+	}()
+}
+
+// Kill the process group, and wait for the process to exit.
+func (m *LocalProcessManager) stop(ctx context.Context, p *localProcess) {
+	if p.cmd == nil {
+		return
+	}
+
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+
+	pgid := -p.cmd.Process.Pid
+	err := syscall.Kill(pgid, syscall.SIGTERM)
+	if err != nil {
+		logger.Get(ctx).Debugf("Error stopping %s: %v", p.name, err)
+	}
+
+	select {
+	case <-p.done:
+		return
+	case <-time.After(localProcessGracePeriod):
+	}
+
+	err = syscall.Kill(pgid, syscall.SIGKILL)
+	if err != nil {
+		logger.Get(ctx).Debugf("Error killing %s: %v", p.name, err)
+	}
+
+	select {
+	case <-p.done:
+	case <-time.After(localProcessGracePeriod):
+		logger.Get(ctx).Infof("Timed out waiting for %s to exit", p.name)
+	}
+}
+
+type LocalProcessLogActionWriter struct {
+	store        store.RStore
+	manifestName model.ManifestName
+	pid          int
+}
+
+func (w LocalProcessLogActionWriter) Write(p []byte) (n int, err error) {
+	w.store.Dispatch(LocalProcessLogAction{
+		ManifestName: w.manifestName,
+		PID:          w.pid,
+		logEvent:     newLogEvent(append([]byte{}, p...)),
+	})
+	return len(p), nil
+}
+
+var _ store.Subscriber = &LocalProcessManager{}
+var _ store.SubscriberLifecycle = &LocalProcessManager{}
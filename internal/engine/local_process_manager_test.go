@@ -0,0 +1,346 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/windmilleng/wmclient/pkg/dirs"
+
+	"github.com/windmilleng/tilt/internal/hud/view"
+	"github.com/windmilleng/tilt/internal/logger"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/testutils/bufsync"
+	"github.com/windmilleng/tilt/internal/testutils/tempdir"
+)
+
+func TestLocalProcessNotStartedBeforeBuild(t *testing.T) {
+	f := newLPMFixture(t)
+	defer f.TearDown()
+
+	f.addServeResource("server", "echo hello; sleep 60")
+	f.lpm.OnChange(f.ctx, f.store)
+
+	assert.Equal(t, 0, f.runtimeState("server").PID)
+	assert.Empty(t, f.lpm.procs)
+}
+
+func TestLocalProcessStartsAfterBuild(t *testing.T) {
+	f := newLPMFixture(t)
+	defer f.TearDown()
+
+	f.addServeResource("server", "echo hello; sleep 60")
+	f.completeBuild("server")
+	f.lpm.OnChange(f.ctx, f.store)
+
+	f.waitForState("server", func(lrs store.LocalRuntimeState) bool {
+		return lrs.Status == store.LocalProcessStatusRunning &&
+			strings.Contains(lrs.CurrentLog.String(), "hello")
+	})
+	assert.NotEqual(t, 0, f.runtimeState("server").PID)
+	assert.Contains(t, f.out.String(), "hello")
+}
+
+func TestLocalProcessRestartsAfterNewBuild(t *testing.T) {
+	f := newLPMFixture(t)
+	defer f.TearDown()
+
+	f.addServeResource("server", "echo hello; sleep 60")
+	f.completeBuild("server")
+	f.lpm.OnChange(f.ctx, f.store)
+	f.waitForState("server", func(lrs store.LocalRuntimeState) bool {
+		return lrs.Status == store.LocalProcessStatusRunning
+	})
+	firstPID := f.runtimeState("server").PID
+
+	f.completeBuild("server")
+	f.lpm.OnChange(f.ctx, f.store)
+	f.waitForState("server", func(lrs store.LocalRuntimeState) bool {
+		return lrs.PID != firstPID && lrs.Status == store.LocalProcessStatusRunning
+	})
+	assert.Equal(t, 1, f.runtimeState("server").Restarts)
+}
+
+func TestLocalProcessRestartsOnRequest(t *testing.T) {
+	f := newLPMFixture(t)
+	defer f.TearDown()
+
+	f.addServeResource("server", "echo hello; sleep 60")
+	f.completeBuild("server")
+	f.lpm.OnChange(f.ctx, f.store)
+	f.waitForState("server", func(lrs store.LocalRuntimeState) bool {
+		return lrs.Status == store.LocalProcessStatusRunning
+	})
+	firstPID := f.runtimeState("server").PID
+
+	f.store.Dispatch(view.RestartResourceAction{Name: "server"})
+	f.waitForRestartRequest("server")
+	f.lpm.OnChange(f.ctx, f.store)
+	f.waitForState("server", func(lrs store.LocalRuntimeState) bool {
+		return lrs.PID != firstPID && lrs.Status == store.LocalProcessStatusRunning
+	})
+}
+
+func TestLocalProcessExitCode(t *testing.T) {
+	f := newLPMFixture(t)
+	defer f.TearDown()
+
+	f.addServeResource("server", "echo oh no; exit 3")
+	f.completeBuild("server")
+	f.lpm.OnChange(f.ctx, f.store)
+
+	f.waitForState("server", func(lrs store.LocalRuntimeState) bool {
+		return lrs.HasExited()
+	})
+	lrs := f.runtimeState("server")
+	assert.Equal(t, store.LocalProcessStatusError, lrs.Status)
+	assert.Equal(t, 3, lrs.ExitCode)
+}
+
+func TestLocalProcessKilledOnTeardown(t *testing.T) {
+	f := newLPMFixture(t)
+	defer f.TearDown()
+
+	f.addServeResource("server", "sleep 60")
+	f.completeBuild("server")
+	f.lpm.OnChange(f.ctx, f.store)
+	f.waitForState("server", func(lrs store.LocalRuntimeState) bool {
+		return lrs.Status == store.LocalProcessStatusRunning
+	})
+
+	f.lpm.Teardown(f.ctx)
+	f.waitForState("server", func(lrs store.LocalRuntimeState) bool {
+		return lrs.HasExited()
+	})
+	assert.Empty(t, f.lpm.procs)
+}
+
+func TestLocalProcessRecordedWhileRunning(t *testing.T) {
+	f := newLPMFixture(t)
+	defer f.TearDown()
+
+	f.addServeResource("server", "sleep 60")
+	f.completeBuild("server")
+	f.lpm.OnChange(f.ctx, f.store)
+	f.waitForState("server", func(lrs store.LocalRuntimeState) bool {
+		return lrs.Status == store.LocalProcessStatusRunning
+	})
+
+	records := f.records()
+	if assert.Equal(t, 1, len(records)) {
+		assert.Equal(t, f.runtimeState("server").PID, records[0].PGID)
+		assert.Equal(t, model.ManifestName("server"), records[0].ManifestName)
+		assert.Equal(t, f.JoinPath("Tiltfile"), records[0].TiltfilePath)
+		assert.Equal(t, os.Getpid(), records[0].OwnerPID)
+	}
+
+	f.lpm.Teardown(f.ctx)
+	assert.Empty(t, f.records())
+}
+
+func TestLocalProcessKillsOrphans(t *testing.T) {
+	f := newLPMFixture(t)
+	defer f.TearDown()
+
+	exited := f.startOrphan("sleep 60")
+
+	f.addServeResource("server", "sleep 60")
+	f.lpm.OnChange(f.ctx, f.store)
+
+	f.waitForExit(exited)
+	assert.Empty(t, f.records())
+}
+
+func TestLocalProcessKillsOrphansAfterExec(t *testing.T) {
+	f := newLPMFixture(t)
+	defer f.TearDown()
+
+	// The shell replaces itself with the command, so ps only shows the command.
+	exited := f.startOrphan("exec sleep 60")
+
+	f.addServeResource("server", "exec sleep 60")
+	f.lpm.OnChange(f.ctx, f.store)
+
+	f.waitForExit(exited)
+	assert.Empty(t, f.records())
+}
+
+func TestLocalProcessIgnoresReusedProcessGroup(t *testing.T) {
+	f := newLPMFixture(t)
+	defer f.TearDown()
+
+	// A process that happens to have the recorded pid, but isn't ours.
+	other := exec.Command("sleep", "60")
+	other.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	err := other.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = other.Process.Kill()
+		_ = other.Wait()
+	}()
+
+	record := localProcessRecord{PGID: other.Process.Pid, ID: "not-this-process"}
+	assert.False(t, isRecordedProcess(record))
+}
+
+type lpmFixture struct {
+	*tempdir.TempDirFixture
+	ctx    context.Context
+	cancel func()
+	out    *bufsync.ThreadSafeBuffer
+	lpm    *LocalProcessManager
+	store  *store.Store
+}
+
+func newLPMFixture(t *testing.T) *lpmFixture {
+	f := tempdir.NewTempDirFixture(t)
+	out := bufsync.NewThreadSafeBuffer()
+	st := store.NewStore(UpperReducer, store.LogActionsFlag(false))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := logger.NewLogger(logger.DebugLvl, out)
+	ctx = logger.WithLogger(ctx, l)
+	go func() {
+		_ = st.Loop(ctx)
+	}()
+
+	return &lpmFixture{
+		TempDirFixture: f,
+		ctx:            ctx,
+		cancel:         cancel,
+		out:            out,
+		lpm:            NewLocalProcessManager(dirs.NewWindmillDirAt(f.JoinPath("windmill"))),
+		store:          st,
+	}
+}
+
+func (f *lpmFixture) addServeResource(name string, serveCmd string) {
+	lt := model.NewLocalTarget(model.TargetName(name), model.Cmd{}, f.Path(), nil).
+		WithServeCmd(model.ToShellCmd(serveCmd))
+	m := model.Manifest{Name: model.ManifestName(name)}.WithDeployTarget(lt)
+
+	state := f.store.LockMutableStateForTesting()
+	state.WatchFiles = true
+	state.TiltfilePath = f.JoinPath("Tiltfile")
+	state.UpsertManifestTarget(store.NewManifestTarget(m))
+	f.store.UnlockMutableState()
+}
+
+func (f *lpmFixture) records() []localProcessRecord {
+	f.lpm.records.mu.Lock()
+	defer f.lpm.records.mu.Unlock()
+	records, err := f.lpm.records.readLocked()
+	if err != nil {
+		f.T().Fatal(err)
+	}
+	return records
+}
+
+// Starts a serve_cmd process group that a 'tilt up' left behind when it exited,
+// and returns a channel that closes when the process exits.
+func (f *lpmFixture) startOrphan(serveCmd string) chan struct{} {
+	owner := exec.Command("true")
+	err := owner.Run()
+	if err != nil {
+		f.T().Fatal(err)
+	}
+
+	id, err := newLocalProcessID()
+	if err != nil {
+		f.T().Fatal(err)
+	}
+
+	orphan := exec.Command("sh", "-c", serveCmd)
+	orphan.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	orphan.Env = append(os.Environ(), fmt.Sprintf("%s=%s", localProcessIDEnv, id))
+	err = orphan.Start()
+	if err != nil {
+		f.T().Fatal(err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = orphan.Wait()
+		close(exited)
+	}()
+
+	err = f.lpm.records.add(localProcessRecord{
+		TiltfilePath: f.JoinPath("Tiltfile"),
+		ManifestName: "server",
+		OwnerPID:     owner.Process.Pid,
+		PGID:         orphan.Process.Pid,
+		ID:           id,
+	})
+	if err != nil {
+		f.T().Fatal(err)
+	}
+
+	// Give the shell a moment to exec the command.
+	time.Sleep(100 * time.Millisecond)
+	return exited
+}
+
+func (f *lpmFixture) waitForExit(exited chan struct{}) {
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		f.T().Fatal("Timed out waiting for the orphan to be killed")
+	}
+}
+
+func (f *lpmFixture) completeBuild(name string) {
+	state := f.store.LockMutableStateForTesting()
+	ms, _ := state.ManifestState(model.ManifestName(name))
+	now := time.Now()
+	ms.AddCompletedBuild(model.BuildRecord{StartTime: now, FinishTime: now})
+	f.store.UnlockMutableState()
+}
+
+func (f *lpmFixture) runtimeState(name string) store.LocalRuntimeState {
+	state := f.store.RLockState()
+	defer f.store.RUnlockState()
+	ms, _ := state.ManifestState(model.ManifestName(name))
+	return ms.LocalRuntimeState()
+}
+
+func (f *lpmFixture) waitForState(name string, isDone func(lrs store.LocalRuntimeState) bool) {
+	start := time.Now()
+	for time.Since(start) < time.Second {
+		if isDone(f.runtimeState(name)) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	f.T().Fatalf("Timeout. Last state: %+v", f.runtimeState(name))
+}
+
+func (f *lpmFixture) waitForRestartRequest(name string) {
+	start := time.Now()
+	for time.Since(start) < time.Second {
+		state := f.store.RLockState()
+		ms, _ := state.ManifestState(model.ManifestName(name))
+		requested := !ms.RestartRequestTime.IsZero()
+		f.store.RUnlockState()
+		if requested {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	f.T().Fatalf("Timeout waiting for restart request for %s", name)
+}
+
+func (f *lpmFixture) TearDown() {
+	f.lpm.Teardown(f.ctx)
+	f.cancel()
+	f.TempDirFixture.TearDown()
+}
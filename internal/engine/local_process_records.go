@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/windmilleng/wmclient/pkg/dirs"
+
+	"github.com/windmilleng/tilt/internal/logger"
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+// The file, relative to the windmill dir, where we record the serve_cmd
+// processes that are running.
+const localProcessRecordsFile = "tilt/local_processes.json"
+
+// The environment variable that tags each serve_cmd process with the ID of its record.
+// Subprocesses inherit it, so we can recognize the process group even after the
+// shell execs the command or exits.
+const localProcessIDEnv = "TILT_LOCAL_PROCESS_ID"
+
+// A serve_cmd process group that a `tilt up` started.
+//
+// We record them so that we can clean up after a `tilt up` that died
+// without killing its processes (e.g., because it crashed or was SIGKILLed).
+type localProcessRecord struct {
+	TiltfilePath string
+	ManifestName model.ManifestName
+
+	// The `tilt up` that started the process.
+	OwnerPID int
+
+	// The process group, which is also the pid of the process we started.
+	PGID int
+
+	// A random ID, which we put in the environment of the process.
+	ID string
+}
+
+func newLocalProcessID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.Wrap(err, "generating local process id")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type localProcessRecords struct {
+	dir *dirs.WindmillDir
+	mu  sync.Mutex
+}
+
+func newLocalProcessRecords(dir *dirs.WindmillDir) *localProcessRecords {
+	return &localProcessRecords{dir: dir}
+}
+
+func (r *localProcessRecords) add(record localProcessRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.readLocked()
+	if err != nil {
+		// If the file is corrupt, start over.
+		records = nil
+	}
+	return r.writeLocked(append(records, record))
+}
+
+func (r *localProcessRecords) remove(pgid int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.readLocked()
+	if err != nil {
+		return err
+	}
+
+	var kept []localProcessRecord
+	for _, record := range records {
+		if record.PGID != pgid {
+			kept = append(kept, record)
+		}
+	}
+	return r.writeLocked(kept)
+}
+
+// Kills the recorded process groups that match, and forgets them.
+func (r *localProcessRecords) kill(ctx context.Context, matches func(record localProcessRecord) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.readLocked()
+	if err != nil {
+		return err
+	}
+
+	var kept []localProcessRecord
+	for _, record := range records {
+		if !matches(record) {
+			kept = append(kept, record)
+			continue
+		}
+
+		if isRecordedProcess(record) {
+			logger.Get(ctx).Infof("Stopping %s (pid %d), left running by an earlier 'tilt up'", record.ManifestName, record.PGID)
+			killProcessGroup(ctx, record.PGID)
+		}
+	}
+	return r.writeLocked(kept)
+}
+
+// Kills the processes left behind by `tilt up`s of this Tiltfile that are no longer running.
+func (r *localProcessRecords) killOrphans(ctx context.Context, tiltfilePath string) error {
+	return r.kill(ctx, func(record localProcessRecord) bool {
+		return record.TiltfilePath == tiltfilePath && !processExists(record.OwnerPID)
+	})
+}
+
+// Kills all the processes that `tilt up`s of this Tiltfile started.
+//
+// If names are given, only kills the processes of those manifests.
+func KillLocalProcesses(ctx context.Context, dir *dirs.WindmillDir, tiltfilePath string, names []model.ManifestName) error {
+	return newLocalProcessRecords(dir).kill(ctx, func(record localProcessRecord) bool {
+		if record.TiltfilePath != tiltfilePath {
+			return false
+		}
+		if len(names) == 0 {
+			return true
+		}
+		for _, name := range names {
+			if record.ManifestName == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *localProcessRecords) readLocked() ([]localProcessRecord, error) {
+	contents, err := r.dir.ReadFile(localProcessRecordsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "reading local process records")
+	}
+
+	var records []localProcessRecord
+	err = json.Unmarshal([]byte(contents), &records)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", localProcessRecordsFile)
+	}
+	return records, nil
+}
+
+func (r *localProcessRecords) writeLocked(records []localProcessRecord) error {
+	if records == nil {
+		records = []localProcessRecord{}
+	}
+
+	contents, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "writing local process records")
+	}
+
+	err = r.dir.WriteFile(localProcessRecordsFile, string(contents))
+	if err != nil {
+		return errors.Wrap(err, "writing local process records")
+	}
+	return nil
+}
+
+func processExists(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+func processGroupExists(pgid int) bool {
+	err := syscall.Kill(-pgid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// Checks that the process group is still the one we started, and not some other
+// group that reused its id. The group leader may be gone, so we look for any
+// process in the group with our ID in its environment.
+func isRecordedProcess(record localProcessRecord) bool {
+	if record.ID == "" || !processGroupExists(record.PGID) {
+		return false
+	}
+
+	out, err := exec.Command("ps", "axeww", "-o", "pgid=", "-o", "command=").Output()
+	if err != nil {
+		return false
+	}
+
+	pgid := strconv.Itoa(record.PGID)
+	tag := fmt.Sprintf("%s=%s", localProcessIDEnv, record.ID)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != pgid {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if field == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Sends SIGTERM to the process group, and SIGKILL if it hasn't exited
+// after the grace period.
+func killProcessGroup(ctx context.Context, pgid int) {
+	err := syscall.Kill(-pgid, syscall.SIGTERM)
+	if err != nil {
+		logger.Get(ctx).Debugf("Error stopping process group %d: %v", pgid, err)
+		return
+	}
+
+	deadline := time.Now().Add(localProcessGracePeriod)
+	for time.Now().Before(deadline) {
+		if syscall.Kill(-pgid, 0) != nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	err = syscall.Kill(-pgid, syscall.SIGKILL)
+	if err != nil {
+		logger.Get(ctx).Debugf("Error killing process group %d: %v", pgid, err)
+	}
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/windmilleng/tilt/internal/build"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+)
+
+// Runs the command of a LocalTarget on the host machine.
+type LocalTargetBuildAndDeployer struct {
+	clock build.Clock
+}
+
+var _ BuildAndDeployer = &LocalTargetBuildAndDeployer{}
+
+func NewLocalTargetBuildAndDeployer(c build.Clock) *LocalTargetBuildAndDeployer {
+	return &LocalTargetBuildAndDeployer{
+		clock: c,
+	}
+}
+
+// Extract the targets we can apply -- LTBaD only supports a single LocalTarget.
+func (bd *LocalTargetBuildAndDeployer) extract(specs []model.TargetSpec) []model.LocalTarget {
+	var targets []model.LocalTarget
+	for _, s := range specs {
+		lt, ok := s.(model.LocalTarget)
+		if !ok {
+			// unrecognized target
+			return nil
+		}
+		targets = append(targets, lt)
+	}
+	return targets
+}
+
+func (bd *LocalTargetBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.RStore, specs []model.TargetSpec, currentState store.BuildStateSet) (store.BuildResultSet, error) {
+	targets := bd.extract(specs)
+	if len(targets) != 1 {
+		return store.BuildResultSet{}, SilentRedirectToNextBuilderf(
+			"LocalTargetBuildAndDeployer requires exactly one LocalTarget (got %d)", len(targets))
+	}
+	target := targets[0]
+
+	// A target with only a serve_cmd has nothing to build; the
+	// LocalProcessManager (re)starts the process once this build completes.
+	if target.Cmd.Empty() {
+		return store.BuildResultSet{
+			target.ID(): store.NewLocalBuildResult(target.ID()),
+		}, nil
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "LocalTargetBuildAndDeployer-BuildAndDeploy")
+	span.SetTag("target", target.Name)
+	defer span.Finish()
+
+	var err error
+	ps := build.NewPipelineState(ctx, 1, bd.clock)
+	defer func() { ps.End(ctx, err) }()
+
+	ps.StartPipelineStep(ctx, "Running command: %s", target.Cmd)
+	err = bd.run(ctx, target, ps)
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			return store.BuildResultSet{}, err
+		}
+
+		// A failing command is a user error, so there's no point in trying other builders.
+		err = DontFallBackErrorf("Command %q failed: %v", target.Cmd.String(), err)
+		return store.BuildResultSet{}, err
+	}
+	ps.EndPipelineStep(ctx)
+
+	return store.BuildResultSet{
+		target.ID(): store.NewLocalBuildResult(target.ID()),
+	}, nil
+}
+
+func (bd *LocalTargetBuildAndDeployer) run(ctx context.Context, target model.LocalTarget, ps *build.PipelineState) error {
+	if len(target.Cmd.Argv) == 0 {
+		return fmt.Errorf("empty command")
+	}
+
+	w := ps.Writer(ctx)
+	cmd := exec.CommandContext(ctx, target.Cmd.Argv[0], target.Cmd.Argv[1:]...)
+	cmd.Dir = target.Workdir
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
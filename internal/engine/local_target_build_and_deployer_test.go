@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/testutils/output"
+	"github.com/windmilleng/tilt/internal/testutils/tempdir"
+)
+
+func TestLocalTargetBuildSuccess(t *testing.T) {
+	f := newLTBADFixture(t)
+	defer f.TearDown()
+
+	f.WriteFile("a.txt", "hello")
+	lt := model.NewLocalTarget("yarn-add", model.ToShellCmd("cat a.txt"), f.Path(), nil)
+
+	res, err := f.ltbad.BuildAndDeploy(f.ctx, f.st, []model.TargetSpec{lt}, store.BuildStateSet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, store.NewLocalBuildResult(lt.ID()), res[lt.ID()])
+	assert.Contains(t, f.out.String(), "hello")
+}
+
+func TestLocalTargetBuildFailure(t *testing.T) {
+	f := newLTBADFixture(t)
+	defer f.TearDown()
+
+	lt := model.NewLocalTarget("fail", model.ToShellCmd("echo oh no; exit 1"), f.Path(), nil)
+
+	_, err := f.ltbad.BuildAndDeploy(f.ctx, f.st, []model.TargetSpec{lt}, store.BuildStateSet{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Command \"echo oh no; exit 1\" failed")
+		assert.False(t, shouldFallBackForErr(err))
+	}
+	assert.Contains(t, f.out.String(), "oh no")
+}
+
+func TestLocalTargetBuildRedirectsOtherTargets(t *testing.T) {
+	f := newLTBADFixture(t)
+	defer f.TearDown()
+
+	_, err := f.ltbad.BuildAndDeploy(f.ctx, f.st, []model.TargetSpec{imgTarg, dcTarg}, store.BuildStateSet{})
+	if assert.Error(t, err) {
+		_, ok := err.(RedirectToNextBuilder)
+		assert.True(t, ok)
+	}
+}
+
+type ltbadFixture struct {
+	*tempdir.TempDirFixture
+	ctx   context.Context
+	out   *bytes.Buffer
+	ltbad *LocalTargetBuildAndDeployer
+	st    *store.Store
+}
+
+func newLTBADFixture(t *testing.T) *ltbadFixture {
+	f := tempdir.NewTempDirFixture(t)
+	out := &bytes.Buffer{}
+	ctx := output.ForkedCtxForTest(out)
+	clock := fakeClock{time.Date(2019, 1, 1, 1, 1, 1, 1, time.UTC)}
+	st, _ := store.NewStoreForTesting()
+	return &ltbadFixture{
+		TempDirFixture: f,
+		ctx:            ctx,
+		out:            out,
+		ltbad:          NewLocalTargetBuildAndDeployer(clock),
+		st:             st,
+	}
+}
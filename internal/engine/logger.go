@@ -13,10 +13,11 @@ func NewLogActionLogger(ctx context.Context, dispatch func(action store.Action))
 	return logger.NewFuncLogger(l.SupportsColor(), l.Level(), func(level logger.Level, b []byte) error {
 		if l.Level() >= level {
 			dispatch(LogAction{
-				logEvent{
+				logEvent: logEvent{
 					ts:      time.Now(),
 					message: append([]byte{}, b...),
 				},
+				Level: level,
 			})
 		}
 		return nil
@@ -25,8 +25,14 @@ func NewPodWatcher(kCli k8s.Client) *PodWatcher {
 }
 
 type PodWatch struct {
-	labels labels.Selector
-	cancel context.CancelFunc
+	// Empty means all namespaces.
+	namespace k8s.Namespace
+	labels    labels.Selector
+	cancel    context.CancelFunc
+}
+
+func (pw PodWatch) equal(other PodWatch) bool {
+	return pw.namespace == other.namespace && k8s.SelectorEqual(pw.labels, other.labels)
 }
 
 // returns all elements of `a` that are not in `b`
@@ -36,7 +42,7 @@ func subtract(a, b []PodWatch) []PodWatch {
 	for _, pwa := range a {
 		inB := false
 		for _, pwb := range b {
-			if k8s.SelectorEqual(pwa.labels, pwb.labels) {
+			if pwa.equal(pwb) {
 				inB = true
 				break
 			}
@@ -52,6 +58,9 @@ func (w *PodWatcher) diff(ctx context.Context, st store.RStore) (setup []PodWatc
 	state := st.RLockState()
 	defer st.RUnlockState()
 
+	// If all the objects are deployed to one namespace, only watch that one.
+	namespace := state.K8sNamespace()
+
 	atLeastOneK8S := false
 	var neededWatches []PodWatch
 	for _, m := range state.Manifests() {
@@ -59,13 +68,13 @@ func (w *PodWatcher) diff(ctx context.Context, st store.RStore) (setup []PodWatc
 			atLeastOneK8S = true
 			for _, ls := range m.K8sTarget().ExtraPodSelectors {
 				if !ls.Empty() {
-					neededWatches = append(neededWatches, PodWatch{labels: ls})
+					neededWatches = append(neededWatches, PodWatch{namespace: namespace, labels: ls})
 				}
 			}
 		}
 	}
 	if atLeastOneK8S {
-		neededWatches = append(neededWatches, PodWatch{labels: k8s.TiltRunSelector()})
+		neededWatches = append(neededWatches, PodWatch{namespace: namespace, labels: k8s.TiltRunSelector()})
 	}
 
 	return subtract(neededWatches, w.watches), subtract(w.watches, neededWatches)
@@ -76,9 +85,9 @@ func (w *PodWatcher) OnChange(ctx context.Context, st store.RStore) {
 
 	for _, pw := range setup {
 		ctx, cancel := context.WithCancel(ctx)
-		pw = PodWatch{labels: pw.labels, cancel: cancel}
+		pw = PodWatch{namespace: pw.namespace, labels: pw.labels, cancel: cancel}
 		w.watches = append(w.watches, pw)
-		ch, err := w.kCli.WatchPods(ctx, pw.labels)
+		ch, err := w.kCli.WatchPods(ctx, pw.namespace, pw.labels)
 		if err != nil {
 			err = errors.Wrap(err, "Error watching pods. Are you connected to kubernetes?\n")
 			st.Dispatch(NewErrorAction(err))
@@ -97,7 +106,7 @@ func (w *PodWatcher) removeWatch(toRemove PodWatch) {
 	oldWatches := append([]PodWatch{}, w.watches...)
 	w.watches = nil
 	for _, e := range oldWatches {
-		if !k8s.SelectorEqual(e.labels, toRemove.labels) {
+		if !e.equal(toRemove) {
 			w.watches = append(w.watches, e)
 		}
 	}
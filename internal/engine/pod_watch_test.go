@@ -95,6 +95,22 @@ func TestPodWatchHandleSelectorChange(t *testing.T) {
 
 }
 
+func TestPodWatchNamespaceOverride(t *testing.T) {
+	f := newPWFixture(t)
+	defer f.TearDown()
+
+	f.addManifestWithSelectors("server")
+	f.pw.OnChange(f.ctx, f.store)
+	f.assertWatchedNamespaces("")
+
+	state := f.store.LockMutableStateForTesting()
+	state.K8sNamespaceFromTiltfile = "dev-jane"
+	f.store.UnlockMutableState()
+
+	f.pw.OnChange(f.ctx, f.store)
+	f.assertWatchedNamespaces("dev-jane")
+}
+
 func podNamed(name string) *corev1.Pod {
 	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
 }
@@ -203,6 +219,19 @@ func (f *pwFixture) assertWatchedSelectors(ls ...labels.Selector) {
 	}
 }
 
+func (f *pwFixture) assertWatchedNamespaces(ns ...k8s.Namespace) {
+	start := time.Now()
+	for time.Since(start) < 200*time.Millisecond {
+		if assert.ObjectsAreEqual(ns, f.kClient.WatchedNamespaces()) {
+			break
+		}
+	}
+
+	if !assert.Equal(f.t, ns, f.kClient.WatchedNamespaces()) {
+		f.t.FailNow()
+	}
+}
+
 func (f *pwFixture) clearPods() {
 	f.pods = nil
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"strings"
 	"time"
 
 	"k8s.io/api/core/v1"
@@ -43,8 +42,9 @@ func (m *PodLogManager) diff(ctx context.Context, st store.RStore) (setup []PodL
 	state := st.RLockState()
 	defer st.RUnlockState()
 
-	// If we're not watching the FS for changes, then don't bother watching logs.
-	if !state.WatchFiles {
+	// If we're not watching the FS for changes (or running in CI),
+	// then don't bother watching logs.
+	if !state.WatchRuntime() {
 		return nil, nil
 	}
 
@@ -160,38 +160,26 @@ func (m *PodLogManager) consumeLogs(watch PodLogWatch, st store.RStore) {
 		_ = readCloser.Close()
 	}()
 
-	logWriter := logger.Get(watch.ctx).Writer(logger.InfoLvl)
-	prefix := logPrefix(name.String())
-	prefixLogWriter := logger.NewPrefixedWriter(prefix, logWriter)
-	actionWriter := PodLogActionWriter{
-		store:        st,
-		manifestName: name,
-		podID:        pID,
+	// We don't write pod output to Tilt's log. The log store interleaves it
+	// with Tilt's log, so that each line is only stored once.
+	var writer io.Writer = PodLogActionWriter{
+		store:         st,
+		manifestName:  name,
+		podID:         pID,
+		containerName: containerName,
 	}
-	multiWriter := io.MultiWriter(prefixLogWriter, actionWriter)
 	if watch.shouldPrefix {
-		prefix = fmt.Sprintf("[%s] ", watch.cName)
-		multiWriter = logger.NewPrefixedWriter(prefix, multiWriter)
+		prefix := fmt.Sprintf("[%s] ", watch.cName)
+		writer = logger.NewPrefixedWriter(prefix, writer)
 	}
 
-	_, err = io.Copy(multiWriter, NewHardCancelReader(watch.ctx, readCloser))
+	_, err = io.Copy(writer, NewHardCancelReader(watch.ctx, readCloser))
 	if err != nil && watch.ctx.Err() == nil {
 		logger.Get(watch.ctx).Infof("Error streaming %s logs: %v", name, err)
 		return
 	}
 }
 
-func logPrefix(n string) string {
-	max := 12
-	spaces := ""
-	if len(n) > max {
-		n = n[:max-1] + "…"
-	} else {
-		spaces = strings.Repeat(" ", max-len(n))
-	}
-	return fmt.Sprintf("%s%s┊ ", n, spaces)
-}
-
 type PodLogWatch struct {
 	ctx    context.Context
 	cancel func()
@@ -212,15 +200,17 @@ type podLogKey struct {
 }
 
 type PodLogActionWriter struct {
-	store        store.RStore
-	podID        k8s.PodID
-	manifestName model.ManifestName
+	store         store.RStore
+	podID         k8s.PodID
+	manifestName  model.ManifestName
+	containerName container.Name
 }
 
 func (w PodLogActionWriter) Write(p []byte) (n int, err error) {
 	w.store.Dispatch(PodLogAction{
 		PodID:        w.podID,
 		ManifestName: w.manifestName,
+		Container:    w.containerName,
 		logEvent:     newLogEvent(append([]byte{}, p...)),
 	})
 	return len(p), nil
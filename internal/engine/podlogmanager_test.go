@@ -15,6 +15,7 @@ import (
 	"github.com/windmilleng/tilt/internal/logger"
 	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/store/logstore"
 	"github.com/windmilleng/tilt/internal/testutils/bufsync"
 	"github.com/windmilleng/tilt/internal/testutils/tempdir"
 )
@@ -43,6 +44,9 @@ func TestLogs(t *testing.T) {
 
 	f.plm.OnChange(f.ctx, f.store)
 	f.AssertOutputContains("hello world!")
+
+	// Pod output only goes to the log store, which interleaves it with Tilt's log.
+	f.AssertOutputDoesNotContain(logstore.ManifestPrefix("server"))
 }
 
 func TestLogActions(t *testing.T) {
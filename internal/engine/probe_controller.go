@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+)
+
+// Runs the readiness probes of deployed resources, and reports
+// the results to the store.
+//
+// We start probing after every successful deploy, so that a resource
+// is never reported ready because an older version was.
+type ProbeController struct {
+	workers map[model.ManifestName]*probeWorker
+}
+
+func NewProbeController() *ProbeController {
+	return &ProbeController{
+		workers: make(map[model.ManifestName]*probeWorker),
+	}
+}
+
+type probeWorker struct {
+	ctx        context.Context
+	cancel     func()
+	name       model.ManifestName
+	probe      model.Probe
+	deployTime time.Time
+}
+
+// Diff the current workers against the state store, returning
+// the workers we need to start and the ones we need to stop.
+func (c *ProbeController) diff(ctx context.Context, st store.RStore) (setup []*probeWorker, teardown []*probeWorker) {
+	state := st.RLockState()
+	defer st.RUnlockState()
+
+	inState := make(map[model.ManifestName]bool)
+	for _, mt := range state.Targets() {
+		probe := mt.Manifest.ReadinessProbe
+		deployTime := mt.State.LastSuccessfulDeployTime
+		if probe == nil || deployTime.IsZero() {
+			continue
+		}
+
+		name := mt.Manifest.Name
+		inState[name] = true
+
+		existing, ok := c.workers[name]
+		if ok {
+			if existing.deployTime.Equal(deployTime) && model.DeepEqual(existing.probe, *probe) {
+				continue
+			}
+			teardown = append(teardown, existing)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		w := &probeWorker{
+			ctx:        ctx,
+			cancel:     cancel,
+			name:       name,
+			probe:      *probe,
+			deployTime: deployTime,
+		}
+		c.workers[name] = w
+		setup = append(setup, w)
+	}
+
+	for name, w := range c.workers {
+		if !inState[name] {
+			delete(c.workers, name)
+			teardown = append(teardown, w)
+		}
+	}
+
+	return setup, teardown
+}
+
+func (c *ProbeController) OnChange(ctx context.Context, st store.RStore) {
+	setup, teardown := c.diff(ctx, st)
+	for _, w := range teardown {
+		w.cancel()
+	}
+
+	for _, w := range setup {
+		go c.runWorker(w, st)
+	}
+}
+
+func (c *ProbeController) Teardown(ctx context.Context) {
+	for name, w := range c.workers {
+		w.cancel()
+		delete(c.workers, name)
+	}
+}
+
+// Probe until canceled, dispatching a result whenever readiness changes.
+func (c *ProbeController) runWorker(w *probeWorker, st store.RStore) {
+	select {
+	case <-w.ctx.Done():
+		return
+	case <-time.After(w.probe.InitialDelay):
+	}
+
+	ticker := time.NewTicker(w.probe.Period)
+	defer ticker.Stop()
+
+	first := true
+	lastReady := false
+	for {
+		err := runProbe(w.ctx, w.probe)
+		if w.ctx.Err() != nil {
+			return
+		}
+
+		ready := err == nil
+		if first || ready != lastReady {
+			message := ""
+			if err != nil {
+				message = err.Error()
+			}
+			st.Dispatch(ProbeResultAction{
+				ManifestName: w.name,
+				DeployTime:   w.deployTime,
+				Ready:        ready,
+				Message:      message,
+				Time:         time.Now(),
+			})
+		}
+		first = false
+		lastReady = ready
+
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Returns nil if the probe succeeded.
+func runProbe(ctx context.Context, probe model.Probe) error {
+	ctx, cancel := context.WithTimeout(ctx, probe.Timeout)
+	defer cancel()
+
+	switch {
+	case probe.HTTPGet != nil:
+		return runHTTPGetProbe(ctx, *probe.HTTPGet)
+	case probe.TCPSocket != nil:
+		return runTCPSocketProbe(ctx, *probe.TCPSocket)
+	case probe.Exec != nil:
+		return runExecProbe(ctx, *probe.Exec)
+	default:
+		return fmt.Errorf("probe has no action")
+	}
+}
+
+func runHTTPGetProbe(ctx context.Context, action model.HTTPGetAction) error {
+	req, err := http.NewRequest("GET", action.URL(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("GET %s: %s", action.URL(), resp.Status)
+	}
+	return nil
+}
+
+func runTCPSocketProbe(ctx context.Context, action model.TCPSocketAction) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(action.Host, strconv.Itoa(action.Port)))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func runExecProbe(ctx context.Context, action model.ExecAction) error {
+	argv := action.Command.Argv
+	if len(argv) == 0 {
+		return fmt.Errorf("empty command")
+	}
+
+	out, err := exec.CommandContext(ctx, argv[0], argv[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%q failed: %v\n%s", action.Command.String(), err, out)
+	}
+	return nil
+}
+
+var _ store.Subscriber = &ProbeController{}
+var _ store.SubscriberLifecycle = &ProbeController{}
@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/testutils/tempdir"
+)
+
+func TestProbeHTTPGet(t *testing.T) {
+	f := newProbeFixture(t)
+	defer f.TearDown()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	f.addDeployedManifest("server", model.Probe{
+		HTTPGet: &model.HTTPGetAction{Host: u.Hostname(), Port: port, Path: "/healthz"},
+	})
+	f.pc.OnChange(f.ctx, f.store)
+
+	f.waitForProbeStatus("server", func(ps store.ProbeStatus) bool {
+		return !ps.LastProbeTime.IsZero() && !ps.Ready
+	})
+	assert.Contains(t, f.probeStatus("server").Message, "503")
+}
+
+func TestProbeTCPSocket(t *testing.T) {
+	f := newProbeFixture(t)
+	defer f.TearDown()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+
+	port := l.Addr().(*net.TCPAddr).Port
+	f.addDeployedManifest("server", model.Probe{
+		TCPSocket: &model.TCPSocketAction{Host: "127.0.0.1", Port: port},
+	})
+	f.pc.OnChange(f.ctx, f.store)
+
+	f.waitForProbeStatus("server", func(ps store.ProbeStatus) bool {
+		return ps.Ready
+	})
+}
+
+func TestProbeExecBecomesReady(t *testing.T) {
+	f := newProbeFixture(t)
+	defer f.TearDown()
+
+	f.WriteFile("ready.sh", "test -f ready")
+	f.addDeployedManifest("server", model.Probe{
+		Exec: &model.ExecAction{Command: model.ToShellCmd("cd " + f.Path() + " && sh ready.sh")},
+	})
+	f.pc.OnChange(f.ctx, f.store)
+
+	f.waitForProbeStatus("server", func(ps store.ProbeStatus) bool {
+		return !ps.LastProbeTime.IsZero() && !ps.Ready
+	})
+	assert.False(t, f.isReady("server"))
+
+	f.WriteFile("ready", "")
+	f.waitForProbeStatus("server", func(ps store.ProbeStatus) bool {
+		return ps.Ready
+	})
+	assert.True(t, f.isReady("server"))
+}
+
+func TestProbeResultForOldDeployIgnored(t *testing.T) {
+	f := newProbeFixture(t)
+	defer f.TearDown()
+
+	f.addDeployedManifest("server", model.Probe{
+		Exec: &model.ExecAction{Command: model.ToShellCmd("true")},
+	})
+
+	deployTime := f.deployTime("server")
+	f.store.Dispatch(ProbeResultAction{
+		ManifestName: "server",
+		DeployTime:   deployTime.Add(-time.Minute),
+		Ready:        true,
+		Time:         time.Now(),
+	})
+	f.store.Dispatch(ProbeResultAction{
+		ManifestName: "server",
+		DeployTime:   deployTime,
+		Ready:        false,
+		Message:      "not yet",
+		Time:         time.Now(),
+	})
+
+	f.waitForProbeStatus("server", func(ps store.ProbeStatus) bool {
+		return ps.Message == "not yet"
+	})
+	assert.False(t, f.probeStatus("server").Ready)
+}
+
+func TestProbeRestartsOnNewDeploy(t *testing.T) {
+	f := newProbeFixture(t)
+	defer f.TearDown()
+
+	f.addDeployedManifest("server", model.Probe{
+		Exec: &model.ExecAction{Command: model.ToShellCmd("true")},
+	})
+	f.pc.OnChange(f.ctx, f.store)
+	f.waitForProbeStatus("server", func(ps store.ProbeStatus) bool {
+		return ps.Ready
+	})
+	firstWorker := f.pc.workers["server"]
+
+	state := f.store.LockMutableStateForTesting()
+	ms, _ := state.ManifestState("server")
+	ms.LastSuccessfulDeployTime = time.Now()
+	newDeployTime := ms.LastSuccessfulDeployTime
+	f.store.UnlockMutableState()
+
+	assert.False(t, f.isReady("server"))
+
+	f.pc.OnChange(f.ctx, f.store)
+	assert.True(t, firstWorker != f.pc.workers["server"], "expected a new worker")
+	assert.Error(t, firstWorker.ctx.Err())
+
+	f.waitForProbeStatus("server", func(ps store.ProbeStatus) bool {
+		return ps.Ready && ps.DeployTime.Equal(newDeployTime)
+	})
+}
+
+type probeFixture struct {
+	*tempdir.TempDirFixture
+	ctx    context.Context
+	cancel func()
+	pc     *ProbeController
+	store  *store.Store
+}
+
+func newProbeFixture(t *testing.T) *probeFixture {
+	f := tempdir.NewTempDirFixture(t)
+	st := store.NewStore(UpperReducer, store.LogActionsFlag(false))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = st.Loop(ctx)
+	}()
+
+	return &probeFixture{
+		TempDirFixture: f,
+		ctx:            ctx,
+		cancel:         cancel,
+		pc:             NewProbeController(),
+		store:          st,
+	}
+}
+
+func (f *probeFixture) addDeployedManifest(name string, probe model.Probe) {
+	probe.Period = 10 * time.Millisecond
+	probe.Timeout = time.Second
+	lt := model.NewLocalTarget(model.TargetName(name), model.ToShellCmd("true"), f.Path(), nil)
+	m := model.Manifest{Name: model.ManifestName(name)}.
+		WithDeployTarget(lt).
+		WithReadinessProbe(&probe)
+
+	state := f.store.LockMutableStateForTesting()
+	state.WatchFiles = true
+	mt := store.NewManifestTarget(m)
+	now := time.Now()
+	mt.State.AddCompletedBuild(model.BuildRecord{StartTime: now, FinishTime: now})
+	mt.State.LastSuccessfulDeployTime = now
+	state.UpsertManifestTarget(mt)
+	f.store.UnlockMutableState()
+}
+
+func (f *probeFixture) deployTime(name string) time.Time {
+	state := f.store.RLockState()
+	defer f.store.RUnlockState()
+	ms, _ := state.ManifestState(model.ManifestName(name))
+	return ms.LastSuccessfulDeployTime
+}
+
+func (f *probeFixture) probeStatus(name string) store.ProbeStatus {
+	state := f.store.RLockState()
+	defer f.store.RUnlockState()
+	ms, _ := state.ManifestState(model.ManifestName(name))
+	return ms.ProbeStatus
+}
+
+func (f *probeFixture) isReady(name string) bool {
+	state := f.store.RLockState()
+	defer f.store.RUnlockState()
+	return state.ManifestTargets[model.ManifestName(name)].IsReady()
+}
+
+func (f *probeFixture) waitForProbeStatus(name string, isDone func(ps store.ProbeStatus) bool) {
+	start := time.Now()
+	for time.Since(start) < time.Second {
+		if isDone(f.probeStatus(name)) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	f.T().Fatalf("Timeout. Last status: %+v", f.probeStatus(name))
+}
+
+func (f *probeFixture) TearDown() {
+	f.pc.Teardown(f.ctx)
+	f.cancel()
+	f.TempDirFixture.TearDown()
+}
@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/windmilleng/tilt/internal/dockercompose"
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/logger"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+)
+
+// Restarts the containers of a resource when the user asks us to
+// (e.g., from the web UI), without rebuilding it.
+//
+// Kubernetes pods are deleted, so that their controllers replace them.
+// Docker-compose services are restarted with `docker-compose restart`.
+// Local serve_cmd processes are restarted by the LocalProcessManager.
+type ResourceRestarter struct {
+	kCli k8s.Client
+	dcc  dockercompose.DockerComposeClient
+
+	// The RestartRequestTime of the last restart we handled, by manifest.
+	handled map[model.ManifestName]time.Time
+}
+
+func NewResourceRestarter(kCli k8s.Client, dcc dockercompose.DockerComposeClient) *ResourceRestarter {
+	return &ResourceRestarter{
+		kCli:    kCli,
+		dcc:     dcc,
+		handled: make(map[model.ManifestName]time.Time),
+	}
+}
+
+type restartRequest struct {
+	name     model.ManifestName
+	pods     []store.Pod
+	dcTarget model.DockerComposeTarget
+	isDC     bool
+}
+
+func (r *ResourceRestarter) requests(st store.RStore) []restartRequest {
+	state := st.RLockState()
+	defer st.RUnlockState()
+
+	var result []restartRequest
+	for _, mt := range state.Targets() {
+		ms := mt.State
+		if ms.RestartRequestTime.IsZero() || !ms.RestartRequestTime.After(r.handled[mt.Manifest.Name]) {
+			continue
+		}
+		r.handled[mt.Manifest.Name] = ms.RestartRequestTime
+
+		req := restartRequest{name: mt.Manifest.Name}
+		if mt.Manifest.IsDC() {
+			req.isDC = true
+			req.dcTarget = mt.Manifest.DockerComposeTarget()
+		} else if mt.Manifest.IsK8s() {
+			req.pods = ms.PodSet.PodList()
+		} else {
+			continue
+		}
+		result = append(result, req)
+	}
+	return result
+}
+
+func (r *ResourceRestarter) OnChange(ctx context.Context, st store.RStore) {
+	for _, req := range r.requests(st) {
+		r.restart(ctx, req)
+	}
+}
+
+func (r *ResourceRestarter) restart(ctx context.Context, req restartRequest) {
+	l := logger.Get(ctx)
+	if req.isDC {
+		out := l.Writer(logger.InfoLvl)
+		err := r.dcc.Restart(ctx, req.dcTarget.ConfigPath, req.dcTarget.Name, out, out)
+		if err != nil {
+			l.Infof("Error restarting %s: %v", req.name, err)
+		}
+		return
+	}
+
+	if len(req.pods) == 0 {
+		l.Infof("Nothing to restart for %s: no pods running", req.name)
+		return
+	}
+
+	entities := make([]k8s.K8sEntity, 0, len(req.pods))
+	for _, pod := range req.pods {
+		entities = append(entities, podEntity(pod))
+	}
+
+	err := r.kCli.Delete(ctx, entities)
+	if err != nil {
+		l.Infof("Error restarting %s: %v", req.name, err)
+	}
+}
+
+// A minimal entity that identifies the pod, for kubectl delete.
+func podEntity(pod store.Pod) k8s.K8sEntity {
+	return k8s.K8sEntity{
+		Obj: &v1.Pod{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pod.PodID.String(),
+				Namespace: pod.Namespace.String(),
+			},
+		},
+		Kind: &schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+	}
+}
+
+var _ store.Subscriber = &ResourceRestarter{}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/dockercompose"
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/model"
+	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/testutils/output"
+)
+
+func TestRestartK8sPods(t *testing.T) {
+	f := newRestarterFixture(t)
+
+	m := model.Manifest{Name: "fe"}.WithDeployTarget(model.K8sTarget{Name: "fe", YAML: "fake-yaml"})
+	mt := store.NewManifestTarget(m)
+	mt.State.PodSet = store.NewPodSet(store.Pod{PodID: "fe-abc", Namespace: "default", Phase: "Running"})
+	f.state.UpsertManifestTarget(mt)
+
+	f.onChange()
+	assert.Equal(t, "", f.kCli.DeletedYaml)
+
+	mt.State.RestartRequestTime = time.Now()
+	f.onChange()
+	assert.Contains(t, f.kCli.DeletedYaml, "kind: Pod")
+	assert.Contains(t, f.kCli.DeletedYaml, "name: fe-abc")
+
+	// We only restart once per request.
+	f.kCli.DeletedYaml = ""
+	f.onChange()
+	assert.Equal(t, "", f.kCli.DeletedYaml)
+}
+
+func TestRestartDockerComposeService(t *testing.T) {
+	f := newRestarterFixture(t)
+
+	m := model.Manifest{Name: "db"}.WithDeployTarget(model.DockerComposeTarget{Name: "db", ConfigPath: "docker-compose.yml"})
+	mt := store.NewManifestTarget(m)
+	mt.State.RestartRequestTime = time.Now()
+	f.state.UpsertManifestTarget(mt)
+
+	f.onChange()
+	assert.Equal(t, []dockercompose.RestartCall{{PathToConfig: "docker-compose.yml", ServiceName: "db"}}, f.dcc.RestartCalls)
+}
+
+type restarterFixture struct {
+	t     *testing.T
+	kCli  *k8s.FakeK8sClient
+	dcc   *dockercompose.FakeDCClient
+	rr    *ResourceRestarter
+	state *store.EngineState
+}
+
+func newRestarterFixture(t *testing.T) *restarterFixture {
+	kCli := k8s.NewFakeK8sClient()
+	dcc := dockercompose.NewFakeDockerComposeClient(t, output.CtxForTest())
+	return &restarterFixture{
+		t:     t,
+		kCli:  kCli,
+		dcc:   dcc,
+		rr:    NewResourceRestarter(kCli, dcc),
+		state: store.NewState(),
+	}
+}
+
+func (f *restarterFixture) onChange() {
+	st := store.NewTestingStore()
+	st.SetState(*f.state)
+	f.rr.OnChange(output.CtxForTest(), st)
+}
@@ -17,9 +17,10 @@ import (
 var watchTimeout = 10 * time.Second
 
 type ServiceWatcher struct {
-	kCli     k8s.Client
-	watching bool
-	nodeIP   k8s.NodeIP
+	kCli      k8s.Client
+	watching  bool
+	namespace k8s.Namespace
+	nodeIP    k8s.NodeIP
 }
 
 func NewServiceWatcher(kCli k8s.Client, nodeIP k8s.NodeIP) *ServiceWatcher {
@@ -29,7 +30,9 @@ func NewServiceWatcher(kCli k8s.Client, nodeIP k8s.NodeIP) *ServiceWatcher {
 	}
 }
 
-func (w *ServiceWatcher) needsWatch(st store.RStore) bool {
+// Returns the namespace to watch (empty means all namespaces),
+// and whether we need to start a new watch.
+func (w *ServiceWatcher) needsWatch(st store.RStore) (k8s.Namespace, bool) {
 	state := st.RLockState()
 	defer st.RUnlockState()
 
@@ -39,18 +42,22 @@ func (w *ServiceWatcher) needsWatch(st store.RStore) bool {
 			atLeastOneK8S = true
 		}
 	}
-	return atLeastOneK8S && state.WatchFiles && !w.watching
+	namespace := state.K8sNamespace()
+	needsWatch := !w.watching || namespace != w.namespace
+	return namespace, atLeastOneK8S && state.WatchFiles && needsWatch
 }
 
 func (w *ServiceWatcher) OnChange(ctx context.Context, st store.RStore) {
-	if !w.needsWatch(st) {
+	namespace, ok := w.needsWatch(st)
+	if !ok {
 		return
 	}
 	w.watching = true
+	w.namespace = namespace
 
 	ctx2, cancel := context.WithTimeout(ctx, watchTimeout)
 	defer cancel()
-	ch, err := w.kCli.WatchServices(ctx2, []model.LabelPair{k8s.TiltRunLabel()})
+	ch, err := w.kCli.WatchServices(ctx2, namespace, []model.LabelPair{k8s.TiltRunLabel()})
 	if err != nil {
 		err = errors.Wrap(err, "Error watching services. Are you connected to kubernetes?\n")
 		st.Dispatch(NewErrorAction(err))
@@ -11,15 +11,21 @@ func ProvideSubscribers(
 	hud hud.HeadsUpDisplay,
 	pw *PodWatcher,
 	sw *ServiceWatcher,
+	ewm *EventWatchManager,
+	jw *JobWatcher,
 	plm *PodLogManager,
 	pfc *PortForwardController,
 	fwm *WatchManager,
 	bc *BuildController,
 	ic *ImageController,
 	gybc *GlobalYAMLBuildController,
+	kp *KubernetesPruner,
 	cc *ConfigsController,
 	dcw *DockerComposeEventWatcher,
 	dclm *DockerComposeLogManager,
+	lpm *LocalProcessManager,
+	prc *ProbeController,
+	rr *ResourceRestarter,
 	pm *ProfilerManager,
 	sm SyncletManager,
 	ar *AnalyticsReporter,
@@ -29,15 +35,21 @@ func ProvideSubscribers(
 		hud,
 		pw,
 		sw,
+		ewm,
+		jw,
 		plm,
 		pfc,
 		fwm,
 		bc,
 		ic,
 		gybc,
+		kp,
 		cc,
 		dcw,
 		dclm,
+		lpm,
+		prc,
+		rr,
 		pm,
 		sm,
 		ar,
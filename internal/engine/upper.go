@@ -21,6 +21,7 @@ import (
 	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/sliceutils"
 	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/store/logstore"
 	"github.com/windmilleng/tilt/internal/synclet/sidecar"
 	"github.com/windmilleng/tilt/internal/watch"
 )
@@ -45,7 +46,8 @@ const maxChangedFilesToPrint = 5
 // TODO(nick): maybe this should be called 'BuildEngine' or something?
 // Upper seems like a poor and undescriptive name.
 type Upper struct {
-	store *store.Store
+	store       *store.Store
+	kubeContext k8s.KubeContext
 }
 
 type FsWatcherMaker func() (watch.Notify, error)
@@ -65,7 +67,7 @@ func ProvideTimerMaker() timerMaker {
 	}
 }
 
-func NewUpper(ctx context.Context, st *store.Store, subs []store.Subscriber) Upper {
+func NewUpper(ctx context.Context, st *store.Store, subs []store.Subscriber, kubeContext k8s.KubeContext) Upper {
 	// There's not really a good reason to add all the subscribers
 	// in NewUpper(), but it's as good a place as any.
 	for _, sub := range subs {
@@ -73,7 +75,8 @@ func NewUpper(ctx context.Context, st *store.Store, subs []store.Subscriber) Upp
 	}
 
 	return Upper{
-		store: st,
+		store:       st,
+		kubeContext: kubeContext,
 	}
 }
 
@@ -81,20 +84,37 @@ func (u Upper) Dispatch(action store.Action) {
 	u.store.Dispatch(action)
 }
 
-func (u Upper) Start(ctx context.Context, args []string, watch bool, triggerMode model.TriggerMode, fileName string, useActionWriter bool) error {
+// How to run the engine, as set on the command line.
+type StartOptions struct {
+	// The resources to run. If empty, runs all of them.
+	ResourceNames []string
+
+	// Args to pass to the Tiltfile.
+	TiltfileArgs []string
+
+	TiltfilePath      string
+	Watch             bool
+	TriggerMode       model.TriggerMode
+	MaxParallelBuilds int
+	Namespace         k8s.Namespace
+	UseActionWriter   bool
+	EngineMode        store.EngineMode
+}
+
+func (u Upper) Start(ctx context.Context, opts StartOptions) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "Start")
 	defer span.Finish()
 
 	startTime := time.Now()
 
-	absTfPath, err := filepath.Abs(fileName)
+	absTfPath, err := filepath.Abs(opts.TiltfilePath)
 	if err != nil {
 		return err
 	}
 
 	var manifestNames []model.ManifestName
 	matching := map[string]bool{}
-	for _, arg := range args {
+	for _, arg := range opts.ResourceNames {
 		manifestNames = append(manifestNames, model.ManifestName(arg))
 		matching[arg] = true
 	}
@@ -102,22 +122,40 @@ func (u Upper) Start(ctx context.Context, args []string, watch bool, triggerMode
 	configFiles := []string{absTfPath}
 
 	return u.Init(ctx, InitAction{
-		WatchFiles:      watch,
-		TiltfilePath:    absTfPath,
-		ConfigFiles:     configFiles,
-		InitManifests:   manifestNames,
-		TriggerMode:     triggerMode,
-		StartTime:       startTime,
-		FinishTime:      time.Now(),
-		ExecuteTiltfile: false,
+		WatchFiles:        opts.Watch,
+		TiltfilePath:      absTfPath,
+		ConfigFiles:       configFiles,
+		InitManifests:     manifestNames,
+		TiltfileArgs:      opts.TiltfileArgs,
+		TriggerMode:       opts.TriggerMode,
+		MaxParallelBuilds: opts.MaxParallelBuilds,
+		K8sNamespace:      opts.Namespace,
+		KubeContext:       u.kubeContext,
+		EngineMode:        opts.EngineMode,
+		StartTime:         startTime,
+		FinishTime:        time.Now(),
+		ExecuteTiltfile:   false,
 	})
 }
 
 func (u Upper) Init(ctx context.Context, action InitAction) error {
 	u.store.Dispatch(action)
+	defer u.closeLogStore(ctx)
 	return u.store.Loop(ctx)
 }
 
+// Cleans up the log lines that we spilled to disk.
+func (u Upper) closeLogStore(ctx context.Context) {
+	state := u.store.RLockState()
+	logStore := state.LogStore
+	u.store.RUnlockState()
+
+	err := logStore.Close()
+	if err != nil {
+		logger.Get(ctx).Debugf("Error cleaning up logs: %v", err)
+	}
+}
+
 var UpperReducer = store.Reducer(func(ctx context.Context, state *store.EngineState, action store.Action) {
 	var err error
 	switch action := action.(type) {
@@ -133,6 +171,10 @@ var UpperReducer = store.Reducer(func(ctx context.Context, state *store.EngineSt
 		handlePodChangeAction(ctx, state, action.Pod)
 	case ServiceChangeAction:
 		handleServiceEvent(ctx, state, action)
+	case JobChangeAction:
+		handleJobChangeAction(ctx, state, action)
+	case K8sEventAction:
+		handleK8sEvent(state, action)
 	case PodLogAction:
 		handlePodLogAction(state, action)
 	case BuildLogAction:
@@ -157,8 +199,22 @@ var UpperReducer = store.Reducer(func(ctx context.Context, state *store.EngineSt
 		handleDockerComposeEvent(ctx, state, action)
 	case DockerComposeLogAction:
 		handleDockerComposeLogAction(state, action)
+	case LocalProcessStartedAction:
+		handleLocalProcessStarted(state, action)
+	case LocalProcessExitedAction:
+		handleLocalProcessExited(state, action)
+	case LocalProcessLogAction:
+		handleLocalProcessLogAction(state, action)
+	case ProbeResultAction:
+		handleProbeResultAction(state, action)
 	case view.AppendToTriggerQueueAction:
 		appendToTriggerQueue(state, action.Name)
+	case view.SetTriggerModeAction:
+		handleSetTriggerModeAction(state, action)
+	case view.RestartResourceAction:
+		handleRestartResourceAction(state, action)
+	case view.ReloadTiltfileAction:
+		handleReloadTiltfileAction(state)
 	case hud.StartProfilingAction:
 		handleStartProfilingAction(state)
 	case hud.StopProfilingAction:
@@ -194,6 +250,8 @@ func handleBuildStarted(ctx context.Context, state *store.EngineState, action Bu
 	ms.ConfigFilesThatCausedChange = []string{}
 	ms.CurrentBuild = bs
 	ms.ExpectedContainerID = ""
+	ms.K8sWarnEvents = nil
+	ms.JobStatus = ""
 
 	for _, pod := range ms.PodSet.Pods {
 		pod.CurrentLog = model.Log{}
@@ -210,13 +268,14 @@ func handleBuildStarted(ctx context.Context, state *store.EngineState, action Bu
 		ms.CrashLog = model.Log{}
 	}
 
-	state.CurrentlyBuilding = mn
+	state.CurrentlyBuilding[mn] = true
+	state.BuildControllerActionCount++
 	removeFromTriggerQueue(state, mn)
 }
 
 func handleBuildCompleted(ctx context.Context, engineState *store.EngineState, cb BuildCompleteAction) error {
 	defer func() {
-		engineState.CurrentlyBuilding = ""
+		delete(engineState.CurrentlyBuilding, cb.ManifestName)
 	}()
 
 	engineState.CompletedBuildCount++
@@ -230,7 +289,7 @@ func handleBuildCompleted(ctx context.Context, engineState *store.EngineState, c
 
 	err := cb.Error
 
-	mt, ok := engineState.ManifestTargets[engineState.CurrentlyBuilding]
+	mt, ok := engineState.ManifestTargets[cb.ManifestName]
 	if !ok {
 		return nil
 	}
@@ -331,7 +390,7 @@ func handleDeployIDAction(ctx context.Context, state *store.EngineState, action
 }
 
 func appendToTriggerQueue(state *store.EngineState, mn model.ManifestName) {
-	if state.TriggerMode != model.TriggerManual {
+	if state.ManifestTriggerMode(mn) != model.TriggerManual {
 		return
 	}
 
@@ -353,6 +412,33 @@ func appendToTriggerQueue(state *store.EngineState, mn model.ManifestName) {
 	state.TriggerQueue = append(state.TriggerQueue, mn)
 }
 
+func handleSetTriggerModeAction(state *store.EngineState, action view.SetTriggerModeAction) {
+	ms, ok := state.ManifestState(action.Name)
+	if !ok {
+		return
+	}
+
+	triggerMode := action.TriggerMode
+	ms.TriggerModeOverride = &triggerMode
+
+	// In auto mode, any pending changes get picked up by the BuildController.
+	if triggerMode == model.TriggerAuto {
+		removeFromTriggerQueue(state, action.Name)
+	}
+}
+
+func handleRestartResourceAction(state *store.EngineState, action view.RestartResourceAction) {
+	ms, ok := state.ManifestState(action.Name)
+	if !ok {
+		return
+	}
+	ms.RestartRequestTime = time.Now()
+}
+
+func handleReloadTiltfileAction(state *store.EngineState) {
+	state.PendingConfigFileChanges[state.TiltfilePath] = time.Now()
+}
+
 func removeFromTriggerQueue(state *store.EngineState, mn model.ManifestName) {
 	for i, triggerName := range state.TriggerQueue {
 		if triggerName == mn {
@@ -496,6 +582,9 @@ func handleConfigsReloaded(
 	state.GlobalYAML = event.GlobalYAML
 	state.ConfigFiles = event.ConfigFiles
 	state.TiltIgnoreContents = event.TiltIgnoreContents
+	state.MaxParallelBuildsFromTiltfile = event.MaxParallelBuilds
+	state.K8sNamespaceFromTiltfile = event.K8sNamespace
+	state.UnmatchedManifests = event.UnmatchedManifests
 
 	// Remove pending file changes that were consumed by this build.
 	for file, modTime := range state.PendingConfigFileChanges {
@@ -656,6 +745,7 @@ func handlePodChangeAction(ctx context.Context, state *store.EngineState, pod *v
 	podInfo.Deleting = pod.DeletionTimestamp != nil
 	podInfo.Phase = pod.Status.Phase
 	podInfo.Status = podStatusToString(*pod)
+	podInfo.Ready = k8s.IsPodReady(pod)
 
 	defer prunePods(ms)
 
@@ -688,7 +778,11 @@ func handlePodChangeAction(ctx context.Context, state *store.EngineState, pod *v
 	}
 
 	populateContainerStatus(ctx, manifest, podInfo, pod, cStatus)
-	checkForPodCrash(ctx, state, ms, *podInfo)
+
+	// A Job replaces its pods when they fail. That's not a crash we need to rebuild for.
+	if !manifest.K8sTarget().IsJob {
+		checkForPodCrash(ctx, state, ms, *podInfo)
+	}
 
 	if int(cStatus.RestartCount) > podInfo.ContainerRestarts {
 		podInfo.CurrentLog = model.Log{}
@@ -717,6 +811,7 @@ func checkForPodCrash(ctx context.Context, state *store.EngineState, ms *store.M
 		ms.BuildHistory[0].Log = model.AppendLog(ms.BuildHistory[0].Log, le, state.LogTimestamps)
 	}
 	ms.CurrentBuild.Log = model.AppendLog(ms.CurrentBuild.Log, le, state.LogTimestamps)
+	appendToLogStore(state, le, logstore.Meta{ManifestName: ms.Name, Source: logstore.SourceTilt})
 	logger.Get(ctx).Infof("%s", msg)
 }
 
@@ -756,7 +851,12 @@ func handlePodLogAction(state *store.EngineState, action PodLogAction) {
 		return
 	}
 
-	ms.CombinedLog = model.AppendLog(ms.CombinedLog, action, state.LogTimestamps)
+	appendToLogStore(state, action, logstore.Meta{
+		ManifestName: manifestName,
+		Source:       logstore.SourcePod,
+		PodID:        action.PodID,
+		Container:    action.Container,
+	})
 
 	podID := action.PodID
 	if !ms.PodSet.ContainsID(podID) {
@@ -782,17 +882,37 @@ func handleBuildLogAction(state *store.EngineState, action BuildLogAction) {
 	manifestName := action.ManifestName
 	ms, ok := state.ManifestState(manifestName)
 
-	if !ok || state.CurrentlyBuilding != manifestName {
+	if !ok || !state.CurrentlyBuilding[manifestName] {
 		// This is OK. The user could have edited the manifest recently.
 		return
 	}
 
-	ms.CombinedLog = model.AppendLog(ms.CombinedLog, action, state.LogTimestamps)
 	ms.CurrentBuild.Log = model.AppendLog(ms.CurrentBuild.Log, action, state.LogTimestamps)
+	appendToLogStore(state, action, logstore.Meta{ManifestName: manifestName, Source: logstore.SourceBuild})
 }
 
 func handleLogAction(state *store.EngineState, action LogAction) {
-	state.Log = model.AppendLog(state.Log, action, state.LogTimestamps)
+	appendToLogStore(state, action, logstore.Meta{Source: logstore.SourceTilt, Level: logStoreLevel(action.Level)})
+}
+
+// Records log output in the log store, with metadata about where it came from.
+func appendToLogStore(state *store.EngineState, le model.LogEvent, meta logstore.Meta) {
+	meta.Time = le.Time()
+	if meta.Level == "" {
+		meta.Level = logstore.LevelInfo
+	}
+	state.LogStore.Append(meta, le.Message())
+}
+
+func logStoreLevel(level logger.Level) logstore.Level {
+	switch level {
+	case logger.VerboseLvl:
+		return logstore.LevelVerbose
+	case logger.DebugLvl:
+		return logstore.LevelDebug
+	default:
+		return logstore.LevelInfo
+	}
 }
 
 func handleServiceEvent(ctx context.Context, state *store.EngineState, action ServiceChangeAction) {
@@ -811,13 +931,84 @@ func handleServiceEvent(ctx context.Context, state *store.EngineState, action Se
 	ms.LBs[k8s.ServiceName(service.Name)] = action.URL
 }
 
+func handleJobChangeAction(ctx context.Context, state *store.EngineState, action JobChangeAction) {
+	job := action.Job
+	manifestName := model.ManifestName(job.ObjectMeta.Labels[k8s.ManifestNameLabel])
+	if manifestName == "" || manifestName == model.GlobalYAMLManifestName {
+		return
+	}
+
+	ms, ok := state.ManifestState(manifestName)
+	if !ok {
+		logger.Get(ctx).Infof("error: got notified of job for unknown manifest '%s'", manifestName)
+		return
+	}
+
+	// Each deploy deletes the old Job and creates a new one. Ignore the old one.
+	if jobDeployID, ok := job.ObjectMeta.Labels[k8s.TiltDeployIDLabel]; ok {
+		if jdID, err := strconv.Atoi(jobDeployID); err != nil || jdID != int(ms.DeployID) {
+			return
+		}
+	}
+	if job.DeletionTimestamp != nil {
+		return
+	}
+
+	ms.JobStatus = jobStatus(job)
+}
+
+func handleK8sEvent(state *store.EngineState, action K8sEventAction) {
+	ms, ok := state.ManifestState(action.ManifestName)
+	if !ok {
+		// This is OK. The user could have edited the manifest recently.
+		return
+	}
+
+	evt := action.Event
+	w := store.K8sWarnEvent{
+		Name:           evt.Name,
+		InvolvedObject: fmt.Sprintf("%s/%s", evt.InvolvedObject.Kind, evt.InvolvedObject.Name),
+		Reason:         evt.Reason,
+		Message:        evt.Message,
+		Count:          evt.Count,
+		LastTimestamp:  k8s.EventTime(evt),
+	}
+
+	// Kubernetes bumps the count on the existing Event when a warning repeats,
+	// so replace our copy rather than adding a new one.
+	found := false
+	for i, e := range ms.K8sWarnEvents {
+		if e.Name == w.Name {
+			ms.K8sWarnEvents[i] = w
+			found = true
+			break
+		}
+	}
+	if !found {
+		ms.K8sWarnEvents = append(ms.K8sWarnEvents, w)
+	}
+
+	msg := fmt.Sprintf("[K8s EVENT: %s] %s: %s\n", w.InvolvedObject, w.Reason, w.Message)
+	le := newLogEvent([]byte(msg))
+	appendToLogStore(state, le, logstore.Meta{
+		ManifestName: ms.Name,
+		Source:       logstore.SourceK8sEvent,
+		Level:        logstore.LevelWarn,
+	})
+}
+
 func handleInitAction(ctx context.Context, engineState *store.EngineState, action InitAction) error {
 	watchFiles := action.WatchFiles
 	engineState.TiltStartTime = action.StartTime
 	engineState.TiltfilePath = action.TiltfilePath
 	engineState.TriggerMode = action.TriggerMode
+	engineState.MaxParallelBuildsFromFlag = action.MaxParallelBuilds
+	engineState.K8sNamespaceFromFlag = action.K8sNamespace
+	engineState.KubeContext = action.KubeContext
+	engineState.EngineMode = action.EngineMode
 	engineState.ConfigFiles = action.ConfigFiles
 	engineState.InitManifests = action.InitManifests
+	engineState.TiltfileArgs = action.TiltfileArgs
 
 	if action.ExecuteTiltfile {
 		engineState.GlobalYAML = action.GlobalYAMLManifest
@@ -909,10 +1100,87 @@ func handleDockerComposeLogAction(state *store.EngineState, action DockerCompose
 
 	dcState, _ := ms.ResourceState.(dockercompose.State)
 	ms.ResourceState = dcState.WithCurrentLog(model.AppendLog(dcState.CurrentLog, action, state.LogTimestamps))
-	ms.CombinedLog = model.AppendLog(ms.CombinedLog, action, state.LogTimestamps)
+	appendToLogStore(state, action, logstore.Meta{ManifestName: manifestName, Source: logstore.SourceCompose})
+}
+
+func handleLocalProcessStarted(state *store.EngineState, action LocalProcessStartedAction) {
+	ms, ok := state.ManifestState(action.ManifestName)
+	if !ok {
+		return
+	}
+
+	lrs := ms.LocalRuntimeState()
+	if lrs.PID != 0 {
+		lrs.Restarts++
+	}
+	lrs.PID = action.PID
+	lrs.Status = store.LocalProcessStatusRunning
+	lrs.ExitCode = 0
+	lrs.StartTime = action.StartTime
+	lrs.CurrentLog = model.Log{}
+	ms.ResourceState = lrs
+}
+
+func handleLocalProcessExited(state *store.EngineState, action LocalProcessExitedAction) {
+	ms, ok := state.ManifestState(action.ManifestName)
+	if !ok {
+		return
+	}
+
+	lrs := ms.LocalRuntimeState()
+	if lrs.PID != action.PID {
+		// An old process that we killed on restart; nothing to update.
+		return
+	}
+
+	lrs.ExitCode = action.ExitCode
+	if action.ExitCode == 0 {
+		lrs.Status = store.LocalProcessStatusCompleted
+	} else {
+		lrs.Status = store.LocalProcessStatusError
+	}
+	ms.ResourceState = lrs
+}
+
+func handleLocalProcessLogAction(state *store.EngineState, action LocalProcessLogAction) {
+	ms, ok := state.ManifestState(action.ManifestName)
+	if !ok {
+		// This is OK. The user could have edited the manifest recently.
+		return
+	}
+
+	appendToLogStore(state, action, logstore.Meta{ManifestName: action.ManifestName, Source: logstore.SourceLocal})
+
+	lrs := ms.LocalRuntimeState()
+	if lrs.PID == action.PID {
+		lrs.CurrentLog = model.AppendLog(lrs.CurrentLog, action, state.LogTimestamps)
+		ms.ResourceState = lrs
+	}
+}
+
+func handleProbeResultAction(state *store.EngineState, action ProbeResultAction) {
+	ms, ok := state.ManifestState(action.ManifestName)
+	if !ok {
+		return
+	}
+
+	if !action.DeployTime.Equal(ms.LastSuccessfulDeployTime) {
+		// A result for an old deploy; the ProbeController will send us a new one.
+		return
+	}
+
+	ms.ProbeStatus = store.ProbeStatus{
+		Ready:         action.Ready,
+		Message:       action.Message,
+		DeployTime:    action.DeployTime,
+		LastProbeTime: action.Time,
+	}
 }
 
 func handleTiltfileLogAction(ctx context.Context, state *store.EngineState, action TiltfileLogAction) {
 	state.CurrentTiltfileBuild.Log = model.AppendLog(state.CurrentTiltfileBuild.Log, action, state.LogTimestamps)
-	state.TiltfileCombinedLog = model.AppendLog(state.TiltfileCombinedLog, action, state.LogTimestamps)
+	appendToLogStore(state, action, logstore.Meta{
+		ManifestName: view.TiltfileResourceName,
+		Source:       logstore.SourceTiltfile,
+	})
 }
@@ -19,6 +19,8 @@ import (
 	"github.com/docker/distribution/reference"
 	"github.com/stretchr/testify/assert"
 	"github.com/windmilleng/wmclient/pkg/analytics"
+	"github.com/windmilleng/wmclient/pkg/dirs"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,6 +37,7 @@ import (
 	"github.com/windmilleng/tilt/internal/logger"
 	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/store/logstore"
 	"github.com/windmilleng/tilt/internal/synclet"
 	"github.com/windmilleng/tilt/internal/testutils/bufsync"
 	testoutput "github.com/windmilleng/tilt/internal/testutils/output"
@@ -104,6 +107,16 @@ func (c buildAndDeployCall) dc() model.DockerComposeTarget {
 	return model.DockerComposeTarget{}
 }
 
+func (c buildAndDeployCall) local() model.LocalTarget {
+	for _, spec := range c.specs {
+		t, ok := spec.(model.LocalTarget)
+		if ok {
+			return t
+		}
+	}
+	return model.LocalTarget{}
+}
+
 func (c buildAndDeployCall) oneState() store.BuildState {
 	if len(c.state) != 1 {
 		panic(fmt.Sprintf("More than one state: %v", c.state))
@@ -152,7 +165,7 @@ func (b *fakeBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.RSto
 	b.buildCount++
 
 	call := buildAndDeployCall{count: b.buildCount, specs: specs, state: state}
-	if call.dc().Empty() && call.k8s().Empty() {
+	if call.dc().Empty() && call.k8s().Empty() && call.local().Empty() {
 		b.t.Fatalf("Invalid call: %+v", call)
 	}
 
@@ -214,6 +227,10 @@ func (b *fakeBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.RSto
 		result[call.dc().ID()] = store.NewContainerBuildResult(call.dc().ID(), b.nextBuildContainer)
 	}
 
+	if !call.local().Empty() {
+		result[call.local().ID()] = store.NewLocalBuildResult(call.local().ID())
+	}
+
 	b.nextBuildContainer = ""
 
 	return result, nil
@@ -913,10 +930,10 @@ func TestReapOldBuilds(t *testing.T) {
 	f.Start([]model.Manifest{manifest}, true)
 
 	f.PollUntil("images reaped", func() bool {
-		return len(f.docker.RemovedImageIDs) > 0
+		return len(f.docker.RemovedImageIDs()) > 0
 	})
 
-	assert.Equal(t, []string{"build-id-0"}, f.docker.RemovedImageIDs)
+	assert.Equal(t, []string{"build-id-0"}, f.docker.RemovedImageIDs())
 	err := f.Stop()
 	assert.Nil(t, err)
 }
@@ -1030,6 +1047,77 @@ func TestPodEvent(t *testing.T) {
 	f.assertAllBuildsConsumed()
 }
 
+func TestK8sWarnEvent(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+	sync := model.Sync{LocalPath: "/go", ContainerPath: "/go"}
+	manifest := f.newManifest("foobar", []model.Sync{sync})
+	f.Start([]model.Manifest{manifest}, true)
+
+	call := f.nextCall()
+	assert.True(t, call.oneState().IsEmpty())
+
+	evt := &v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "my-pod.123"},
+		InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "my-pod"},
+		Type:           v1.EventTypeWarning,
+		Reason:         "FailedScheduling",
+		Message:        "0/1 nodes are available",
+		Count:          1,
+	}
+	f.store.Dispatch(NewK8sEventAction(evt, "foobar"))
+
+	evt2 := evt.DeepCopy()
+	evt2.Count = 2
+	f.store.Dispatch(NewK8sEventAction(evt2, "foobar"))
+
+	f.WaitUntilHUDResource("hud update", "foobar", func(res view.Resource) bool {
+		return len(res.K8SInfo().Warnings) == 1 && strings.Contains(res.K8SInfo().Warnings[0], "(x2)")
+	})
+
+	rv := f.hudResource("foobar")
+	assert.Equal(t, []string{"Pod/my-pod FailedScheduling: 0/1 nodes are available (x2)"}, rv.K8SInfo().Warnings)
+
+	state := f.upper.store.RLockState()
+	log := state.LogStore.ManifestLogTail("foobar", false).String()
+	f.upper.store.RUnlockState()
+	assert.Contains(t, log, "[K8s EVENT: Pod/my-pod] FailedScheduling: 0/1 nodes are available")
+
+	assert.NoError(t, f.Stop())
+	f.assertAllBuildsConsumed()
+}
+
+func TestJobStatus(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	manifest := f.newManifest("pi", nil)
+	kTarget := manifest.K8sTarget()
+	kTarget.IsJob = true
+	manifest = manifest.WithDeployTarget(kTarget)
+	f.Start([]model.Manifest{manifest}, true)
+
+	f.nextCall()
+	f.waitForCompletedBuildCount(1)
+
+	// The Job from an older deploy finished, but that doesn't tell us anything.
+	f.store.Dispatch(NewJobChangeAction(f.testJob("pi", model.DeployID(111), batchv1.JobComplete)))
+	f.store.Dispatch(NewJobChangeAction(f.testJob("pi", testDeployID, batchv1.JobFailed)))
+
+	f.WaitUntilHUDResource("job failed", "pi", func(res view.Resource) bool {
+		return res.K8SInfo().Status() == "Failed"
+	})
+
+	f.store.Dispatch(NewJobChangeAction(f.testJob("pi", testDeployID, batchv1.JobComplete)))
+	f.WaitUntilHUDResource("job completed", "pi", func(res view.Resource) bool {
+		return res.K8SInfo().Status() == "Completed"
+	})
+	assert.True(t, f.hudResource("pi").K8SInfo().IsJob)
+
+	assert.NoError(t, f.Stop())
+	f.assertAllBuildsConsumed()
+}
+
 func TestPodEventOrdering(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
@@ -1212,7 +1300,8 @@ func TestPodUnexpectedContainerStartsImageBuild(t *testing.T) {
 		StartTime:    time.Now(),
 	})
 	f.store.Dispatch(BuildCompleteAction{
-		Result: containerResultSet(manifest, "theOriginalContainer"),
+		ManifestName: manifest.Name,
+		Result:       containerResultSet(manifest, "theOriginalContainer"),
 	})
 	f.setDeployIDForManifest(manifest, testDeployID)
 
@@ -1259,7 +1348,8 @@ func TestPodUnexpectedContainerStartsImageBuildOutOfOrderEvents(t *testing.T) {
 	// ...and finish the build. Even though this action comes in AFTER the pod
 	// event w/ unexpected container,  we should still be able to detect the mismatch.
 	f.store.Dispatch(BuildCompleteAction{
-		Result: containerResultSet(manifest, "theOriginalContainer"),
+		ManifestName: manifest.Name,
+		Result:       containerResultSet(manifest, "theOriginalContainer"),
 	})
 
 	f.WaitUntilManifestState("NeedsRebuildFromCrash set to True", "foobar", func(ms store.ManifestState) bool {
@@ -1294,7 +1384,8 @@ func TestPodUnexpectedContainerAfterSuccessfulUpdate(t *testing.T) {
 	})
 	podStartTime := time.Now()
 	f.store.Dispatch(BuildCompleteAction{
-		Result: containerResultSet(manifest, "normal-container-id"),
+		ManifestName: manifest.Name,
+		Result:       containerResultSet(manifest, "normal-container-id"),
 	})
 	f.setDeployIDForManifest(manifest, testDeployID)
 
@@ -1316,7 +1407,8 @@ func TestPodUnexpectedContainerAfterSuccessfulUpdate(t *testing.T) {
 	// Simulate a pod crash, then a build completion
 	f.podEvent(f.testPod("mypod", "foobar", "Running", "funny-container-id", podStartTime))
 	f.store.Dispatch(BuildCompleteAction{
-		Result: containerResultSet(manifest, "normal-container-id"),
+		ManifestName: manifest.Name,
+		Result:       containerResultSet(manifest, "normal-container-id"),
 	})
 
 	f.WaitUntilManifestState("NeedsRebuildFromCrash set to True", "foobar", func(ms store.ManifestState) bool {
@@ -1583,8 +1675,11 @@ func TestUpperPodLogInCrashLoopThirdInstanceStillUp(t *testing.T) {
 	// the third instance is still up, so we want to show the log from the last crashed pod plus the log from the current pod
 	f.withManifestState(name, func(ms store.ManifestState) {
 		assert.Equal(t, "third string\n", ms.MostRecentPod().Log().String())
-		assert.Contains(t, ms.CombinedLog.String(), "second string\n")
-		assert.Contains(t, ms.CombinedLog.String(), "third string\n")
+	})
+	f.withState(func(st store.EngineState) {
+		log := st.LogStore.ManifestLogTail(name, false).String()
+		assert.Contains(t, log, "second string\n")
+		assert.Contains(t, log, "third string\n")
 	})
 
 	err := f.Stop()
@@ -1722,7 +1817,24 @@ func TestUpper_PodLogs(t *testing.T) {
 
 	f.podLog(name, "Hello world!\n")
 
-	err := f.Stop()
+	state := f.store.RLockState()
+	result, err := state.LogStore.Query(logstore.Query{ManifestNames: []model.ManifestName{name}})
+	f.store.RUnlockState()
+	assert.NoError(t, err)
+
+	var podLines []logstore.Line
+	for _, line := range result.Lines {
+		if line.Source == logstore.SourcePod {
+			podLines = append(podLines, line)
+		}
+	}
+	if assert.NotEmpty(t, podLines) {
+		assert.Equal(t, "Hello world!\n", podLines[0].Text)
+		assert.Equal(t, k8s.PodID(f.pod.Name), podLines[0].PodID)
+		assert.Equal(t, logstore.LevelInfo, podLines[0].Level)
+	}
+
+	err = f.Stop()
 	assert.NoError(t, err)
 }
 
@@ -1842,6 +1954,32 @@ func TestDockerComposeUp(t *testing.T) {
 	assert.Equal(t, server.DockerComposeTarget().ID(), call.dc().ID())
 }
 
+func TestK8sAndDockerComposeUp(t *testing.T) {
+	f := newTestFixture(t)
+	sancho := f.newManifest("sancho", nil)
+	redis := f.newDCManifest("redis", "version: '3'\nservices:\n  redis:\n    image: redis", "")
+
+	f.Start([]model.Manifest{sancho, redis}, true)
+
+	// The build order isn't specified, so collect both deploy targets.
+	var deployed []model.TargetID
+	for i := 0; i < 2; i++ {
+		call := f.nextCall()
+		if call.dc().ID().Empty() {
+			deployed = append(deployed, call.k8s().ID())
+		} else {
+			deployed = append(deployed, call.dc().ID())
+		}
+	}
+	assert.ElementsMatch(t, []model.TargetID{sancho.K8sTarget().ID(), redis.DockerComposeTarget().ID()}, deployed)
+
+	f.waitForCompletedBuildCount(2)
+	f.WaitUntilHUDResource("redis in the HUD", redis.Name, func(res view.Resource) bool {
+		return res.IsDC()
+	})
+	assert.False(t, f.hudResource(sancho.Name).IsDC())
+}
+
 func TestDockerComposeRedeployFromFileChange(t *testing.T) {
 	f := newTestFixture(t)
 	_, m := f.setupDCFixture()
@@ -1966,7 +2104,10 @@ func TestDockerComposeRecordsRunLogs(t *testing.T) {
 	// recorded on manifest state
 	f.withManifestState(m.ManifestName(), func(st store.ManifestState) {
 		assert.Contains(t, st.DCResourceState().Log().String(), expected)
-		assert.Equal(t, 1, strings.Count(st.CombinedLog.String(), expected))
+	})
+	f.withState(func(st store.EngineState) {
+		log := st.LogStore.ManifestLogTail(m.ManifestName(), false).String()
+		assert.Equal(t, 1, strings.Count(log, expected))
 	})
 }
 
@@ -2082,17 +2223,64 @@ func TestDockerComposeBuildCompletedDoesntSetStatusIfNotSuccessful(t *testing.T)
 func TestEmptyTiltfile(t *testing.T) {
 	f := newTestFixture(t)
 	f.WriteFile("Tiltfile", "")
-	go f.upper.Start(f.ctx, []string{}, false, model.TriggerAuto, f.JoinPath("Tiltfile"), true)
+	go f.upper.Start(f.ctx, StartOptions{
+		TiltfilePath:    f.JoinPath("Tiltfile"),
+		TriggerMode:     model.TriggerAuto,
+		UseActionWriter: true,
+		EngineMode:      store.EngineModeUp,
+	})
 	f.WaitUntil("build is set", func(st store.EngineState) bool {
 		return !st.LastTiltfileBuild.Empty()
 	})
 	f.withState(func(st store.EngineState) {
 		assert.Contains(t, st.LastTiltfileBuild.Error.Error(), "No resources found. Check out ")
-		assertContainsOnce(t, st.TiltfileCombinedLog.String(), "No resources found. Check out ")
+		assertContainsOnce(t, st.LogStore.ManifestLogTail(view.TiltfileResourceName, false).String(), "No resources found. Check out ")
 		assertContainsOnce(t, st.LastTiltfileBuild.Log.String(), "No resources found. Check out ")
 	})
 }
 
+func TestCIExitsWhenPodReady(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+	manifest := f.newManifest("foobar", nil)
+	f.startCI(manifest)
+
+	f.nextCall()
+	f.waitForCompletedBuildCount(1)
+	f.startPod("foobar")
+
+	select {
+	case err := <-f.createManifestsResult:
+		t.Fatalf("Expected CI to wait for the pod to be ready. Exited with: %v", err)
+	default:
+	}
+
+	f.pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	f.podEvent(f.pod)
+
+	err := f.WaitForExit()
+	assert.NoError(t, err)
+}
+
+func TestCIExitsOnPodRestart(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+	manifest := f.newManifest("foobar", nil)
+	f.startCI(manifest)
+
+	f.nextCall()
+	f.waitForCompletedBuildCount(1)
+	f.startPod("foobar")
+
+	f.pod.Status.ContainerStatuses[0].RestartCount = 1
+	f.podEvent(f.pod)
+
+	err := f.WaitForExit()
+	if assert.Error(t, err) {
+		assert.Equal(t, "foobar failed: pod mypod restarted 1 times", err.Error())
+	}
+}
+
 func TestWatchManifestsWithCommonAncestor(t *testing.T) {
 	f := newTestFixture(t)
 	m1, m2 := NewManifestsWithCommonAncestor(f)
@@ -2224,9 +2412,12 @@ func newTestFixture(t *testing.T) *testFixture {
 	dockerClient := docker.NewFakeClient()
 	reaper := build.NewImageReaper(dockerClient)
 
+	contextGuard := k8s.ProvideContextGuard(k8s.EnvDockerDesktop, "docker-for-desktop")
 	k8s := k8s.NewFakeK8sClient()
 	pw := NewPodWatcher(k8s)
 	sw := NewServiceWatcher(k8s, "")
+	ewm := NewEventWatchManager(k8s, "")
+	jw := NewJobWatcher(k8s)
 
 	fakeHud := hud.NewFakeHud()
 
@@ -2248,7 +2439,8 @@ func newTestFixture(t *testing.T) *testFixture {
 	fwm := NewWatchManager(watcher.newSub, timerMaker.maker())
 	pfc := NewPortForwardController(k8s)
 	ic := NewImageController(reaper)
-	gybc := NewGlobalYAMLBuildController(k8s)
+	gybc := NewGlobalYAMLBuildController(k8s, NewK8sNamespaceEnsurer(k8s))
+	kp := NewKubernetesPruner(k8s, "", dirs.NewWindmillDirAt(f.JoinPath("windmill")))
 	an := analytics.NewMemoryAnalytics()
 	ar := ProvideAnalyticsReporter(an, st)
 
@@ -2256,18 +2448,21 @@ func newTestFixture(t *testing.T) *testFixture {
 	fakeDcc := dockercompose.NewFakeDockerComposeClient(t, ctx)
 	realDcc := dockercompose.NewDockerComposeClient(docker.Env{})
 
-	tfl := tiltfile.ProvideTiltfileLoader(an, realDcc)
-	cc := NewConfigsController(tfl)
+	tfl := tiltfile.ProvideTiltfileLoader(an, realDcc, "docker-for-desktop", "docker-for-desktop")
+	cc := NewConfigsController(tfl, contextGuard)
 	dcw := NewDockerComposeEventWatcher(fakeDcc)
 	dclm := NewDockerComposeLogManager(fakeDcc)
+	lpm := NewLocalProcessManager(dirs.NewWindmillDirAt(f.JoinPath("windmill")))
+	prc := NewProbeController()
+	rr := NewResourceRestarter(k8s, fakeDcc)
 	pm := NewProfilerManager()
 	sCli := synclet.NewFakeSyncletClient()
 	sm := NewSyncletManagerForTests(k8s, sCli)
 	hudsc := server.ProvideHeadsUpServerController(0, server.HeadsUpServer{}, server.NewFakeAssetServer())
 	subs := []store.Subscriber{
-		fakeHud, pw, sw, plm, pfc, fwm, bc, ic, gybc, cc, dcw, dclm, pm, sm, ar, hudsc,
+		fakeHud, pw, sw, ewm, jw, plm, pfc, fwm, bc, ic, gybc, kp, cc, dcw, dclm, lpm, prc, rr, pm, sm, ar, hudsc,
 	}
-	upper := NewUpper(ctx, st, subs)
+	upper := NewUpper(ctx, st, subs, "docker-for-desktop")
 
 	go func() {
 		fakeHud.Run(ctx, upper.Dispatch, hud.DefaultRefreshInterval)
@@ -2318,6 +2513,17 @@ func (f *testFixture) startWithInitManifests(initManifests []model.ManifestName,
 	})
 }
 
+func (f *testFixture) startCI(manifests ...model.Manifest) {
+	f.Init(InitAction{
+		Manifests:       manifests,
+		TiltfilePath:    f.JoinPath("Tiltfile"),
+		ExecuteTiltfile: true,
+		EngineMode:      store.EngineModeCI,
+		StartTime:       time.Now(),
+		FinishTime:      time.Now(),
+	})
+}
+
 func (f *testFixture) Init(action InitAction) {
 	if action.TiltfilePath == "" {
 		action.TiltfilePath = "/Tiltfile"
@@ -2370,7 +2576,7 @@ func (f *testFixture) SetNextBuildFailure(err error) {
 	// Don't set the nextBuildFailure flag when a completed build needs to be processed
 	// by the state machine.
 	f.WaitUntil("build complete processed", func(state store.EngineState) bool {
-		return state.CurrentlyBuilding == ""
+		return !state.IsBuilding()
 	})
 	_ = f.store.RLockState()
 	f.b.nextBuildFailure = err
@@ -2574,6 +2780,21 @@ func (f *testFixture) podEvent(pod *v1.Pod) {
 	f.store.Dispatch(NewPodChangeAction(pod))
 }
 
+func (f *testFixture) testJob(manifestName string, deployID model.DeployID, condition batchv1.JobConditionType) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: manifestName,
+			Labels: map[string]string{
+				k8s.ManifestNameLabel: manifestName,
+				k8s.TiltDeployIDLabel: deployID.String(),
+			},
+		},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: condition, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
 func (f *testFixture) imageNameForManifest(manifestName string) reference.Named {
 	return container.MustParseNamed(manifestName)
 }
@@ -2615,7 +2836,7 @@ func (f *testFixture) assertAllBuildsConsumed() {
 }
 
 func (f *testFixture) loadAndStart() {
-	tlr, err := f.tfl.Load(f.ctx, f.JoinPath(tiltfile.FileName), nil)
+	tlr, err := f.tfl.Load(f.ctx, f.JoinPath(tiltfile.FileName), nil, nil)
 	if err != nil {
 		f.T().Fatal(err)
 	}
@@ -2664,7 +2885,7 @@ func (f *testFixture) setupDCFixture() (redis, server model.Manifest) {
 
 	f.WriteFile("Tiltfile", `docker_compose('docker-compose.yml')`)
 
-	tlr, err := f.tfl.Load(f.ctx, f.JoinPath("Tiltfile"), nil)
+	tlr, err := f.tfl.Load(f.ctx, f.JoinPath("Tiltfile"), nil, nil)
 	if err != nil {
 		f.T().Fatal(err)
 	}
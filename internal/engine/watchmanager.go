@@ -39,6 +39,14 @@ func watchableTargetsForManifests(manifests []model.Manifest) []WatchableTarget
 			}
 		}
 
+		if m.IsLocal() {
+			localTarget := m.LocalTarget()
+			if !seen[localTarget.ID()] {
+				watchable = append(watchable, localTarget)
+				seen[localTarget.ID()] = true
+			}
+		}
+
 		for _, iTarget := range m.ImageTargets {
 			if !seen[iTarget.ID()] {
 				watchable = append(watchable, iTarget)
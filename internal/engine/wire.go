@@ -28,6 +28,7 @@ var DeployerBaseWireSet = wire.NewSet(
 	docker.ProvideEnv,
 	build.DefaultImageBuilder,
 	build.NewCacheBuilder,
+	build.NewImageCache,
 	build.NewDockerImageBuilder,
 	build.NewExecCustomBuilder,
 	wire.Bind(new(build.CustomBuilder), new(build.ExecCustomBuilder)),
@@ -38,6 +39,7 @@ var DeployerBaseWireSet = wire.NewSet(
 	NewSyncletBuildAndDeployer,
 	NewLocalContainerBuildAndDeployer,
 	NewDockerComposeBuildAndDeployer,
+	NewLocalTargetBuildAndDeployer,
 	NewImageAndCacheBuilder,
 	DefaultBuildOrder,
 
@@ -45,6 +47,7 @@ var DeployerBaseWireSet = wire.NewSet(
 	NewCompositeBuildAndDeployer,
 	ProvideUpdateMode,
 	NewGlobalYAMLBuildController,
+	NewK8sNamespaceEnsurer,
 )
 
 var DeployerWireSetTest = wire.NewSet(
@@ -113,6 +116,7 @@ func provideDockerComposeBuildAndDeployer(
 		k8s.ProvideConfigNamespace,
 		k8s.ProvideKubeContext,
 		k8s.ProvideKubectlRunner,
+		k8s.ProvideContextGuard,
 		k8s.ProvideK8sClient,
 		k8s.ProvidePortForwarder,
 		k8s.ProvideContainerRuntime,
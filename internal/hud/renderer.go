@@ -61,6 +61,7 @@ var statusColors = map[string]tcell.Color{
 	string(dockercompose.StatusUp):     cGood,
 	string(dockercompose.StatusDown):   cBad,
 	"Completed":                        cGood,
+	"Failed":                           cBad,
 }
 
 func (r *Renderer) layout(v view.View, vs view.ViewState) rty.Component {
@@ -70,6 +71,9 @@ func (r *Renderer) layout(v view.View, vs view.ViewState) rty.Component {
 		l.Add(rty.NewLine())
 	}
 
+	if v.KubeContext != "" {
+		l.Add(r.renderKubeContext(v))
+	}
 	l.Add(r.renderResourceHeader(v))
 	l.Add(r.renderResources(v, vs))
 	l.Add(r.renderLogPane(v, vs))
@@ -151,7 +155,7 @@ func (r *Renderer) renderStatusBar(v view.View) rty.Component {
 	sb.Text(" ") // Indent
 	errorCount := 0
 	for _, res := range v.Resources {
-		if isInError(res, v.TriggerMode) {
+		if isInError(res, res.TriggerMode) {
 			errorCount++
 		}
 	}
@@ -193,7 +197,7 @@ func keyLegend(v view.View, vs view.ViewState) string {
 	defaultKeys := "Browse (↓ ↑), Expand (→) ┊ (enter) log, (b)rowser ┊ (ctrl-C) quit  "
 	if vs.AlertMessage != "" {
 		return "Tilt (l)og ┊ (esc) close alert "
-	} else if v.TriggerMode == model.TriggerManual {
+	} else if v.HasManualTrigger() {
 		return "Build (space) ┊ " + defaultKeys
 	}
 	return defaultKeys
@@ -204,11 +208,16 @@ func isInError(res view.Resource, triggerMode model.TriggerMode) bool {
 }
 
 func warnings(res view.Resource) []string {
-	return res.LastBuild().Warnings
+	ret := append([]string{}, res.LastBuild().Warnings...)
+	if res.IsK8S() {
+		ret = append(ret, res.K8SInfo().Warnings...)
+	}
+	return ret
 }
 
 func isCrashing(res view.Resource) bool {
-	return (res.IsK8S() && res.K8SInfo().PodRestarts > 0) ||
+	// A Job's pods can restart on failure and still complete. The Job's status tells us if it failed.
+	return (res.IsK8S() && !res.K8SInfo().IsJob && res.K8SInfo().PodRestarts > 0) ||
 		res.LastBuild().Reason.Has(model.BuildReasonFlagCrash) ||
 		res.CurrentBuild.Reason.Has(model.BuildReasonFlagCrash) ||
 		res.PendingBuildReason.Has(model.BuildReasonFlagCrash) ||
@@ -231,6 +240,14 @@ func renderNarration(msg string) rty.Component {
 	return rty.NewFixedSize(box, rty.GROW, 3)
 }
 
+// So that nobody deploys to the wrong cluster by accident.
+func (r *Renderer) renderKubeContext(v view.View) rty.Component {
+	l := rty.NewConcatLayout(rty.DirHor)
+	l.Add(rty.ColoredString("  K8S CONTEXT: ", cLightText))
+	l.Add(rty.TextString(v.KubeContext))
+	return rty.OneLine(l)
+}
+
 func (r *Renderer) renderResourceHeader(v view.View) rty.Component {
 	l := rty.NewConcatLayout(rty.DirHor)
 	l.Add(rty.ColoredString("  RESOURCE NAME ", cLightText))
@@ -266,7 +283,7 @@ func (r *Renderer) renderResources(v view.View, vs view.ViewState) rty.Component
 
 	if len(rs) > 0 {
 		for i, res := range rs {
-			l.Add(r.renderResource(res, vs.Resources[i], v.TriggerMode, selectedResource == res.Name.String()))
+			l.Add(r.renderResource(res, vs.Resources[i], res.TriggerMode, selectedResource == res.Name.String()))
 		}
 	}
 
@@ -529,6 +529,7 @@ func TestPendingBuildInManualTriggerMode(t *testing.T) {
 				PendingBuildSince: ts.Add(-5 * time.Second),
 				PendingBuildEdits: []string{"main.go"},
 				ResourceInfo:      view.K8SResourceInfo{},
+				TriggerMode:       model.TriggerManual,
 			},
 		},
 	}
@@ -728,6 +729,42 @@ func TestTiltfileResourcePending(t *testing.T) {
 	rtf.run("Tiltfile resource pending", 80, 20, v, vs)
 }
 
+func TestRenderKubeContext(t *testing.T) {
+	rtf := newRendererTestFixture(t)
+	vs := fakeViewState(1, view.CollapseNo)
+	v := view.View{
+		KubeContext: "gke_blorg-dev_us-central1-b_blorg",
+		Resources: []view.Resource{
+			{
+				Name:               "foo",
+				DirectoriesWatched: []string{"bar"},
+				ResourceInfo:       view.K8SResourceInfo{},
+			},
+		},
+	}
+	rtf.run("kube context in header", 80, 20, v, vs)
+}
+
+func TestRenderK8sWarnings(t *testing.T) {
+	rtf := newRendererTestFixture(t)
+	vs := fakeViewState(1, view.CollapseNo)
+	v := view.View{
+		Resources: []view.Resource{
+			{
+				Name:               "foo",
+				DirectoriesWatched: []string{"bar"},
+				LastDeployTime:     time.Now(),
+				ResourceInfo: view.K8SResourceInfo{
+					PodName:   "foo-abc",
+					PodStatus: "Pending",
+					Warnings:  []string{"Pod/foo-abc FailedScheduling: 0/1 nodes are available: 1 Insufficient cpu. (x3)"},
+				},
+			},
+		},
+	}
+	rtf.run("k8s warning events", 80, 20, v, vs)
+}
+
 func TestRenderEscapedNbsp(t *testing.T) {
 	rtf := newRendererTestFixture(t)
 	plainVs := fakeViewState(1, view.CollapseNo)
@@ -61,6 +61,11 @@ func (v *ResourceView) resourceTitle() rty.Component {
 		l.Add(middotText())
 	}
 
+	if v.res.Readiness == model.ReadinessNotReady {
+		l.Add(rty.NewStringBuilder().Fg(cPending).Text("Not Ready").Build())
+		l.Add(middotText())
+	}
+
 	l.Add(v.titleTextBuild())
 	l.Add(middotText())
 	l.Add(v.titleTextDeploy())
@@ -90,20 +95,28 @@ func statusColor(res view.Resource, triggerMode model.TriggerMode) tcell.Color {
 		return cBad
 	} else if res.LastBuild().Error != nil {
 		return cBad
-	} else if res.IsYAML() && !res.LastDeployTime.IsZero() {
-		return cGood
+	} else if (res.IsYAML() || (res.IsLocal() && !res.LocalInfo().HasServeCmd)) && !res.LastDeployTime.IsZero() {
+		return readinessColor(res, cGood)
 	} else if !res.LastBuild().FinishTime.IsZero() && res.ResourceInfo.Status() == "" {
 		return cPending // pod status hasn't shown up yet
 	} else {
 		if res.ResourceInfo != nil {
 			if statusColor, ok := statusColors[res.ResourceInfo.Status()]; ok {
-				return statusColor
+				return readinessColor(res, statusColor)
 			}
 		}
 		return cLightText
 	}
 }
 
+// A resource that's up but not ready to serve yet is still pending.
+func readinessColor(res view.Resource, c tcell.Color) tcell.Color {
+	if c == cGood && res.Readiness == model.ReadinessNotReady {
+		return cPending
+	}
+	return c
+}
+
 func (v *ResourceView) titleTextName() rty.Component {
 	sb := rty.NewStringBuilder()
 	selected := v.selected
@@ -137,6 +150,8 @@ func (v *ResourceView) titleText() rty.Component {
 		return titleTextDC(i)
 	case view.K8SResourceInfo:
 		return titleTextK8s(i)
+	case view.LocalResourceInfo:
+		return titleTextLocal(i)
 	default:
 		return nil
 	}
@@ -150,6 +165,17 @@ func titleTextK8s(k8sInfo view.K8SResourceInfo) rty.Component {
 	return rty.TextString(status)
 }
 
+func titleTextLocal(localInfo view.LocalResourceInfo) rty.Component {
+	if !localInfo.HasServeCmd {
+		return nil
+	}
+	status := localInfo.Status()
+	if status == "" {
+		status = "Pending"
+	}
+	return rty.TextString(status)
+}
+
 func titleTextDC(dcInfo view.DCResourceInfo) rty.Component {
 	sb := rty.NewStringBuilder()
 	status := dcInfo.Status()
@@ -189,6 +215,8 @@ func (v *ResourceView) resourceExpanded() rty.Component {
 		return v.resourceExpandedK8s()
 	case view.YAMLResourceInfo:
 		return v.resourceExpandedYAML()
+	case view.LocalResourceInfo:
+		return v.resourceExpandedLocal()
 	default:
 		return rty.EmptyLayout
 	}
@@ -277,6 +305,44 @@ func (v *ResourceView) resourceExpandedK8s() rty.Component {
 	return rty.OneLine(l)
 }
 
+func (v *ResourceView) resourceExpandedLocal() rty.Component {
+	localInfo := v.res.LocalInfo()
+	if localInfo.PID == 0 {
+		return rty.EmptyLayout
+	}
+
+	l := rty.NewConcatLayout(rty.DirHor)
+	l.Add(resourceTextPID(localInfo))
+	l.Add(rty.TextString(" "))
+	l.AddDynamic(rty.NewFillerString(' '))
+	l.Add(rty.TextString(" "))
+
+	if localInfo.ExitCode != 0 {
+		l.Add(rty.NewStringBuilder().Fg(cBad).Textf("exit code %d", localInfo.ExitCode).Build())
+		l.Add(middotText())
+	}
+
+	if localInfo.Restarts > 0 {
+		l.Add(resourceTextRestarts(localInfo.Restarts))
+		l.Add(middotText())
+	}
+
+	if len(v.res.Endpoints) > 0 && !v.endpointsNeedSecondLine() {
+		v.appendEndpoints(l)
+		l.Add(middotText())
+	}
+
+	l.Add(resourceTextAge(localInfo.StartTime))
+	return rty.OneLine(l)
+}
+
+func resourceTextPID(localInfo view.LocalResourceInfo) rty.Component {
+	sb := rty.NewStringBuilder()
+	sb.Fg(cLightText).Text("PID: ")
+	sb.Fg(tcell.ColorDefault).Textf("%d", localInfo.PID)
+	return sb.Build()
+}
+
 func resourceTextPodName(k8sInfo view.K8SResourceInfo) rty.Component {
 	sb := rty.NewStringBuilder()
 	sb.Fg(cLightText).Text("K8S POD: ")
@@ -285,13 +351,17 @@ func resourceTextPodName(k8sInfo view.K8SResourceInfo) rty.Component {
 }
 
 func resourceTextPodRestarts(k8sInfo view.K8SResourceInfo) rty.Component {
+	return resourceTextRestarts(k8sInfo.PodRestarts)
+}
+
+func resourceTextRestarts(restarts int) rty.Component {
 	s := "restarts"
-	if k8sInfo.PodRestarts == 1 {
+	if restarts == 1 {
 		s = "restart"
 	}
 	return rty.NewStringBuilder().
 		Fg(cPending).
-		Textf("%d %s", k8sInfo.PodRestarts, s).
+		Textf("%d %s", restarts, s).
 		Build()
 }
 
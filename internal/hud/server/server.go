@@ -1,13 +1,22 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	_ "github.com/gorilla/websocket"
+	"github.com/windmilleng/tilt/internal/hud/view"
+	"github.com/windmilleng/tilt/internal/hud/webview"
+	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/store/logstore"
 	"github.com/windmilleng/wmclient/pkg/analytics"
 )
 
@@ -17,22 +26,48 @@ type analyticsPayload struct {
 	Tags map[string]string `json:"tags"`
 }
 
+type triggerPayload struct {
+	ManifestNames []string `json:"manifest_names"`
+}
+
+type triggerModePayload struct {
+	ManifestName string            `json:"manifest_name"`
+	TriggerMode  model.TriggerMode `json:"trigger_mode"`
+}
+
+// The Sail room that this Tilt session is shared in, if any.
+type SailRoom interface {
+	RevokeFollowers(ctx context.Context) error
+	CloseRoom(ctx context.Context) error
+}
+
 type HeadsUpServer struct {
-	store  *store.Store
-	router *mux.Router
-	a      analytics.Analytics
+	store       *store.Store
+	router      *mux.Router
+	a           analytics.Analytics
+	sailRoom    SailRoom
+	viewHistory *webview.ViewHistory
 }
 
-func ProvideHeadsUpServer(store *store.Store, assetServer AssetServer, analytics analytics.Analytics) HeadsUpServer {
+func ProvideHeadsUpServer(store *store.Store, assetServer AssetServer, analytics analytics.Analytics, sailRoom SailRoom) HeadsUpServer {
 	r := mux.NewRouter().UseEncodedPath()
 	s := HeadsUpServer{
-		store:  store,
-		router: r,
-		a:      analytics,
+		store:       store,
+		router:      r,
+		a:           analytics,
+		sailRoom:    sailRoom,
+		viewHistory: webview.NewViewHistory(),
 	}
 
 	r.HandleFunc("/api/view", s.ViewJSON)
+	r.HandleFunc("/api/logs", s.HandleLogs)
 	r.HandleFunc("/api/analytics", s.HandleAnalytics)
+	r.HandleFunc("/api/trigger", s.HandleTrigger)
+	r.HandleFunc("/api/trigger_mode", s.HandleTriggerMode)
+	r.HandleFunc("/api/restart", s.HandleRestart)
+	r.HandleFunc("/api/reload_tiltfile", s.HandleReloadTiltfile)
+	r.HandleFunc("/api/sail/revoke", s.HandleSailRevoke)
+	r.HandleFunc("/api/sail/close", s.HandleSailClose)
 	r.HandleFunc("/ws/view", s.ViewWebsocket)
 	r.PathPrefix("/").Handler(assetServer)
 
@@ -55,6 +90,80 @@ func (s HeadsUpServer) ViewJSON(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// The most lines that one response from /api/logs has.
+const maxLogsPageLines = 5000
+
+// Reads a range of lines from the log store, a page at a time.
+//
+// Takes the query params name (repeated, for each resource to read logs from),
+// from and to (the range of line numbers), since (an RFC3339 time), and limit
+// (the most lines to return, up to maxLogsPageLines).
+// An empty name reads Tilt's own log, which interleaves the output of every
+// resource, the way the terminal shows it. Without any names, reads every line.
+// If the response has More set, there are more lines in the range. Clients can
+// read the next page, or follow the log, by passing the checkpoint of the
+// last response as the next from.
+func (s HeadsUpServer) HandleLogs(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	query := logstore.Query{}
+
+	state := s.store.RLockState()
+	logStore := state.LogStore
+	for _, name := range params["name"] {
+		mn := model.ManifestName(name)
+		_, ok := state.ManifestTargets[mn]
+		if !ok && name != "" && name != view.TiltfileResourceName {
+			s.store.RUnlockState()
+			http.Error(w, fmt.Sprintf("no resource found with name %q", name), http.StatusNotFound)
+			return
+		}
+		query.ManifestNames = append(query.ManifestNames, mn)
+	}
+	s.store.RUnlockState()
+
+	var err error
+	query.From, err = intParam(params, "from")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query.To, err = intParam(params, "to")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query.Limit, err = intParam(params, "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.Limit == 0 || query.Limit > maxLogsPageLines {
+		query.Limit = maxLogsPageLines
+	}
+
+	if since := params.Get("since"); since != "" {
+		query.Since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid since: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := logStore.Query(query)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error rendering logs: %v", err), http.StatusInternalServerError)
+	}
+}
+
 func (s HeadsUpServer) HandleAnalytics(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
 		http.Error(w, "must be POST request", http.StatusBadRequest)
@@ -79,3 +188,170 @@ func (s HeadsUpServer) HandleAnalytics(w http.ResponseWriter, req *http.Request)
 		s.a.Incr(p.Name, p.Tags)
 	}
 }
+
+// Trigger a build of each of the given manifests, as if the user had
+// pressed space on them in the terminal HUD.
+func (s HeadsUpServer) HandleTrigger(w http.ResponseWriter, req *http.Request) {
+	var payload triggerPayload
+	if !decodePost(w, req, &payload) {
+		return
+	}
+
+	names, ok := s.manifestNames(w, payload.ManifestNames)
+	if !ok {
+		return
+	}
+
+	for _, mn := range names {
+		s.store.Dispatch(view.AppendToTriggerQueueAction{Name: mn})
+	}
+}
+
+func (s HeadsUpServer) HandleTriggerMode(w http.ResponseWriter, req *http.Request) {
+	var payload triggerModePayload
+	if !decodePost(w, req, &payload) {
+		return
+	}
+
+	if payload.TriggerMode != model.TriggerAuto && payload.TriggerMode != model.TriggerManual {
+		http.Error(w, fmt.Sprintf("invalid trigger mode: %d", payload.TriggerMode), http.StatusBadRequest)
+		return
+	}
+
+	names, ok := s.manifestNames(w, []string{payload.ManifestName})
+	if !ok {
+		return
+	}
+
+	s.store.Dispatch(view.SetTriggerModeAction{Name: names[0], TriggerMode: payload.TriggerMode})
+}
+
+func (s HeadsUpServer) HandleRestart(w http.ResponseWriter, req *http.Request) {
+	var payload triggerPayload
+	if !decodePost(w, req, &payload) {
+		return
+	}
+
+	names, ok := s.manifestNames(w, payload.ManifestNames)
+	if !ok {
+		return
+	}
+
+	for _, mn := range names {
+		s.store.Dispatch(view.RestartResourceAction{Name: mn})
+	}
+}
+
+func (s HeadsUpServer) HandleReloadTiltfile(w http.ResponseWriter, req *http.Request) {
+	if !checkPost(w, req) {
+		return
+	}
+
+	s.store.Dispatch(view.ReloadTiltfileAction{})
+}
+
+// Disconnects everyone following this session in Sail.
+func (s HeadsUpServer) HandleSailRevoke(w http.ResponseWriter, req *http.Request) {
+	if !checkPost(w, req) {
+		return
+	}
+
+	err := s.sailRoom.RevokeFollowers(req.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error revoking followers: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Stops sharing this session in Sail.
+func (s HeadsUpServer) HandleSailClose(w http.ResponseWriter, req *http.Request) {
+	if !checkPost(w, req) {
+		return
+	}
+
+	err := s.sailRoom.CloseRoom(req.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error closing room: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Checks that each of the given names refers to a manifest, and writes
+// an error to the response if one doesn't.
+func (s HeadsUpServer) manifestNames(w http.ResponseWriter, names []string) ([]model.ManifestName, bool) {
+	if len(names) == 0 {
+		http.Error(w, "no manifest names given", http.StatusBadRequest)
+		return nil, false
+	}
+
+	state := s.store.RLockState()
+	defer s.store.RUnlockState()
+
+	result := make([]model.ManifestName, 0, len(names))
+	for _, name := range names {
+		mn := model.ManifestName(name)
+		if _, ok := state.ManifestTargets[mn]; !ok {
+			http.Error(w, fmt.Sprintf("no resource found with name %q", name), http.StatusNotFound)
+			return nil, false
+		}
+		result = append(result, mn)
+	}
+	return result, true
+}
+
+// Parses an optional, non-negative integer query param.
+func intParam(params url.Values, key string) (int, error) {
+	value := params.Get(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, value)
+	}
+	return i, nil
+}
+
+// Checks that a request that changes Tilt's state is a JSON POST from the
+// same origin, and writes an error to the response if it's not.
+//
+// Any web page can make the browser send a POST to localhost. But a browser
+// won't send a cross-origin POST with a JSON content type without a CORS
+// preflight, which we never allow, so requiring one keeps other sites out.
+// We also reject requests whose Origin doesn't match the host they were sent to.
+func checkPost(w http.ResponseWriter, req *http.Request) bool {
+	if req.Method != http.MethodPost {
+		http.Error(w, "must be POST request", http.StatusBadRequest)
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		http.Error(w, "must have Content-Type: application/json", http.StatusUnsupportedMediaType)
+		return false
+	}
+
+	origin := req.Header.Get("Origin")
+	if origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != req.Host {
+			http.Error(w, fmt.Sprintf("cross-origin request from %q not allowed", origin), http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+// Decodes the JSON body of a POST request, and writes an error to the
+// response if it's not one.
+func decodePost(w http.ResponseWriter, req *http.Request, payload interface{}) bool {
+	if !checkPost(w, req) {
+		return false
+	}
+
+	err := json.NewDecoder(req.Body).Decode(payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error parsing JSON payload: %v", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -2,19 +2,30 @@ package server_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/windmilleng/tilt/internal/engine"
 	"github.com/windmilleng/tilt/internal/hud/server"
+	"github.com/windmilleng/tilt/internal/hud/webview"
+	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/store"
+	"github.com/windmilleng/tilt/internal/store/logstore"
+	"github.com/windmilleng/tilt/internal/testutils/output"
 	"github.com/windmilleng/wmclient/pkg/analytics"
 )
 
 func TestHandleAnalyticsEmptyRequest(t *testing.T) {
 	f := newTestFixture(t)
+	defer f.TearDown()
 
 	var jsonStr = []byte(`[]`)
 	req, err := http.NewRequest(http.MethodPost, "/api/analytics", bytes.NewBuffer(jsonStr))
@@ -36,6 +47,7 @@ func TestHandleAnalyticsEmptyRequest(t *testing.T) {
 
 func TestHandleAnalyticsRecordsIncr(t *testing.T) {
 	f := newTestFixture(t)
+	defer f.TearDown()
 
 	var jsonStr = []byte(`[{"verb": "incr", "name": "foo", "tags": {}}]`)
 	req, err := http.NewRequest(http.MethodPost, "/api/analytics", bytes.NewBuffer(jsonStr))
@@ -59,6 +71,7 @@ func TestHandleAnalyticsRecordsIncr(t *testing.T) {
 
 func TestHandleAnalyticsNonPost(t *testing.T) {
 	f := newTestFixture(t)
+	defer f.TearDown()
 
 	req, err := http.NewRequest(http.MethodGet, "/api/analytics", nil)
 	if err != nil {
@@ -79,6 +92,7 @@ func TestHandleAnalyticsNonPost(t *testing.T) {
 
 func TestHandleAnalyticsMalformedPayload(t *testing.T) {
 	f := newTestFixture(t)
+	defer f.TearDown()
 
 	var jsonStr = []byte(`[{"Verb": ]`)
 	req, err := http.NewRequest(http.MethodPost, "/api/analytics", bytes.NewBuffer(jsonStr))
@@ -100,6 +114,7 @@ func TestHandleAnalyticsMalformedPayload(t *testing.T) {
 
 func TestHandleAnalyticsErrorsIfNotIncr(t *testing.T) {
 	f := newTestFixture(t)
+	defer f.TearDown()
 
 	var jsonStr = []byte(`[{"verb": "count", "name": "foo", "tags": {}}]`)
 	req, err := http.NewRequest(http.MethodPost, "/api/analytics", bytes.NewBuffer(jsonStr))
@@ -119,24 +134,346 @@ func TestHandleAnalyticsErrorsIfNotIncr(t *testing.T) {
 	}
 }
 
+func TestHandleTrigger(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+	rr := f.post(f.s.HandleTriggerMode, `{"manifest_name": "foo", "trigger_mode": 1}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = f.post(f.s.HandleTrigger, `{"manifest_names": ["foo"]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	f.waitForState("foo in trigger queue", func(state store.EngineState) bool {
+		return len(state.TriggerQueue) == 1 && state.TriggerQueue[0] == "foo"
+	})
+}
+
+func TestHandleTriggerUnknownManifest(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+	rr := f.post(f.s.HandleTrigger, `{"manifest_names": ["foo", "bar"]}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), `no resource found with name "bar"`)
+}
+
+func TestHandleTriggerNonPost(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	req, err := http.NewRequest(http.MethodGet, "/api/trigger", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(f.s.HandleTrigger).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandleTriggerRequiresJSON(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+	req, err := http.NewRequest(http.MethodPost, "/api/trigger", bytes.NewBufferString(`{"manifest_names": ["foo"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(f.s.HandleTrigger).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+}
+
+func TestHandleTriggerCrossOrigin(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+	req, err := http.NewRequest(http.MethodPost, "http://localhost:10350/api/trigger", bytes.NewBufferString(`{"manifest_names": ["foo"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "http://evil.example.com")
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(f.s.HandleTrigger).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req.Header.Set("Origin", "http://localhost:10350")
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(f.s.HandleTrigger).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestHandleReloadTiltfileRequiresJSON(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	req, err := http.NewRequest(http.MethodPost, "/api/reload_tiltfile", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(f.s.HandleReloadTiltfile).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+}
+
+func TestHandleTriggerModeInvalid(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+	rr := f.post(f.s.HandleTriggerMode, `{"manifest_name": "foo", "trigger_mode": 5}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandleTriggerModeSwitchesBackToAuto(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+	f.post(f.s.HandleTriggerMode, `{"manifest_name": "foo", "trigger_mode": 1}`)
+	f.post(f.s.HandleTrigger, `{"manifest_names": ["foo"]}`)
+	f.waitForState("foo in trigger queue", func(state store.EngineState) bool {
+		return len(state.TriggerQueue) == 1
+	})
+
+	rr := f.post(f.s.HandleTriggerMode, `{"manifest_name": "foo", "trigger_mode": 0}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	f.waitForState("foo in auto mode", func(state store.EngineState) bool {
+		return state.ManifestTriggerMode("foo") == model.TriggerAuto && len(state.TriggerQueue) == 0
+	})
+}
+
+func TestHandleRestart(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+	rr := f.post(f.s.HandleRestart, `{"manifest_names": ["foo"]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	f.waitForState("restart requested", func(state store.EngineState) bool {
+		ms, _ := state.ManifestState("foo")
+		return !ms.RestartRequestTime.IsZero()
+	})
+}
+
+func TestHandleSailRevoke(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	rr := f.post(f.s.HandleSailRevoke, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"revoke"}, f.sailRoom.calls)
+}
+
+func TestHandleSailClose(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	rr := f.post(f.s.HandleSailClose, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"close"}, f.sailRoom.calls)
+}
+
+func TestHandleSailCloseNotShared(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.sailRoom.err = fmt.Errorf("This Tilt session isn't shared in a Sail room")
+	rr := f.post(f.s.HandleSailClose, "")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "error closing room: This Tilt session isn't shared")
+}
+
+func TestHandleReloadTiltfile(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	state := f.st.LockMutableStateForTesting()
+	state.TiltfilePath = "/Tiltfile"
+	f.st.UnlockMutableState()
+
+	rr := f.post(f.s.HandleReloadTiltfile, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	f.waitForState("Tiltfile change pending", func(state store.EngineState) bool {
+		_, ok := state.PendingConfigFileChanges["/Tiltfile"]
+		return ok
+	})
+}
+
+func TestHandleLogs(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+	f.addManifest("bar")
+	f.appendLog("foo", "foo 1\n")
+	f.appendLog("bar", "bar 1\n")
+	f.appendLog("foo", "foo 2\n")
+
+	rr := f.get(f.s.HandleLogs, "/api/logs?name=foo")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	result := f.decodeLogs(rr)
+	assert.Equal(t, []string{"foo 1\n", "foo 2\n"}, lineTexts(result))
+	assert.Equal(t, 3, result.Checkpoint)
+	assert.Equal(t, logstore.SourcePod, result.Lines[0].Source)
+
+	rr = f.get(f.s.HandleLogs, "/api/logs?from=1")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"bar 1\n", "foo 2\n"}, lineTexts(f.decodeLogs(rr)))
+
+	rr = f.get(f.s.HandleLogs, "/api/logs?from=3")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	result = f.decodeLogs(rr)
+	assert.Empty(t, result.Lines)
+	assert.Equal(t, 3, result.Checkpoint)
+}
+
+func TestHandleLogsPages(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+	f.appendLog("foo", "foo 1\n")
+	f.appendLog("foo", "foo 2\n")
+	f.appendLog("foo", "foo 3\n")
+
+	rr := f.get(f.s.HandleLogs, "/api/logs?limit=2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	result := f.decodeLogs(rr)
+	assert.Equal(t, []string{"foo 1\n", "foo 2\n"}, lineTexts(result))
+	assert.Equal(t, 2, result.Checkpoint)
+	assert.True(t, result.More)
+
+	rr = f.get(f.s.HandleLogs, "/api/logs?limit=2&from=2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	result = f.decodeLogs(rr)
+	assert.Equal(t, []string{"foo 3\n"}, lineTexts(result))
+	assert.Equal(t, 3, result.Checkpoint)
+	assert.False(t, result.More)
+
+	rr = f.get(f.s.HandleLogs, "/api/logs?limit=lots")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `invalid limit: "lots"`)
+}
+
+func TestHandleLogsTiltLog(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+	f.appendLog("foo", "foo 1\n")
+	f.appendLog("", "tilt 1\n")
+
+	// Tilt's own log interleaves the output of pods.
+	rr := f.get(f.s.HandleLogs, "/api/logs?name=")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"foo 1\n", "tilt 1\n"}, lineTexts(f.decodeLogs(rr)))
+}
+
+func TestHandleLogsInvalidParams(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.addManifest("foo")
+
+	rr := f.get(f.s.HandleLogs, "/api/logs?name=baz")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), `no resource found with name "baz"`)
+
+	rr = f.get(f.s.HandleLogs, "/api/logs?from=-1")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `invalid from: "-1"`)
+
+	rr = f.get(f.s.HandleLogs, "/api/logs?since=yesterday")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid since")
+}
+
+func TestViewWebsocketSendsDeltas(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	httpServer := httptest.NewServer(f.s.Router())
+	defer httpServer.Close()
+
+	conn := f.dialView(httpServer, "")
+	snapshot := f.readViewUpdate(conn)
+	assert.NotNil(t, snapshot.Snapshot)
+	assert.Nil(t, snapshot.Delta)
+
+	f.log("hello")
+	delta := f.readViewUpdate(conn)
+	assert.Equal(t, snapshot.StreamID, delta.StreamID)
+	assert.Equal(t, snapshot.Seq+1, delta.Seq)
+	if assert.NotNil(t, delta.Delta) {
+		assert.Equal(t, "hello\n", delta.Delta.Fields["Log"].Append)
+	}
+	_ = conn.Close()
+
+	// A client that reconnects only gets what it missed.
+	f.log("goodbye")
+	conn = f.dialView(httpServer, fmt.Sprintf("?stream=%s&since=%d", delta.StreamID, delta.Seq))
+	defer conn.Close()
+
+	resumed := f.readViewUpdate(conn)
+	assert.Equal(t, delta.Seq+1, resumed.Seq)
+	if assert.NotNil(t, resumed.Delta) {
+		assert.Equal(t, "goodbye\n", resumed.Delta.Fields["Log"].Append)
+	}
+}
+
 type serverFixture struct {
-	t *testing.T
-	s server.HeadsUpServer
-	a *analytics.MemoryAnalytics
+	t        *testing.T
+	ctx      context.Context
+	cancel   func()
+	s        server.HeadsUpServer
+	a        *analytics.MemoryAnalytics
+	st       *store.Store
+	sailRoom *fakeSailRoom
 }
 
 func newTestFixture(t *testing.T) *serverFixture {
 	st := store.NewStore(engine.UpperReducer, store.LogActionsFlag(false))
 	a := analytics.NewMemoryAnalytics()
-	s := server.ProvideHeadsUpServer(st, server.NewFakeAssetServer(), a)
+	sailRoom := &fakeSailRoom{}
+	s := server.ProvideHeadsUpServer(st, server.NewFakeAssetServer(), a, sailRoom)
+
+	state := st.LockMutableStateForTesting()
+	state.WatchFiles = true
+	st.UnlockMutableState()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = st.Loop(ctx)
+	}()
 
 	return &serverFixture{
-		t: t,
-		s: s,
-		a: a,
+		t:        t,
+		ctx:      ctx,
+		cancel:   cancel,
+		s:        s,
+		a:        a,
+		st:       st,
+		sailRoom: sailRoom,
 	}
 }
 
+func (f *serverFixture) TearDown() {
+	f.cancel()
+}
+
 func (f *serverFixture) assertIncrement(name string, count int) {
 	runningCount := 0
 	for _, c := range f.a.Counts {
@@ -147,3 +484,118 @@ func (f *serverFixture) assertIncrement(name string, count int) {
 
 	assert.Equalf(f.t, count, runningCount, "Expected the total count to be %d, got %d", count, runningCount)
 }
+
+// Adds a manifest with a pending file change, so that it can be triggered.
+func (f *serverFixture) addManifest(name string) {
+	m := model.Manifest{Name: model.ManifestName(name)}.
+		WithDeployTarget(model.NewLocalTarget(model.TargetName(name), model.ToShellCmd("true"), "/", []string{"/"}))
+
+	state := f.st.LockMutableStateForTesting()
+	mt := store.NewManifestTarget(m)
+	mt.State.AddCompletedBuild(model.BuildRecord{StartTime: time.Now(), FinishTime: time.Now()})
+	mt.State.MutableBuildStatus(m.LocalTarget().ID()).PendingFileChanges["/foo"] = time.Now()
+	state.UpsertManifestTarget(mt)
+	f.st.UnlockMutableState()
+}
+
+func (f *serverFixture) log(msg string) {
+	engine.NewLogActionLogger(output.CtxForTest(), f.st.Dispatch).Infof(msg)
+}
+
+func (f *serverFixture) dialView(httpServer *httptest.Server, query string) *websocket.Conn {
+	url := strings.Replace(httpServer.URL, "http://", "ws://", 1) + "/ws/view" + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return conn
+}
+
+func (f *serverFixture) readViewUpdate(conn *websocket.Conn) webview.ViewUpdate {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	var update webview.ViewUpdate
+	err := conn.ReadJSON(&update)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return update
+}
+
+func (f *serverFixture) post(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func (f *serverFixture) get(handler http.HandlerFunc, url string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func (f *serverFixture) appendLog(name model.ManifestName, msg string) {
+	state := f.st.RLockState()
+	logStore := state.LogStore
+	f.st.RUnlockState()
+
+	meta := logstore.Meta{Time: time.Now(), ManifestName: name, Source: logstore.SourcePod}
+	logStore.Append(meta, []byte(msg))
+}
+
+func (f *serverFixture) decodeLogs(rr *httptest.ResponseRecorder) logstore.QueryResult {
+	var result logstore.QueryResult
+	err := json.NewDecoder(rr.Body).Decode(&result)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return result
+}
+
+func lineTexts(result logstore.QueryResult) []string {
+	texts := []string{}
+	for _, line := range result.Lines {
+		texts = append(texts, line.Text)
+	}
+	return texts
+}
+
+func (f *serverFixture) waitForState(msg string, isDone func(state store.EngineState) bool) {
+	start := time.Now()
+	for time.Since(start) < time.Second {
+		state := f.st.RLockState()
+		done := isDone(state)
+		f.st.RUnlockState()
+		if done {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	f.t.Fatalf("Timed out waiting for: %s", msg)
+}
+
+type fakeSailRoom struct {
+	calls []string
+	err   error
+}
+
+func (r *fakeSailRoom) RevokeFollowers(ctx context.Context) error {
+	r.calls = append(r.calls, "revoke")
+	return r.err
+}
+
+func (r *fakeSailRoom) CloseRoom(ctx context.Context) error {
+	r.calls = append(r.calls, "close")
+	return r.err
+}
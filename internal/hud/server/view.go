@@ -78,12 +78,17 @@ func StateToWebView(s store.EngineState) webview.View {
 			PendingBuildReason: ms.NextBuildReason(),
 			CurrentBuild:       currentBuild,
 			Endpoints:          endpoints,
+			Readiness:          mt.Readiness(),
+			TriggerMode:        s.ManifestTriggerMode(name),
 			ResourceInfo:       resourceInfoView(mt),
-			ShowBuildStatus:    len(mt.Manifest.ImageTargets) > 0 || mt.Manifest.IsDC(),
-			CombinedLog:        ms.CombinedLog,
+			ShowBuildStatus:    len(mt.Manifest.ImageTargets) > 0 || mt.Manifest.IsDC() || mt.Manifest.IsLocal(),
+			CombinedLog:        s.LogStore.ManifestLogTail(name, s.LogTimestamps),
 		}
 
 		r.RuntimeStatus = runtimeStatus(r.ResourceInfo)
+		if r.RuntimeStatus == webview.RuntimeStatusOK && r.Readiness == model.ReadinessNotReady {
+			r.RuntimeStatus = webview.RuntimeStatusPending
+		}
 
 		ret.Resources = append(ret.Resources, r)
 	}
@@ -110,7 +115,7 @@ func StateToWebView(s store.EngineState) webview.View {
 		ret.Resources = append(ret.Resources, r)
 	}
 
-	ret.Log = s.Log
+	ret.Log = s.LogStore.TiltLogTail(s.LogTimestamps)
 
 	return ret
 }
@@ -127,7 +132,7 @@ func tiltfileResourceView(s store.EngineState) webview.Resource {
 		BuildHistory: []model.BuildRecord{
 			ltfb,
 		},
-		CombinedLog:   s.TiltfileCombinedLog,
+		CombinedLog:   s.LogStore.ManifestLogTail(view.TiltfileResourceName, s.LogTimestamps),
 		RuntimeStatus: webview.RuntimeStatusOK,
 	}
 	if !s.CurrentTiltfileBuild.Empty() {
@@ -139,6 +144,18 @@ func tiltfileResourceView(s store.EngineState) webview.Resource {
 }
 
 func resourceInfoView(mt *store.ManifestTarget) webview.ResourceInfoView {
+	if mt.Manifest.IsLocal() {
+		lrs := mt.State.LocalRuntimeState()
+		return webview.LocalResourceInfo{
+			HasServeCmd:   !mt.Manifest.LocalTarget().ServeCmd.Empty(),
+			PID:           lrs.PID,
+			ProcessStatus: string(lrs.Status),
+			ExitCode:      lrs.ExitCode,
+			StartTime:     lrs.StartTime,
+			Restarts:      lrs.Restarts,
+			Log:           lrs.Log(),
+		}
+	}
 	if dcState, ok := mt.State.ResourceState.(dockercompose.State); ok {
 		return webview.NewDCResourceInfo(mt.Manifest.DockerComposeTarget().ConfigPath, dcState.Status, dcState.ContainerID, dcState.Log(), dcState.StartTime)
 	} else {
@@ -151,6 +168,9 @@ func resourceInfoView(mt *store.ManifestTarget) webview.ResourceInfoView {
 			PodRestarts:        pod.ContainerRestarts - pod.OldRestarts,
 			PodLog:             pod.Log(),
 			YAML:               mt.Manifest.K8sTarget().YAML,
+			Warnings:           mt.State.K8sWarnings(),
+			IsJob:              mt.Manifest.K8sTarget().IsJob,
+			JobStatus:          string(mt.State.JobStatus),
 		}
 	}
 }
@@ -158,7 +178,8 @@ func resourceInfoView(mt *store.ManifestTarget) webview.ResourceInfoView {
 func runtimeStatus(res webview.ResourceInfoView) webview.RuntimeStatus {
 	// if we have no images to build, we have no runtime status monitoring.
 	_, isYAML := res.(webview.YAMLResourceInfo)
-	if isYAML {
+	localInfo, isLocal := res.(webview.LocalResourceInfo)
+	if isYAML || (isLocal && !localInfo.HasServeCmd) {
 		return webview.RuntimeStatusOK
 	}
 
@@ -182,6 +203,7 @@ var runtimeStatusMap = map[string]webview.RuntimeStatus{
 	string(dockercompose.StatusUp):     webview.RuntimeStatusOK,
 	string(dockercompose.StatusDown):   webview.RuntimeStatusError,
 	"Completed":                        webview.RuntimeStatusOK,
+	"Failed":                           webview.RuntimeStatusError,
 
 	// If the runtime status hasn't shown up yet, we assume it's pending.
 	"": webview.RuntimeStatusPending,
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
 
+	"github.com/windmilleng/tilt/internal/hud/webview"
 	"github.com/windmilleng/tilt/internal/store"
 
 	"github.com/gorilla/websocket"
@@ -15,17 +18,33 @@ var upgrader = websocket.Upgrader{
 	WriteBufferSize: 1024,
 }
 
+// Streams view updates to a websocket.
+//
+// The first update is a snapshot of the view, unless the client is resuming
+// a stream, and every update after that only has what changed.
 type WebsocketSubscriber struct {
-	conn *websocket.Conn
+	conn    *websocket.Conn
+	history *webview.ViewHistory
+
+	// Stream calls OnChange while the store may be calling it too,
+	// so only one of them writes to the websocket at a time.
+	mu sync.Mutex
+
+	// The last update that the client got.
+	streamID string
+	seq      int
 }
 
-func NewWebsocketSubscriber(conn *websocket.Conn) WebsocketSubscriber {
-	return WebsocketSubscriber{
-		conn: conn,
+func NewWebsocketSubscriber(conn *websocket.Conn, history *webview.ViewHistory, streamID string, seq int) *WebsocketSubscriber {
+	return &WebsocketSubscriber{
+		conn:     conn,
+		history:  history,
+		streamID: streamID,
+		seq:      seq,
 	}
 }
 
-func (ws WebsocketSubscriber) Stream(ctx context.Context, store *store.Store) {
+func (ws *WebsocketSubscriber) Stream(ctx context.Context, store *store.Store) {
 	done := make(chan bool)
 
 	go func() {
@@ -50,14 +69,28 @@ func (ws WebsocketSubscriber) Stream(ctx context.Context, store *store.Store) {
 	_ = store.RemoveSubscriber(context.Background(), ws)
 }
 
-func (ws WebsocketSubscriber) OnChange(ctx context.Context, s store.RStore) {
-	state := s.RLockState()
-	view := StateToWebView(state)
-	s.RUnlockState()
+func (ws *WebsocketSubscriber) OnChange(ctx context.Context, s store.RStore) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
 
-	err := ws.conn.WriteJSON(view)
+	err := ws.history.Update(func() webview.View {
+		state := s.RLockState()
+		defer s.RUnlockState()
+		return StateToWebView(state)
+	})
 	if err != nil {
 		_ = ws.conn.Close()
+		return
+	}
+
+	for _, update := range ws.history.Since(ws.streamID, ws.seq) {
+		err := ws.conn.WriteJSON(update)
+		if err != nil {
+			_ = ws.conn.Close()
+			return
+		}
+		ws.streamID = update.StreamID
+		ws.seq = update.Seq
 	}
 }
 
@@ -68,7 +101,11 @@ func (s HeadsUpServer) ViewWebsocket(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	ws := NewWebsocketSubscriber(conn)
+	// A client that reconnects tells us the last update it got,
+	// so that we only send it what it missed.
+	query := req.URL.Query()
+	since, _ := strconv.Atoi(query.Get("since"))
+	ws := NewWebsocketSubscriber(conn, s.viewHistory, query.Get("stream"), since)
 
 	// TODO(nick): Handle clean shutdown when the server shuts down
 	ws.Stream(context.TODO(), s.store)
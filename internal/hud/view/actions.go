@@ -7,3 +7,23 @@ type AppendToTriggerQueueAction struct {
 }
 
 func (AppendToTriggerQueueAction) Action() {}
+
+// Switch a manifest between automatic and manual builds at runtime.
+type SetTriggerModeAction struct {
+	Name        model.ManifestName
+	TriggerMode model.TriggerMode
+}
+
+func (SetTriggerModeAction) Action() {}
+
+// Restart the running containers or processes of a manifest, without rebuilding it.
+type RestartResourceAction struct {
+	Name model.ManifestName
+}
+
+func (RestartResourceAction) Action() {}
+
+// Re-execute the Tiltfile, even if none of the config files changed.
+type ReloadTiltfileAction struct{}
+
+func (ReloadTiltfileAction) Action() {}
@@ -48,13 +48,26 @@ type K8SResourceInfo struct {
 	PodRestarts        int
 	PodLog             model.Log
 	YAML               string
+
+	// Warning events that Kubernetes reported about the resource's objects.
+	Warnings []string
+
+	// Whether the resource's workload is a Job, and if so, whether it finished.
+	IsJob     bool
+	JobStatus string
 }
 
 var _ ResourceInfoView = K8SResourceInfo{}
 
 func (K8SResourceInfo) resourceInfoView()             {}
 func (k8sInfo K8SResourceInfo) RuntimeLog() model.Log { return k8sInfo.PodLog }
-func (k8sInfo K8SResourceInfo) Status() string        { return k8sInfo.PodStatus }
+
+func (k8sInfo K8SResourceInfo) Status() string {
+	if k8sInfo.JobStatus != "" {
+		return k8sInfo.JobStatus
+	}
+	return k8sInfo.PodStatus
+}
 
 type YAMLResourceInfo struct {
 	K8sResources []string
@@ -66,6 +79,23 @@ func (YAMLResourceInfo) resourceInfoView()              {}
 func (yamlInfo YAMLResourceInfo) RuntimeLog() model.Log { return model.NewLog("") }
 func (yamlInfo YAMLResourceInfo) Status() string        { return "" }
 
+type LocalResourceInfo struct {
+	// Only set for resources with a long-running process (serve_cmd).
+	HasServeCmd   bool
+	PID           int
+	ProcessStatus string
+	ExitCode      int
+	StartTime     time.Time
+	Restarts      int
+	Log           model.Log
+}
+
+var _ ResourceInfoView = LocalResourceInfo{}
+
+func (LocalResourceInfo) resourceInfoView()         {}
+func (lri LocalResourceInfo) RuntimeLog() model.Log { return lri.Log }
+func (lri LocalResourceInfo) Status() string        { return lri.ProcessStatus }
+
 type Resource struct {
 	Name               model.ManifestName
 	DirectoriesWatched []string
@@ -81,6 +111,13 @@ type Resource struct {
 
 	Endpoints []string
 
+	// Whether the resource is ready to serve, according to its readiness
+	// probe or the Kubernetes readiness condition.
+	Readiness model.Readiness
+
+	// Whether the resource builds automatically on changes, or waits for a trigger.
+	TriggerMode model.TriggerMode
+
 	ResourceInfo ResourceInfoView
 
 	// If a pod had to be killed because it was crashing, we keep the old log around
@@ -129,6 +166,16 @@ func (r Resource) IsYAML() bool {
 	return ok
 }
 
+func (r Resource) LocalInfo() LocalResourceInfo {
+	ret, _ := r.ResourceInfo.(LocalResourceInfo)
+	return ret
+}
+
+func (r Resource) IsLocal() bool {
+	_, ok := r.ResourceInfo.(LocalResourceInfo)
+	return ok
+}
+
 func (r Resource) LastBuild() model.BuildRecord {
 	if len(r.BuildHistory) == 0 {
 		return model.BuildRecord{}
@@ -177,6 +224,22 @@ type View struct {
 	TriggerMode   model.TriggerMode
 	IsProfiling   bool
 	LogTimestamps bool
+
+	// The kubeconfig context that Tilt deploys to, if any.
+	KubeContext string
+}
+
+// Whether any resource waits for the user to trigger its builds.
+func (v View) HasManualTrigger() bool {
+	if v.TriggerMode == model.TriggerManual {
+		return true
+	}
+	for _, res := range v.Resources {
+		if res.TriggerMode == model.TriggerManual {
+			return true
+		}
+	}
+	return false
 }
 
 func (v View) TiltfileErrorMessage() string {
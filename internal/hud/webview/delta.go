@@ -0,0 +1,355 @@
+package webview
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+// A message on a view websocket.
+//
+// The first update that a client gets is a snapshot of the whole view.
+// Every update after that is a delta from the update before it.
+type ViewUpdate struct {
+	// Identifies the stream that the sequence numbers belong to, so that
+	// a client doesn't resume from a sequence number of a different stream
+	// (e.g., from before Tilt restarted).
+	StreamID string
+
+	// The position of this update in the stream.
+	Seq int
+
+	Snapshot *ViewState `json:",omitempty"`
+	Delta    *ViewDelta `json:",omitempty"`
+}
+
+// The changes between two versions of the View.
+type ViewDelta struct {
+	// Changes to the top-level fields of the View, other than Resources.
+	Fields map[string]FieldDelta `json:",omitempty"`
+
+	// The names of all the resources, in order. Only set when resources
+	// were added, removed, or reordered.
+	ResourceNames []model.ManifestName `json:",omitempty"`
+
+	// Changes to the fields of each resource, by name.
+	// New resources have a delta for every field.
+	Resources map[model.ManifestName]map[string]FieldDelta `json:",omitempty"`
+}
+
+// The change to a single field. Only one kind of change is set.
+type FieldDelta struct {
+	// Replaces the field.
+	Value json.RawMessage `json:",omitempty"`
+
+	// Deletes the field.
+	Removed bool `json:",omitempty"`
+
+	// Changes some of the fields of an object.
+	Fields map[string]FieldDelta `json:",omitempty"`
+
+	// Changes a string that grew at the end, and may have been truncated
+	// at the start, like a log. TrimStart counts UTF-16 code units, because
+	// that's how JavaScript indexes strings.
+	TrimStart int    `json:",omitempty"`
+	Append    string `json:",omitempty"`
+}
+
+// A View, in the generic JSON form that we diff.
+type ViewState struct {
+	fields        map[string]interface{}
+	resourceNames []model.ManifestName
+	resources     map[model.ManifestName]map[string]interface{}
+}
+
+func NewViewState(v View) (ViewState, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ViewState{}, err
+	}
+
+	var s ViewState
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+func (s ViewState) Empty() bool {
+	return s.fields == nil
+}
+
+func (s *ViewState) UnmarshalJSON(data []byte) error {
+	var fields map[string]interface{}
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	resourceList, _ := fields["Resources"].([]interface{})
+	delete(fields, "Resources")
+
+	s.fields = fields
+	s.resourceNames = nil
+	s.resources = make(map[model.ManifestName]map[string]interface{}, len(resourceList))
+	for _, r := range resourceList {
+		resource, ok := r.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("malformed resource in view: %v", r)
+		}
+		name, _ := resource["Name"].(string)
+		mn := model.ManifestName(name)
+		s.resourceNames = append(s.resourceNames, mn)
+		s.resources[mn] = resource
+	}
+	return nil
+}
+
+func (s ViewState) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(s.fields)+1)
+	for k, v := range s.fields {
+		fields[k] = v
+	}
+
+	resources := make([]interface{}, 0, len(s.resourceNames))
+	for _, name := range s.resourceNames {
+		resources = append(resources, s.resources[name])
+	}
+	fields["Resources"] = resources
+	return json.Marshal(fields)
+}
+
+// Computes the changes from one version of the view to the next.
+// Returns false if nothing changed.
+func DiffViews(old, new ViewState) (ViewDelta, bool) {
+	delta := ViewDelta{
+		Fields: diffFields(old.fields, new.fields),
+	}
+
+	if !reflect.DeepEqual(old.resourceNames, new.resourceNames) {
+		delta.ResourceNames = new.resourceNames
+	}
+
+	for _, name := range new.resourceNames {
+		fields := diffFields(old.resources[name], new.resources[name])
+		if len(fields) == 0 {
+			continue
+		}
+		if delta.Resources == nil {
+			delta.Resources = make(map[model.ManifestName]map[string]FieldDelta)
+		}
+		delta.Resources[name] = fields
+	}
+
+	changed := len(delta.Fields) > 0 || delta.ResourceNames != nil || len(delta.Resources) > 0
+	return delta, changed
+}
+
+// Applies the changes in the delta to the view, and returns the new view.
+// Doesn't modify the original view.
+func (s ViewState) Apply(delta ViewDelta) (ViewState, error) {
+	fields, err := applyFields(s.fields, delta.Fields)
+	if err != nil {
+		return ViewState{}, err
+	}
+
+	names := s.resourceNames
+	if delta.ResourceNames != nil {
+		names = delta.ResourceNames
+	}
+
+	resources := make(map[model.ManifestName]map[string]interface{}, len(names))
+	for _, name := range names {
+		resource, err := applyFields(s.resources[name], delta.Resources[name])
+		if err != nil {
+			return ViewState{}, err
+		}
+		resources[name] = resource
+	}
+
+	return ViewState{
+		fields:        fields,
+		resourceNames: names,
+		resources:     resources,
+	}, nil
+}
+
+func diffFields(old, new map[string]interface{}) map[string]FieldDelta {
+	var result map[string]FieldDelta
+	set := func(k string, d FieldDelta) {
+		if result == nil {
+			result = make(map[string]FieldDelta)
+		}
+		result[k] = d
+	}
+
+	for k, newV := range new {
+		oldV, ok := old[k]
+		if !ok {
+			set(k, valueDelta(newV))
+			continue
+		}
+
+		d, changed := diffValues(oldV, newV)
+		if changed {
+			set(k, d)
+		}
+	}
+
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			set(k, FieldDelta{Removed: true})
+		}
+	}
+	return result
+}
+
+func diffValues(old, new interface{}) (FieldDelta, bool) {
+	if reflect.DeepEqual(old, new) {
+		return FieldDelta{}, false
+	}
+
+	switch new := new.(type) {
+	case string:
+		if old, ok := old.(string); ok {
+			if d, ok := diffStrings(old, new); ok {
+				return d, true
+			}
+		}
+	case map[string]interface{}:
+		if old, ok := old.(map[string]interface{}); ok {
+			return FieldDelta{Fields: diffFields(old, new)}, true
+		}
+	}
+
+	return valueDelta(new), true
+}
+
+// The most places we'll look for where the old string starts in the new one.
+// Logs only get truncated at a line break near the start, so we usually
+// find it on the first try.
+const maxTrimCandidates = 100
+
+// Finds the prefix that was trimmed off the old string, and the text that
+// was appended to it, if the new string only changed like that.
+func diffStrings(old, new string) (FieldDelta, bool) {
+	if strings.HasPrefix(new, old) {
+		return FieldDelta{Append: new[len(old):]}, true
+	}
+
+	if new == "" {
+		return FieldDelta{}, false
+	}
+
+	// Logs are truncated at line breaks, so the new string starts with
+	// some line of the old one.
+	firstLine := new
+	if i := strings.IndexByte(new, '\n'); i >= 0 {
+		firstLine = new[:i+1]
+	}
+
+	start := 0
+	for i := 0; i < maxTrimCandidates; i++ {
+		idx := strings.Index(old[start:], firstLine)
+		if idx < 0 {
+			break
+		}
+		trim := start + idx
+		if trim > 0 && strings.HasPrefix(new, old[trim:]) {
+			return FieldDelta{
+				TrimStart: utf16Len(old[:trim]),
+				Append:    new[len(old)-trim:],
+			}, true
+		}
+		start = trim + 1
+	}
+
+	return FieldDelta{}, false
+}
+
+func valueDelta(v interface{}) FieldDelta {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// Everything in the view came from JSON, so this should never happen.
+		panic(fmt.Sprintf("marshaling view value: %v", err))
+	}
+	return FieldDelta{Value: data}
+}
+
+func applyFields(old map[string]interface{}, deltas map[string]FieldDelta) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(old)+len(deltas))
+	for k, v := range old {
+		result[k] = v
+	}
+
+	for k, d := range deltas {
+		if d.Removed {
+			delete(result, k)
+			continue
+		}
+
+		v, err := applyValue(result[k], d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", k, err)
+		}
+		result[k] = v
+	}
+	return result, nil
+}
+
+func applyValue(old interface{}, d FieldDelta) (interface{}, error) {
+	if d.Value != nil {
+		var v interface{}
+		err := json.Unmarshal(d.Value, &v)
+		return v, err
+	}
+
+	if d.Fields != nil {
+		oldFields, _ := old.(map[string]interface{})
+		return applyFields(oldFields, d.Fields)
+	}
+
+	oldString, ok := old.(string)
+	if !ok {
+		return nil, fmt.Errorf("can't append to %T", old)
+	}
+
+	trim, ok := utf16Index(oldString, d.TrimStart)
+	if !ok {
+		return nil, fmt.Errorf("can't trim %d characters from a string of length %d", d.TrimStart, utf16Len(oldString))
+	}
+	return oldString[trim:] + d.Append, nil
+}
+
+// The number of UTF-16 code units in a string, i.e., its length in JavaScript.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+
+// Converts an index in UTF-16 code units to a byte index in the string.
+func utf16Index(s string, n int) (int, bool) {
+	count := 0
+	for i, r := range s {
+		if count == n {
+			return i, true
+		}
+		if count > n {
+			return 0, false
+		}
+		count += utf16RuneLen(r)
+	}
+	return len(s), count == n
+}
+
+// Runes outside the Basic Multilingual Plane take two UTF-16 code units.
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
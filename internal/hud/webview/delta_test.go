@@ -0,0 +1,192 @@
+package webview
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+func TestDiffNoChange(t *testing.T) {
+	v := View{Resources: []Resource{{Name: "fe"}}}
+	_, changed := DiffViews(newState(t, v), newState(t, v))
+	assert.False(t, changed)
+}
+
+func TestDiffAppendedLog(t *testing.T) {
+	old := View{Resources: []Resource{{Name: "fe", CombinedLog: model.NewLog("line 1\n")}}}
+	new := View{Resources: []Resource{{Name: "fe", CombinedLog: model.NewLog("line 1\nline 2\n")}}}
+
+	delta := assertRoundTrip(t, old, new)
+	assert.Equal(t, map[string]FieldDelta{"CombinedLog": {Append: "line 2\n"}}, delta.Resources["fe"])
+	assert.Nil(t, delta.ResourceNames)
+	assert.Empty(t, delta.Fields)
+}
+
+func TestDiffTruncatedLog(t *testing.T) {
+	// The emoji takes two UTF-16 code units, like in JavaScript.
+	old := View{Log: model.NewLog("🚀 line 1\nline 2\n"), Resources: []Resource{{Name: "fe"}}}
+	new := View{Log: model.NewLog("line 2\nline 3\n"), Resources: []Resource{{Name: "fe"}}}
+
+	delta := assertRoundTrip(t, old, new)
+	assert.Equal(t, FieldDelta{TrimStart: 10, Append: "line 3\n"}, delta.Fields["Log"])
+}
+
+func TestDiffRewrittenString(t *testing.T) {
+	old := View{Resources: []Resource{{Name: "fe", RuntimeStatus: RuntimeStatusPending}}}
+	new := View{Resources: []Resource{{Name: "fe", RuntimeStatus: RuntimeStatusOK}}}
+
+	delta := assertRoundTrip(t, old, new)
+	assert.Equal(t, `"ok"`, string(delta.Resources["fe"]["RuntimeStatus"].Value))
+}
+
+func TestDiffNestedField(t *testing.T) {
+	old := View{Resources: []Resource{{Name: "fe", ResourceInfo: K8SResourceInfo{PodStatus: "Pending", PodLog: model.NewLog("hi\n")}}}}
+	new := View{Resources: []Resource{{Name: "fe", ResourceInfo: K8SResourceInfo{PodStatus: "Running", PodLog: model.NewLog("hi\nthere\n")}}}}
+
+	delta := assertRoundTrip(t, old, new)
+	info := delta.Resources["fe"]["ResourceInfo"].Fields
+	assert.Equal(t, 2, len(info))
+	assert.Equal(t, `"Running"`, string(info["PodStatus"].Value))
+	assert.Equal(t, FieldDelta{Append: "there\n"}, info["PodLog"])
+}
+
+func TestDiffChangedResourceInfoType(t *testing.T) {
+	old := View{Resources: []Resource{{Name: "fe", ResourceInfo: K8SResourceInfo{PodName: "fe-abc"}}}}
+	new := View{Resources: []Resource{{Name: "fe", ResourceInfo: YAMLResourceInfo{K8sResources: []string{"fe"}}}}}
+
+	delta := assertRoundTrip(t, old, new)
+	info := delta.Resources["fe"]["ResourceInfo"].Fields
+	assert.True(t, info["PodName"].Removed)
+	assert.Equal(t, `["fe"]`, string(info["K8sResources"].Value))
+}
+
+func TestDiffNullValue(t *testing.T) {
+	old := View{Resources: []Resource{{Name: "fe", Endpoints: []string{"http://localhost:8080"}}}}
+	new := View{Resources: []Resource{{Name: "fe"}}}
+
+	delta := assertRoundTrip(t, old, new)
+	assert.Equal(t, `null`, string(delta.Resources["fe"]["Endpoints"].Value))
+}
+
+func TestDiffAddAndRemoveResources(t *testing.T) {
+	old := View{Resources: []Resource{{Name: "(Tiltfile)"}, {Name: "fe"}, {Name: "be"}}}
+	new := View{Resources: []Resource{{Name: "(Tiltfile)"}, {Name: "be"}, {Name: "db"}}}
+
+	delta := assertRoundTrip(t, old, new)
+	assert.Equal(t, []model.ManifestName{"(Tiltfile)", "be", "db"}, delta.ResourceNames)
+	assert.Equal(t, []model.ManifestName{"db"}, resourceNames(delta))
+	assert.Equal(t, `"db"`, string(delta.Resources["db"]["Name"].Value))
+}
+
+func TestDiffReorderResources(t *testing.T) {
+	old := View{Resources: []Resource{{Name: "fe"}, {Name: "be"}}}
+	new := View{Resources: []Resource{{Name: "be"}, {Name: "fe"}}}
+
+	delta := assertRoundTrip(t, old, new)
+	assert.Equal(t, []model.ManifestName{"be", "fe"}, delta.ResourceNames)
+	assert.Empty(t, delta.Resources)
+}
+
+func TestApplyDoesNotModifyOriginal(t *testing.T) {
+	old := newState(t, View{Log: model.NewLog("a\n"), Resources: []Resource{{Name: "fe"}}})
+	oldJSON := marshal(t, old)
+
+	new := newState(t, View{Log: model.NewLog("a\nb\n"), Resources: []Resource{{Name: "fe", IsTiltfile: true}}})
+	delta, _ := DiffViews(old, new)
+	_, err := old.Apply(delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, oldJSON, marshal(t, old))
+}
+
+func TestApplyBadTrim(t *testing.T) {
+	old := newState(t, View{Log: model.NewLog("a\n")})
+	_, err := old.Apply(ViewDelta{Fields: map[string]FieldDelta{"Log": {TrimStart: 5, Append: "b\n"}}})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Log: can't trim 5 characters")
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	v := View{
+		Log: model.NewLog("hello\n"),
+		Resources: []Resource{
+			{Name: "fe", ResourceInfo: K8SResourceInfo{PodName: "fe-abc"}},
+			{Name: "be"},
+		},
+	}
+	expected := marshal(t, v)
+
+	var state ViewState
+	err := json.Unmarshal([]byte(marshal(t, newState(t, v))), &state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, expected, marshal(t, state))
+}
+
+func TestUTF16Index(t *testing.T) {
+	s := "a🚀b"
+	assert.Equal(t, 4, utf16Len(s))
+
+	i, ok := utf16Index(s, 3)
+	assert.True(t, ok)
+	assert.Equal(t, "b", s[i:])
+
+	_, ok = utf16Index(s, 2)
+	assert.False(t, ok, "index in the middle of a surrogate pair")
+}
+
+// Checks that the delta survives the trip over the wire, and turns the old view into the new one.
+func assertRoundTrip(t *testing.T, old, new View) ViewDelta {
+	oldState := newState(t, old)
+	delta, changed := DiffViews(oldState, newState(t, new))
+	if !changed {
+		t.Fatal("expected a change")
+	}
+
+	var wireDelta ViewDelta
+	err := json.Unmarshal([]byte(marshal(t, delta)), &wireDelta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := oldState.Apply(wireDelta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, marshal(t, new), marshal(t, actual))
+	return wireDelta
+}
+
+func newState(t *testing.T, v View) ViewState {
+	s, err := NewViewState(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func marshal(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// The names of the resources with every field in the delta, i.e., the new ones.
+func resourceNames(delta ViewDelta) []model.ManifestName {
+	var result []model.ManifestName
+	for name, fields := range delta.Resources {
+		if _, ok := fields["Name"]; ok {
+			result = append(result, name)
+		}
+	}
+	return result
+}
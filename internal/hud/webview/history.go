@@ -0,0 +1,101 @@
+package webview
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// How many deltas we keep, so that clients can resume after reconnecting.
+const maxHistoryLength = 100
+
+// Records each version of the view as an update in a stream, so that each
+// client can catch up from wherever it left off.
+type ViewHistory struct {
+	streamID string
+
+	// Mutable data, guarded by mu
+	mu      sync.Mutex
+	seq     int
+	current ViewState
+
+	// The most recent deltas, oldest first.
+	deltas []ViewUpdate
+}
+
+func NewViewHistory() *ViewHistory {
+	return &ViewHistory{
+		streamID: uuid.New().String(),
+	}
+}
+
+func (h *ViewHistory) StreamID() string {
+	return h.streamID
+}
+
+// Records the view that viewFn returns, if it changed.
+//
+// We call viewFn while holding the lock, so that views are recorded in
+// the same order that they were read, even when many clients update at once.
+func (h *ViewHistory) Update(viewFn func() View) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	next, err := NewViewState(viewFn())
+	if err != nil {
+		return err
+	}
+
+	if h.current.Empty() {
+		h.seq++
+		h.current = next
+		return nil
+	}
+
+	delta, changed := DiffViews(h.current, next)
+	if !changed {
+		return nil
+	}
+
+	h.seq++
+	h.current = next
+	h.deltas = append(h.deltas, ViewUpdate{
+		StreamID: h.streamID,
+		Seq:      h.seq,
+		Delta:    &delta,
+	})
+	if len(h.deltas) > maxHistoryLength {
+		h.deltas = append([]ViewUpdate{}, h.deltas[len(h.deltas)-maxHistoryLength:]...)
+	}
+	return nil
+}
+
+// Returns the updates that a client needs to catch up, given the last update
+// that it got. A client that's new, from another stream, or too far behind
+// gets a snapshot of the current view.
+func (h *ViewHistory) Since(streamID string, seq int) []ViewUpdate {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.current.Empty() {
+		return nil
+	}
+
+	if streamID == h.streamID && seq <= h.seq {
+		if seq == h.seq {
+			return nil
+		}
+
+		oldest := h.seq - len(h.deltas) + 1
+		if seq+1 >= oldest {
+			return append([]ViewUpdate{}, h.deltas[seq+1-oldest:]...)
+		}
+	}
+
+	current := h.current
+	return []ViewUpdate{{
+		StreamID: h.streamID,
+		Seq:      h.seq,
+		Snapshot: &current,
+	}}
+}
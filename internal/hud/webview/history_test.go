@@ -0,0 +1,90 @@
+package webview
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+func TestHistoryNewClientGetsSnapshot(t *testing.T) {
+	h := NewViewHistory()
+	assert.Empty(t, h.Since("", 0))
+
+	record(t, h, "a\n")
+	record(t, h, "a\nb\n")
+
+	updates := h.Since("", 0)
+	if assert.Equal(t, 1, len(updates)) {
+		assert.Equal(t, 2, updates[0].Seq)
+		assert.NotNil(t, updates[0].Snapshot)
+		assert.Nil(t, updates[0].Delta)
+	}
+}
+
+func TestHistoryIgnoresUnchangedViews(t *testing.T) {
+	h := NewViewHistory()
+	record(t, h, "a\n")
+	record(t, h, "a\n")
+
+	updates := h.Since("", 0)
+	assert.Equal(t, 1, updates[0].Seq)
+}
+
+func TestHistoryResume(t *testing.T) {
+	h := NewViewHistory()
+	record(t, h, "a\n")
+	record(t, h, "a\nb\n")
+	record(t, h, "a\nb\nc\n")
+
+	updates := h.Since(h.StreamID(), 1)
+	if assert.Equal(t, 2, len(updates)) {
+		assert.Equal(t, 2, updates[0].Seq)
+		assert.Equal(t, "b\n", updates[0].Delta.Fields["Log"].Append)
+		assert.Equal(t, 3, updates[1].Seq)
+		assert.Equal(t, "c\n", updates[1].Delta.Fields["Log"].Append)
+	}
+
+	assert.Empty(t, h.Since(h.StreamID(), 3))
+}
+
+func TestHistoryResumeFromOtherStream(t *testing.T) {
+	h := NewViewHistory()
+	record(t, h, "a\n")
+	record(t, h, "a\nb\n")
+
+	updates := h.Since("some-other-stream", 1)
+	if assert.Equal(t, 1, len(updates)) {
+		assert.NotNil(t, updates[0].Snapshot)
+	}
+}
+
+func TestHistoryResumeTooFarBehind(t *testing.T) {
+	h := NewViewHistory()
+	log := ""
+	for i := 0; i < maxHistoryLength+10; i++ {
+		log += fmt.Sprintf("line %d\n", i)
+		record(t, h, log)
+	}
+
+	updates := h.Since(h.StreamID(), 5)
+	if assert.Equal(t, 1, len(updates)) {
+		assert.NotNil(t, updates[0].Snapshot)
+		assert.Equal(t, maxHistoryLength+10, updates[0].Seq)
+	}
+
+	updates = h.Since(h.StreamID(), 10)
+	assert.Equal(t, maxHistoryLength, len(updates))
+	assert.Equal(t, 11, updates[0].Seq)
+}
+
+func record(t *testing.T, h *ViewHistory, log string) {
+	err := h.Update(func() View {
+		return View{Log: model.NewLog(log), Resources: []Resource{{Name: "fe"}}}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -46,13 +46,26 @@ type K8SResourceInfo struct {
 	PodRestarts        int
 	PodLog             model.Log
 	YAML               string
+
+	// Warning events that Kubernetes reported about the resource's objects.
+	Warnings []string
+
+	// Whether the resource's workload is a Job, and if so, whether it finished.
+	IsJob     bool
+	JobStatus string
 }
 
 var _ ResourceInfoView = K8SResourceInfo{}
 
 func (K8SResourceInfo) resourceInfoView()             {}
 func (k8sInfo K8SResourceInfo) RuntimeLog() model.Log { return k8sInfo.PodLog }
-func (k8sInfo K8SResourceInfo) Status() string        { return k8sInfo.PodStatus }
+
+func (k8sInfo K8SResourceInfo) Status() string {
+	if k8sInfo.JobStatus != "" {
+		return k8sInfo.JobStatus
+	}
+	return k8sInfo.PodStatus
+}
 
 type YAMLResourceInfo struct {
 	K8sResources []string
@@ -64,6 +77,23 @@ func (YAMLResourceInfo) resourceInfoView()              {}
 func (yamlInfo YAMLResourceInfo) RuntimeLog() model.Log { return model.NewLog("") }
 func (yamlInfo YAMLResourceInfo) Status() string        { return "" }
 
+type LocalResourceInfo struct {
+	// Only set for resources with a long-running process (serve_cmd).
+	HasServeCmd   bool
+	PID           int
+	ProcessStatus string
+	ExitCode      int
+	StartTime     time.Time
+	Restarts      int
+	Log           model.Log
+}
+
+var _ ResourceInfoView = LocalResourceInfo{}
+
+func (LocalResourceInfo) resourceInfoView()         {}
+func (lri LocalResourceInfo) RuntimeLog() model.Log { return lri.Log }
+func (lri LocalResourceInfo) Status() string        { return lri.ProcessStatus }
+
 type Resource struct {
 	Name               model.ManifestName
 	DirectoriesWatched []string
@@ -79,6 +109,13 @@ type Resource struct {
 
 	Endpoints []string
 
+	// Whether the resource is ready to serve, according to its readiness
+	// probe or the Kubernetes readiness condition.
+	Readiness model.Readiness
+
+	// Whether the resource builds automatically on changes, or waits for a trigger.
+	TriggerMode model.TriggerMode
+
 	// TODO(nick): Remove ResourceInfoView. This is fundamentally a bad
 	// data structure for the webview because the webview loses the Go type
 	// on serialization to JS.
@@ -3,6 +3,7 @@ package k8s
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/url"
@@ -16,9 +17,11 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/browser"
 	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/validation"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
@@ -57,12 +60,19 @@ type Client interface {
 	// we might need to fallback to deleting and re-creating them.
 	Upsert(ctx context.Context, entities []K8sEntity) error
 
+	// Creates the entities. Fails if any of them already exist.
+	Create(ctx context.Context, entities []K8sEntity) error
+
 	// Deletes all given entities.
 	//
 	// Currently ignores any "not found" errors, because that seems like the correct
 	// behavior for our use cases.
 	Delete(ctx context.Context, entities []K8sEntity) error
 
+	// Lists the entities of every kind in the given namespaces that have all the given labels.
+	// Cluster-scoped entities are listed once for each namespace.
+	ListByLabels(ctx context.Context, namespaces []Namespace, lps []model.LabelPair) ([]K8sEntity, error)
+
 	PodByID(ctx context.Context, podID PodID, n Namespace) (*v1.Pod, error)
 
 	// Creates a channel where all changes to the pod are brodcast.
@@ -75,9 +85,20 @@ type Client interface {
 	// Opens a tunnel to the specified pod+port. Returns the tunnel's local port and a function that closes the tunnel
 	ForwardPort(ctx context.Context, namespace Namespace, podID PodID, optionalLocalPort, remotePort int) (localPort int, closer func(), err error)
 
-	WatchPods(ctx context.Context, lps labels.Selector) (<-chan *v1.Pod, error)
+	// Watches the pods in namespace `ns`. An empty namespace means all namespaces.
+	WatchPods(ctx context.Context, ns Namespace, lps labels.Selector) (<-chan *v1.Pod, error)
+
+	// Watches the services in namespace `ns`. An empty namespace means all namespaces.
+	WatchServices(ctx context.Context, ns Namespace, lps []model.LabelPair) (<-chan *v1.Service, error)
+
+	// Watches the jobs in namespace `ns`. An empty namespace means all namespaces.
+	WatchJobs(ctx context.Context, ns Namespace, lps []model.LabelPair) (<-chan *batchv1.Job, error)
 
-	WatchServices(ctx context.Context, lps []model.LabelPair) (<-chan *v1.Service, error)
+	// Watches the events in namespace `ns`. An empty namespace means all namespaces.
+	WatchEvents(ctx context.Context, ns Namespace) (<-chan *v1.Event, error)
+
+	// Gets the object that an event or owner reference points to.
+	GetByReference(ctx context.Context, ref v1.ObjectReference) (K8sEntity, error)
 
 	ConnectedToCluster(ctx context.Context) error
 
@@ -95,6 +116,7 @@ type K8sClient struct {
 	configNamespace Namespace
 	clientSet       kubernetes.Interface
 	runtimeAsync    *runtimeAsync
+	contextGuard    *ContextGuard
 }
 
 var _ Client = K8sClient{}
@@ -107,7 +129,8 @@ func ProvideK8sClient(
 	pf PortForwarder,
 	configNamespace Namespace,
 	runner kubectlRunner,
-	clientLoader clientcmd.ClientConfig) Client {
+	clientLoader clientcmd.ClientConfig,
+	contextGuard *ContextGuard) Client {
 	if env == EnvNone {
 		// No k8s, so no need to get any further configs
 		return &explodingClient{err: fmt.Errorf("Kubernetes context not set")}
@@ -140,6 +163,7 @@ func ProvideK8sClient(
 		configNamespace: configNamespace,
 		clientSet:       clientset,
 		runtimeAsync:    runtimeAsync,
+		contextGuard:    contextGuard,
 	}
 }
 
@@ -198,6 +222,11 @@ func (k K8sClient) Upsert(ctx context.Context, entities []K8sEntity) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "daemon-k8sUpsert")
 	defer span.Finish()
 
+	err := k.contextGuard.Check()
+	if err != nil {
+		return err
+	}
+
 	l := logger.Get(ctx)
 	prefix := logger.Blue(l).Sprint("  │ ")
 	l.Infof("%sApplying via kubectl", prefix)
@@ -259,11 +288,33 @@ func maybeImmutableFieldStderr(stderr string) bool {
 		strings.Contains(stderr, "Forbidden")
 }
 
+func (k K8sClient) Create(ctx context.Context, entities []K8sEntity) error {
+	err := k.contextGuard.Check()
+	if err != nil {
+		return err
+	}
+
+	l := logger.Get(ctx)
+	prefix := logger.Blue(l).Sprint("  │ ")
+	l.Infof("%sCreating via kubectl", prefix)
+
+	_, stderr, err := k.actOnEntities(ctx, []string{"create"}, entities)
+	if err != nil {
+		return errors.Wrapf(err, "kubectl create:\nstderr: %s", stderr)
+	}
+	return nil
+}
+
 // Deletes all given entities.
 //
 // Currently ignores any "not found" errors, because that seems like the correct
 // behavior for our use cases.
 func (k K8sClient) Delete(ctx context.Context, entities []K8sEntity) error {
+	err := k.contextGuard.Check()
+	if err != nil {
+		return err
+	}
+
 	l := logger.Get(ctx)
 	for _, e := range entities {
 		l.Infof("Deleting via kubectl: %s/%s\n", e.Kind.Kind, e.Name())
@@ -276,6 +327,91 @@ func (k K8sClient) Delete(ctx context.Context, entities []K8sEntity) error {
 	return nil
 }
 
+func (k K8sClient) ListByLabels(ctx context.Context, namespaces []Namespace, lps []model.LabelPair) ([]K8sEntity, error) {
+	if len(namespaces) == 0 {
+		return nil, nil
+	}
+
+	stdout, stderr, err := k.kubectlRunner.exec(ctx, []string{"api-resources", "--verbs=list,delete", "-o", "name"})
+	if err != nil {
+		return nil, errors.Wrapf(err, "kubectl api-resources:\nstderr: %s", stderr)
+	}
+
+	kinds := strings.Fields(stdout)
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	selector := makeLabelSelector(lps)
+	var result []K8sEntity
+	for _, ns := range namespaces {
+		stdout, stderr, err = k.kubectlRunner.exec(ctx,
+			[]string{"get", strings.Join(kinds, ","), "-n", ns.String(), "-l", selector, "-o", "json"})
+		if err != nil {
+			return nil, errors.Wrapf(err, "kubectl get -n %s -l %s:\nstderr: %s", ns, selector, stderr)
+		}
+
+		entities, err := parseEntityList(stdout)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, entities...)
+	}
+	return result, nil
+}
+
+func (k K8sClient) GetByReference(ctx context.Context, ref v1.ObjectReference) (K8sEntity, error) {
+	// kubectl understands kinds as well as resource names,
+	// as long as we qualify them with their version and group.
+	kind := ref.Kind
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return K8sEntity{}, err
+	}
+	if gv.Group != "" {
+		kind = fmt.Sprintf("%s.%s.%s", ref.Kind, gv.Version, gv.Group)
+	}
+
+	args := []string{"get", kind, ref.Name, "-o", "json"}
+	if ref.Namespace != "" {
+		args = append(args, "-n", ref.Namespace)
+	}
+	stdout, stderr, err := k.kubectlRunner.exec(ctx, args)
+	if err != nil {
+		return K8sEntity{}, errors.Wrapf(err, "kubectl get %s %s:\nstderr: %s", kind, ref.Name, stderr)
+	}
+
+	entities, err := ParseYAMLFromString(stdout)
+	if err != nil {
+		return K8sEntity{}, errors.Wrap(err, "parsing kubectl get output")
+	}
+	if len(entities) != 1 {
+		return K8sEntity{}, fmt.Errorf("kubectl get %s %s: expected 1 object, got %d", kind, ref.Name, len(entities))
+	}
+	return entities[0], nil
+}
+
+// Parses the List that `kubectl get -o json` prints.
+func parseEntityList(listJSON string) ([]K8sEntity, error) {
+	var list struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	err := json.Unmarshal([]byte(listJSON), &list)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing kubectl get output")
+	}
+
+	result := make([]K8sEntity, 0, len(list.Items))
+	for _, item := range list.Items {
+		entities, err := ParseYAMLFromString(string(item))
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing kubectl get output")
+		}
+		result = append(result, entities...)
+	}
+	return result, nil
+}
+
 func (k K8sClient) actOnEntities(ctx context.Context, cmdArgs []string, entities []K8sEntity) (stdout string, stderr string, err error) {
 	args := append([]string{}, cmdArgs...)
 	args = append(args, "-f", "-")
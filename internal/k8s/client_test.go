@@ -21,6 +21,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/windmilleng/tilt/internal/k8s/testyaml"
+	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/testutils/output"
 )
 
@@ -48,6 +49,91 @@ func TestUpsertStatefulsetForbidden(t *testing.T) {
 	}
 }
 
+func TestCreate(t *testing.T) {
+	f := newClientTestFixture(t)
+	jobs, err := ParseYAMLFromString(testyaml.JobYAML)
+	assert.Nil(t, err)
+
+	err = f.client.Create(f.ctx, jobs)
+	if assert.Nil(t, err) && assert.Equal(t, 1, len(f.runner.calls)) {
+		assert.Equal(t, []string{"create", "-f", "-"}, f.runner.calls[0].argv)
+		assert.Contains(t, f.runner.calls[0].stdin, "kind: Job")
+	}
+}
+
+func TestContextGuard(t *testing.T) {
+	f := newClientTestFixture(t)
+	f.client.contextGuard = ProvideContextGuard(EnvGKE, "gke_prod")
+
+	entities, err := ParseYAMLFromString(testyaml.SanchoYAML)
+	assert.Nil(t, err)
+
+	err = f.client.Upsert(f.ctx, entities)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `Refusing to change "gke_prod"`)
+	}
+	assert.Error(t, f.client.Create(f.ctx, entities))
+	assert.Error(t, f.client.Delete(f.ctx, entities))
+	assert.Empty(t, f.runner.calls)
+
+	f.client.contextGuard.Allow([]KubeContext{"gke_prod"})
+	err = f.client.Delete(f.ctx, entities)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(f.runner.calls))
+	}
+}
+
+func TestListByLabels(t *testing.T) {
+	f := newClientTestFixture(t)
+	f.runner.stdoutQueue = []string{
+		"deployments.apps\nservices\n",
+		`{"apiVersion": "v1", "kind": "List", "items": [
+  {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "foo", "namespace": "default"}}
+]}`,
+		`{"apiVersion": "v1", "kind": "List", "items": [
+  {"apiVersion": "v1", "kind": "Service", "metadata": {"name": "foo-svc", "namespace": "dev"}}
+]}`,
+	}
+
+	entities, err := f.client.ListByLabels(f.ctx, []Namespace{"default", "dev"}, []model.LabelPair{{Key: "tilt-owner", Value: "abc"}})
+	if assert.NoError(t, err) && assert.Equal(t, 2, len(entities)) {
+		assert.Equal(t, "Deployment", entities[0].Kind.Kind)
+		assert.Equal(t, "foo", entities[0].Name())
+		assert.Equal(t, "Service", entities[1].Kind.Kind)
+		assert.Equal(t, "foo-svc", entities[1].Name())
+	}
+	if assert.Equal(t, 3, len(f.runner.calls)) {
+		assert.Equal(t, []string{"api-resources", "--verbs=list,delete", "-o", "name"}, f.runner.calls[0].argv)
+		assert.Equal(t, []string{"get", "deployments.apps,services", "-n", "default", "-l", "tilt-owner=abc", "-o", "json"}, f.runner.calls[1].argv)
+		assert.Equal(t, []string{"get", "deployments.apps,services", "-n", "dev", "-l", "tilt-owner=abc", "-o", "json"}, f.runner.calls[2].argv)
+	}
+}
+
+func TestListByLabelsNoNamespaces(t *testing.T) {
+	f := newClientTestFixture(t)
+
+	entities, err := f.client.ListByLabels(f.ctx, nil, []model.LabelPair{{Key: "tilt-owner", Value: "abc"}})
+	if assert.NoError(t, err) {
+		assert.Empty(t, entities)
+	}
+	assert.Empty(t, f.runner.calls)
+}
+
+func TestGetByReference(t *testing.T) {
+	f := newClientTestFixture(t)
+	f.runner.stdout = `{"apiVersion": "apps/v1", "kind": "ReplicaSet", "metadata": {"name": "foo-123", "namespace": "dev-jane"}}`
+
+	ref := v1.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "foo-123", Namespace: "dev-jane"}
+	entity, err := f.client.GetByReference(f.ctx, ref)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ReplicaSet", entity.Kind.Kind)
+		assert.Equal(t, "foo-123", entity.Name())
+	}
+	if assert.Equal(t, 1, len(f.runner.calls)) {
+		assert.Equal(t, []string{"get", "ReplicaSet.v1.apps", "foo-123", "-o", "json", "-n", "dev-jane"}, f.runner.calls[0].argv)
+	}
+}
+
 type call struct {
 	argv  []string
 	stdin string
@@ -58,6 +144,9 @@ type fakeKubectlRunner struct {
 	stderr string
 	err    error
 
+	// Output for each successive call to exec, for commands that run kubectl more than once.
+	stdoutQueue []string
+
 	calls []call
 }
 
@@ -78,6 +167,10 @@ func (f *fakeKubectlRunner) execWithStdin(ctx context.Context, args []string, st
 
 func (f *fakeKubectlRunner) exec(ctx context.Context, args []string) (stdout string, stderr string, err error) {
 	f.calls = append(f.calls, call{argv: args})
+	if len(f.stdoutQueue) > 0 {
+		stdout, f.stdoutQueue = f.stdoutQueue[0], f.stdoutQueue[1:]
+		return stdout, "", nil
+	}
 	defer func() {
 		f.stdout = ""
 		f.stderr = ""
@@ -125,7 +218,7 @@ func newClientTestFixture(t *testing.T) *clientTestFixture {
 
 	core := cs.CoreV1()
 	runtimeAsync := newRuntimeAsync(core)
-	ret.client = K8sClient{EnvUnknown, ret.runner, core, nil, fakePortForwarder, "", nil, runtimeAsync}
+	ret.client = K8sClient{EnvUnknown, ret.runner, core, nil, fakePortForwarder, "", nil, runtimeAsync, nil}
 	return ret
 }
 
@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Keeps Tilt from changing a cluster that might be production.
+//
+// Tilt may always change local clusters. Other contexts have to be allowed
+// by the Tiltfile, which we only know once it loads, so the client refuses
+// to change anything until then.
+type ContextGuard struct {
+	env         Env
+	kubeContext KubeContext
+
+	mu      sync.Mutex
+	allowed bool
+}
+
+func ProvideContextGuard(env Env, kubeContext KubeContext) *ContextGuard {
+	return &ContextGuard{env: env, kubeContext: kubeContext}
+}
+
+// Called with the contexts that the Tiltfile allows, each time it loads.
+func (g *ContextGuard) Allow(contexts []KubeContext) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.allowed = false
+	for _, c := range contexts {
+		if c == g.kubeContext {
+			g.allowed = true
+		}
+	}
+}
+
+// Returns an error if Tilt may not change the cluster of the current context.
+func (g *ContextGuard) Check() error {
+	if g == nil || g.env.IsLocalCluster() {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.allowed {
+		return nil
+	}
+	return fmt.Errorf("Refusing to change %q, which might be production. "+
+		"Tilt only changes local clusters and the contexts that the Tiltfile allows with allow_k8s_contexts()", g.kubeContext)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pkg/errors"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -86,6 +87,23 @@ func (e K8sEntity) Namespace() Namespace {
 	return Namespace(n)
 }
 
+func (e K8sEntity) Labels() map[string]string {
+	m, err := meta.Accessor(e.Obj)
+	if err != nil {
+		return nil
+	}
+	return m.GetLabels()
+}
+
+// The owner that manages this entity (e.g., the ReplicaSet of a Pod), if any.
+func (e K8sEntity) ControllerRef() *metav1.OwnerReference {
+	m, err := meta.Accessor(e.Obj)
+	if err != nil {
+		return nil
+	}
+	return metav1.GetControllerOf(m)
+}
+
 // Most entities can be updated once running, but a few cannot.
 func (e K8sEntity) ImmutableOnceCreated() bool {
 	if e.Kind != nil {
@@ -258,3 +276,67 @@ func (e K8sEntity) HasKind(kind string) bool {
 	// TODO(maia): support kind aliases (e.g. "po" for "pod")
 	return strings.ToLower(e.Kind.Kind) == strings.ToLower(kind)
 }
+
+// Returns the spec.replicas field of a workload (e.g., a Deployment or a StatefulSet),
+// or false if this kind of entity doesn't have replicas.
+func (e K8sEntity) Replicas() (int32, bool) {
+	if u, ok := e.Obj.(*unstructured.Unstructured); ok {
+		replicas, found, err := unstructured.NestedInt64(u.Object, "spec", "replicas")
+		if err != nil || !found {
+			return 0, false
+		}
+		return int32(replicas), true
+	}
+
+	field, ok := e.replicasField()
+	if !ok {
+		return 0, false
+	}
+	if field.IsNil() {
+		// Kubernetes defaults unset replicas to 1.
+		return 1, true
+	}
+	return int32(field.Elem().Int()), true
+}
+
+// Sets the spec.replicas field of a workload in place.
+func (e K8sEntity) SetReplicas(replicas int32) error {
+	if u, ok := e.Obj.(*unstructured.Unstructured); ok {
+		_, found, err := unstructured.NestedInt64(u.Object, "spec", "replicas")
+		if err != nil || !found {
+			return fmt.Errorf("%s %q has no replicas", e.Kind.Kind, e.Name())
+		}
+		return unstructured.SetNestedField(u.Object, int64(replicas), "spec", "replicas")
+	}
+
+	field, ok := e.replicasField()
+	if !ok {
+		return fmt.Errorf("%s %q has no replicas", e.Kind.Kind, e.Name())
+	}
+	field.Set(reflect.ValueOf(&replicas))
+	return nil
+}
+
+// Finds the Spec.Replicas field of a typed entity, which all the
+// workload types declare as a *int32.
+func (e K8sEntity) replicasField() (reflect.Value, bool) {
+	objVal := reflect.ValueOf(e.Obj)
+	if objVal.Kind() != reflect.Ptr || objVal.IsNil() {
+		return reflect.Value{}, false
+	}
+	objVal = objVal.Elem()
+	if objVal.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	spec := objVal.FieldByName("Spec")
+	if !spec.IsValid() || spec.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	replicas := spec.FieldByName("Replicas")
+	if !replicas.IsValid() || replicas.Type() != reflect.TypeOf((*int32)(nil)) {
+		return reflect.Value{}, false
+	}
+	return replicas, true
+}
@@ -29,6 +29,33 @@ func TestNamespace(t *testing.T) {
 	assert.Equal(t, "kube-system", string(entities[0].Namespace()))
 }
 
+func TestControllerRef(t *testing.T) {
+	entities, err := ParseYAMLFromString(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: foo-abc
+  labels:
+    app: foo
+  ownerReferences:
+  - apiVersion: apps/v1
+    kind: ReplicaSet
+    name: foo-123
+    uid: abc
+    controller: true
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]string{"app": "foo"}, entities[0].Labels())
+	ref := entities[0].ControllerRef()
+	if assert.NotNil(t, ref) {
+		assert.Equal(t, "ReplicaSet", ref.Kind)
+		assert.Equal(t, "foo-123", ref.Name)
+	}
+}
+
 func TestImmutableFilter(t *testing.T) {
 	yaml := fmt.Sprintf("%s\n---\n%s\n---\n%s", testyaml.JobYAML, testyaml.SanchoYAML, testyaml.PodYAML)
 	entities, err := ParseYAMLFromString(yaml)
@@ -183,3 +210,36 @@ func parseYAMLFromStrings(yaml ...string) ([]K8sEntity, error) {
 	}
 	return res, nil
 }
+
+func TestReplicas(t *testing.T) {
+	yaml := fmt.Sprintf("%s\n---\n%s\n---\n%s", testyaml.SanchoYAML, testyaml.PodYAML, testyaml.CRDYAML)
+	entities, err := ParseYAMLFromString(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 4, len(entities))
+
+	deployment, pod, project := entities[0], entities[1], entities[3]
+
+	replicas, ok := deployment.Replicas()
+	assert.True(t, ok)
+	assert.Equal(t, int32(1), replicas)
+
+	err = deployment.SetReplicas(3)
+	assert.NoError(t, err)
+	replicas, _ = deployment.Replicas()
+	assert.Equal(t, int32(3), replicas)
+
+	_, ok = pod.Replicas()
+	assert.False(t, ok)
+	err = pod.SetReplicas(3)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "has no replicas")
+	}
+
+	err = project.SetReplicas(2)
+	assert.NoError(t, err)
+	replicas, ok = project.Replicas()
+	assert.True(t, ok)
+	assert.Equal(t, int32(2), replicas)
+}
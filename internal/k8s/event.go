@@ -0,0 +1,21 @@
+package k8s
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// The last time that an event happened.
+//
+// Events from older clusters only set the timestamps, and events from newer
+// ones may only set EventTime, so we fall back through all of them.
+func EventTime(e *v1.Event) time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
+	}
+	return e.FirstTimestamp.Time
+}
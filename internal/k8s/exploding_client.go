@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/windmilleng/tilt/internal/container"
 	"github.com/windmilleng/tilt/internal/model"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/watch"
@@ -24,10 +25,18 @@ func (ec *explodingClient) Upsert(ctx context.Context, entities []K8sEntity) err
 	return errors.Wrap(ec.err, "could not set up k8s client")
 }
 
+func (ec *explodingClient) Create(ctx context.Context, entities []K8sEntity) error {
+	return errors.Wrap(ec.err, "could not set up k8s client")
+}
+
 func (ec *explodingClient) Delete(ctx context.Context, entities []K8sEntity) error {
 	return errors.Wrap(ec.err, "could not set up k8s client")
 }
 
+func (ec *explodingClient) ListByLabels(ctx context.Context, namespaces []Namespace, lps []model.LabelPair) ([]K8sEntity, error) {
+	return nil, errors.Wrap(ec.err, "could not set up k8s client")
+}
+
 func (ec *explodingClient) PodsWithImage(ctx context.Context, image reference.NamedTagged, n Namespace, lp []model.LabelPair) ([]v1.Pod, error) {
 	return nil, errors.Wrap(ec.err, "could not set up k8s client")
 }
@@ -52,14 +61,26 @@ func (ec *explodingClient) ForwardPort(ctx context.Context, namespace Namespace,
 	return 0, nil, errors.Wrap(ec.err, "could not set up k8s client")
 }
 
-func (ec *explodingClient) WatchPods(ctx context.Context, lps labels.Selector) (<-chan *v1.Pod, error) {
+func (ec *explodingClient) WatchPods(ctx context.Context, ns Namespace, lps labels.Selector) (<-chan *v1.Pod, error) {
+	return nil, errors.Wrap(ec.err, "could not set up k8s client")
+}
+
+func (ec *explodingClient) WatchServices(ctx context.Context, ns Namespace, lps []model.LabelPair) (<-chan *v1.Service, error) {
 	return nil, errors.Wrap(ec.err, "could not set up k8s client")
 }
 
-func (ec *explodingClient) WatchServices(ctx context.Context, lps []model.LabelPair) (<-chan *v1.Service, error) {
+func (ec *explodingClient) WatchJobs(ctx context.Context, ns Namespace, lps []model.LabelPair) (<-chan *batchv1.Job, error) {
 	return nil, errors.Wrap(ec.err, "could not set up k8s client")
 }
 
+func (ec *explodingClient) WatchEvents(ctx context.Context, ns Namespace) (<-chan *v1.Event, error) {
+	return nil, errors.Wrap(ec.err, "could not set up k8s client")
+}
+
+func (ec *explodingClient) GetByReference(ctx context.Context, ref v1.ObjectReference) (K8sEntity, error) {
+	return K8sEntity{}, errors.Wrap(ec.err, "could not set up k8s client")
+}
+
 func (ec *explodingClient) ConnectedToCluster(ctx context.Context) error {
 	return errors.Wrap(ec.err, "could not set up k8s client")
 }
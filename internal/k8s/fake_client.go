@@ -3,6 +3,7 @@ package k8s
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"sync"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/docker/distribution/reference"
 	"github.com/pkg/errors"
 	"github.com/windmilleng/tilt/internal/container"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/watch"
 )
@@ -33,6 +35,7 @@ type PodAndCName struct {
 type FakeK8sClient struct {
 	Yaml        string
 	DeletedYaml string
+	CreatedYaml string
 	Lb          LoadBalancerSpec
 
 	LastPodQueryNamespace Namespace
@@ -49,17 +52,55 @@ type FakeK8sClient struct {
 
 	UpsertError error
 	Runtime     container.Runtime
+
+	// The namespace passed to the last WatchServices
+	WatchedServicesNamespace Namespace
+
+	// Entities returned by ListByLabels, if they have the labels
+	// and are in one of the namespaces.
+	ListedEntities []K8sEntity
+	ListError      error
+
+	// The arguments of the last call to WatchJobs.
+	WatchedJobsNamespace Namespace
+	WatchedJobsCtx       context.Context
+
+	// Events sent by the test to whoever called WatchEvents.
+	EventsCh chan *v1.Event
+
+	// Entities returned by GetByReference, keyed by name.
+	GetByReferenceEntities map[string]K8sEntity
 }
 
 type fakePodWatch struct {
+	ns Namespace
 	ls labels.Selector
 	ch chan *v1.Pod
 }
 
-func (c *FakeK8sClient) WatchServices(ctx context.Context, lps []model.LabelPair) (<-chan *v1.Service, error) {
+func (c *FakeK8sClient) WatchServices(ctx context.Context, ns Namespace, lps []model.LabelPair) (<-chan *v1.Service, error) {
+	c.WatchedServicesNamespace = ns
 	return nil, nil
 }
 
+func (c *FakeK8sClient) WatchJobs(ctx context.Context, ns Namespace, lps []model.LabelPair) (<-chan *batchv1.Job, error) {
+	c.WatchedJobsNamespace = ns
+	c.WatchedJobsCtx = ctx
+	return nil, nil
+}
+
+func (c *FakeK8sClient) WatchEvents(ctx context.Context, ns Namespace) (<-chan *v1.Event, error) {
+	return c.EventsCh, nil
+}
+
+func (c *FakeK8sClient) GetByReference(ctx context.Context, ref v1.ObjectReference) (K8sEntity, error) {
+	e, ok := c.GetByReferenceEntities[ref.Name]
+	if !ok {
+		return K8sEntity{}, fmt.Errorf("object not found: %s %s", ref.Kind, ref.Name)
+	}
+	return e, nil
+}
+
 func (c *FakeK8sClient) WatchedSelectors() []labels.Selector {
 	c.watcherMu.Lock()
 	defer c.watcherMu.Unlock()
@@ -70,6 +111,16 @@ func (c *FakeK8sClient) WatchedSelectors() []labels.Selector {
 	return ret
 }
 
+func (c *FakeK8sClient) WatchedNamespaces() []Namespace {
+	c.watcherMu.Lock()
+	defer c.watcherMu.Unlock()
+	var ret []Namespace
+	for _, w := range c.watches {
+		ret = append(ret, w.ns)
+	}
+	return ret
+}
+
 func (c *FakeK8sClient) EmitPod(ls labels.Selector, p *v1.Pod) {
 	c.watcherMu.Lock()
 	defer c.watcherMu.Unlock()
@@ -80,10 +131,10 @@ func (c *FakeK8sClient) EmitPod(ls labels.Selector, p *v1.Pod) {
 	}
 }
 
-func (c *FakeK8sClient) WatchPods(ctx context.Context, ls labels.Selector) (<-chan *v1.Pod, error) {
+func (c *FakeK8sClient) WatchPods(ctx context.Context, ns Namespace, ls labels.Selector) (<-chan *v1.Pod, error) {
 	c.watcherMu.Lock()
 	ch := make(chan *v1.Pod, 20)
-	c.watches = append(c.watches, fakePodWatch{ls, ch})
+	c.watches = append(c.watches, fakePodWatch{ns, ls, ch})
 	c.watcherMu.Unlock()
 
 	go func() {
@@ -92,7 +143,7 @@ func (c *FakeK8sClient) WatchPods(ctx context.Context, ls labels.Selector) (<-ch
 		c.watcherMu.Lock()
 		var newWatches []fakePodWatch
 		for _, e := range c.watches {
-			if !SelectorEqual(e.ls, ls) {
+			if e.ns != ns || !SelectorEqual(e.ls, ls) {
 				newWatches = append(newWatches, e)
 			}
 		}
@@ -124,6 +175,15 @@ func (c *FakeK8sClient) Upsert(ctx context.Context, entities []K8sEntity) error
 	return nil
 }
 
+func (c *FakeK8sClient) Create(ctx context.Context, entities []K8sEntity) error {
+	yaml, err := SerializeYAML(entities)
+	if err != nil {
+		return errors.Wrap(err, "kubectl create")
+	}
+	c.CreatedYaml = yaml
+	return nil
+}
+
 func (c *FakeK8sClient) Delete(ctx context.Context, entities []K8sEntity) error {
 	yaml, err := SerializeYAML(entities)
 	if err != nil {
@@ -133,6 +193,35 @@ func (c *FakeK8sClient) Delete(ctx context.Context, entities []K8sEntity) error
 	return nil
 }
 
+func (c *FakeK8sClient) ListByLabels(ctx context.Context, namespaces []Namespace, lps []model.LabelPair) ([]K8sEntity, error) {
+	if c.ListError != nil {
+		return nil, c.ListError
+	}
+
+	var result []K8sEntity
+	for _, e := range c.ListedEntities {
+		// Entities without a namespace are cluster-scoped, so kubectl lists them in every namespace.
+		inNamespace := e.meta().GetNamespace() == "" && len(namespaces) > 0
+		for _, ns := range namespaces {
+			if e.Namespace() == ns {
+				inNamespace = true
+			}
+		}
+		if !inNamespace {
+			continue
+		}
+
+		ok, err := e.MatchesMetadataLabels(makeLabelSet(lps))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
 func (c *FakeK8sClient) WatchPod(ctx context.Context, pod *v1.Pod) (watch.Interface, error) {
 	return watch.NewEmptyWatch(), nil
 }
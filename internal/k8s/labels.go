@@ -1,6 +1,10 @@
 package k8s
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/user"
 	"strconv"
 
 	"github.com/google/uuid"
@@ -16,6 +20,8 @@ const ManifestNameLabel = "tilt-manifest"
 
 const TiltDeployIDLabel = "tilt-deployid"
 
+const TiltOwnerIDLabel = "tilt-owner"
+
 func TiltRunLabel() model.LabelPair {
 	return model.LabelPair{
 		Key:   TiltRunIDLabel,
@@ -30,6 +36,40 @@ func TiltDeployLabel(dID model.DeployID) model.LabelPair {
 	}
 }
 
+// Identifies the checkout that deployed an entity. Unlike the run ID, it's stable
+// across runs, so Tilt can find the entities it deployed in earlier runs.
+//
+// Two machines (or containers, or users) can have a Tiltfile at the same path
+// and deploy to the same cluster, so we include the host and user too.
+func TiltOwnerLabel(tiltfilePath string) model.LabelPair {
+	return ownerLabel(ownerHost(), ownerUser(), tiltfilePath)
+}
+
+func ownerLabel(host, username, tiltfilePath string) model.LabelPair {
+	// Label values can't contain slashes and are limited to 63 characters, so use a hash.
+	owner := fmt.Sprintf("%s\x00%s\x00%s", host, username, tiltfilePath)
+	return model.LabelPair{
+		Key:   TiltOwnerIDLabel,
+		Value: fmt.Sprintf("%x", sha256.Sum256([]byte(owner)))[:16],
+	}
+}
+
+func ownerHost() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+func ownerUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+	return u.Username
+}
+
 func TiltRunSelector() labels.Selector {
 	return labels.Set{TiltRunIDLabel: TiltRunID}.AsSelector()
 }
@@ -0,0 +1,18 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnerLabelIncludesHostAndUser(t *testing.T) {
+	label := ownerLabel("laptop", "jane", "/home/jane/Tiltfile")
+	assert.Equal(t, TiltOwnerIDLabel, label.Key)
+	assert.Len(t, label.Value, 16)
+
+	assert.Equal(t, label, ownerLabel("laptop", "jane", "/home/jane/Tiltfile"))
+	assert.NotEqual(t, label, ownerLabel("ci-runner", "jane", "/home/jane/Tiltfile"))
+	assert.NotEqual(t, label, ownerLabel("laptop", "root", "/home/jane/Tiltfile"))
+	assert.NotEqual(t, label, ownerLabel("laptop", "jane", "/home/jane/other/Tiltfile"))
+}
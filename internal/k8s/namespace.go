@@ -0,0 +1,135 @@
+package k8s
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	rbacv1beta1 "k8s.io/api/rbac/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Kinds that don't live in a namespace. We can't ask the cluster at parse time,
+// so this covers the built-in kinds. Custom resources are assumed to be namespaced.
+var clusterScopedKinds = map[string]bool{
+	"APIService":                     true,
+	"CertificateSigningRequest":      true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"ComponentStatus":                true,
+	"CSIDriver":                      true,
+	"CSINode":                        true,
+	"CustomResourceDefinition":       true,
+	"MutatingWebhookConfiguration":   true,
+	"Namespace":                      true,
+	"Node":                           true,
+	"PersistentVolume":               true,
+	"PodSecurityPolicy":              true,
+	"PriorityClass":                  true,
+	"RuntimeClass":                   true,
+	"StorageClass":                   true,
+	"ValidatingWebhookConfiguration": true,
+	"VolumeAttachment":               true,
+}
+
+func (e K8sEntity) IsClusterScoped() bool {
+	return clusterScopedKinds[e.Kind.Kind]
+}
+
+func ValidateNamespace(ns string) error {
+	errs := validation.IsDNS1123Label(ns)
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid namespace %q: %s", ns, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Returns a copy of the entity, moved into namespace `ns`.
+//
+// Also points ServiceAccount subjects of (Cluster)RoleBindings at `ns`,
+// if they referred to the namespace the binding was declared in.
+// Subjects in other namespaces are left alone.
+func InjectNamespace(entity K8sEntity, ns Namespace) (K8sEntity, error) {
+	entity = entity.DeepCopy()
+	m, err := meta.Accessor(entity.Obj)
+	if err != nil {
+		return K8sEntity{}, err
+	}
+
+	oldNs := m.GetNamespace()
+	if !entity.IsClusterScoped() {
+		m.SetNamespace(ns.String())
+	}
+
+	err = injectSubjectNamespace(entity.Obj, oldNs, ns)
+	if err != nil {
+		return K8sEntity{}, err
+	}
+	return entity, nil
+}
+
+func InjectNamespaceAll(entities []K8sEntity, ns Namespace) ([]K8sEntity, error) {
+	result := make([]K8sEntity, 0, len(entities))
+	for _, e := range entities {
+		e, err := InjectNamespace(e, ns)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+func injectSubjectNamespace(obj interface{}, oldNs string, ns Namespace) error {
+	// Subjects written without a namespace (or in the default one) usually mean
+	// "the namespace I'm deploying to".
+	matches := func(subjectNs string) bool {
+		return subjectNs == "" || subjectNs == oldNs || subjectNs == string(DefaultNamespace)
+	}
+
+	subjects, err := extractPointersOf(obj, reflect.TypeOf(rbacv1.Subject{}))
+	if err != nil {
+		return err
+	}
+	for _, s := range subjects {
+		s := s.(*rbacv1.Subject)
+		if s.Kind == rbacv1.ServiceAccountKind && matches(s.Namespace) {
+			s.Namespace = ns.String()
+		}
+	}
+
+	subjectsBeta, err := extractPointersOf(obj, reflect.TypeOf(rbacv1beta1.Subject{}))
+	if err != nil {
+		return err
+	}
+	for _, s := range subjectsBeta {
+		s := s.(*rbacv1beta1.Subject)
+		if s.Kind == rbacv1beta1.ServiceAccountKind && matches(s.Namespace) {
+			s.Namespace = ns.String()
+		}
+	}
+	return nil
+}
+
+// An entity that creates namespace `ns`. Applying it is a no-op
+// if the namespace already exists.
+func NewNamespaceEntity(ns Namespace) K8sEntity {
+	obj := &v1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ns.String(),
+		},
+	}
+	kind := obj.GroupVersionKind()
+	return K8sEntity{
+		Obj:  obj,
+		Kind: &kind,
+	}
+}
@@ -0,0 +1,100 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/windmilleng/tilt/internal/k8s/testyaml"
+)
+
+const roleBindingYAML = `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: sancho-reader
+  namespace: sancho-ns
+subjects:
+- kind: ServiceAccount
+  name: sancho
+  namespace: sancho-ns
+- kind: ServiceAccount
+  name: monitor
+  namespace: monitoring
+- kind: User
+  name: jane
+roleRef:
+  kind: Role
+  name: reader
+  apiGroup: rbac.authorization.k8s.io
+`
+
+func TestInjectNamespace(t *testing.T) {
+	entities, err := ParseYAMLFromString(testyaml.SanchoYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newEntity, err := InjectNamespace(entities[0], "dev-jane")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "dev-jane", newEntity.Namespace().String())
+	assert.Equal(t, "sancho-ns", entities[0].Namespace().String())
+}
+
+func TestInjectNamespaceSkipsClusterScoped(t *testing.T) {
+	entities, err := ParseYAMLFromString(testyaml.MyNamespaceYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newEntity, err := InjectNamespace(entities[0], "dev-jane")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "", newEntity.meta().GetNamespace())
+}
+
+func TestInjectNamespaceRoleBindingSubjects(t *testing.T) {
+	entities, err := ParseYAMLFromString(roleBindingYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newEntity, err := InjectNamespace(entities[0], "dev-jane")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rb := newEntity.Obj.(*rbacv1.RoleBinding)
+	assert.Equal(t, "dev-jane", rb.Namespace)
+	assert.Equal(t, "dev-jane", rb.Subjects[0].Namespace)
+	assert.Equal(t, "monitoring", rb.Subjects[1].Namespace)
+	assert.Equal(t, "", rb.Subjects[2].Namespace)
+}
+
+func TestNewNamespaceEntity(t *testing.T) {
+	yaml, err := SerializeYAML([]K8sEntity{NewNamespaceEntity("dev-jane")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entities, err := ParseYAMLFromString(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Equal(t, 1, len(entities)) {
+		assert.Equal(t, "Namespace", entities[0].Kind.Kind)
+		assert.Equal(t, "dev-jane", entities[0].Name())
+	}
+}
+
+func TestValidateNamespace(t *testing.T) {
+	assert.NoError(t, ValidateNamespace("dev-jane"))
+	assert.Error(t, ValidateNamespace("Dev_Jane"))
+	assert.Error(t, ValidateNamespace(""))
+}
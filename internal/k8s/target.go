@@ -22,15 +22,35 @@ func NewTarget(
 		resourceNames = append(resourceNames, e.ResourceName())
 	}
 
+	workloads, _, err := FilterByHasPodTemplateSpec(entities)
+	if err != nil {
+		return model.K8sTarget{}, err
+	}
+
 	return model.K8sTarget{
 		Name:              name,
 		YAML:              yaml,
 		ResourceNames:     resourceNames,
 		PortForwards:      portForwards,
 		ExtraPodSelectors: extraPodSelectors,
+		IsJob:             isJobWorkload(workloads),
+		NoPods:            len(workloads) == 0,
 	}.WithDependencyIDs(dependencyIDs), nil
 }
 
+// Whether all the entities that run pods are Jobs.
+func isJobWorkload(workloads []K8sEntity) bool {
+	if len(workloads) == 0 {
+		return false
+	}
+	for _, w := range workloads {
+		if !w.HasKind("Job") {
+			return false
+		}
+	}
+	return true
+}
+
 func NewK8sOnlyManifest(name model.ManifestName, entities []K8sEntity) (model.Manifest, error) {
 	kTarget, err := NewTarget(name.TargetName(), entities, nil, nil, nil)
 	if err != nil {
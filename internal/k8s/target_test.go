@@ -0,0 +1,38 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/k8s/testyaml"
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+func TestNewTargetWorkloads(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		yaml   string
+		isJob  bool
+		noPods bool
+	}{
+		{"deployment", testyaml.SanchoYAML, false, false},
+		{"job", testyaml.JobYAML, true, false},
+		{"service", testyaml.DoggosServiceYaml, false, true},
+		{"secret", testyaml.SecretYaml, false, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			entities, err := ParseYAMLFromString(test.yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			target, err := NewTarget(model.TargetName(test.name), entities, nil, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.isJob, target.IsJob)
+			assert.Equal(t, test.noPods, target.NoPods)
+		})
+	}
+}
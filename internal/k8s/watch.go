@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/windmilleng/tilt/internal/model"
 
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,9 +24,10 @@ type watcher interface {
 	Watch(options metav1.ListOptions) (watch.Interface, error)
 }
 
-func (kCli K8sClient) makeWatcher(f watcherFactory, ls labels.Selector) (watch.Interface, error) {
+// Watches namespace `ns`. An empty namespace means all namespaces.
+func (kCli K8sClient) makeWatcher(f watcherFactory, ns Namespace, ls labels.Selector) (watch.Interface, error) {
 	// passing "" gets us all namespaces
-	watcher, err := f("").Watch(metav1.ListOptions{LabelSelector: ls.String()})
+	watcher, err := f(string(ns)).Watch(metav1.ListOptions{LabelSelector: ls.String()})
 	if err == nil {
 		return watcher, nil
 	}
@@ -37,7 +39,7 @@ func (kCli K8sClient) makeWatcher(f watcherFactory, ls labels.Selector) (watch.I
 	}
 
 	status := statusErr.ErrStatus
-	if status.Code == http.StatusForbidden {
+	if status.Code == http.StatusForbidden && ns == "" {
 		// If this is a forbidden error, maybe the user just isn't allowed to watch this namespace.
 		// Let's narrow our request to just the config namespace, and see if that helps.
 		watcher, err := f(kCli.configNamespace.String()).Watch(metav1.ListOptions{LabelSelector: ls.String()})
@@ -50,21 +52,23 @@ func (kCli K8sClient) makeWatcher(f watcherFactory, ls labels.Selector) (watch.I
 	return nil, fmt.Errorf("%s, Reason: %s, Code: %d", status.Message, status.Reason, status.Code)
 }
 
-func (kCli K8sClient) WatchPods(ctx context.Context, ls labels.Selector) (<-chan *v1.Pod, error) {
+func (kCli K8sClient) WatchPods(ctx context.Context, ns Namespace, ls labels.Selector) (<-chan *v1.Pod, error) {
 	// HACK(dmiller): There's no way to get errors out of an informer. See https://github.com/kubernetes/client-go/issues/155
 	// In the meantime, at least to get authorization and some other errors let's try to set up a watcher and then just
 	// throw it away.
 	watcher, err := kCli.makeWatcher(func(ns string) watcher {
 		return kCli.core.Pods(ns)
-	}, ls)
+	}, ns, ls)
 	if err != nil {
 		return nil, errors.Wrap(err, "pods.WatchFiles")
 	}
 	watcher.Stop()
 
-	factory := informers.NewSharedInformerFactoryWithOptions(kCli.clientSet, 5*time.Second, informers.WithTweakListOptions(func(o *metav1.ListOptions) {
-		o.LabelSelector = ls.String()
-	}))
+	factory := informers.NewSharedInformerFactoryWithOptions(kCli.clientSet, 5*time.Second,
+		informers.WithNamespace(string(ns)),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = ls.String()
+		}))
 	informer := factory.Core().V1().Pods().Informer()
 
 	stopper := make(chan struct{})
@@ -110,7 +114,83 @@ func (kCli K8sClient) WatchPods(ctx context.Context, ls labels.Selector) (<-chan
 	return ch, nil
 }
 
-func (kCli K8sClient) WatchServices(ctx context.Context, lps []model.LabelPair) (<-chan *v1.Service, error) {
+func (kCli K8sClient) WatchJobs(ctx context.Context, ns Namespace, lps []model.LabelPair) (<-chan *batchv1.Job, error) {
+	ch := make(chan *batchv1.Job)
+
+	ls := labels.Set{}
+	for _, lp := range lps {
+		ls[lp.Key] = lp.Value
+	}
+
+	watcher, err := kCli.makeWatcher(func(ns string) watcher {
+		return kCli.clientSet.BatchV1().Jobs(ns)
+	}, ns, ls.AsSelector())
+	if err != nil {
+		return nil, errors.Wrap(err, "Jobs.WatchFiles")
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					close(ch)
+					return
+				}
+
+				job, ok := event.Object.(*batchv1.Job)
+				if !ok {
+					continue
+				}
+
+				ch <- job
+			case <-ctx.Done():
+				watcher.Stop()
+				close(ch)
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (kCli K8sClient) WatchEvents(ctx context.Context, ns Namespace) (<-chan *v1.Event, error) {
+	watcher, err := kCli.makeWatcher(func(ns string) watcher {
+		return kCli.core.Events(ns)
+	}, ns, labels.Everything())
+	if err != nil {
+		return nil, errors.Wrap(err, "Events.WatchFiles")
+	}
+
+	ch := make(chan *v1.Event)
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					close(ch)
+					return
+				}
+
+				k8sEvent, ok := event.Object.(*v1.Event)
+				if !ok {
+					continue
+				}
+
+				ch <- k8sEvent
+			case <-ctx.Done():
+				watcher.Stop()
+				close(ch)
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (kCli K8sClient) WatchServices(ctx context.Context, ns Namespace, lps []model.LabelPair) (<-chan *v1.Service, error) {
 	ch := make(chan *v1.Service)
 
 	ls := labels.Set{}
@@ -120,7 +200,7 @@ func (kCli K8sClient) WatchServices(ctx context.Context, lps []model.LabelPair)
 
 	watcher, err := kCli.makeWatcher(func(ns string) watcher {
 		return kCli.core.Services(ns)
-	}, ls.AsSelector())
+	}, ns, ls.AsSelector())
 	if err != nil {
 		return nil, errors.Wrap(err, "Services.WatchFiles")
 	}
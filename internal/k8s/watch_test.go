@@ -13,6 +13,8 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 
 	v1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -88,17 +90,113 @@ func TestK8sClient_WatchPodsError(t *testing.T) {
 			Code:    http.StatusForbidden,
 		},
 	}
-	_, err := tf.kCli.WatchPods(tf.ctx, labels.Set{}.AsSelector())
+	_, err := tf.kCli.WatchPods(tf.ctx, "", labels.Set{}.AsSelector())
 	if assert.Error(t, err) {
 		assert.Contains(t, err.Error(), "Forbidden")
 	}
 }
 
+func TestK8sClient_WatchServicesInNamespace(t *testing.T) {
+	tf := newWatchTestFixture(t)
+	_, err := tf.kCli.WatchServices(tf.ctx, "dev-jane", []model.LabelPair{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "dev-jane", tf.watchNamespace)
+	}
+}
+
+func TestK8sClient_WatchEvents(t *testing.T) {
+	tf := newWatchTestFixture(t)
+
+	event := corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "foo.123"}, Reason: "Failed"}
+	pod := fakePod(PodID("abcd"), "efgh")
+	tf.w.Add(&event)
+	tf.w.Add(&pod)
+	tf.w.Stop()
+
+	ch, err := tf.kCli.WatchEvents(tf.ctx, "dev-jane")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "dev-jane", tf.watchNamespace)
+
+	var observed []*corev1.Event
+	timeout := time.After(500 * time.Millisecond)
+	for done := false; !done; {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				done = true
+			} else {
+				observed = append(observed, e)
+			}
+		case <-timeout:
+			t.Fatal("test timed out")
+		}
+	}
+
+	assert.Equal(t, []*corev1.Event{&event}, observed)
+}
+
+func TestK8sClient_WatchJobs(t *testing.T) {
+	tf := newWatchTestFixture(t)
+
+	job := batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "migrate"}}
+	pod := fakePod(PodID("abcd"), "efgh")
+	tf.w.Add(&job)
+	tf.w.Add(&pod)
+	tf.w.Stop()
+
+	ch, err := tf.kCli.WatchJobs(tf.ctx, "", []model.LabelPair{{Key: "foo", Value: "bar"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "foo=bar", tf.watchRestrictions.Labels.String())
+
+	var observed []*batchv1.Job
+	timeout := time.After(500 * time.Millisecond)
+	for done := false; !done; {
+		select {
+		case j, ok := <-ch:
+			if !ok {
+				done = true
+			} else {
+				observed = append(observed, j)
+			}
+		case <-timeout:
+			t.Fatal("test timed out")
+		}
+	}
+
+	assert.Equal(t, []*batchv1.Job{&job}, observed)
+}
+
+func TestK8sClient_WatchPodsInNamespaceNoFallback(t *testing.T) {
+	tf := newWatchTestFixture(t)
+	tf.kCli = K8sClient{
+		core:            tf.kCli.(K8sClient).core,
+		clientSet:       tf.kCli.(K8sClient).clientSet,
+		configNamespace: "other",
+	}
+	tf.watchErr = &errors.StatusError{
+		ErrStatus: metav1.Status{
+			Message: "unknown",
+			Reason:  "Forbidden",
+			Code:    http.StatusForbidden,
+		},
+	}
+	_, err := tf.kCli.WatchPods(tf.ctx, "dev-jane", labels.Set{}.AsSelector())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Forbidden")
+	}
+	assert.Equal(t, "dev-jane", tf.watchNamespace)
+}
+
 type watchTestFixture struct {
 	t                 *testing.T
 	kCli              Client
 	w                 *watch.FakeWatcher
 	watchRestrictions k8stesting.WatchRestrictions
+	watchNamespace    string
 	ctx               context.Context
 	watchErr          error
 }
@@ -115,6 +213,7 @@ func newWatchTestFixture(t *testing.T) *watchTestFixture {
 	wr := func(action k8stesting.Action) (handled bool, wi watch.Interface, err error) {
 		wa := action.(k8stesting.WatchAction)
 		ret.watchRestrictions = wa.GetWatchRestrictions()
+		ret.watchNamespace = wa.GetNamespace()
 		if ret.watchErr != nil {
 			return true, nil, ret.watchErr
 		}
@@ -141,7 +240,7 @@ func (tf *watchTestFixture) runPods(input []runtime.Object, expectedOutput []run
 
 	tf.w.Stop()
 
-	ch, err := tf.kCli.WatchPods(tf.ctx, labels.Set{}.AsSelector())
+	ch, err := tf.kCli.WatchPods(tf.ctx, "", labels.Set{}.AsSelector())
 	if !assert.NoError(tf.t, err) {
 		return
 	}
@@ -177,7 +276,7 @@ func (tf *watchTestFixture) runServices(input []runtime.Object, expectedOutput [
 
 	tf.w.Stop()
 
-	ch, err := tf.kCli.WatchServices(tf.ctx, []model.LabelPair{})
+	ch, err := tf.kCli.WatchServices(tf.ctx, "", []model.LabelPair{})
 	if !assert.NoError(tf.t, err) {
 		return
 	}
@@ -203,7 +302,7 @@ func (tf *watchTestFixture) runServices(input []runtime.Object, expectedOutput [
 }
 
 func (tf *watchTestFixture) testPodLabels(input labels.Set, expectedLabels labels.Set) {
-	_, err := tf.kCli.WatchPods(tf.ctx, input.AsSelector())
+	_, err := tf.kCli.WatchPods(tf.ctx, "", input.AsSelector())
 	if !assert.NoError(tf.t, err) {
 		return
 	}
@@ -212,7 +311,7 @@ func (tf *watchTestFixture) testPodLabels(input labels.Set, expectedLabels label
 }
 
 func (tf *watchTestFixture) testServiceLabels(input []model.LabelPair, expectedLabels []model.LabelPair) {
-	_, err := tf.kCli.WatchServices(tf.ctx, input)
+	_, err := tf.kCli.WatchServices(tf.ctx, "", input)
 	if !assert.NoError(tf.t, err) {
 		return
 	}
@@ -151,6 +151,13 @@ type K8sTarget struct {
 	ExtraPodSelectors []labels.Selector
 	ResourceNames     []string
 
+	// Whether the workload is a Job, which runs to completion rather than staying up.
+	IsJob bool
+
+	// Whether none of the objects run pods (e.g., only Services, ConfigMaps,
+	// or CRDs), so there's no pod to wait for before the resource is ready.
+	NoPods bool
+
 	dependencyIDs []TargetID
 }
 
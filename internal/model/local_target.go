@@ -0,0 +1,86 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/windmilleng/tilt/internal/sliceutils"
+)
+
+// A command that runs on the host machine, e.g., a code generator
+// or a database migration.
+type LocalTarget struct {
+	Name     TargetName
+	Cmd      Cmd    // run once per build, e.g., to generate code
+	ServeCmd Cmd    // long-running process, restarted after every build
+	Workdir  string // directory from which the commands should be run
+
+	deps  []string // ABSOLUTE file paths that this target depends on
+	repos []LocalGitRepo
+}
+
+var _ TargetSpec = LocalTarget{}
+
+func NewLocalTarget(name TargetName, cmd Cmd, workdir string, deps []string) LocalTarget {
+	return LocalTarget{
+		Name:    name,
+		Cmd:     cmd,
+		Workdir: workdir,
+		deps:    sliceutils.DedupedAndSorted(deps),
+	}
+}
+
+func (lt LocalTarget) WithServeCmd(cmd Cmd) LocalTarget {
+	lt.ServeCmd = cmd
+	return lt
+}
+
+func (lt LocalTarget) WithRepos(repos []LocalGitRepo) LocalTarget {
+	lt.repos = append([]LocalGitRepo{}, repos...)
+	return lt
+}
+
+func (lt LocalTarget) ID() TargetID {
+	return TargetID{
+		Type: TargetTypeLocal,
+		Name: lt.Name,
+	}
+}
+
+func (lt LocalTarget) Empty() bool { return lt.ID().Empty() }
+
+func (lt LocalTarget) DependencyIDs() []TargetID {
+	return nil
+}
+
+func (lt LocalTarget) Validate() error {
+	if lt.ID().Empty() {
+		return fmt.Errorf("[Validate] LocalTarget missing name")
+	}
+
+	if lt.Cmd.Empty() && lt.ServeCmd.Empty() {
+		return fmt.Errorf("[Validate] LocalTarget %q must have a command or a serve command", lt.Name)
+	}
+
+	if lt.Workdir == "" {
+		return fmt.Errorf("[Validate] LocalTarget %q missing workdir", lt.Name)
+	}
+
+	return nil
+}
+
+// Implements: engine.WatchableTarget
+func (lt LocalTarget) Dependencies() []string {
+	return append([]string{}, lt.deps...)
+}
+
+func (lt LocalTarget) LocalRepos() []LocalGitRepo {
+	return lt.repos
+}
+
+func (lt LocalTarget) Dockerignores() []Dockerignore {
+	return nil
+}
+
+func (lt LocalTarget) IgnoredLocalDirectories() []string {
+	return nil
+}
@@ -30,6 +30,18 @@ type Manifest struct {
 
 	// Info needed to deploy. Can be k8s yaml, docker compose, etc.
 	deployTarget TargetSpec
+
+	// Other manifests that must be deployed and ready before this one
+	// starts its first build.
+	ResourceDependencies []ManifestName
+
+	// Checks whether this manifest is ready to serve, beyond what
+	// its deploy target reports. May be nil.
+	ReadinessProbe *Probe
+
+	// Whether this manifest builds automatically when its files change,
+	// or waits for the user to trigger it.
+	TriggerMode TriggerMode
 }
 
 func (m Manifest) ID() TargetID {
@@ -47,9 +59,27 @@ func (m Manifest) DependencyIDs() []TargetID {
 	if !m.deployTarget.ID().Empty() {
 		result = append(result, m.deployTarget.ID())
 	}
+	for _, dep := range m.ResourceDependencies {
+		result = append(result, TargetID{Type: TargetTypeManifest, Name: dep.TargetName()})
+	}
 	return result
 }
 
+func (m Manifest) WithReadinessProbe(p *Probe) Manifest {
+	m.ReadinessProbe = p
+	return m
+}
+
+func (m Manifest) WithTriggerMode(mode TriggerMode) Manifest {
+	m.TriggerMode = mode
+	return m
+}
+
+func (m Manifest) WithResourceDependencies(deps []ManifestName) Manifest {
+	m.ResourceDependencies = append([]ManifestName{}, deps...)
+	return m
+}
+
 func (m Manifest) WithImageTarget(iTarget ImageTarget) Manifest {
 	m.ImageTargets = []ImageTarget{iTarget}
 	return m
@@ -89,6 +119,16 @@ func (m Manifest) IsK8s() bool {
 	return ok
 }
 
+func (m Manifest) LocalTarget() LocalTarget {
+	ret, _ := m.deployTarget.(LocalTarget)
+	return ret
+}
+
+func (m Manifest) IsLocal() bool {
+	_, ok := m.deployTarget.(LocalTarget)
+	return ok
+}
+
 func (m Manifest) DeployTarget() TargetSpec {
 	return m.deployTarget
 }
@@ -101,6 +141,9 @@ func (m Manifest) WithDeployTarget(t TargetSpec) Manifest {
 	case DockerComposeTarget:
 		typedTarget.Name = m.Name.TargetName()
 		t = typedTarget
+	case LocalTarget:
+		typedTarget.Name = m.Name.TargetName()
+		t = typedTarget
 	}
 	m.deployTarget = t
 	return m
@@ -111,6 +154,8 @@ func (m Manifest) LocalPaths() []string {
 	switch di := m.deployTarget.(type) {
 	case DockerComposeTarget:
 		return di.LocalPaths()
+	case LocalTarget:
+		return di.Dependencies()
 	default:
 		paths := []string{}
 		for _, iTarget := range m.ImageTargets {
@@ -139,11 +184,20 @@ func (m Manifest) Validate() error {
 		}
 	}
 
+	if m.ReadinessProbe != nil {
+		err := m.ReadinessProbe.Validate()
+		if err != nil {
+			return fmt.Errorf("[validate] manifest %s: %v", m.Name, err)
+		}
+	}
+
 	return nil
 }
 
 func (m1 Manifest) Equal(m2 Manifest) bool {
-	primitivesMatch := m1.Name == m2.Name
+	primitivesMatch := m1.Name == m2.Name && m1.TriggerMode == m2.TriggerMode
+	resourceDepsEqual := DeepEqual(m1.ResourceDependencies, m2.ResourceDependencies)
+	probeEqual := DeepEqual(m1.ReadinessProbe, m2.ReadinessProbe)
 	dockerEqual := DeepEqual(m1.ImageTargets, m2.ImageTargets)
 
 	dc1 := m1.DockerComposeTarget()
@@ -154,10 +208,17 @@ func (m1 Manifest) Equal(m2 Manifest) bool {
 	k8s2 := m2.K8sTarget()
 	k8sEqual := DeepEqual(k8s1, k8s2)
 
+	local1 := m1.LocalTarget()
+	local2 := m2.LocalTarget()
+	localEqual := DeepEqual(local1, local2)
+
 	return primitivesMatch &&
+		resourceDepsEqual &&
+		probeEqual &&
 		dockerEqual &&
 		dockerComposeEqual &&
-		k8sEqual
+		k8sEqual &&
+		localEqual
 }
 
 func (m Manifest) ManifestName() ManifestName {
@@ -308,6 +369,7 @@ var imageTargetAllowUnexported = cmp.AllowUnexported(ImageTarget{})
 var dcTargetAllowUnexported = cmp.AllowUnexported(DockerComposeTarget{})
 var labelRequirementAllowUnexported = cmp.AllowUnexported(labels.Requirement{})
 var k8sTargetAllowUnexported = cmp.AllowUnexported(K8sTarget{})
+var localTargetAllowUnexported = cmp.AllowUnexported(LocalTarget{})
 var selectorAllowUnexported = cmp.AllowUnexported(container.RefSelector{})
 
 var dockerRefEqual = cmp.Comparer(func(a, b reference.Named) bool {
@@ -331,6 +393,7 @@ func DeepEqual(x, y interface{}) bool {
 		dcTargetAllowUnexported,
 		labelRequirementAllowUnexported,
 		k8sTargetAllowUnexported,
+		localTargetAllowUnexported,
 		selectorAllowUnexported,
 		dockerRefEqual)
 }
@@ -2,6 +2,7 @@ package model
 
 import (
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/labels"
 
@@ -349,6 +350,41 @@ var equalitytests = []struct {
 		}),
 		false,
 	},
+	{
+		Manifest{ResourceDependencies: []ManifestName{"db"}},
+		Manifest{ResourceDependencies: []ManifestName{"db"}},
+		true,
+	},
+	{
+		Manifest{}.WithDeployTarget(NewLocalTarget("foo", ToShellCmd("make"), "/src", []string{"/src/a"})),
+		Manifest{}.WithDeployTarget(NewLocalTarget("foo", ToShellCmd("make"), "/src", []string{"/src/a"})),
+		true,
+	},
+	{
+		Manifest{}.WithDeployTarget(NewLocalTarget("foo", ToShellCmd("make"), "/src", []string{"/src/a"})),
+		Manifest{}.WithDeployTarget(NewLocalTarget("foo", ToShellCmd("make"), "/src", []string{"/src/b"})),
+		false,
+	},
+	{
+		Manifest{}.WithDeployTarget(NewLocalTarget("foo", Cmd{}, "/src", nil).WithServeCmd(ToShellCmd("go run ."))),
+		Manifest{}.WithDeployTarget(NewLocalTarget("foo", Cmd{}, "/src", nil).WithServeCmd(ToShellCmd("go run ./cmd"))),
+		false,
+	},
+	{
+		Manifest{ResourceDependencies: []ManifestName{"db"}},
+		Manifest{ResourceDependencies: []ManifestName{"redis"}},
+		false,
+	},
+	{
+		Manifest{ReadinessProbe: &Probe{Period: time.Second, HTTPGet: &HTTPGetAction{Host: "localhost", Port: 8000, Path: "/"}}},
+		Manifest{ReadinessProbe: &Probe{Period: time.Second, HTTPGet: &HTTPGetAction{Host: "localhost", Port: 8000, Path: "/"}}},
+		true,
+	},
+	{
+		Manifest{ReadinessProbe: &Probe{Period: time.Second, HTTPGet: &HTTPGetAction{Host: "localhost", Port: 8000, Path: "/"}}},
+		Manifest{ReadinessProbe: &Probe{Period: time.Second, TCPSocket: &TCPSocketAction{Host: "localhost", Port: 8000}}},
+		false,
+	},
 }
 
 func TestManifestEquality(t *testing.T) {
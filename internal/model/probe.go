@@ -0,0 +1,77 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const DefaultProbePeriod = 10 * time.Second
+const DefaultProbeTimeout = time.Second
+
+// Checks whether a resource is ready to serve, modeled after Kubernetes
+// readiness probes. Exactly one of the actions should be set.
+type Probe struct {
+	InitialDelay time.Duration
+	Period       time.Duration
+	Timeout      time.Duration
+
+	HTTPGet   *HTTPGetAction
+	TCPSocket *TCPSocketAction
+	Exec      *ExecAction
+}
+
+// Succeeds if a GET request returns a 2xx or 3xx status code.
+type HTTPGetAction struct {
+	Host string
+	Port int
+	Path string
+}
+
+func (a HTTPGetAction) URL() string {
+	return fmt.Sprintf("http://%s:%d%s", a.Host, a.Port, a.Path)
+}
+
+// Succeeds if a TCP connection can be opened.
+type TCPSocketAction struct {
+	Host string
+	Port int
+}
+
+// Succeeds if the command exits with status 0.
+type ExecAction struct {
+	Command Cmd
+}
+
+func (p Probe) Validate() error {
+	actions := 0
+	if p.HTTPGet != nil {
+		actions++
+	}
+	if p.TCPSocket != nil {
+		actions++
+	}
+	if p.Exec != nil {
+		actions++
+	}
+	if actions != 1 {
+		return fmt.Errorf("probe must have exactly one action (http_get, tcp_socket or exec); got %d", actions)
+	}
+	if p.Period <= 0 {
+		return fmt.Errorf("probe period must be positive; got %s", p.Period)
+	}
+	if p.Timeout <= 0 {
+		return fmt.Errorf("probe timeout must be positive; got %s", p.Timeout)
+	}
+	return nil
+}
+
+// Whether a resource is ready to serve, according to its readiness probe
+// or the Kubernetes readiness condition.
+type Readiness string
+
+const (
+	// We have nothing to report (e.g., the resource hasn't been deployed yet).
+	ReadinessUnknown  Readiness = ""
+	ReadinessReady    Readiness = "ready"
+	ReadinessNotReady Readiness = "not ready"
+)
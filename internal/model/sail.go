@@ -15,3 +15,21 @@ func (u SailURL) String() string {
 func (u SailURL) Empty() bool {
 	return SailURL{} == u
 }
+
+type RoomID string
+
+// The first message that the Sail server sends to a source,
+// telling it the room that followers can join to watch.
+//
+// The server sends it again whenever the source revokes followers,
+// because revoking changes the follower token.
+type SailRoomConnected struct {
+	RoomID RoomID
+
+	// Followers must present this token to join the room.
+	FollowerToken string
+
+	// Only the source knows this token. The source presents it to
+	// revoke followers or close the room.
+	SecretToken string
+}
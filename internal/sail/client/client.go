@@ -2,7 +2,12 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -14,17 +19,26 @@ import (
 )
 
 type SailClient struct {
-	addr     model.SailURL
-	dialer   SailDialer
-	conn     SailConn
-	mu       sync.Mutex
-	initDone bool
+	addr       model.SailURL
+	dialer     SailDialer
+	httpClient *http.Client
+	history    *webview.ViewHistory
+	conn       SailConn
+	room       model.SailRoomConnected
+	mu         sync.Mutex
+	initDone   bool
+
+	// The last view update that we sent on this connection.
+	sentStreamID string
+	sentSeq      int
 }
 
 func ProvideSailClient(dialer SailDialer, addr model.SailURL) *SailClient {
 	return &SailClient{
-		addr:   addr,
-		dialer: dialer,
+		addr:       addr,
+		dialer:     dialer,
+		httpClient: http.DefaultClient,
+		history:    webview.NewViewHistory(),
 	}
 }
 
@@ -42,6 +56,7 @@ func (s *SailClient) disconnect() {
 
 	_ = s.conn.Close()
 	s.conn = nil
+	s.room = model.SailRoomConnected{}
 }
 
 func (s *SailClient) isConnected() bool {
@@ -50,16 +65,22 @@ func (s *SailClient) isConnected() bool {
 	return s.conn != nil
 }
 
-func (s *SailClient) broadcast(ctx context.Context, view webview.View) {
+// Sends the server the view updates that it hasn't seen yet.
+func (s *SailClient) broadcast(ctx context.Context) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.conn == nil {
 		return
 	}
 
-	err := s.conn.WriteJSON(view)
-	if err != nil {
-		logger.Get(ctx).Infof("broadcast(%s): %v", s.addr, err)
+	for _, update := range s.history.Since(s.sentStreamID, s.sentSeq) {
+		err := s.conn.WriteJSON(update)
+		if err != nil {
+			logger.Get(ctx).Infof("broadcast(%s): %v", s.addr, err)
+			return
+		}
+		s.sentStreamID = update.StreamID
+		s.sentSeq = update.Seq
 	}
 }
 
@@ -68,21 +89,105 @@ func (s *SailClient) setConnection(ctx context.Context, conn SailConn) {
 	defer s.mu.Unlock()
 	s.conn = conn
 
+	// A new connection starts with a snapshot.
+	s.sentStreamID = ""
+	s.sentSeq = 0
+
 	// set up socket control handling
 	go func() {
 		defer s.disconnect()
 
 		for ctx.Err() == nil {
 			// We need to read from the connection so that the websocket
-			// library handles control messages, but we can otherwise discard them.
-			if _, _, err := conn.NextReader(); err != nil {
+			// library handles control messages.
+			_, r, err := conn.NextReader()
+			if err != nil {
 				logger.Get(ctx).Infof("SailClient connection: %v", err)
 				return
 			}
+
+			// The only message the server sends us is the room we're broadcasting to.
+			var msg model.SailRoomConnected
+			err = json.NewDecoder(r).Decode(&msg)
+			if err != nil {
+				logger.Get(ctx).Infof("SailClient: unexpected message: %v", err)
+				continue
+			}
+			s.setRoom(ctx, msg)
 		}
 	}()
 }
 
+func (s *SailClient) setRoom(ctx context.Context, room model.SailRoomConnected) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.room = room
+
+	// Never log the secret token. Followers see our logs.
+	logger.Get(ctx).Infof("Sharing this Tilt session. Teammates can follow along at: %s", s.followURL(room))
+}
+
+// The URL of the websocket that followers connect to.
+func (s *SailClient) followURL(room model.SailRoomConnected) string {
+	u := s.addr
+	u.Path = fmt.Sprintf("/join/%s", room.RoomID)
+	u.RawQuery = url.Values{"token": []string{room.FollowerToken}}.Encode()
+	return u.String()
+}
+
+// Disconnects everyone following this session, and changes the follow URL
+// so that they can't rejoin.
+func (s *SailClient) RevokeFollowers(ctx context.Context) error {
+	return s.manageRoom(ctx, "revoke")
+}
+
+// Stops sharing this session, and disconnects everyone following it.
+func (s *SailClient) CloseRoom(ctx context.Context) error {
+	return s.manageRoom(ctx, "close")
+}
+
+func (s *SailClient) manageRoom(ctx context.Context, action string) error {
+	s.mu.Lock()
+	room := s.room
+	s.mu.Unlock()
+
+	if room.RoomID == "" {
+		return fmt.Errorf("This Tilt session isn't shared in a Sail room")
+	}
+
+	// The Sail server handles plain HTTP requests on the same
+	// host as its websockets.
+	u := s.addr
+	switch u.Scheme {
+	case "wss":
+		u.Scheme = "https"
+	case "ws":
+		u.Scheme = "http"
+	}
+	u.Path = fmt.Sprintf("/room/%s/%s", room.RoomID, action)
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+room.SecretToken)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "%s room %s", action, room.RoomID)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s room %s: %s", action, room.RoomID, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
 func (s *SailClient) Connect(ctx context.Context) error {
 	header := make(http.Header)
 	header.Add("Origin", s.addr.String())
@@ -122,11 +227,18 @@ func (s *SailClient) OnChange(ctx context.Context, st store.RStore) {
 		return
 	}
 
-	state := st.RLockState()
-	view := server.StateToWebView(state)
-	st.RUnlockState()
+	err := s.history.Update(func() webview.View {
+		state := st.RLockState()
+		defer st.RUnlockState()
+		return server.StateToWebView(state)
+	})
+	if err != nil {
+		logger.Get(ctx).Infof("SailClient: %v", err)
+		return
+	}
 
-	s.broadcast(ctx, view)
+	s.broadcast(ctx)
 }
 
 var _ store.SubscriberLifecycle = &SailClient{}
+var _ server.SailRoom = &SailClient{}
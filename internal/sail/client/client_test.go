@@ -2,11 +2,17 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/windmilleng/tilt/internal/hud/view"
 	"github.com/windmilleng/tilt/internal/hud/webview"
@@ -21,22 +27,116 @@ func TestBroadcast(t *testing.T) {
 
 	f.client.OnChange(f.ctx, f.store)
 
-	assert.Equal(t, 1, len(f.conn().json.(webview.View).Resources))
-	assert.Equal(t, view.TiltfileResourceName, f.conn().json.(webview.View).Resources[0].Name.String())
+	// The first update is a snapshot of the whole view.
+	snapshot := f.conn().json.(webview.ViewUpdate)
+	if assert.NotNil(t, snapshot.Snapshot) {
+		assert.Contains(t, f.marshal(snapshot.Snapshot), fmt.Sprintf(`"Name":"%s"`, view.TiltfileResourceName))
+	}
 
 	state := f.store.LockMutableStateForTesting()
 	state.UpsertManifestTarget(store.NewManifestTarget(model.Manifest{Name: "fe"}))
 	f.store.UnlockMutableState()
 
+	// After that, we only send what changed.
+	f.client.OnChange(f.ctx, f.store)
+	delta := f.conn().json.(webview.ViewUpdate)
+	assert.Equal(t, snapshot.Seq+1, delta.Seq)
+	if assert.NotNil(t, delta.Delta) {
+		assert.Equal(t, []model.ManifestName{view.TiltfileResourceName, "fe"}, delta.Delta.ResourceNames)
+		assert.Equal(t, []model.ManifestName{"fe"}, mapKeys(delta.Delta.Resources))
+	}
+
+	// Nothing changed, so we don't send anything.
+	f.conn().json = nil
+	f.client.OnChange(f.ctx, f.store)
+	assert.Nil(t, f.conn().json)
+}
+
+func TestBroadcastSnapshotOnReconnect(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
 	f.client.OnChange(f.ctx, f.store)
-	assert.Equal(t, 2, len(f.conn().json.(webview.View).Resources))
+	f.client.OnChange(f.ctx, f.store)
+
+	f.client.disconnect()
+	err := f.client.Connect(f.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.client.OnChange(f.ctx, f.store)
+	assert.NotNil(t, f.conn().json.(webview.ViewUpdate).Snapshot)
+}
+
+func TestRoomConnected(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.connectToRoom()
+
+	assert.Equal(t, model.RoomID("some-room"), f.room().RoomID)
+	assert.Equal(t, "ws://localhost:12345/join/some-room?token=follow-me", f.client.followURL(f.room()))
+}
+
+func TestRevokeFollowers(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.connectToRoom()
+	s := f.startSailServer(http.StatusOK)
+
+	err := f.client.RevokeFollowers(f.ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/room/some-room/revoke", s.path)
+		assert.Equal(t, "Bearer shh", s.auth)
+	}
+}
+
+func TestCloseRoom(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.connectToRoom()
+	s := f.startSailServer(http.StatusOK)
+
+	err := f.client.CloseRoom(f.ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/room/some-room/close", s.path)
+		assert.Equal(t, "Bearer shh", s.auth)
+	}
+}
+
+func TestCloseRoomError(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.connectToRoom()
+	f.startSailServer(http.StatusUnauthorized)
+
+	err := f.client.CloseRoom(f.ctx)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "close room some-room: Invalid secret token")
+	}
+}
+
+func TestCloseRoomNotShared(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	err := f.client.CloseRoom(f.ctx)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "isn't shared")
+	}
 }
 
 type fixture struct {
-	ctx    context.Context
-	cancel func()
-	client *SailClient
-	store  *store.Store
+	t        *testing.T
+	ctx      context.Context
+	cancel   func()
+	client   *SailClient
+	store    *store.Store
+	cleanups []func()
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -50,6 +150,7 @@ func newFixture(t *testing.T) *fixture {
 
 	client := ProvideSailClient(fakeSailDialer{}, model.SailURL(*url))
 	return &fixture{
+		t:      t,
 		ctx:    ctx,
 		cancel: cancel,
 		client: client,
@@ -61,21 +162,88 @@ func (f *fixture) conn() *fakeSailConn {
 	return f.client.conn.(*fakeSailConn)
 }
 
+func (f *fixture) room() model.SailRoomConnected {
+	f.client.mu.Lock()
+	defer f.client.mu.Unlock()
+	return f.client.room
+}
+
+// Connects to the Sail server, and waits for it to tell us our room.
+func (f *fixture) connectToRoom() {
+	f.client.OnChange(f.ctx, f.store)
+	f.conn().incoming <- `{"RoomID": "some-room", "FollowerToken": "follow-me", "SecretToken": "shh"}`
+
+	for i := 0; i < 20 && f.room().RoomID == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if f.room().RoomID == "" {
+		f.t.Fatal("never connected to room")
+	}
+}
+
+// Points the client at a fake Sail server that records the last request.
+func (f *fixture) startSailServer(status int) *fakeSailServer {
+	s := &fakeSailServer{status: status}
+	server := httptest.NewServer(s)
+	f.cleanups = append(f.cleanups, server.Close)
+
+	u, err := url.Parse(strings.Replace(server.URL, "http://", "ws://", 1))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.client.addr = model.SailURL(*u)
+	return s
+}
+
+func (f *fixture) marshal(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return string(data)
+}
+
+func mapKeys(m map[model.ManifestName]map[string]webview.FieldDelta) []model.ManifestName {
+	var result []model.ManifestName
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
+
 func (f *fixture) TearDown() {
+	for _, cleanup := range f.cleanups {
+		cleanup()
+	}
 	f.cancel()
 }
 
+type fakeSailServer struct {
+	status int
+	path   string
+	auth   string
+}
+
+func (s *fakeSailServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.path = req.URL.Path
+	s.auth = req.Header.Get("Authorization")
+	if s.status != http.StatusOK {
+		http.Error(w, "Invalid secret token", s.status)
+	}
+}
+
 type fakeSailDialer struct {
 }
 
 func (d fakeSailDialer) DialContext(ctx context.Context, addr string, headers http.Header) (SailConn, error) {
-	return &fakeSailConn{ctx: ctx}, nil
+	return &fakeSailConn{ctx: ctx, incoming: make(chan string)}, nil
 }
 
 type fakeSailConn struct {
-	ctx    context.Context
-	json   interface{}
-	closed bool
+	ctx      context.Context
+	json     interface{}
+	closed   bool
+	incoming chan string
 }
 
 func (c *fakeSailConn) WriteJSON(v interface{}) error {
@@ -84,8 +252,12 @@ func (c *fakeSailConn) WriteJSON(v interface{}) error {
 }
 
 func (c *fakeSailConn) NextReader() (int, io.Reader, error) {
-	<-c.ctx.Done()
-	return 0, nil, c.ctx.Err()
+	select {
+	case msg := <-c.incoming:
+		return websocket.TextMessage, strings.NewReader(msg), nil
+	case <-c.ctx.Done():
+		return 0, nil, c.ctx.Err()
+	}
 }
 
 func (c *fakeSailConn) Close() error {
@@ -1,8 +1,13 @@
 package client
 
-import "github.com/google/wire"
+import (
+	"github.com/google/wire"
+
+	"github.com/windmilleng/tilt/internal/hud/server"
+)
 
 var SailWireSet = wire.NewSet(
 	ProvideSailClient,
 	ProvideSailDialer,
+	wire.Bind(new(server.SailRoom), new(SailClient)),
 )
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/windmilleng/tilt/internal/model"
@@ -11,6 +12,7 @@ import (
 )
 
 var port = 0
+var roomIdleTimeout time.Duration
 
 func Execute() {
 	rootCmd := &cobra.Command{
@@ -19,6 +21,7 @@ func Execute() {
 		Run:   run,
 	}
 	rootCmd.Flags().IntVar(&port, "port", model.DefaultSailPort, "Port to listen on")
+	rootCmd.Flags().DurationVar(&roomIdleTimeout, "room-idle-timeout", server.DefaultRoomIdleTimeout, "Close rooms that haven't had an update in this long. Set to 0 to keep rooms open until the source disconnects")
 
 	err := rootCmd.Execute()
 	if err != nil {
@@ -27,7 +30,7 @@ func Execute() {
 }
 
 func run(cmd *cobra.Command, args []string) {
-	ss := server.ProvideSailServer()
+	ss := server.ProvideSailServer(roomIdleTimeout)
 	httpServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
 		Handler: http.DefaultServeMux,
@@ -2,40 +2,120 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+
+	"github.com/windmilleng/tilt/internal/hud/webview"
+	"github.com/windmilleng/tilt/internal/model"
 )
 
-type RoomID string
+// How long we wait for a follower to take a message before we give up on it.
+// We write to followers while holding the room's lock, so a follower that
+// stops reading mustn't hold up the source and everyone else for long.
+const followerWriteTimeout = 5 * time.Second
 
 // A room where messages from a source are broadcast to all the followers.
 type Room struct {
 	// Immutable data
-	id     RoomID
-	source SourceConn
+	id          model.RoomID
+	secret      string
+	source      SourceConn
+	idleTimeout time.Duration
+
+	// Mutable data, guarded by mu
+	mu            sync.Mutex
+	followerToken string
+	followers     map[FollowerConn]bool
+	closed        bool
+
+	// The view as of the last update from the source, so that
+	// followers who join later can start from a snapshot.
+	view     webview.ViewState
+	streamID string
+	seq      int
 }
 
 // A websocket that we only read messages from
 type SourceConn interface {
 	ReadMessage() (int, []byte, error)
+	WriteJSON(v interface{}) error
 	Close() error
 }
 
 var _ SourceConn = &websocket.Conn{}
 
-func NewRoom(conn SourceConn) *Room {
+// A websocket that we only write messages to
+type FollowerConn interface {
+	WriteMessage(messageType int, data []byte) error
+	SetWriteDeadline(t time.Time) error
+	NextReader() (int, io.Reader, error)
+	Close() error
+}
+
+var _ FollowerConn = &websocket.Conn{}
+
+// Creates a new room for the source.
+//
+// If idleTimeout is non-zero, the room closes when the source
+// hasn't sent anything for that long.
+func NewRoom(conn SourceConn, idleTimeout time.Duration) (*Room, error) {
+	secret, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	followerToken, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Room{
-		id:     RoomID(uuid.New().String()),
-		source: conn,
+		id:            model.RoomID(uuid.New().String()),
+		secret:        secret,
+		source:        conn,
+		idleTimeout:   idleTimeout,
+		followerToken: followerToken,
+		followers:     make(map[FollowerConn]bool),
+	}, nil
+}
+
+// Generates an unguessable token for authenticating to a room.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("generating room token: %v", err)
 	}
+	return hex.EncodeToString(b), nil
+}
+
+func tokensEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
 }
 
 // Receive messages from the source websocket and put them through the state loop.
 func (r *Room) ConsumeSource(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Tell the source where it's broadcasting to, so that it can share the room.
+	err := r.sendConnected()
+	if err != nil {
+		r.close()
+		return err
+	}
 
+	activity := make(chan struct{})
 	go func() {
 		// Shutdown everything if the source shuts down
 		defer cancel()
@@ -47,15 +127,223 @@ func (r *Room) ConsumeSource(ctx context.Context) error {
 				return
 			}
 
-			log.Printf("Received new message: %s", string(data))
+			r.broadcast(data)
+
+			select {
+			case activity <- struct{}{}:
+			case <-ctx.Done():
+			}
 		}
 	}()
 
+	// A nil channel never fires, so rooms without a timeout never expire.
+	var idle <-chan time.Time
+	var timer *time.Timer
+	if r.idleTimeout > 0 {
+		timer = time.NewTimer(r.idleTimeout)
+		defer timer.Stop()
+		idle = timer.C
+	}
+
 	for {
 		select {
+		case <-activity:
+			if timer != nil {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(r.idleTimeout)
+			}
+		case <-idle:
+			r.close()
+			return fmt.Errorf("room %s expired after %s idle", r.id, r.idleTimeout)
 		case <-ctx.Done():
-			_ = r.source.Close()
+			r.close()
 			return ctx.Err()
 		}
 	}
 }
+
+// Sends the source the current tokens for the room.
+func (r *Room) sendConnected() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.source.WriteJSON(model.SailRoomConnected{
+		RoomID:        r.id,
+		FollowerToken: r.followerToken,
+		SecretToken:   r.secret,
+	})
+}
+
+// Sends a view update from the source to all the followers, and applies
+// it to the view that we send to followers who join later.
+func (r *Room) broadcast(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.applyUpdate(data)
+	if err != nil {
+		log.Printf("broadcast: %v", err)
+	}
+
+	for f := range r.followers {
+		err := writeToFollower(f, data)
+		if err != nil {
+			log.Printf("broadcast: %v", err)
+			delete(r.followers, f)
+			_ = f.Close()
+		}
+	}
+}
+
+func (r *Room) applyUpdate(data []byte) error {
+	var update webview.ViewUpdate
+	err := json.Unmarshal(data, &update)
+	if err != nil {
+		return fmt.Errorf("malformed view update: %v", err)
+	}
+
+	switch {
+	case update.Snapshot != nil:
+		r.view = *update.Snapshot
+	case update.Delta != nil:
+		if update.StreamID != r.streamID || update.Seq != r.seq+1 {
+			return fmt.Errorf("view update %s/%d doesn't follow %s/%d", update.StreamID, update.Seq, r.streamID, r.seq)
+		}
+
+		view, err := r.view.Apply(*update.Delta)
+		if err != nil {
+			return fmt.Errorf("applying view update: %v", err)
+		}
+		r.view = view
+	default:
+		return fmt.Errorf("view update has no snapshot or delta")
+	}
+
+	r.streamID = update.StreamID
+	r.seq = update.Seq
+	return nil
+}
+
+// Checks the token that a follower presented to join the room.
+func (r *Room) CanFollow(token string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return tokensEqual(token, r.followerToken)
+}
+
+// Checks the token that a client presented to manage the room.
+func (r *Room) IsSource(secret string) bool {
+	return tokensEqual(secret, r.secret)
+}
+
+// Adds a follower to the room, and sends it the latest view.
+func (r *Room) AddFollower(conn FollowerConn, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return fmt.Errorf("room %s is closed", r.id)
+	}
+
+	// The token may have been revoked since the follower connected.
+	if !tokensEqual(token, r.followerToken) {
+		return fmt.Errorf("invalid token for room %s", r.id)
+	}
+
+	if !r.view.Empty() {
+		view := r.view
+		data, err := json.Marshal(webview.ViewUpdate{
+			StreamID: r.streamID,
+			Seq:      r.seq,
+			Snapshot: &view,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = writeToFollower(conn, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.followers[conn] = true
+	return nil
+}
+
+// Writes a message to the follower, or fails if the follower
+// doesn't take it within followerWriteTimeout.
+func writeToFollower(conn FollowerConn, data []byte) error {
+	err := conn.SetWriteDeadline(time.Now().Add(followerWriteTimeout))
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Reads from the follower websocket until it disconnects, then removes it.
+//
+// Followers are read-only. We only read so that the websocket library
+// handles control messages, and so that we notice when the follower leaves.
+func (r *Room) ConsumeFollower(conn FollowerConn) {
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			r.removeFollower(conn)
+			return
+		}
+	}
+}
+
+func (r *Room) removeFollower(conn FollowerConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.followers[conn] {
+		delete(r.followers, conn)
+		_ = conn.Close()
+	}
+}
+
+// Disconnects all the followers, and changes the follower token so
+// that they can't rejoin. The source gets the new token, so that it
+// can share the room again with the people it trusts.
+func (r *Room) RevokeFollowers() error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return fmt.Errorf("room %s is closed", r.id)
+	}
+
+	r.followerToken = token
+	for f := range r.followers {
+		_ = f.Close()
+	}
+	r.followers = make(map[FollowerConn]bool)
+	r.mu.Unlock()
+
+	return r.sendConnected()
+}
+
+// Closes the source and all the followers.
+func (r *Room) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	r.closed = true
+	_ = r.source.Close()
+	for f := range r.followers {
+		_ = f.Close()
+	}
+	r.followers = make(map[FollowerConn]bool)
+}
@@ -1,12 +1,17 @@
 package server
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+
+	"github.com/windmilleng/tilt/internal/model"
 )
 
 var upgrader = websocket.Upgrader{
@@ -14,21 +19,30 @@ var upgrader = websocket.Upgrader{
 	WriteBufferSize: 1024,
 }
 
+// How long a room stays open when the source isn't sending anything.
+const DefaultRoomIdleTimeout = time.Hour
+
 type SailServer struct {
-	router *mux.Router
-	rooms  map[RoomID]*Room
-	mu     *sync.Mutex
+	router      *mux.Router
+	rooms       map[model.RoomID]*Room
+	mu          *sync.Mutex
+	idleTimeout time.Duration
 }
 
-func ProvideSailServer() SailServer {
+// Creates a server for Sail rooms. If idleTimeout is zero, rooms never expire.
+func ProvideSailServer(idleTimeout time.Duration) SailServer {
 	r := mux.NewRouter().UseEncodedPath()
 	s := SailServer{
-		router: r,
-		rooms:  make(map[RoomID]*Room, 0),
-		mu:     &sync.Mutex{},
+		router:      r,
+		rooms:       make(map[model.RoomID]*Room, 0),
+		mu:          &sync.Mutex{},
+		idleTimeout: idleTimeout,
 	}
 
 	r.HandleFunc("/share", s.startRoom)
+	r.HandleFunc("/join/{roomID}", s.joinRoom)
+	r.HandleFunc("/room/{roomID}/revoke", s.revokeFollowers).Methods(http.MethodPost)
+	r.HandleFunc("/room/{roomID}/close", s.closeRoom).Methods(http.MethodPost)
 
 	return s
 }
@@ -37,16 +51,27 @@ func (s SailServer) Router() http.Handler {
 	return s.router
 }
 
-func (s SailServer) newRoom(conn *websocket.Conn) *Room {
+func (s SailServer) newRoom(conn *websocket.Conn) (*Room, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	room := NewRoom(conn)
+	room, err := NewRoom(conn, s.idleTimeout)
+	if err != nil {
+		return nil, err
+	}
 	s.rooms[room.id] = room
-	return room
+	return room, nil
+}
+
+func (s SailServer) getRoom(id model.RoomID) (*Room, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[id]
+	return room, ok
 }
 
-func (s SailServer) closeRoom(room *Room) {
+func (s SailServer) removeRoom(room *Room) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -60,11 +85,89 @@ func (s SailServer) startRoom(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	room := s.newRoom(conn)
+	room, err := s.newRoom(conn)
+	if err != nil {
+		log.Printf("startRoom: %v", err)
+		_ = conn.Close()
+		return
+	}
+
 	err = room.ConsumeSource(req.Context())
 	if err != nil {
 		log.Printf("websocket closed: %v", err)
 	}
 
-	s.closeRoom(room)
+	s.removeRoom(room)
+}
+
+func (s SailServer) joinRoom(w http.ResponseWriter, req *http.Request) {
+	roomID := model.RoomID(mux.Vars(req)["roomID"])
+	room, ok := s.getRoom(roomID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Room not found: %s", roomID), http.StatusNotFound)
+		return
+	}
+
+	// Browsers can't set headers on websocket requests, so followers
+	// pass the token in the URL.
+	token := req.URL.Query().Get("token")
+	if !room.CanFollow(token) {
+		http.Error(w, "Invalid access token", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		log.Printf("joinRoom: %v", err)
+		return
+	}
+
+	err = room.AddFollower(conn, token)
+	if err != nil {
+		log.Printf("joinRoom: %v", err)
+		_ = conn.Close()
+		return
+	}
+
+	room.ConsumeFollower(conn)
+}
+
+// Finds the room in the request, and checks that the request comes from
+// its source. Writes an error to the response if it doesn't.
+func (s SailServer) sourceRoom(w http.ResponseWriter, req *http.Request) (*Room, bool) {
+	roomID := model.RoomID(mux.Vars(req)["roomID"])
+	room, ok := s.getRoom(roomID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Room not found: %s", roomID), http.StatusNotFound)
+		return nil, false
+	}
+
+	secret := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !room.IsSource(secret) {
+		http.Error(w, "Invalid secret token", http.StatusUnauthorized)
+		return nil, false
+	}
+	return room, true
+}
+
+func (s SailServer) revokeFollowers(w http.ResponseWriter, req *http.Request) {
+	room, ok := s.sourceRoom(w, req)
+	if !ok {
+		return
+	}
+
+	err := room.RevokeFollowers()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Revoking followers: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func (s SailServer) closeRoom(w http.ResponseWriter, req *http.Request) {
+	room, ok := s.sourceRoom(w, req)
+	if !ok {
+		return
+	}
+
+	room.close()
+	s.removeRoom(room)
 }
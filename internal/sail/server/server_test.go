@@ -0,0 +1,458 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/hud/webview"
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+func TestFollowerGetsSnapshotAndUpdates(t *testing.T) {
+	f := newFixture(t, 0)
+	defer f.TearDown()
+
+	source, room := f.share()
+	f.sendView(source, "a\n")
+	f.sendView(source, "a\nb\n")
+
+	// Wait for the room to see the views, so that the follower gets them as a snapshot.
+	f.waitForSeq(room.RoomID, 2)
+
+	follower := f.join(room)
+	snapshot := f.readUpdate(follower)
+	assert.Equal(t, 2, snapshot.Seq)
+	if assert.NotNil(t, snapshot.Snapshot) {
+		assert.Contains(t, f.marshal(snapshot.Snapshot), `"Log":"a\nb\n"`)
+	}
+
+	// After the snapshot, the follower gets the same deltas as the source sent.
+	sent := f.sendView(source, "a\nb\nc\n")
+	assert.Equal(t, sent, f.read(follower))
+
+	delta := f.readUpdateFromString(sent)
+	assert.Equal(t, 3, delta.Seq)
+	if assert.NotNil(t, delta.Delta) {
+		assert.Equal(t, "c\n", delta.Delta.Fields["Log"].Append)
+	}
+}
+
+func TestSourceReconnectSendsSnapshot(t *testing.T) {
+	f := newFixture(t, 0)
+	defer f.TearDown()
+
+	source, room := f.share()
+	f.sendView(source, "a\n")
+	f.sendView(source, "a\nb\n")
+	f.waitForSeq(room.RoomID, 2)
+
+	// Updates from a different stream replace the view.
+	f.history = webview.NewViewHistory()
+	f.sentSeq = 0
+	f.sendView(source, "new\n")
+	f.waitForSeq(room.RoomID, 1)
+
+	follower := f.join(room)
+	snapshot := f.readUpdate(follower)
+	if assert.NotNil(t, snapshot.Snapshot) {
+		assert.Contains(t, f.marshal(snapshot.Snapshot), `"Log":"new\n"`)
+	}
+}
+
+func TestFanOutToMultipleFollowers(t *testing.T) {
+	f := newFixture(t, 0)
+	defer f.TearDown()
+
+	source, room := f.share()
+	follower1 := f.join(room)
+	follower2 := f.join(room)
+	f.waitForFollowers(room.RoomID, 2)
+
+	sent := f.sendView(source, "a\n")
+	assert.Equal(t, sent, f.read(follower1))
+	assert.Equal(t, sent, f.read(follower2))
+}
+
+func TestSourceDisconnectClosesFollowers(t *testing.T) {
+	f := newFixture(t, 0)
+	defer f.TearDown()
+
+	source, room := f.share()
+	follower := f.join(room)
+	f.waitForFollowers(room.RoomID, 1)
+
+	_ = source.Close()
+
+	f.assertClosed(follower)
+	f.waitForRoomRemoved(room.RoomID)
+}
+
+func TestJoinUnknownRoom(t *testing.T) {
+	f := newFixture(t, 0)
+	defer f.TearDown()
+
+	f.assertDialStatus("/join/nonexistent", http.StatusNotFound)
+}
+
+func TestJoinWithInvalidToken(t *testing.T) {
+	f := newFixture(t, 0)
+	defer f.TearDown()
+
+	_, room := f.share()
+	f.assertDialStatus(joinPath(room.RoomID, ""), http.StatusUnauthorized)
+	f.assertDialStatus(joinPath(room.RoomID, "not-the-token"), http.StatusUnauthorized)
+	f.assertDialStatus(joinPath(room.RoomID, room.SecretToken), http.StatusUnauthorized)
+}
+
+func TestRevokeFollowers(t *testing.T) {
+	f := newFixture(t, 0)
+	defer f.TearDown()
+
+	source, room := f.share()
+	follower := f.join(room)
+	f.waitForFollowers(room.RoomID, 1)
+
+	resp := f.post(room.RoomID, "revoke", room.SecretToken)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	f.assertClosed(follower)
+
+	// The source gets a new token, and the old one no longer works.
+	newRoom := f.readConnected(source)
+	assert.Equal(t, room.RoomID, newRoom.RoomID)
+	assert.Equal(t, room.SecretToken, newRoom.SecretToken)
+	assert.NotEqual(t, room.FollowerToken, newRoom.FollowerToken)
+	f.assertDialStatus(joinPath(room.RoomID, room.FollowerToken), http.StatusUnauthorized)
+
+	follower = f.join(newRoom)
+	sent := f.sendView(source, "a\n")
+	assert.Equal(t, sent, f.read(follower))
+}
+
+func TestCloseRoom(t *testing.T) {
+	f := newFixture(t, 0)
+	defer f.TearDown()
+
+	source, room := f.share()
+	follower := f.join(room)
+	f.waitForFollowers(room.RoomID, 1)
+
+	resp := f.post(room.RoomID, "close", room.SecretToken)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	f.assertClosed(follower)
+	f.assertClosed(source)
+	f.waitForRoomRemoved(room.RoomID)
+	f.assertDialStatus(joinPath(room.RoomID, room.FollowerToken), http.StatusNotFound)
+}
+
+func TestManageRoomRequiresSecret(t *testing.T) {
+	f := newFixture(t, 0)
+	defer f.TearDown()
+
+	_, room := f.share()
+	f.join(room)
+	f.waitForFollowers(room.RoomID, 1)
+
+	// Followers know the follower token, but that doesn't let them manage the room.
+	for _, action := range []string{"revoke", "close"} {
+		resp := f.post(room.RoomID, action, room.FollowerToken)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		resp = f.post(room.RoomID, action, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	f.waitForFollowers(room.RoomID, 1)
+	_, ok := f.ss.getRoom(room.RoomID)
+	assert.True(t, ok)
+}
+
+func TestRoomExpiresWhenIdle(t *testing.T) {
+	f := newFixture(t, 100*time.Millisecond)
+	defer f.TearDown()
+
+	source, room := f.share()
+	follower := f.join(room)
+	f.waitForFollowers(room.RoomID, 1)
+
+	// Activity from the source keeps the room open.
+	log := ""
+	for i := 0; i < 4; i++ {
+		log += fmt.Sprintf("line %d\n", i)
+		sent := f.sendView(source, log)
+		assert.Equal(t, sent, f.read(follower))
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	f.assertClosed(follower)
+	f.assertClosed(source)
+	f.waitForRoomRemoved(room.RoomID)
+}
+
+func TestSlowFollowerIsDropped(t *testing.T) {
+	room, err := NewRoom(nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slow := &fakeFollowerConn{writeErr: fmt.Errorf("i/o timeout")}
+	fast := &fakeFollowerConn{}
+	room.followers[slow] = true
+	room.followers[fast] = true
+
+	start := time.Now()
+	room.broadcast([]byte("{}"))
+
+	assert.True(t, slow.closed)
+	assert.False(t, room.followers[slow])
+	assert.True(t, room.followers[fast])
+	assert.Equal(t, []string{"{}"}, fast.written)
+	assert.WithinDuration(t, start.Add(followerWriteTimeout), fast.deadline, time.Second)
+}
+
+type fixture struct {
+	t      *testing.T
+	ss     SailServer
+	server *httptest.Server
+	conns  []*websocket.Conn
+
+	// Generates view updates, like the source would.
+	history      *webview.ViewHistory
+	sentStreamID string
+	sentSeq      int
+}
+
+func newFixture(t *testing.T, idleTimeout time.Duration) *fixture {
+	ss := ProvideSailServer(idleTimeout)
+	return &fixture{
+		t:       t,
+		ss:      ss,
+		server:  httptest.NewServer(ss.Router()),
+		history: webview.NewViewHistory(),
+	}
+}
+
+func (f *fixture) wsURL(path string) string {
+	return strings.Replace(f.server.URL, "http://", "ws://", 1) + path
+}
+
+func (f *fixture) dial(path string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(f.wsURL(path), nil)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.conns = append(f.conns, conn)
+	return conn
+}
+
+func (f *fixture) share() (*websocket.Conn, model.SailRoomConnected) {
+	conn := f.dial("/share")
+	return conn, f.readConnected(conn)
+}
+
+func (f *fixture) readConnected(conn *websocket.Conn) model.SailRoomConnected {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	var msg model.SailRoomConnected
+	err := conn.ReadJSON(&msg)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if msg.RoomID == "" || msg.FollowerToken == "" || msg.SecretToken == "" {
+		f.t.Fatalf("incomplete room message: %+v", msg)
+	}
+	return msg
+}
+
+func joinPath(roomID model.RoomID, token string) string {
+	return fmt.Sprintf("/join/%s?token=%s", roomID, url.QueryEscape(token))
+}
+
+func (f *fixture) join(room model.SailRoomConnected) *websocket.Conn {
+	return f.dial(joinPath(room.RoomID, room.FollowerToken))
+}
+
+func (f *fixture) assertDialStatus(path string, status int) {
+	_, resp, err := websocket.DefaultDialer.Dial(f.wsURL(path), nil)
+	if assert.Error(f.t, err) && assert.NotNil(f.t, resp) {
+		assert.Equal(f.t, status, resp.StatusCode)
+	}
+}
+
+func (f *fixture) post(roomID model.RoomID, action, secret string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/room/%s/%s", f.server.URL, roomID, action), nil)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+secret)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	return resp
+}
+
+// Reads until the connection closes, skipping any messages still in flight.
+func (f *fixture) assertClosed(conn *websocket.Conn) {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+
+		// A deadline error means the server never closed the connection.
+		assert.NotContains(f.t, err.Error(), "timeout")
+		return
+	}
+}
+
+func (f *fixture) waitForRoomRemoved(roomID model.RoomID) {
+	for i := 0; i < 100; i++ {
+		if _, ok := f.ss.getRoom(roomID); !ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	f.t.Errorf("room %s was never cleaned up", roomID)
+}
+
+// Sends the source's view updates for a view with the given log,
+// and returns the last update that it sent.
+func (f *fixture) sendView(conn *websocket.Conn, log string) string {
+	err := f.history.Update(func() webview.View {
+		return webview.View{Log: model.NewLog(log), Resources: []webview.Resource{{Name: "fe"}}}
+	})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	sent := ""
+	for _, update := range f.history.Since(f.sentStreamID, f.sentSeq) {
+		sent = f.marshal(update)
+		f.write(conn, sent)
+		f.sentStreamID = update.StreamID
+		f.sentSeq = update.Seq
+	}
+	return sent
+}
+
+func (f *fixture) readUpdate(conn *websocket.Conn) webview.ViewUpdate {
+	return f.readUpdateFromString(f.read(conn))
+}
+
+func (f *fixture) readUpdateFromString(data string) webview.ViewUpdate {
+	var update webview.ViewUpdate
+	err := json.Unmarshal([]byte(data), &update)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return update
+}
+
+func (f *fixture) marshal(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return string(data)
+}
+
+func (f *fixture) write(conn *websocket.Conn, msg string) {
+	err := conn.WriteMessage(websocket.TextMessage, []byte(msg))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *fixture) read(conn *websocket.Conn) string {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return string(data)
+}
+
+func (f *fixture) room(roomID model.RoomID) *Room {
+	room, ok := f.ss.getRoom(roomID)
+	if !ok {
+		f.t.Fatalf("room not found: %s", roomID)
+	}
+	return room
+}
+
+func (f *fixture) waitForSeq(roomID model.RoomID, seq int) {
+	room := f.room(roomID)
+	for i := 0; i < 100; i++ {
+		room.mu.Lock()
+		current := room.seq
+		room.mu.Unlock()
+		if current == seq {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	f.t.Fatalf("room never got view update %d", seq)
+}
+
+func (f *fixture) waitForFollowers(roomID model.RoomID, count int) {
+	room := f.room(roomID)
+	for i := 0; i < 100; i++ {
+		room.mu.Lock()
+		n := len(room.followers)
+		room.mu.Unlock()
+		if n == count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	f.t.Fatalf("room never got %d followers", count)
+}
+
+func (f *fixture) TearDown() {
+	for _, conn := range f.conns {
+		_ = conn.Close()
+	}
+	f.server.Close()
+}
+
+type fakeFollowerConn struct {
+	deadline time.Time
+	writeErr error
+	written  []string
+	closed   bool
+}
+
+func (c *fakeFollowerConn) WriteMessage(messageType int, data []byte) error {
+	if c.writeErr != nil {
+		return c.writeErr
+	}
+	c.written = append(c.written, string(data))
+	return nil
+}
+
+func (c *fakeFollowerConn) SetWriteDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
+
+func (c *fakeFollowerConn) NextReader() (int, io.Reader, error) {
+	return 0, nil, io.EOF
+}
+
+func (c *fakeFollowerConn) Close() error {
+	c.closed = true
+	return nil
+}
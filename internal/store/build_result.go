@@ -39,6 +39,13 @@ func NewContainerBuildResult(id model.TargetID, containerID container.ID) BuildR
 	}
 }
 
+// For local commands, which don't produce any artifacts we track.
+func NewLocalBuildResult(id model.TargetID) BuildResult {
+	return BuildResult{
+		TargetID: id,
+	}
+}
+
 // For image targets. The container id will be added later.
 func NewImageBuildResult(id model.TargetID, image reference.NamedTagged) BuildResult {
 	return BuildResult{
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/windmilleng/tilt/internal/dockercompose"
+)
+
+type EngineMode int
+
+const (
+	// Keep the resources up to date until the user exits (`tilt up`).
+	EngineModeUp EngineMode = iota
+
+	// Build and deploy everything once, then exit when every resource
+	// is ready or as soon as one fails (`tilt ci`).
+	EngineModeCI
+)
+
+// Pod statuses that mean the pod won't come up without intervention.
+var ciPodErrorStatuses = map[string]bool{
+	"Error":            true,
+	"CrashLoopBackOff": true,
+	"ErrImagePull":     true,
+	"ImagePullBackOff": true,
+}
+
+// Whether we should watch the runtime (pod logs, docker-compose events) of
+// deployed resources. We don't watch files in CI mode, but we still need to
+// know how the resources come up.
+func (e EngineState) WatchRuntime() bool {
+	return e.WatchFiles || e.EngineMode == EngineModeCI
+}
+
+// In CI mode, we're finished when every resource is ready, or as soon as
+// the Tiltfile or any resource fails.
+func (e EngineState) ciFinished() (bool, error) {
+	if e.LastTiltfileBuild.Empty() {
+		return false, nil
+	}
+
+	if err := e.LastTiltfileBuild.Error; err != nil {
+		return true, fmt.Errorf("Tiltfile failed: %v", err)
+	}
+
+	if e.GlobalYAMLState != nil && e.GlobalYAMLState.LastError != nil {
+		return true, fmt.Errorf("%s failed: %v", e.GlobalYAML.Name, e.GlobalYAMLState.LastError)
+	}
+
+	allReady := true
+	for _, mt := range e.Targets() {
+		ready, err := mt.CIStatus()
+		if err != nil {
+			return true, fmt.Errorf("%s failed: %v", mt.Manifest.Name, err)
+		}
+		allReady = allReady && ready
+	}
+	return allReady, nil
+}
+
+// Whether this manifest is ready, or the reason it failed, as far as
+// `tilt ci` is concerned.
+func (t ManifestTarget) CIStatus() (bool, error) {
+	ms := t.State
+	if err := ms.LastBuild().Error; err != nil {
+		return false, fmt.Errorf("build failed: %v", err)
+	}
+
+	if t.Manifest.IsLocal() {
+		lrs := ms.LocalRuntimeState()
+		if !t.Manifest.LocalTarget().ServeCmd.Empty() && lrs.HasExited() {
+			return false, fmt.Errorf("serve_cmd exited with code %d", lrs.ExitCode)
+		}
+	} else if t.Manifest.IsDC() {
+		if ms.DCResourceState().Status == dockercompose.StatusCrash {
+			return false, fmt.Errorf("container crashed")
+		}
+	} else if t.Manifest.IsK8s() && t.Manifest.K8sTarget().IsJob {
+		// Kubernetes retries the Job's pods, so only the Job knows
+		// whether it failed.
+		if ms.JobStatus == K8sJobStatusFailed {
+			return false, fmt.Errorf("job failed")
+		}
+	} else if t.Manifest.IsK8s() {
+		pod := ms.MostRecentPod()
+		if pod.Phase == v1.PodFailed || ciPodErrorStatuses[pod.Status] {
+			return false, fmt.Errorf("pod %s: %s", pod.PodID, pod.Status)
+		}
+		if restarts := pod.ContainerRestarts - pod.OldRestarts; restarts > 0 {
+			return false, fmt.Errorf("pod %s restarted %d times", pod.PodID, restarts)
+		}
+	}
+
+	return t.IsReady(), nil
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+func TestCIFinishedWaitsForTiltfile(t *testing.T) {
+	state := newState(nil, model.Manifest{})
+	state.EngineMode = EngineModeCI
+
+	done, err := state.ciFinished()
+	assert.False(t, done)
+	assert.NoError(t, err)
+
+	state.LastTiltfileBuild = model.BuildRecord{StartTime: time.Now(), Error: fmt.Errorf("syntax error")}
+	done, err = state.ciFinished()
+	assert.True(t, done)
+	if assert.Error(t, err) {
+		assert.Equal(t, "Tiltfile failed: syntax error", err.Error())
+	}
+}
+
+func TestCIFinishedLocalResource(t *testing.T) {
+	lt := model.NewLocalTarget("server", model.Cmd{}, "/", nil).
+		WithServeCmd(model.ToShellCmd("./server"))
+	m := model.Manifest{Name: "server"}.WithDeployTarget(lt)
+	state := newState([]model.Manifest{m}, model.Manifest{})
+	state.EngineMode = EngineModeCI
+	state.LastTiltfileBuild = model.BuildRecord{StartTime: time.Now()}
+
+	done, err := state.ciFinished()
+	assert.False(t, done)
+	assert.NoError(t, err)
+
+	ms := state.ManifestTargets[m.Name].State
+	ms.AddCompletedBuild(model.BuildRecord{StartTime: time.Now(), FinishTime: time.Now()})
+	ms.ResourceState = LocalRuntimeState{PID: 123, Status: LocalProcessStatusRunning}
+	done, err = state.ciFinished()
+	assert.True(t, done)
+	assert.NoError(t, err)
+
+	ms.ResourceState = LocalRuntimeState{Status: LocalProcessStatusError, ExitCode: 2}
+	done, err = state.ciFinished()
+	assert.True(t, done)
+	if assert.Error(t, err) {
+		assert.Equal(t, "server failed: serve_cmd exited with code 2", err.Error())
+	}
+}
+
+func TestCIFinishedK8sWithoutPods(t *testing.T) {
+	m := model.Manifest{Name: "db-service"}.WithDeployTarget(model.K8sTarget{NoPods: true})
+	state := newState([]model.Manifest{m}, model.Manifest{})
+	state.EngineMode = EngineModeCI
+	state.LastTiltfileBuild = model.BuildRecord{StartTime: time.Now()}
+
+	done, err := state.ciFinished()
+	assert.False(t, done)
+	assert.NoError(t, err)
+
+	ms := state.ManifestTargets[m.Name].State
+	ms.AddCompletedBuild(model.BuildRecord{StartTime: time.Now(), FinishTime: time.Now()})
+	done, err = state.ciFinished()
+	assert.True(t, done)
+	assert.NoError(t, err)
+}
+
+func TestCIFinishedK8sJob(t *testing.T) {
+	m := model.Manifest{Name: "migrate"}.WithDeployTarget(model.K8sTarget{IsJob: true})
+	state := newState([]model.Manifest{m}, model.Manifest{})
+	state.EngineMode = EngineModeCI
+	state.LastTiltfileBuild = model.BuildRecord{StartTime: time.Now()}
+
+	ms := state.ManifestTargets[m.Name].State
+	ms.AddCompletedBuild(model.BuildRecord{StartTime: time.Now(), FinishTime: time.Now()})
+	ms.PodSet = NewPodSet(Pod{PodID: "migrate-1", Phase: v1.PodFailed, Status: "Error"})
+	done, err := state.ciFinished()
+	assert.False(t, done)
+	assert.NoError(t, err)
+
+	ms.PodSet = NewPodSet(Pod{PodID: "migrate-2", Phase: v1.PodSucceeded, Status: "Completed"})
+	ms.JobStatus = K8sJobStatusCompleted
+	done, err = state.ciFinished()
+	assert.True(t, done)
+	assert.NoError(t, err)
+
+	ms.JobStatus = K8sJobStatusFailed
+	done, err = state.ciFinished()
+	assert.True(t, done)
+	if assert.Error(t, err) {
+		assert.Equal(t, "migrate failed: job failed", err.Error())
+	}
+}
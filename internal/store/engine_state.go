@@ -18,6 +18,7 @@ import (
 	"github.com/windmilleng/tilt/internal/k8s"
 	"github.com/windmilleng/tilt/internal/model"
 	"github.com/windmilleng/tilt/internal/ospath"
+	"github.com/windmilleng/tilt/internal/store/logstore"
 )
 
 type EngineState struct {
@@ -29,8 +30,10 @@ type EngineState struct {
 	// TODO(nick): This will eventually be a general Target index.
 	ManifestTargets map[model.ManifestName]*ManifestTarget
 
-	CurrentlyBuilding model.ManifestName
+	// The manifests that have a build in progress.
+	CurrentlyBuilding map[model.ManifestName]bool
 	WatchFiles        bool
+	EngineMode        EngineMode
 
 	// How many builds were queued on startup (i.e., how many manifests there were)
 	InitialBuildCount int
@@ -38,19 +41,35 @@ type EngineState struct {
 	// How many builds have been completed (pass or fail) since starting tilt
 	CompletedBuildCount int
 
-	// For synchronizing BuildController so that it's only
-	// doing one action at a time. In the future, we might
-	// want to allow it to parallelize builds better, but that
-	// would require better tools for triaging output to different streams.
+	// For synchronizing BuildController so that it doesn't start
+	// a new build until it has seen the results of its previous action.
+	// Incremented every time a build starts or completes.
 	BuildControllerActionCount int
 
+	// The maximum number of manifests we're allowed to build at once.
+	// The value from the command line takes precedence over the Tiltfile.
+	// Zero means unset. See MaxParallelBuilds().
+	MaxParallelBuildsFromFlag     int
+	MaxParallelBuildsFromTiltfile int
+
+	// The kubeconfig context that Tilt deploys to.
+	KubeContext k8s.KubeContext
+
+	// The namespace to deploy Kubernetes objects to, overriding the namespaces in the YAML.
+	// The value from the command line takes precedence over the Tiltfile.
+	// Empty means unset. See K8sNamespace().
+	K8sNamespaceFromFlag     k8s.Namespace
+	K8sNamespaceFromTiltfile k8s.Namespace
+
 	PermanentError error
 
 	// The user has indicated they want to exit
 	UserExited bool
 
-	// The full log stream for tilt. This might deserve gc or file storage at some point.
-	Log model.Log `testdiff:"ignore"`
+	// Every log line of the session, from all sources, with metadata about where
+	// it came from. The HUD and the web view render the tail of Tilt's log and
+	// of each resource's log from here.
+	LogStore *logstore.LogStore `testdiff:"ignore"`
 
 	// GlobalYAML is a special manifest that has no images, but has dependencies
 	// and a bunch of YAML that is deployed when those dependencies change.
@@ -58,6 +77,11 @@ type EngineState struct {
 	GlobalYAML      model.Manifest
 	GlobalYAMLState *YAMLManifestState
 
+	// Manifests in the Tiltfile that we aren't running, because Tilt was
+	// started with the names of other resources (e.g., `tilt up frontend`).
+	// They're still part of the Tiltfile, so we mustn't prune their objects.
+	UnmatchedManifests []model.Manifest
+
 	TiltfilePath             string
 	ConfigFiles              []string
 	TiltIgnoreContents       string
@@ -66,6 +90,9 @@ type EngineState struct {
 	// InitManifests is the list of manifest names that we were told to init from the CLI.
 	InitManifests []model.ManifestName
 
+	// TiltfileArgs are the args after `--` on the CLI, passed to the Tiltfile.
+	TiltfileArgs []string
+
 	TriggerMode  model.TriggerMode
 	TriggerQueue []model.ManifestName
 
@@ -74,7 +101,6 @@ type EngineState struct {
 
 	LastTiltfileBuild    model.BuildRecord
 	CurrentTiltfileBuild model.BuildRecord
-	TiltfileCombinedLog  model.Log
 }
 
 func (e *EngineState) ManifestNamesForTargetID(id model.TargetID) []model.ManifestName {
@@ -92,6 +118,9 @@ func (e *EngineState) ManifestNamesForTargetID(id model.TargetID) []model.Manife
 		if manifest.DockerComposeTarget().ID() == id {
 			result = append(result, mn)
 		}
+		if manifest.LocalTarget().ID() == id {
+			result = append(result, mn)
+		}
 	}
 	return result
 }
@@ -172,6 +201,48 @@ func (e EngineState) Targets() []*ManifestTarget {
 	return result
 }
 
+// The trigger mode of the given manifest.
+//
+// A mode set at runtime (e.g., from the web UI) wins. Otherwise, the manifest is manual
+// if either the command line (--auto-deploy=false) or the Tiltfile says so.
+func (e EngineState) ManifestTriggerMode(mn model.ManifestName) model.TriggerMode {
+	mt, ok := e.ManifestTargets[mn]
+	if !ok {
+		return e.TriggerMode
+	}
+	if mt.State.TriggerModeOverride != nil {
+		return *mt.State.TriggerModeOverride
+	}
+	if e.TriggerMode == model.TriggerManual {
+		return model.TriggerManual
+	}
+	return mt.Manifest.TriggerMode
+}
+
+// The number of manifests that are allowed to build at the same time.
+func (e EngineState) MaxParallelBuilds() int {
+	if e.MaxParallelBuildsFromFlag > 0 {
+		return e.MaxParallelBuildsFromFlag
+	}
+	if e.MaxParallelBuildsFromTiltfile > 0 {
+		return e.MaxParallelBuildsFromTiltfile
+	}
+	return 1
+}
+
+// The namespace to deploy all Kubernetes objects to,
+// or empty if they should stay in the namespaces from their YAML.
+func (e EngineState) K8sNamespace() k8s.Namespace {
+	if e.K8sNamespaceFromFlag != "" {
+		return e.K8sNamespaceFromFlag
+	}
+	return e.K8sNamespaceFromTiltfile
+}
+
+func (e EngineState) IsBuilding() bool {
+	return len(e.CurrentlyBuilding) > 0
+}
+
 func (e EngineState) RelativeTiltfilePath() (string, error) {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -208,6 +279,48 @@ func newBuildStatus() *BuildStatus {
 	}
 }
 
+type ProbeStatus struct {
+	Ready   bool
+	Message string // why the last probe failed, if it did
+
+	// The LastSuccessfulDeployTime of the deploy that we probed. Probe results
+	// from an older deploy are stale.
+	DeployTime    time.Time
+	LastProbeTime time.Time
+}
+
+// A Warning event that Kubernetes reported about one of a manifest's objects,
+// e.g., a pod that can't pull its image or can't be scheduled.
+type K8sWarnEvent struct {
+	// The name of the Event object. Kubernetes updates the same Event
+	// when a warning repeats, so this identifies the warning.
+	Name string
+
+	InvolvedObject string // e.g., "Pod/foo-abc123"
+	Reason         string
+	Message        string
+	Count          int32
+	LastTimestamp  time.Time
+}
+
+func (e K8sWarnEvent) String() string {
+	s := fmt.Sprintf("%s %s: %s", e.InvolvedObject, e.Reason, e.Message)
+	if e.Count > 1 {
+		s = fmt.Sprintf("%s (x%d)", s, e.Count)
+	}
+	return s
+}
+
+// The outcome of a Kubernetes Job. Empty while the Job is still running,
+// since the status of its pod says more.
+type K8sJobStatus string
+
+// Named like the pod statuses, so that the HUD colors them the same way.
+const (
+	K8sJobStatusCompleted = K8sJobStatus("Completed")
+	K8sJobStatusFailed    = K8sJobStatus("Failed")
+)
+
 func (s BuildStatus) IsEmpty() bool {
 	return len(s.PendingFileChanges) == 0 && s.LastSuccessfulResult.IsEmpty()
 }
@@ -232,6 +345,15 @@ type ManifestState struct {
 
 	LastSuccessfulDeployTime time.Time
 
+	// The result of the readiness probe (if any) against the current deploy
+	ProbeStatus ProbeStatus
+
+	// Warnings that Kubernetes reported about the current deploy
+	K8sWarnEvents []K8sWarnEvent
+
+	// If the workload is a Job, whether the current deploy's Job has finished
+	JobStatus K8sJobStatus
+
 	// The last `BuildHistoryLimit` builds. The most recent build is first in the slice.
 	BuildHistory []model.BuildRecord
 
@@ -244,17 +366,22 @@ type ManifestState struct {
 	// around for a little while so we can show it in the UX.
 	CrashLog model.Log
 
-	// The log stream for this resource
-	CombinedLog model.Log `testdiff:"ignore"`
-
 	// If this manifest was changed, which config files led to the most recent change in manifest definition
 	ConfigFilesThatCausedChange []string
+
+	// Set when the user switches this manifest's trigger mode at runtime (e.g., from the web UI).
+	// Overrides the global trigger mode.
+	TriggerModeOverride *model.TriggerMode
+
+	// The last time the user asked us to restart this manifest's containers or processes.
+	RestartRequestTime time.Time
 }
 
 func NewState() *EngineState {
 	ret := &EngineState{}
-	ret.Log = model.Log{}
+	ret.LogStore = logstore.NewLogStore()
 	ret.ManifestTargets = make(map[model.ManifestName]*ManifestTarget)
+	ret.CurrentlyBuilding = make(map[model.ManifestName]bool)
 	ret.PendingConfigFileChanges = make(map[string]time.Time)
 	return ret
 }
@@ -267,6 +394,14 @@ func newManifestState(mn model.ManifestName) *ManifestState {
 	}
 }
 
+func (ms *ManifestState) K8sWarnings() []string {
+	var ret []string
+	for _, e := range ms.K8sWarnEvents {
+		ret = append(ret, e.String())
+	}
+	return ret
+}
+
 func (ms *ManifestState) TargetID() model.TargetID {
 	return model.TargetID{
 		Type: model.TargetTypeManifest,
@@ -301,6 +436,11 @@ func (ms *ManifestState) IsDC() bool {
 	return ok
 }
 
+func (ms *ManifestState) LocalRuntimeState() LocalRuntimeState {
+	ret, _ := ms.ResourceState.(LocalRuntimeState)
+	return ret
+}
+
 func (ms *ManifestState) ActiveBuild() model.BuildRecord {
 	return ms.CurrentBuild
 }
@@ -510,6 +650,10 @@ type Pod struct {
 	ContainerReady    bool
 	ContainerImageRef reference.Named
 
+	// The Kubernetes PodReady condition, i.e., all containers are ready
+	// and passing their readiness probes.
+	Ready bool
+
 	// We want to show the user # of restarts since pod has been running current code,
 	// i.e. OldRestarts - Total Restarts
 	ContainerRestarts int
@@ -582,6 +726,7 @@ func StateToView(s EngineState) view.View {
 		TriggerMode:   s.TriggerMode,
 		IsProfiling:   s.IsProfiling,
 		LogTimestamps: s.LogTimestamps,
+		KubeContext:   string(s.KubeContext),
 	}
 
 	ret.Resources = append(ret.Resources, tiltfileResourceView(s))
@@ -648,6 +793,8 @@ func StateToView(s EngineState) view.View {
 			CurrentBuild:       currentBuild,
 			CrashLog:           ms.CrashLog,
 			Endpoints:          endpoints,
+			Readiness:          mt.Readiness(),
+			TriggerMode:        s.ManifestTriggerMode(name),
 			ResourceInfo:       resourceInfoView(mt),
 		}
 
@@ -674,7 +821,7 @@ func StateToView(s EngineState) view.View {
 		ret.Resources = append(ret.Resources, r)
 	}
 
-	ret.Log = s.Log
+	ret.Log = s.LogStore.TiltLogTail(s.LogTimestamps)
 
 	return ret
 }
@@ -707,6 +854,18 @@ func tiltfileResourceView(s EngineState) view.Resource {
 }
 
 func resourceInfoView(mt *ManifestTarget) view.ResourceInfoView {
+	if mt.Manifest.IsLocal() {
+		lrs := mt.State.LocalRuntimeState()
+		return view.LocalResourceInfo{
+			HasServeCmd:   !mt.Manifest.LocalTarget().ServeCmd.Empty(),
+			PID:           lrs.PID,
+			ProcessStatus: string(lrs.Status),
+			ExitCode:      lrs.ExitCode,
+			StartTime:     lrs.StartTime,
+			Restarts:      lrs.Restarts,
+			Log:           lrs.Log(),
+		}
+	}
 	if dcState, ok := mt.State.ResourceState.(dockercompose.State); ok {
 		return view.NewDCResourceInfo(mt.Manifest.DockerComposeTarget().ConfigPath, dcState.Status, dcState.ContainerID, dcState.Log(), dcState.StartTime)
 	} else {
@@ -719,6 +878,9 @@ func resourceInfoView(mt *ManifestTarget) view.ResourceInfoView {
 			PodRestarts:        pod.ContainerRestarts - pod.OldRestarts,
 			PodLog:             pod.CurrentLog,
 			YAML:               mt.Manifest.K8sTarget().YAML,
+			Warnings:           mt.State.K8sWarnings(),
+			IsJob:              mt.Manifest.K8sTarget().IsJob,
+			JobStatus:          string(mt.State.JobStatus),
 		}
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 
 	"github.com/windmilleng/tilt/internal/k8s"
 	"github.com/windmilleng/tilt/internal/model"
@@ -84,6 +85,39 @@ func TestStateViewYAMLManifestWithYAML(t *testing.T) {
 	assert.Equal(t, []string{"global.yaml"}, r.DirectoriesWatched)
 }
 
+func TestStateToViewReadinessProbe(t *testing.T) {
+	m := model.Manifest{
+		Name: "foo",
+	}.WithDeployTarget(model.K8sTarget{}).
+		WithReadinessProbe(&model.Probe{
+			Period:  time.Second,
+			Timeout: time.Second,
+			Exec:    &model.ExecAction{Command: model.ToShellCmd("true")},
+		})
+	state := newState([]model.Manifest{m}, model.Manifest{})
+	mt := state.ManifestTargets[m.Name]
+
+	v := StateToView(*state)
+	r, _ := v.Resource(m.Name)
+	assert.Equal(t, model.ReadinessUnknown, r.Readiness)
+
+	deployTime := time.Now()
+	mt.State.AddCompletedBuild(model.BuildRecord{StartTime: deployTime, FinishTime: deployTime})
+	mt.State.LastSuccessfulDeployTime = deployTime
+	mt.State.PodSet = NewPodSet(Pod{PodID: "pod-a", Phase: v1.PodRunning, Ready: true})
+	mt.State.ProbeStatus = ProbeStatus{Ready: true, DeployTime: deployTime.Add(-time.Minute)}
+	v = StateToView(*state)
+	r, _ = v.Resource(m.Name)
+	assert.Equal(t, model.ReadinessNotReady, r.Readiness)
+	assert.False(t, mt.IsReady())
+
+	mt.State.ProbeStatus = ProbeStatus{Ready: true, DeployTime: deployTime}
+	v = StateToView(*state)
+	r, _ = v.Resource(m.Name)
+	assert.Equal(t, model.ReadinessReady, r.Readiness)
+	assert.True(t, mt.IsReady())
+}
+
 func TestMostRecentPod(t *testing.T) {
 	podA := Pod{PodID: "pod-a", StartedAt: time.Now()}
 	podB := Pod{PodID: "pod-b", StartedAt: time.Now().Add(time.Minute)}
@@ -118,3 +152,32 @@ func newState(manifests []model.Manifest, YAMLManifest model.Manifest) *EngineSt
 
 	return ret
 }
+
+func TestManifestTriggerMode(t *testing.T) {
+	auto := model.Manifest{Name: "auto"}
+	manual := model.Manifest{Name: "manual"}.WithTriggerMode(model.TriggerManual)
+	state := newState([]model.Manifest{auto, manual}, model.Manifest{})
+
+	assert.Equal(t, model.TriggerAuto, state.ManifestTriggerMode("auto"))
+	assert.Equal(t, model.TriggerManual, state.ManifestTriggerMode("manual"))
+
+	// --auto-deploy=false makes everything manual.
+	state.TriggerMode = model.TriggerManual
+	assert.Equal(t, model.TriggerManual, state.ManifestTriggerMode("auto"))
+
+	// Unless the user switches a manifest back at runtime.
+	override := model.TriggerAuto
+	state.ManifestTargets["auto"].State.TriggerModeOverride = &override
+	assert.Equal(t, model.TriggerAuto, state.ManifestTriggerMode("auto"))
+}
+
+func TestK8sNamespaceFlagOverridesTiltfile(t *testing.T) {
+	state := NewState()
+	assert.Equal(t, k8s.Namespace(""), state.K8sNamespace())
+
+	state.K8sNamespaceFromTiltfile = "dev-jane"
+	assert.Equal(t, k8s.Namespace("dev-jane"), state.K8sNamespace())
+
+	state.K8sNamespaceFromFlag = "dev-bob"
+	assert.Equal(t, k8s.Namespace("dev-bob"), state.K8sNamespace())
+}
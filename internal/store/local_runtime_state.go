@@ -0,0 +1,37 @@
+package store
+
+import (
+	"time"
+
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+type LocalProcessStatus string
+
+// Named to match the pod statuses, so that the HUD colors them the same way.
+const (
+	LocalProcessStatusPending   = LocalProcessStatus("")
+	LocalProcessStatusRunning   = LocalProcessStatus("Running")
+	LocalProcessStatusCompleted = LocalProcessStatus("Completed")
+	LocalProcessStatusError     = LocalProcessStatus("Error")
+)
+
+// The state of the long-running process (serve_cmd) of a local resource.
+type LocalRuntimeState struct {
+	PID        int
+	Status     LocalProcessStatus
+	ExitCode   int
+	StartTime  time.Time
+	Restarts   int
+	CurrentLog model.Log
+}
+
+func (LocalRuntimeState) ResourceState() {}
+
+func (s LocalRuntimeState) Log() model.Log {
+	return s.CurrentLog
+}
+
+func (s LocalRuntimeState) HasExited() bool {
+	return s.Status == LocalProcessStatusCompleted || s.Status == LocalProcessStatusError
+}
@@ -0,0 +1,639 @@
+package logstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/windmilleng/tilt/internal/container"
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+// How much log text we keep in memory before we spill the oldest lines to disk.
+const defaultMaxMemoryBytes = 4 * 1000 * 1000
+
+// We spill lines to disk a segment at a time.
+const defaultSegmentBytes = 64 * 1000
+
+// A line that never ends shouldn't grow forever. We cut it off at this length.
+const maxLineBytes = 64 * 1000
+
+// A rough estimate of the memory that each line takes on top of its text.
+const lineOverheadBytes = 64
+
+// How much of a log the HUD and the web view render. See model.Log.
+const maxTailBytes = 120 * 1000
+
+// How much of the spill file a query with a limit reads before it returns a page.
+const defaultPageReadBytes = 4 * 1000 * 1000
+
+// Where a log line came from.
+type Source string
+
+const (
+	SourceTilt     Source = "tilt"
+	SourceTiltfile Source = "tiltfile"
+	SourceBuild    Source = "build"
+	SourcePod      Source = "pod"
+	SourceCompose  Source = "compose"
+	SourceLocal    Source = "local"
+	SourceK8sEvent Source = "k8s-event"
+)
+
+type Level string
+
+const (
+	LevelInfo    Level = "info"
+	LevelVerbose Level = "verbose"
+	LevelDebug   Level = "debug"
+	LevelWarn    Level = "warn"
+)
+
+// Metadata about where a piece of log output came from.
+type Meta struct {
+	Time time.Time
+
+	// Empty for Tilt's own output, which doesn't belong to any manifest.
+	ManifestName model.ManifestName
+
+	Source    Source
+	PodID     k8s.PodID
+	Container container.Name
+	Level     Level
+}
+
+// Output from the same stream is stitched together into lines.
+type streamKey struct {
+	manifestName model.ManifestName
+	source       Source
+	podID        k8s.PodID
+	container    container.Name
+}
+
+func (m Meta) streamKey() streamKey {
+	return streamKey{
+		manifestName: m.ManifestName,
+		source:       m.Source,
+		podID:        m.PodID,
+		container:    m.Container,
+	}
+}
+
+type Line struct {
+	Meta
+
+	// Lines are numbered in the order they were completed, starting at 0.
+	Seq int
+
+	// Ends in a newline, unless the line was cut off.
+	Text string
+}
+
+func (l Line) size() int {
+	return len(l.Text) + lineOverheadBytes
+}
+
+// Tilt's own log has Tilt's output, interleaved with the output of pods.
+func (l Line) inTiltLog() bool {
+	return l.ManifestName == "" || l.Source == SourcePod
+}
+
+// The text of the line as Tilt's own log shows it, with pod output
+// prefixed by the name of its resource.
+func (l Line) TiltLogText() string {
+	if l.ManifestName != "" && l.Source == SourcePod {
+		return ManifestPrefix(string(l.ManifestName)) + l.Text
+	}
+	return l.Text
+}
+
+// The prefix that tells the output of a resource apart in Tilt's own log.
+func ManifestPrefix(n string) string {
+	max := 12
+	spaces := ""
+	if len(n) > max {
+		n = n[:max-1] + "…"
+	} else {
+		spaces = strings.Repeat(" ", max-len(n))
+	}
+	return fmt.Sprintf("%s%s┊ ", n, spaces)
+}
+
+// Lines that are still in memory.
+type segment struct {
+	firstSeq int
+	lines    []Line
+	bytes    int
+}
+
+// Lines that we've written to the spill file.
+type spilledSegment struct {
+	firstSeq int
+	count    int
+	offset   int64
+	length   int64
+}
+
+type Query struct {
+	// If empty, matches lines from all manifests. The empty name
+	// matches Tilt's own log.
+	ManifestNames []model.ManifestName
+
+	// The range of line numbers to read, [From, To).
+	// If To is zero, reads up to the latest line.
+	From int
+	To   int
+
+	// If non-zero, skips lines from before this time.
+	Since time.Time
+
+	// If non-zero, returns at most this many lines, and stops reading
+	// spilled lines after a few megabytes. The result's checkpoint is where
+	// the next page starts.
+	Limit int
+}
+
+func (q Query) full(lines []Line) bool {
+	return q.Limit > 0 && len(lines) >= q.Limit
+}
+
+func (q Query) matches(l Line) bool {
+	if !q.Since.IsZero() && l.Time.Before(q.Since) {
+		return false
+	}
+
+	if len(q.ManifestNames) == 0 {
+		return true
+	}
+	for _, mn := range q.ManifestNames {
+		if l.ManifestName == mn || (mn == "" && l.inTiltLog()) {
+			return true
+		}
+	}
+	return false
+}
+
+type QueryResult struct {
+	Lines []Line
+
+	// The line number to start from to read what comes next.
+	Checkpoint int
+
+	// True if the query stopped at its limit before the end of the range.
+	// Query again from the checkpoint to read the rest.
+	More bool
+
+	// True if some lines in the range were dropped because we couldn't
+	// spill them to disk.
+	Truncated bool
+}
+
+// All the log output of a Tilt session, with metadata about where each line
+// came from.
+//
+// We keep the most recent lines in memory, and spill older lines to a temp file,
+// so that nothing is truncated (like the start of a crash loop).
+//
+// Append is called from the reducer, so it never touches the disk. A background
+// goroutine writes the spilled lines, and Query reads them without holding the
+// lock that Append needs.
+type LogStore struct {
+	maxMemoryBytes int
+	segmentBytes   int
+	pageReadBytes  int64
+
+	// Mutable data, guarded by mu
+	mu          sync.Mutex
+	nextSeq     int
+	segments    []*segment
+	memoryBytes int
+	closed      bool
+
+	// Output from each stream since its last newline.
+	pending map[streamKey]*Line
+
+	// Segments waiting for the spill goroutine to write them to disk.
+	// Until they're written, we read them from memory.
+	unspilled []*segment
+	spilled   []spilledSegment
+	spillErr  error
+	spilling  bool
+	spillCh   chan struct{}
+
+	// Lines before this one are gone, because we failed to spill them.
+	droppedBefore int
+
+	// The spill file, guarded by fileMu. Only the spill goroutine writes to it.
+	fileMu      sync.RWMutex
+	spillFile   *os.File
+	fileClosed  bool
+	spillOffset int64
+}
+
+func NewLogStore() *LogStore {
+	return newLogStore(defaultMaxMemoryBytes, defaultSegmentBytes)
+}
+
+func newLogStore(maxMemoryBytes, segmentBytes int) *LogStore {
+	return &LogStore{
+		maxMemoryBytes: maxMemoryBytes,
+		segmentBytes:   segmentBytes,
+		pageReadBytes:  defaultPageReadBytes,
+		pending:        make(map[streamKey]*Line),
+		spillCh:        make(chan struct{}, 1),
+	}
+}
+
+// Adds output from a stream. Lines are committed once they end in a newline.
+func (s *LogStore) Append(meta Meta, message []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := meta.streamKey()
+	for len(message) > 0 {
+		line, ok := s.pending[key]
+		if !ok {
+			line = &Line{Meta: meta}
+			s.pending[key] = line
+		}
+
+		chunk := message
+		if i := bytes.IndexByte(message, '\n'); i != -1 {
+			chunk = message[:i+1]
+		}
+		if room := maxLineBytes - len(line.Text); len(chunk) > room {
+			chunk = chunk[:room]
+		}
+		message = message[len(chunk):]
+		line.Text += string(chunk)
+
+		if chunk[len(chunk)-1] == '\n' || len(line.Text) >= maxLineBytes {
+			delete(s.pending, key)
+			s.commit(*line)
+		}
+	}
+
+	s.spillIfNeeded()
+}
+
+func (s *LogStore) commit(line Line) {
+	line.Seq = s.nextSeq
+	s.nextSeq++
+
+	var seg *segment
+	if len(s.segments) > 0 {
+		seg = s.segments[len(s.segments)-1]
+	}
+	if seg == nil || seg.bytes >= s.segmentBytes {
+		seg = &segment{firstSeq: line.Seq}
+		s.segments = append(s.segments, seg)
+	}
+
+	seg.lines = append(seg.lines, line)
+	seg.bytes += line.size()
+	s.memoryBytes += line.size()
+}
+
+// Hands the oldest segments to the spill goroutine until we're under the limit.
+// We never spill the segment that we're appending to. Must hold the lock.
+func (s *LogStore) spillIfNeeded() {
+	queued := false
+	for s.memoryBytes > s.maxMemoryBytes && len(s.segments) > 1 {
+		seg := s.segments[0]
+		s.segments = s.segments[1:]
+		s.memoryBytes -= seg.bytes
+
+		if s.spillErr != nil {
+			// Without a spill file, the best we can do is drop the oldest lines.
+			s.droppedBefore = seg.firstSeq + len(seg.lines)
+			continue
+		}
+		s.unspilled = append(s.unspilled, seg)
+		queued = true
+	}
+
+	if !queued {
+		return
+	}
+	if !s.spilling {
+		s.spilling = true
+		go s.spillLoop()
+	}
+	select {
+	case s.spillCh <- struct{}{}:
+	default:
+		// The spill goroutine already has work to pick up.
+	}
+}
+
+// Writes the unspilled segments to disk, oldest first, until the store closes.
+func (s *LogStore) spillLoop() {
+	for range s.spillCh {
+		for {
+			s.mu.Lock()
+			if len(s.unspilled) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			seg := s.unspilled[0]
+			s.mu.Unlock()
+
+			spilled, err := s.spill(seg)
+
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				return
+			}
+			s.unspilled = s.unspilled[1:]
+			if err != nil {
+				// Without a spill file, the best we can do is drop the oldest lines.
+				s.spillErr = err
+				for _, seg := range append([]*segment{seg}, s.unspilled...) {
+					s.droppedBefore = seg.firstSeq + len(seg.lines)
+				}
+				s.unspilled = nil
+			} else {
+				s.spilled = append(s.spilled, spilled)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *LogStore) spill(seg *segment) (spilledSegment, error) {
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	for _, line := range seg.lines {
+		err := encoder.Encode(line)
+		if err != nil {
+			return spilledSegment{}, fmt.Errorf("encoding log line: %v", err)
+		}
+	}
+
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+
+	if s.fileClosed {
+		return spilledSegment{}, fmt.Errorf("log store closed")
+	}
+
+	if s.spillFile == nil {
+		f, err := ioutil.TempFile("", "tilt-logs-")
+		if err != nil {
+			return spilledSegment{}, fmt.Errorf("creating log spill file: %v", err)
+		}
+		s.spillFile = f
+	}
+
+	_, err := s.spillFile.WriteAt(buf.Bytes(), s.spillOffset)
+	if err != nil {
+		return spilledSegment{}, fmt.Errorf("writing log spill file: %v", err)
+	}
+
+	result := spilledSegment{
+		firstSeq: seg.firstSeq,
+		count:    len(seg.lines),
+		offset:   s.spillOffset,
+		length:   int64(buf.Len()),
+	}
+	s.spillOffset += int64(buf.Len())
+	return result, nil
+}
+
+// The line number of the next line to be committed.
+func (s *LogStore) Checkpoint() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextSeq
+}
+
+// Reads the lines that match the query, reading from disk if they've been spilled.
+func (s *LogStore) Query(q Query) (QueryResult, error) {
+	result, spilled, inMemory := s.queryMemory(q)
+	end := result.Checkpoint
+
+	if len(spilled) > 0 {
+		s.fileMu.RLock()
+		defer s.fileMu.RUnlock()
+
+		if s.spillFile == nil {
+			// The store closed since we looked at the index.
+			result.Truncated = true
+			spilled = nil
+		}
+	}
+
+	readBytes := int64(0)
+	for _, seg := range spilled {
+		if q.Limit > 0 && readBytes >= s.pageReadBytes {
+			// Lines that the query doesn't match can still take a lot of reading,
+			// so end the page at the start of this segment.
+			result.Checkpoint = seg.firstSeq
+			result.More = true
+			return result, nil
+		}
+
+		lines, err := readSpilled(s.spillFile, seg)
+		if err != nil {
+			return QueryResult{}, err
+		}
+		readBytes += seg.length
+
+		result.Lines = appendMatches(result.Lines, lines, q, q.From, end)
+		if q.full(result.Lines) {
+			endPage(&result, q.Limit, end)
+			return result, nil
+		}
+	}
+
+	result.Lines = append(result.Lines, inMemory...)
+	if q.full(result.Lines) {
+		endPage(&result, q.Limit, end)
+	}
+	return result, nil
+}
+
+// Cuts the result off at the limit, so that the next page starts after the last line.
+func endPage(result *QueryResult, limit, end int) {
+	result.Lines = result.Lines[:limit]
+	result.Checkpoint = result.Lines[limit-1].Seq + 1
+	result.More = result.Checkpoint < end
+}
+
+// Finds the lines that match the query in memory, and the spilled segments
+// to read them from disk, without doing any I/O under the lock.
+func (s *LogStore) queryMemory(q Query) (QueryResult, []spilledSegment, []Line) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if q.From < 0 {
+		q.From = 0
+	}
+	from := q.From
+	to := q.To
+	if to == 0 || to > s.nextSeq {
+		to = s.nextSeq
+	}
+
+	result := QueryResult{Checkpoint: to}
+	if from >= to {
+		return result, nil, nil
+	}
+
+	if from < s.droppedBefore {
+		result.Truncated = true
+	}
+
+	var spilled []spilledSegment
+	for _, seg := range s.spilled {
+		if seg.firstSeq+seg.count <= from || seg.firstSeq >= to {
+			continue
+		}
+		spilled = append(spilled, seg)
+	}
+
+	var inMemory []Line
+	for _, seg := range s.unspilled {
+		inMemory = appendMatches(inMemory, seg.lines, q, from, to)
+	}
+	for _, seg := range s.segments {
+		inMemory = appendMatches(inMemory, seg.lines, q, from, to)
+	}
+	return result, spilled, inMemory
+}
+
+// The latest lines of Tilt's own log, as much as the HUD renders.
+func (s *LogStore) TiltLogTail(timestamps bool) model.Log {
+	return s.tail(Line.inTiltLog, Line.TiltLogText, timestamps)
+}
+
+// The latest lines from a manifest, as much as the HUD renders.
+func (s *LogStore) ManifestLogTail(mn model.ManifestName, timestamps bool) model.Log {
+	matches := func(l Line) bool {
+		return l.ManifestName == mn
+	}
+	text := func(l Line) string {
+		return l.Text
+	}
+	return s.tail(matches, text, timestamps)
+}
+
+// Renders the latest matching lines that fit in maxTailBytes. We only look at
+// the lines in memory, so this never reads from disk.
+func (s *LogStore) tail(matches func(Line) bool, text func(Line) string, timestamps bool) model.Log {
+	if s == nil {
+		return model.Log{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments := append(append([]*segment{}, s.unspilled...), s.segments...)
+	var reversed []string
+	size := 0
+
+outer:
+	for i := len(segments) - 1; i >= 0; i-- {
+		lines := segments[i].lines
+		for j := len(lines) - 1; j >= 0; j-- {
+			line := lines[j]
+			if !matches(line) {
+				continue
+			}
+
+			t := text(line)
+			if timestamps {
+				t = line.Time.Format("2006/01/02 15:04:05 ") + t
+			}
+			if size+len(t) > maxTailBytes {
+				break outer
+			}
+			size += len(t)
+			reversed = append(reversed, t)
+		}
+	}
+
+	var sb strings.Builder
+	for i := len(reversed) - 1; i >= 0; i-- {
+		sb.WriteString(reversed[i])
+	}
+	return model.NewLog(sb.String())
+}
+
+// Stops once the result has as many lines as the query's limit.
+func appendMatches(result []Line, lines []Line, q Query, from, to int) []Line {
+	for _, line := range lines {
+		if q.full(result) {
+			break
+		}
+		if line.Seq >= from && line.Seq < to && q.matches(line) {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+func readSpilled(f *os.File, seg spilledSegment) ([]Line, error) {
+	reader := io.NewSectionReader(f, seg.offset, seg.length)
+	scanner := bufio.NewScanner(reader)
+	// JSON escaping can make a line up to 6x longer.
+	scanner.Buffer(nil, 6*maxLineBytes+64*1000)
+
+	lines := make([]Line, 0, seg.count)
+	for scanner.Scan() {
+		var line Line
+		err := json.Unmarshal(scanner.Bytes(), &line)
+		if err != nil {
+			return nil, fmt.Errorf("reading log spill file: %v", err)
+		}
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading log spill file: %v", err)
+	}
+	return lines, nil
+}
+
+// Deletes the spill file. The store only has the lines in memory after this.
+func (s *LogStore) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.closed = true
+	s.spillErr = fmt.Errorf("log store closed")
+	s.spilled = nil
+	s.unspilled = nil
+	if len(s.segments) > 0 {
+		s.droppedBefore = s.segments[0].firstSeq
+	} else {
+		s.droppedBefore = s.nextSeq
+	}
+	close(s.spillCh)
+	s.mu.Unlock()
+
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+
+	s.fileClosed = true
+	if s.spillFile == nil {
+		return nil
+	}
+
+	f := s.spillFile
+	s.spillFile = nil
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
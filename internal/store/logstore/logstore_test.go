@@ -0,0 +1,328 @@
+package logstore
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+func TestAppendSplitsLines(t *testing.T) {
+	s := NewLogStore()
+	s.Append(podMeta("fe"), []byte("line 1\nline 2\n"))
+
+	assert.Equal(t, []string{"line 1\n", "line 2\n"}, texts(query(t, s, Query{})))
+	assert.Equal(t, 2, s.Checkpoint())
+}
+
+func TestAppendStitchesPartialLinesPerStream(t *testing.T) {
+	s := NewLogStore()
+	s.Append(podMeta("fe"), []byte("fe hel"))
+	s.Append(podMeta("be"), []byte("be hello\n"))
+	s.Append(podMeta("fe"), []byte("lo\nfe bye"))
+
+	lines := query(t, s, Query{})
+	assert.Equal(t, []string{"be hello\n", "fe hello\n"}, texts(lines))
+	assert.Equal(t, model.ManifestName("fe"), lines[1].ManifestName)
+	assert.Equal(t, 1, lines[1].Seq)
+}
+
+func TestAppendCutsOffLongLines(t *testing.T) {
+	s := NewLogStore()
+	long := make([]byte, maxLineBytes+10)
+	for i := range long {
+		long[i] = 'x'
+	}
+	s.Append(podMeta("fe"), long)
+	s.Append(podMeta("fe"), []byte("\n"))
+
+	lines := query(t, s, Query{})
+	if assert.Equal(t, 2, len(lines)) {
+		assert.Equal(t, maxLineBytes, len(lines[0].Text))
+		assert.Equal(t, "xxxxxxxxxx\n", lines[1].Text)
+	}
+}
+
+func TestQueryByManifest(t *testing.T) {
+	s := NewLogStore()
+	s.Append(podMeta("fe"), []byte("fe 1\n"))
+	s.Append(Meta{Source: SourceTilt}, []byte("tilt 1\n"))
+	s.Append(podMeta("be"), []byte("be 1\n"))
+	s.Append(podMeta("fe"), []byte("fe 2\n"))
+
+	result := query(t, s, Query{ManifestNames: []model.ManifestName{"fe"}})
+	assert.Equal(t, []string{"fe 1\n", "fe 2\n"}, texts(result))
+
+	s.Append(Meta{ManifestName: "be", Source: SourceBuild}, []byte("be build\n"))
+	result = query(t, s, Query{ManifestNames: []model.ManifestName{"be"}})
+	assert.Equal(t, []string{"be 1\n", "be build\n"}, texts(result))
+
+	// Tilt's own log interleaves the output of pods.
+	result = query(t, s, Query{ManifestNames: []model.ManifestName{""}})
+	assert.Equal(t, []string{"fe 1\n", "tilt 1\n", "be 1\n", "fe 2\n"}, texts(result))
+}
+
+func TestTiltLogTail(t *testing.T) {
+	s := NewLogStore()
+	s.Append(Meta{Source: SourceTilt}, []byte("Starting Tilt\n"))
+	s.Append(podMeta("fe"), []byte("hello\n"))
+	s.Append(Meta{ManifestName: "fe", Source: SourceBuild}, []byte("building\n"))
+
+	assert.Equal(t, "Starting Tilt\nfe          ┊ hello\n", s.TiltLogTail(false).String())
+	assert.Equal(t, "hello\nbuilding\n", s.ManifestLogTail("fe", false).String())
+}
+
+func TestLogTailFitsInMaxBytes(t *testing.T) {
+	s := NewLogStore()
+	appendLines(s, "fe", 0, 20000)
+
+	tail := s.ManifestLogTail("fe", false).String()
+	assert.True(t, len(tail) <= maxTailBytes, "tail bytes: %d", len(tail))
+	assert.True(t, strings.HasPrefix(tail, "line "))
+	assert.True(t, strings.HasSuffix(tail, "line 19999\n"))
+}
+
+func TestQueryRangeAndCheckpoint(t *testing.T) {
+	s := NewLogStore()
+	appendLines(s, "fe", 0, 5)
+
+	result, err := s.Query(Query{From: 1, To: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"line 1\n", "line 2\n"}, texts(result.Lines))
+	assert.Equal(t, 3, result.Checkpoint)
+
+	result, err = s.Query(Query{From: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"line 3\n", "line 4\n"}, texts(result.Lines))
+	assert.Equal(t, 5, result.Checkpoint)
+
+	result, err = s.Query(Query{From: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, result.Lines)
+	assert.Equal(t, 5, result.Checkpoint)
+}
+
+func TestQuerySince(t *testing.T) {
+	s := NewLogStore()
+	start := time.Now()
+	s.Append(Meta{Time: start.Add(-time.Minute), Source: SourceTilt}, []byte("old\n"))
+	s.Append(Meta{Time: start, Source: SourceTilt}, []byte("new\n"))
+
+	assert.Equal(t, []string{"new\n"}, texts(query(t, s, Query{Since: start})))
+}
+
+func TestSpillToDisk(t *testing.T) {
+	s := newLogStore(1000, 200)
+	defer func() {
+		_ = s.Close()
+	}()
+
+	appendLines(s, "fe", 0, 100)
+	appendLines(s, "be", 100, 200)
+	waitForSpills(t, s)
+
+	s.mu.Lock()
+	assert.True(t, s.memoryBytes <= 1000+200, "memory bytes: %d", s.memoryBytes)
+	assert.NotEmpty(t, s.spilled)
+	s.mu.Unlock()
+
+	result, err := s.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, result.Truncated)
+	if assert.Equal(t, 200, len(result.Lines)) {
+		for i, line := range result.Lines {
+			assert.Equal(t, i, line.Seq)
+			assert.Equal(t, fmt.Sprintf("line %d\n", i), line.Text)
+		}
+		assert.Equal(t, model.ManifestName("fe"), result.Lines[0].ManifestName)
+		assert.Equal(t, SourcePod, result.Lines[0].Source)
+	}
+
+	result, err = s.Query(Query{From: 10, To: 12, ManifestNames: []model.ManifestName{"fe"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"line 10\n", "line 11\n"}, texts(result.Lines))
+}
+
+func TestQueryLimit(t *testing.T) {
+	s := NewLogStore()
+	appendLines(s, "fe", 0, 3)
+	appendLines(s, "be", 3, 5)
+	appendLines(s, "fe", 5, 6)
+
+	q := Query{ManifestNames: []model.ManifestName{"fe"}, Limit: 2}
+	result, err := s.Query(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"line 0\n", "line 1\n"}, texts(result.Lines))
+	assert.Equal(t, 2, result.Checkpoint)
+	assert.True(t, result.More)
+
+	q.From = result.Checkpoint
+	result, err = s.Query(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"line 2\n", "line 5\n"}, texts(result.Lines))
+	assert.Equal(t, 6, result.Checkpoint)
+	assert.False(t, result.More)
+}
+
+func TestQueryPagesThroughSpilledLines(t *testing.T) {
+	s := newLogStore(1000, 200)
+	s.pageReadBytes = 400
+	defer func() {
+		_ = s.Close()
+	}()
+
+	appendLines(s, "fe", 0, 100)
+	appendLines(s, "be", 100, 200)
+	waitForSpills(t, s)
+
+	var seqs []int
+	pages := 0
+	q := Query{ManifestNames: []model.ManifestName{"be"}, Limit: 30}
+	for {
+		result, err := s.Query(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, len(result.Lines) <= 30)
+		for _, line := range result.Lines {
+			seqs = append(seqs, line.Seq)
+		}
+		pages++
+
+		if !result.More {
+			assert.Equal(t, 200, result.Checkpoint)
+			break
+		}
+		if !assert.True(t, result.Checkpoint > q.From, "checkpoint didn't move") {
+			return
+		}
+		q.From = result.Checkpoint
+	}
+
+	// The limit alone needs 4 pages. We also end pages while reading
+	// the spilled lines from fe, even though none of them match.
+	assert.True(t, pages > 4, "pages: %d", pages)
+	if assert.Equal(t, 100, len(seqs)) {
+		for i, seq := range seqs {
+			assert.Equal(t, 100+i, seq)
+		}
+	}
+}
+
+func TestQueryWhileSpilling(t *testing.T) {
+	s := newLogStore(1000, 200)
+	defer func() {
+		_ = s.Close()
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		appendLines(s, "fe", 0, 500)
+	}()
+
+	for {
+		result, err := s.Query(Query{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, line := range result.Lines {
+			if !assert.Equal(t, i, line.Seq) {
+				return
+			}
+		}
+
+		select {
+		case <-done:
+			waitForSpills(t, s)
+			assert.Equal(t, 500, len(query(t, s, Query{})))
+			return
+		default:
+		}
+	}
+}
+
+func TestCloseDropsSpilledLines(t *testing.T) {
+	s := newLogStore(1000, 200)
+	appendLines(s, "fe", 0, 100)
+	waitForSpills(t, s)
+
+	err := s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appendLines(s, "fe", 100, 200)
+	result, err := s.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, result.Truncated)
+	assert.Equal(t, "line 199\n", result.Lines[len(result.Lines)-1].Text)
+	assert.True(t, result.Lines[0].Seq > 0)
+}
+
+func podMeta(mn model.ManifestName) Meta {
+	return Meta{
+		Time:         time.Now(),
+		ManifestName: mn,
+		Source:       SourcePod,
+		PodID:        "pod-id",
+		Container:    "container",
+		Level:        LevelInfo,
+	}
+}
+
+func appendLines(s *LogStore, mn model.ManifestName, start, end int) {
+	for i := start; i < end; i++ {
+		s.Append(podMeta(mn), []byte(fmt.Sprintf("line %d\n", i)))
+	}
+}
+
+// Waits for the spill goroutine to write everything it was handed.
+func waitForSpills(t *testing.T, s *LogStore) {
+	for i := 0; i < 100; i++ {
+		s.mu.Lock()
+		n := len(s.unspilled)
+		s.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for lines to spill to disk")
+}
+
+func query(t *testing.T, s *LogStore, q Query) []Line {
+	result, err := s.Query(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Lines
+}
+
+func texts(lines []Line) []string {
+	result := []string{}
+	for _, line := range lines {
+		result = append(result, line.Text)
+	}
+	return result
+}
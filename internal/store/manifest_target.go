@@ -1,6 +1,11 @@
 package store
 
-import "github.com/windmilleng/tilt/internal/model"
+import (
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/windmilleng/tilt/internal/dockercompose"
+	"github.com/windmilleng/tilt/internal/model"
+)
 
 type ManifestTarget struct {
 	Manifest model.Manifest
@@ -22,4 +27,83 @@ func (t ManifestTarget) Status() model.TargetStatus {
 	return t.State
 }
 
+// Whether this manifest has been deployed successfully and is ready for
+// the resources that depend on it: its docker-compose container is up, its
+// most recent pod is running and ready, or (for local resources) its command
+// has succeeded and its serve command, if any, is running. Kubernetes objects
+// that don't run pods are ready as soon as they're deployed. If the manifest
+// has a readiness probe, the probe must also be passing.
+func (t ManifestTarget) IsReady() bool {
+	ms := t.State
+	if !ms.StartedFirstBuild() || !ms.CurrentBuild.Empty() {
+		return false
+	}
+
+	if ms.LastBuild().Error != nil {
+		return false
+	}
+
+	if t.Manifest.ReadinessProbe != nil && !t.probeReady() {
+		return false
+	}
+
+	if t.Manifest.IsLocal() {
+		if t.Manifest.LocalTarget().ServeCmd.Empty() {
+			return true
+		}
+		return ms.LocalRuntimeState().Status == LocalProcessStatusRunning
+	}
+
+	if t.Manifest.IsDC() {
+		return ms.DCResourceState().Status == dockercompose.StatusUp
+	}
+
+	// A Job's pod stops running when the Job is done, so its phase
+	// doesn't tell us anything.
+	if t.Manifest.K8sTarget().IsJob {
+		return ms.JobStatus == K8sJobStatusCompleted
+	}
+
+	if t.Manifest.K8sTarget().NoPods {
+		return true
+	}
+
+	pod := ms.MostRecentPod()
+	return pod.Phase == v1.PodRunning && pod.Ready && !pod.Deleting
+}
+
+// Readiness for display. Unlike IsReady, this only reports on resources
+// that have something to tell us: a readiness probe or a running pod.
+func (t ManifestTarget) Readiness() model.Readiness {
+	ms := t.State
+	if ms.LastSuccessfulDeployTime.IsZero() {
+		return model.ReadinessUnknown
+	}
+
+	if t.Manifest.ReadinessProbe != nil {
+		if t.probeReady() {
+			return model.ReadinessReady
+		}
+		return model.ReadinessNotReady
+	}
+
+	if t.Manifest.IsK8s() {
+		pod := ms.MostRecentPod()
+		if pod.Phase != v1.PodRunning {
+			return model.ReadinessUnknown
+		}
+		if pod.Ready {
+			return model.ReadinessReady
+		}
+		return model.ReadinessNotReady
+	}
+
+	return model.ReadinessUnknown
+}
+
+func (t ManifestTarget) probeReady() bool {
+	ps := t.State.ProbeStatus
+	return ps.Ready && ps.DeployTime.Equal(t.State.LastSuccessfulDeployTime)
+}
+
 var _ model.Target = &ManifestTarget{}
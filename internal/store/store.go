@@ -173,6 +173,10 @@ func (s *Store) maybeFinished() (bool, error) {
 		return true, nil
 	}
 
+	if state.EngineMode == EngineModeCI {
+		return state.ciFinished()
+	}
+
 	if len(state.ManifestTargets) == 0 {
 		return false, nil
 	}
@@ -8,11 +8,12 @@ type TestingStore struct {
 	state   *EngineState
 	stateMu sync.RWMutex
 
-	Actions []Action
+	actionsMu sync.Mutex
+	Actions   []Action
 }
 
 func NewTestingStore() *TestingStore {
-	return &TestingStore{}
+	return &TestingStore{state: NewState()}
 }
 
 func (s *TestingStore) SetState(state EngineState) {
@@ -31,5 +32,15 @@ func (s *TestingStore) RUnlockState() {
 }
 
 func (s *TestingStore) Dispatch(action Action) {
+	s.actionsMu.Lock()
+	defer s.actionsMu.Unlock()
 	s.Actions = append(s.Actions, action)
 }
+
+// Returns a copy of the actions dispatched so far. Safe to call while
+// other goroutines are dispatching.
+func (s *TestingStore) ActionsSnapshot() []Action {
+	s.actionsMu.Lock()
+	defer s.actionsMu.Unlock()
+	return append([]Action{}, s.Actions...)
+}
@@ -1 +1,301 @@
 package tiltfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"go.starlark.net/starlark"
+)
+
+// The file next to the Tiltfile that can set config values, as a JSON object.
+const UserConfigFileName = "tilt_config.json"
+
+const (
+	configN             = "config"
+	configDefineStringN = "define_string"
+	configDefineListN   = "define_string_list"
+	configDefineBoolN   = "define_bool"
+	configParseN        = "parse"
+)
+
+type configSettingType int
+
+const (
+	configString configSettingType = iota
+	configStringList
+	configBool
+)
+
+func (t configSettingType) String() string {
+	switch t {
+	case configStringList:
+		return "string_list"
+	case configBool:
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+// A setting declared in the Tiltfile with config.define_*.
+type configSetting struct {
+	name  string
+	typ   configSettingType
+	usage string
+
+	// Whether this setting takes the positional args from the command line.
+	isArgs bool
+}
+
+// Settings that the Tiltfile can read with config.parse(), set from
+// the command line (after `--`) or from tilt_config.json.
+type userConfig struct {
+	// The args after `--` on the command line.
+	args []string
+
+	settings []configSetting
+	byName   map[string]configSetting
+
+	// The result of config.parse(), once it's been called.
+	parsed *starlark.Dict
+}
+
+func newUserConfig(args []string) *userConfig {
+	return &userConfig{
+		args:   args,
+		byName: make(map[string]configSetting),
+	}
+}
+
+func (s *tiltfileState) configDefineString(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return s.configDefine(fn, args, kwargs, configString)
+}
+
+func (s *tiltfileState) configDefineStringList(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return s.configDefine(fn, args, kwargs, configStringList)
+}
+
+func (s *tiltfileState) configDefineBool(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return s.configDefine(fn, args, kwargs, configBool)
+}
+
+func (s *tiltfileState) configDefine(fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, typ configSettingType) (starlark.Value, error) {
+	var name, usage string
+	var isArgs bool
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"name", &name,
+		"args?", &isArgs,
+		"usage?", &usage)
+	if err != nil {
+		return nil, err
+	}
+
+	c := s.userConfig
+	if c.parsed != nil {
+		return nil, fmt.Errorf("%s: can't define settings after config.%s()", fn.Name(), configParseN)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%s: name can't be empty", fn.Name())
+	}
+	if _, ok := c.byName[name]; ok {
+		return nil, fmt.Errorf("%s: setting %q already defined", fn.Name(), name)
+	}
+	if isArgs {
+		if typ == configBool {
+			return nil, fmt.Errorf("%s: a bool setting can't take the positional args", fn.Name())
+		}
+		for _, other := range c.settings {
+			if other.isArgs {
+				return nil, fmt.Errorf("%s: %q and %q can't both take the positional args", fn.Name(), other.name, name)
+			}
+		}
+	}
+
+	setting := configSetting{name: name, typ: typ, usage: usage, isArgs: isArgs}
+	c.settings = append(c.settings, setting)
+	c.byName[name] = setting
+	return starlark.None, nil
+}
+
+// Returns a dict of the settings that were set, from tilt_config.json
+// with the command line taking precedence.
+func (s *tiltfileState) configParse(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	c := s.userConfig
+	if c.parsed != nil {
+		return c.parsed, nil
+	}
+
+	values := make(map[string]starlark.Value)
+
+	configPath := filepath.Join(s.absWorkingDir(), UserConfigFileName)
+	err = c.readFile(s, configPath, values)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", configPath, err)
+	}
+
+	err = c.parseArgs(values)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &starlark.Dict{}
+	for _, setting := range c.settings {
+		v, ok := values[setting.name]
+		if !ok {
+			continue
+		}
+		err := result.SetKey(starlark.String(setting.name), v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.parsed = result
+	return result, nil
+}
+
+// Args on the command line do nothing unless the Tiltfile reads them.
+func (s *tiltfileState) checkUserConfigParsed() {
+	c := s.userConfig
+	if len(c.args) > 0 && c.parsed == nil {
+		s.warnings = append(s.warnings, fmt.Sprintf("Tiltfile args %v were ignored, because the Tiltfile doesn't call config.%s()", c.args, configParseN))
+	}
+}
+
+func (c *userConfig) readFile(s *tiltfileState, path string, values map[string]starlark.Value) error {
+	// Record the file even if it doesn't exist, so that creating it reloads the Tiltfile.
+	contents, err := s.readFile(s.localPathFromString(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var m map[string]interface{}
+	err = json.Unmarshal(contents, &m)
+	if err != nil {
+		return fmt.Errorf("parsing JSON: %v", err)
+	}
+
+	for name, v := range m {
+		setting, ok := c.byName[name]
+		if !ok {
+			return fmt.Errorf("%q is not a defined setting", name)
+		}
+
+		val, err := setting.starlarkValueFromJSON(v)
+		if err != nil {
+			return err
+		}
+		values[name] = val
+	}
+	return nil
+}
+
+func (c *userConfig) parseArgs(values map[string]starlark.Value) error {
+	var usage bytes.Buffer
+	fs := pflag.NewFlagSet("Tiltfile", pflag.ContinueOnError)
+	fs.SetOutput(&usage)
+
+	stringVals := make(map[string]*string)
+	lists := make(map[string]*[]string)
+	bools := make(map[string]*bool)
+	var argsSetting *configSetting
+	for i, setting := range c.settings {
+		if setting.isArgs {
+			argsSetting = &c.settings[i]
+			continue
+		}
+		switch setting.typ {
+		case configString:
+			stringVals[setting.name] = fs.String(setting.name, "", setting.usage)
+		case configStringList:
+			lists[setting.name] = fs.StringArray(setting.name, nil, setting.usage)
+		case configBool:
+			bools[setting.name] = fs.Bool(setting.name, false, setting.usage)
+		}
+	}
+
+	err := fs.Parse(c.args)
+	if err != nil {
+		if err == pflag.ErrHelp {
+			return fmt.Errorf("Tiltfile settings:\n%s", fs.FlagUsages())
+		}
+		return fmt.Errorf("parsing Tiltfile args: %v\nTiltfile settings:\n%s", err, fs.FlagUsages())
+	}
+
+	fs.Visit(func(f *pflag.Flag) {
+		name := f.Name
+		if v, ok := stringVals[name]; ok {
+			values[name] = starlark.String(*v)
+		} else if v, ok := lists[name]; ok {
+			values[name] = stringList(*v)
+		} else if v, ok := bools[name]; ok {
+			values[name] = starlark.Bool(*v)
+		}
+	})
+
+	positional := fs.Args()
+	if len(positional) == 0 {
+		return nil
+	}
+	if argsSetting == nil {
+		return fmt.Errorf("positional Tiltfile args given, but no setting was defined with args=True: %v", positional)
+	}
+	switch argsSetting.typ {
+	case configStringList:
+		values[argsSetting.name] = stringList(positional)
+	default:
+		if len(positional) > 1 {
+			return fmt.Errorf("%q takes one positional arg; got %v", argsSetting.name, positional)
+		}
+		values[argsSetting.name] = starlark.String(positional[0])
+	}
+	return nil
+}
+
+func (setting configSetting) starlarkValueFromJSON(v interface{}) (starlark.Value, error) {
+	switch setting.typ {
+	case configString:
+		s, ok := v.(string)
+		if ok {
+			return starlark.String(s), nil
+		}
+	case configBool:
+		b, ok := v.(bool)
+		if ok {
+			return starlark.Bool(b), nil
+		}
+	case configStringList:
+		list, ok := v.([]interface{})
+		if ok {
+			var result []string
+			for _, elem := range list {
+				s, ok := elem.(string)
+				if !ok {
+					return nil, fmt.Errorf("%q must be a %s; found element %v", setting.name, setting.typ, elem)
+				}
+				result = append(result, s)
+			}
+			return stringList(result), nil
+		}
+	}
+	return nil, fmt.Errorf("%q must be a %s; got %v", setting.name, setting.typ, v)
+}
+
+func stringList(l []string) *starlark.List {
+	var elems []starlark.Value
+	for _, s := range l {
+		elems = append(elems, starlark.String(s))
+	}
+	return starlark.NewList(elems)
+}
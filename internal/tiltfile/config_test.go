@@ -0,0 +1,177 @@
+package tiltfile
+
+import (
+	"testing"
+)
+
+const selectServicesTiltfile = `
+config.define_string_list('to-run', args=True)
+cfg = config.parse()
+for name in cfg.get('to-run', ['foo', 'bar']):
+  docker_build('gcr.io/' + name, name)
+  k8s_yaml(name + '.yaml')
+`
+
+func TestConfigDefaultsWhenUnset(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("Tiltfile", selectServicesTiltfile)
+
+	f.load()
+
+	f.assertNumManifests(2)
+	f.assertConfigFiles("Tiltfile", ".tiltignore", UserConfigFileName, "foo/Dockerfile", "foo.yaml", "bar/Dockerfile", "bar.yaml")
+}
+
+func TestConfigPositionalArgs(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("Tiltfile", selectServicesTiltfile)
+	f.tiltfileArgs = []string{"bar"}
+
+	f.load()
+
+	f.assertNextManifest("bar", db(image("gcr.io/bar")))
+	f.assertNumManifests(0)
+}
+
+func TestConfigFile(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("Tiltfile", selectServicesTiltfile)
+	f.file(UserConfigFileName, `{"to-run": ["foo"]}`)
+
+	f.load()
+
+	f.assertNextManifest("foo", db(image("gcr.io/foo")))
+	f.assertNumManifests(0)
+}
+
+func TestConfigArgsOverrideFile(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+config.define_string('env')
+config.define_bool('debug')
+config.define_string_list('services')
+cfg = config.parse()
+expected = {'env': 'prod', 'debug': True, 'services': ['a', 'b']}
+if cfg != expected:
+  fail('expected %s; got %s' % (expected, cfg))
+`)
+	f.file(UserConfigFileName, `{"env": "dev", "debug": true}`)
+	f.tiltfileArgs = []string{"--env=prod", "--services", "a", "--services=b"}
+
+	f.load()
+}
+
+func TestConfigParseReturnsSameDict(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+config.define_string('env')
+if config.parse() != config.parse():
+  fail('parse() returned different results')
+`)
+	f.tiltfileArgs = []string{"--env=prod"}
+
+	f.load()
+}
+
+func TestConfigUnknownFlag(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+config.define_string('env', usage='which environment to deploy to')
+config.parse()
+`)
+	f.tiltfileArgs = []string{"--enviroment=prod"}
+
+	f.loadErrString("unknown flag: --enviroment", "--env string", "which environment to deploy to")
+}
+
+func TestConfigPositionalArgsWithoutArgsSetting(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+config.define_string('env')
+config.parse()
+`)
+	f.tiltfileArgs = []string{"foo"}
+
+	f.loadErrString("no setting was defined with args=True")
+}
+
+func TestConfigFileUndefinedSetting(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+config.define_string('env')
+config.parse()
+`)
+	f.file(UserConfigFileName, `{"environment": "prod"}`)
+
+	f.loadErrString(UserConfigFileName, `"environment" is not a defined setting`)
+}
+
+func TestConfigFileWrongType(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+config.define_string_list('services')
+config.parse()
+`)
+	f.file(UserConfigFileName, `{"services": "foo"}`)
+
+	f.loadErrString(`"services" must be a string_list`)
+}
+
+func TestConfigDefineAfterParse(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+config.parse()
+config.define_string('env')
+`)
+
+	f.loadErrString("config.define_string: can't define settings after config.parse()")
+}
+
+func TestConfigTwoArgsSettings(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+config.define_string_list('a', args=True)
+config.define_string_list('b', args=True)
+`)
+
+	f.loadErrString(`"a" and "b" can't both take the positional args`)
+}
+
+func TestConfigArgsWithoutParse(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+	f.tiltfileArgs = []string{"--env=prod"}
+
+	f.loadAssertWarnings("Tiltfile args [--env=prod] were ignored, because the Tiltfile doesn't call config.parse()")
+}
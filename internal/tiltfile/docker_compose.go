@@ -60,10 +60,16 @@ func (s *tiltfileState) dockerCompose(thread *starlark.Thread, fn *starlark.Buil
 func (s *tiltfileState) dcResource(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var name string
 	var imageVal starlark.Value
+	var resourceDepsVal starlark.Value
+	var readinessProbeVal starlark.Value
+	var triggerModeVal starlark.Value
 
 	if err := starlark.UnpackArgs(fn.Name(), args, kwargs,
 		"name", &name,
 		"image", &imageVal, // in future this will be optional
+		"resource_deps?", &resourceDepsVal,
+		"readiness_probe?", &readinessProbeVal,
+		"trigger_mode?", &triggerModeVal,
 	); err != nil {
 		return nil, err
 	}
@@ -95,6 +101,21 @@ func (s *tiltfileState) dcResource(thread *starlark.Thread, fn *starlark.Builtin
 	}
 	svc.ImageRef = normalized
 
+	svc.ResourceDeps, err = resourceDepsFromStarlarkValue(fn, resourceDepsVal)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.ReadinessProbe, err = probeFromStarlarkValue(fn, readinessProbeVal)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.TriggerMode, err = triggerModeFromStarlarkValue(fn, triggerModeVal)
+	if err != nil {
+		return nil, err
+	}
+
 	return starlark.None, nil
 }
 
@@ -263,6 +284,13 @@ type dcService struct {
 
 	DependencyIDs  []model.TargetID
 	PublishedPorts []int
+
+	// Other resources that must be ready before this service is first deployed
+	ResourceDeps []model.ManifestName
+
+	ReadinessProbe *model.Probe
+
+	TriggerMode triggerMode
 }
 
 func (c dcConfig) GetService(name string) (dcService, error) {
@@ -47,6 +47,13 @@ type k8sResource struct {
 	extraPodSelectors []labels.Selector
 
 	dependencyIDs []model.TargetID
+
+	// other resources that must be ready before this one is first deployed
+	resourceDeps []model.ManifestName
+
+	readinessProbe *model.Probe
+
+	triggerMode triggerMode
 }
 
 const deprecatedResourceAssemblyV1Warning = "This Tiltfile is using k8s resource assembly version 1, which has been " +
@@ -60,6 +67,9 @@ type k8sResourceOptions struct {
 	newName           string
 	portForwards      []portForward
 	extraPodSelectors []labels.Selector
+	resourceDeps      []model.ManifestName
+	readinessProbe    *model.Probe
+	triggerMode       triggerMode
 	tiltfilePosition  syntax.Position
 	consumed          bool
 }
@@ -200,6 +210,9 @@ func (s *tiltfileState) k8sResourceV1(thread *starlark.Thread, fn *starlark.Buil
 	var imageVal starlark.Value
 	var portForwardsVal starlark.Value
 	var extraPodSelectorsVal starlark.Value
+	var resourceDepsVal starlark.Value
+	var readinessProbeVal starlark.Value
+	var triggerModeVal starlark.Value
 
 	if err := starlark.UnpackArgs(fn.Name(), args, kwargs,
 		"name", &name,
@@ -207,6 +220,9 @@ func (s *tiltfileState) k8sResourceV1(thread *starlark.Thread, fn *starlark.Buil
 		"image?", &imageVal,
 		"port_forwards?", &portForwardsVal,
 		"extra_pod_selectors?", &extraPodSelectorsVal,
+		"resource_deps?", &resourceDepsVal,
+		"readiness_probe?", &readinessProbeVal,
+		"trigger_mode?", &triggerModeVal,
 	); err != nil {
 		return nil, err
 	}
@@ -260,6 +276,21 @@ func (s *tiltfileState) k8sResourceV1(thread *starlark.Thread, fn *starlark.Buil
 		return nil, err
 	}
 
+	r.resourceDeps, err = resourceDepsFromStarlarkValue(fn, resourceDepsVal)
+	if err != nil {
+		return nil, err
+	}
+
+	r.readinessProbe, err = probeFromStarlarkValue(fn, readinessProbeVal)
+	if err != nil {
+		return nil, err
+	}
+
+	r.triggerMode, err = triggerModeFromStarlarkValue(fn, triggerModeVal)
+	if err != nil {
+		return nil, err
+	}
+
 	return starlark.None, nil
 }
 
@@ -279,12 +310,18 @@ func (s *tiltfileState) k8sResourceV2(thread *starlark.Thread, fn *starlark.Buil
 	var newName string
 	var portForwardsVal starlark.Value
 	var extraPodSelectorsVal starlark.Value
+	var resourceDepsVal starlark.Value
+	var readinessProbeVal starlark.Value
+	var triggerModeVal starlark.Value
 
 	if err := starlark.UnpackArgs(fn.Name(), args, kwargs,
 		"workload", &workload,
 		"new_name?", &newName,
 		"port_forwards?", &portForwardsVal,
 		"extra_pod_selectors?", &extraPodSelectorsVal,
+		"resource_deps?", &resourceDepsVal,
+		"readiness_probe?", &readinessProbeVal,
+		"trigger_mode?", &triggerModeVal,
 	); err != nil {
 		return nil, err
 	}
@@ -303,6 +340,21 @@ func (s *tiltfileState) k8sResourceV2(thread *starlark.Thread, fn *starlark.Buil
 		return nil, err
 	}
 
+	resourceDeps, err := resourceDepsFromStarlarkValue(fn, resourceDepsVal)
+	if err != nil {
+		return nil, err
+	}
+
+	readinessProbe, err := probeFromStarlarkValue(fn, readinessProbeVal)
+	if err != nil {
+		return nil, err
+	}
+
+	triggerMode, err := triggerModeFromStarlarkValue(fn, triggerModeVal)
+	if err != nil {
+		return nil, err
+	}
+
 	if opts, ok := s.k8sResourceOptions[workload]; ok {
 		return nil, fmt.Errorf("%s already called for %s, at %s", fn.Name(), workload, opts.tiltfilePosition.String())
 	}
@@ -311,6 +363,9 @@ func (s *tiltfileState) k8sResourceV2(thread *starlark.Thread, fn *starlark.Buil
 		newName:           newName,
 		portForwards:      portForwards,
 		extraPodSelectors: extraPodSelectors,
+		resourceDeps:      resourceDeps,
+		readinessProbe:    readinessProbe,
+		triggerMode:       triggerMode,
 		tiltfilePosition:  thread.Caller().Position(),
 	}
 
@@ -598,6 +653,8 @@ func (s *tiltfileState) yamlEntitiesFromSkylarkValue(v starlark.Value) ([]k8s.K8
 		return nil, nil
 	case *blob:
 		return parseYAMLFromBlob(*v)
+	case *k8sObjectValue:
+		return []k8s.K8sEntity{v.entity.DeepCopy()}, nil
 	default:
 		yamlPath, err := s.localPathFromSkylarkValue(v)
 		if err != nil {
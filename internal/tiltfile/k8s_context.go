@@ -0,0 +1,58 @@
+package tiltfile
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+func (s *tiltfileState) allowK8sContexts(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var contexts starlark.Value
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs, "contexts", &contexts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range starlarkValueOrSequenceToSlice(contexts) {
+		switch c := c.(type) {
+		case starlark.String:
+			s.allowedK8sContexts = append(s.allowedK8sContexts, k8s.KubeContext(c))
+		default:
+			return nil, fmt.Errorf("%s: contexts must be a string or a sequence of strings; found a %s", fn.Name(), c.Type())
+		}
+	}
+
+	return starlark.None, nil
+}
+
+// Deploying to the wrong cluster can take down production, so we only deploy
+// to local clusters, or to contexts that the Tiltfile explicitly allows.
+func (s *tiltfileState) validateK8sContext(kubeContext k8s.KubeContext, env k8s.Env, manifests []model.Manifest, unresourced []k8s.K8sEntity) error {
+	if env.IsLocalCluster() {
+		return nil
+	}
+
+	usesK8s := len(unresourced) > 0
+	for _, m := range manifests {
+		if m.IsK8s() {
+			usesK8s = true
+		}
+	}
+	if !usesK8s {
+		return nil
+	}
+
+	for _, c := range s.allowedK8sContexts {
+		if c == kubeContext {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Stop! %q might be production.\n"+
+		"If you're sure you want to deploy there, add:\n"+
+		"  %s(%q)\n"+
+		"to your Tiltfile. Otherwise, switch k8s contexts and restart Tilt.", kubeContext, allowK8sContextsN, kubeContext)
+}
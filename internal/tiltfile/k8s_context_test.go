@@ -0,0 +1,97 @@
+package tiltfile
+
+import (
+	"testing"
+
+	"github.com/windmilleng/tilt/internal/docker"
+	"github.com/windmilleng/tilt/internal/dockercompose"
+	"github.com/windmilleng/tilt/internal/k8s"
+)
+
+func TestK8sContextDisallowed(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setK8sContext("gke_prod", k8s.EnvGKE)
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+
+	f.loadErrString(`Stop! "gke_prod" might be production`, `allow_k8s_contexts("gke_prod")`)
+}
+
+func TestK8sContextAllowed(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setK8sContext("gke_dev", k8s.EnvGKE)
+	f.setupFoo()
+	f.file("Tiltfile", `
+allow_k8s_contexts(['gke_staging', 'gke_dev'])
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+
+	f.load()
+	f.assertNextManifest("foo")
+}
+
+func TestK8sContextAllowedString(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setK8sContext("gke_dev", k8s.EnvGKE)
+	f.setupFoo()
+	f.file("Tiltfile", `
+allow_k8s_contexts('gke_dev')
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+
+	f.load()
+	f.assertNextManifest("foo")
+}
+
+func TestK8sContextDisallowedGlobalYAML(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setK8sContext("gke_prod", k8s.EnvGKE)
+	f.file("Tiltfile", `
+k8s_yaml(blob('''apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+'''))
+`)
+
+	f.loadErrString(`Stop! "gke_prod" might be production`)
+}
+
+func TestK8sContextIgnoredWithoutK8s(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setK8sContext("gke_prod", k8s.EnvGKE)
+	f.file("Tiltfile", `
+local_resource('hello', 'echo hi')
+`)
+
+	f.load()
+}
+
+func TestAllowK8sContextsWrongType(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `allow_k8s_contexts([1])`)
+
+	f.loadErrString("allow_k8s_contexts: contexts must be a string or a sequence of strings; found a int")
+}
+
+func (f *fixture) setK8sContext(kubeContext k8s.KubeContext, env k8s.Env) {
+	dcc := dockercompose.NewDockerComposeClient(docker.Env{})
+	f.tfl = ProvideTiltfileLoader(f.an, dcc, kubeContext, env)
+}
@@ -0,0 +1,478 @@
+package tiltfile
+
+import (
+	"fmt"
+	"sort"
+
+	"go.starlark.net/starlark"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+)
+
+const (
+	k8sObjectsN = "k8s_objects"
+
+	k8sObjectKindN       = "kind"
+	k8sObjectNameN       = "name"
+	k8sObjectNamespaceN  = "namespace"
+	k8sObjectLabelsN     = "labels"
+	k8sObjectContainersN = "containers"
+	k8sObjectEnvN        = "env"
+	k8sObjectReplicasN   = "replicas"
+
+	k8sContainerNameN     = "name"
+	k8sContainerImageN    = "image"
+	k8sContainerEnvN      = "env"
+	k8sContainerLimitsN   = "limits"
+	k8sContainerRequestsN = "requests"
+)
+
+// Parses YAML (in any form k8s_yaml accepts) into a list of k8s_objects,
+// which can be edited and passed back to k8s_yaml.
+func (s *tiltfileState) k8sObjects(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var yamlValue starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"yaml", &yamlValue,
+	); err != nil {
+		return nil, err
+	}
+
+	entities, err := s.yamlEntitiesFromSkylarkValueOrList(yamlValue)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []starlark.Value
+	for _, e := range entities {
+		result = append(result, newK8sObjectValue(e))
+	}
+	return starlark.NewList(result), nil
+}
+
+// A k8s_object wraps a single Kubernetes entity, and exposes the fields
+// people most often want to change locally as attributes.
+type k8sObjectValue struct {
+	entity k8s.K8sEntity
+	frozen bool
+}
+
+var _ starlark.HasSetField = &k8sObjectValue{}
+
+func newK8sObjectValue(e k8s.K8sEntity) *k8sObjectValue {
+	// Copy the entity, so that editing the object doesn't change the YAML it came from.
+	return &k8sObjectValue{entity: e.DeepCopy()}
+}
+
+func (o *k8sObjectValue) String() string {
+	return fmt.Sprintf("k8s_object(kind=%q, name=%q)", o.entity.Kind.Kind, o.entity.Name())
+}
+
+func (o *k8sObjectValue) Type() string {
+	return "k8s_object"
+}
+
+func (o *k8sObjectValue) Freeze() {
+	o.frozen = true
+}
+
+func (o *k8sObjectValue) Truth() starlark.Bool {
+	return true
+}
+
+func (o *k8sObjectValue) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: k8s_object")
+}
+
+func (o *k8sObjectValue) AttrNames() []string {
+	return []string{
+		k8sObjectContainersN,
+		k8sObjectEnvN,
+		k8sObjectKindN,
+		k8sObjectLabelsN,
+		k8sObjectNameN,
+		k8sObjectNamespaceN,
+		k8sObjectReplicasN,
+	}
+}
+
+func (o *k8sObjectValue) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case k8sObjectKindN:
+		return starlark.String(o.entity.Kind.Kind), nil
+	case k8sObjectNameN, k8sObjectNamespaceN, k8sObjectLabelsN:
+		m, err := o.meta()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case k8sObjectNameN:
+			return starlark.String(m.GetName()), nil
+		case k8sObjectNamespaceN:
+			return starlark.String(m.GetNamespace()), nil
+		default:
+			return goStringMapToStarlarkDict(m.GetLabels()), nil
+		}
+	case k8sObjectContainersN:
+		containers, err := o.containers()
+		if err != nil {
+			return nil, err
+		}
+		var result []starlark.Value
+		for _, c := range containers {
+			result = append(result, &k8sContainerValue{container: c, owner: o})
+		}
+		return starlark.NewList(result), nil
+	case k8sObjectEnvN:
+		containers, err := o.containers()
+		if err != nil {
+			return nil, err
+		}
+		result := &starlark.Dict{}
+		for _, c := range containers {
+			err := addEnvToDict(result, c.Env)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case k8sObjectReplicasN:
+		replicas, ok := o.entity.Replicas()
+		if !ok {
+			return starlark.None, nil
+		}
+		return starlark.MakeInt(int(replicas)), nil
+	}
+
+	// Returning nil, nil tells starlark to report a missing attribute.
+	return nil, nil
+}
+
+func (o *k8sObjectValue) SetField(name string, val starlark.Value) error {
+	if o.frozen {
+		return fmt.Errorf("cannot set .%s on frozen k8s_object", name)
+	}
+
+	switch name {
+	case k8sObjectNameN, k8sObjectNamespaceN:
+		s, ok := val.(starlark.String)
+		if !ok {
+			return fmt.Errorf("k8s_object.%s must be a string; got %s", name, val.Type())
+		}
+		m, err := o.meta()
+		if err != nil {
+			return err
+		}
+		if name == k8sObjectNameN {
+			m.SetName(s.GoString())
+		} else {
+			m.SetNamespace(s.GoString())
+		}
+		return nil
+	case k8sObjectLabelsN:
+		d, ok := val.(*starlark.Dict)
+		if !ok {
+			return fmt.Errorf("k8s_object.%s must be a dict; got %s", name, val.Type())
+		}
+		labels, err := skylarkStringDictToGoMap(d)
+		if err != nil {
+			return fmt.Errorf("k8s_object.%s: %v", name, err)
+		}
+		m, err := o.meta()
+		if err != nil {
+			return err
+		}
+		m.SetLabels(labels)
+		return nil
+	case k8sObjectContainersN:
+		return o.setContainers(val)
+	case k8sObjectEnvN:
+		d, ok := val.(*starlark.Dict)
+		if !ok {
+			return fmt.Errorf("k8s_object.%s must be a dict; got %s", name, val.Type())
+		}
+		containers, err := o.containers()
+		if err != nil {
+			return err
+		}
+		for _, c := range containers {
+			err := setEnv(c, d)
+			if err != nil {
+				return fmt.Errorf("k8s_object.%s: %v", name, err)
+			}
+		}
+		return nil
+	case k8sObjectReplicasN:
+		replicas, err := starlark.AsInt32(val)
+		if err != nil {
+			return fmt.Errorf("k8s_object.%s must be an int: %v", name, err)
+		}
+		return o.entity.SetReplicas(int32(replicas))
+	case k8sObjectKindN:
+		return fmt.Errorf("k8s_object.%s can't be changed", name)
+	}
+
+	return fmt.Errorf("k8s_object has no .%s field", name)
+}
+
+func (o *k8sObjectValue) meta() (metav1.Object, error) {
+	m, err := meta.Accessor(o.entity.Obj)
+	if err != nil {
+		return nil, fmt.Errorf("%s has no metadata: %v", o.entity.Kind.Kind, err)
+	}
+	return m, nil
+}
+
+// The containers of every pod spec in the object (but not the init containers).
+func (o *k8sObjectValue) containers() ([]*v1.Container, error) {
+	pods, err := k8s.ExtractPods(&o.entity)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*v1.Container
+	for _, pod := range pods {
+		for i := range pod.Containers {
+			result = append(result, &pod.Containers[i])
+		}
+	}
+	return result, nil
+}
+
+// Replaces the containers of the object's pod spec, e.g., to remove a sidecar.
+func (o *k8sObjectValue) setContainers(val starlark.Value) error {
+	list, ok := val.(*starlark.List)
+	if !ok {
+		return fmt.Errorf("k8s_object.%s must be a list; got %s", k8sObjectContainersN, val.Type())
+	}
+
+	pods, err := k8s.ExtractPods(&o.entity)
+	if err != nil {
+		return err
+	}
+	if len(pods) != 1 {
+		return fmt.Errorf("k8s_object.%s can only be set on objects with one pod spec; %s %q has %d",
+			k8sObjectContainersN, o.entity.Kind.Kind, o.entity.Name(), len(pods))
+	}
+
+	containers := make([]v1.Container, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		c, ok := list.Index(i).(*k8sContainerValue)
+		if !ok {
+			return fmt.Errorf("k8s_object.%s: element %d must be a k8s_container; got %s",
+				k8sObjectContainersN, i, list.Index(i).Type())
+		}
+		containers = append(containers, *c.container.DeepCopy())
+	}
+	pods[0].Containers = containers
+	return nil
+}
+
+// A container in the pod spec of a k8s_object. Edits write through to the object.
+type k8sContainerValue struct {
+	container *v1.Container
+	owner     *k8sObjectValue
+}
+
+var _ starlark.HasSetField = &k8sContainerValue{}
+
+func (c *k8sContainerValue) String() string {
+	return fmt.Sprintf("k8s_container(name=%q)", c.container.Name)
+}
+
+func (c *k8sContainerValue) Type() string {
+	return "k8s_container"
+}
+
+func (c *k8sContainerValue) Freeze() {
+	c.owner.Freeze()
+}
+
+func (c *k8sContainerValue) Truth() starlark.Bool {
+	return true
+}
+
+func (c *k8sContainerValue) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: k8s_container")
+}
+
+func (c *k8sContainerValue) AttrNames() []string {
+	return []string{
+		k8sContainerEnvN,
+		k8sContainerImageN,
+		k8sContainerLimitsN,
+		k8sContainerNameN,
+		k8sContainerRequestsN,
+	}
+}
+
+func (c *k8sContainerValue) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case k8sContainerNameN:
+		return starlark.String(c.container.Name), nil
+	case k8sContainerImageN:
+		return starlark.String(c.container.Image), nil
+	case k8sContainerEnvN:
+		result := &starlark.Dict{}
+		err := addEnvToDict(result, c.container.Env)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	case k8sContainerLimitsN:
+		return resourceListToStarlarkDict(c.container.Resources.Limits), nil
+	case k8sContainerRequestsN:
+		return resourceListToStarlarkDict(c.container.Resources.Requests), nil
+	}
+
+	return nil, nil
+}
+
+func (c *k8sContainerValue) SetField(name string, val starlark.Value) error {
+	if c.owner.frozen {
+		return fmt.Errorf("cannot set .%s on frozen k8s_container", name)
+	}
+
+	switch name {
+	case k8sContainerNameN, k8sContainerImageN:
+		s, ok := val.(starlark.String)
+		if !ok {
+			return fmt.Errorf("k8s_container.%s must be a string; got %s", name, val.Type())
+		}
+		if name == k8sContainerNameN {
+			c.container.Name = s.GoString()
+		} else {
+			c.container.Image = s.GoString()
+		}
+		return nil
+	case k8sContainerEnvN:
+		d, ok := val.(*starlark.Dict)
+		if !ok {
+			return fmt.Errorf("k8s_container.%s must be a dict; got %s", name, val.Type())
+		}
+		err := setEnv(c.container, d)
+		if err != nil {
+			return fmt.Errorf("k8s_container.%s: %v", name, err)
+		}
+		return nil
+	case k8sContainerLimitsN, k8sContainerRequestsN:
+		d, ok := val.(*starlark.Dict)
+		if !ok {
+			return fmt.Errorf("k8s_container.%s must be a dict; got %s", name, val.Type())
+		}
+		resources, err := starlarkDictToResourceList(d)
+		if err != nil {
+			return fmt.Errorf("k8s_container.%s: %v", name, err)
+		}
+		if name == k8sContainerLimitsN {
+			c.container.Resources.Limits = resources
+		} else {
+			c.container.Resources.Requests = resources
+		}
+		return nil
+	}
+
+	return fmt.Errorf("k8s_container has no .%s field", name)
+}
+
+// Adds the env vars with literal values to the dict. Env vars set from
+// secrets, config maps, etc. (i.e., with valueFrom) are left out.
+func addEnvToDict(d *starlark.Dict, env []v1.EnvVar) error {
+	for _, e := range env {
+		if e.ValueFrom != nil {
+			continue
+		}
+		err := d.SetKey(starlark.String(e.Name), starlark.String(e.Value))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replaces the env vars with literal values with the contents of the dict.
+// Env vars set with valueFrom are kept, unless the dict overrides them.
+func setEnv(c *v1.Container, d *starlark.Dict) error {
+	values := make(map[string]string, d.Len())
+	var names []string
+	for _, item := range d.Items() {
+		k, ok := item[0].(starlark.String)
+		if !ok {
+			return fmt.Errorf("key is not a string: %s", item[0].Type())
+		}
+		v, ok := item[1].(starlark.String)
+		if !ok {
+			return fmt.Errorf("value of %s is not a string: %s", k.GoString(), item[1].Type())
+		}
+		values[k.GoString()] = v.GoString()
+		names = append(names, k.GoString())
+	}
+
+	var env []v1.EnvVar
+	seen := make(map[string]bool, len(names))
+	for _, e := range c.Env {
+		v, ok := values[e.Name]
+		if !ok {
+			if e.ValueFrom != nil {
+				env = append(env, e)
+			}
+			continue
+		}
+		env = append(env, v1.EnvVar{Name: e.Name, Value: v})
+		seen[e.Name] = true
+	}
+	for _, name := range names {
+		if !seen[name] {
+			env = append(env, v1.EnvVar{Name: name, Value: values[name]})
+		}
+	}
+	c.Env = env
+	return nil
+}
+
+func resourceListToStarlarkDict(resources v1.ResourceList) *starlark.Dict {
+	m := make(map[string]string, len(resources))
+	for name, quantity := range resources {
+		m[string(name)] = quantity.String()
+	}
+	return goStringMapToStarlarkDict(m)
+}
+
+func starlarkDictToResourceList(d *starlark.Dict) (v1.ResourceList, error) {
+	m, err := skylarkStringDictToGoMap(d)
+	if err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	result := make(v1.ResourceList, len(m))
+	for name, value := range m {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		result[v1.ResourceName(name)] = quantity
+	}
+	return result, nil
+}
+
+// Converts a map to a dict, with sorted keys so the order is deterministic.
+func goStringMapToStarlarkDict(m map[string]string) *starlark.Dict {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := &starlark.Dict{}
+	for _, k := range keys {
+		// SetKey only fails on frozen dicts and unhashable keys.
+		_ = result.SetKey(starlark.String(k), starlark.String(m[k]))
+	}
+	return result
+}
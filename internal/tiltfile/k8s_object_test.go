@@ -0,0 +1,203 @@
+package tiltfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/windmilleng/tilt/internal/k8s/testyaml"
+)
+
+func TestK8sObjectsAttrs(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+objs = k8s_objects('foo.yaml')
+if len(objs) != 1:
+  fail('expected 1 object; got %d' % len(objs))
+o = objs[0]
+if o.kind != 'Deployment':
+  fail('kind: got %s' % o.kind)
+if o.name != 'foo':
+  fail('name: got %s' % o.name)
+if o.namespace != '':
+  fail('namespace: got %s' % o.namespace)
+if o.labels != {'app': 'foo'}:
+  fail('labels: got %s' % o.labels)
+if o.replicas != 1:
+  fail('replicas: got %s' % o.replicas)
+if [c.image for c in o.containers] != ['gcr.io/foo']:
+  fail('containers: got %s' % o.containers)
+if o.env != {}:
+  fail('env: got %s' % o.env)
+`)
+
+	f.load()
+}
+
+func TestK8sObjectsEditAndDeploy(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+for o in k8s_objects('foo.yaml'):
+  o.replicas = 3
+  o.namespace = 'dev'
+  o.labels = dict(o.labels, team='platform')
+  env = o.env
+  env['DEBUG'] = '1'
+  o.env = env
+  k8s_yaml(o)
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("foo", db(image("gcr.io/foo")))
+	entities := f.entities(m.K8sTarget().YAML)
+	if !assert.Equal(t, 1, len(entities)) {
+		return
+	}
+	d := entities[0].Obj.(*appsv1.Deployment)
+	assert.Equal(t, int32(3), *d.Spec.Replicas)
+	assert.Equal(t, "dev", d.Namespace)
+	assert.Equal(t, map[string]string{"app": "foo", "team": "platform"}, d.Labels)
+	assert.Equal(t, []v1.EnvVar{{Name: "DEBUG", Value: "1"}}, d.Spec.Template.Spec.Containers[0].Env)
+}
+
+func TestK8sObjectsStripResourceLimits(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("sancho.yaml", testyaml.SanchoYAML)
+	f.file("Tiltfile", `
+o = k8s_objects('sancho.yaml')[0]
+c = o.containers[0]
+c.limits = {'cpu': '500m', 'memory': '1Gi'}
+if c.limits != {'cpu': '500m', 'memory': '1Gi'}:
+  fail('limits: got %s' % c.limits)
+c.limits = {}
+c.requests = {'cpu': '100m'}
+k8s_yaml(o)
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("sancho")
+	entities := f.entities(m.K8sTarget().YAML)
+	if !assert.Equal(t, 1, len(entities)) {
+		return
+	}
+	c := entities[0].Obj.(*appsv1.Deployment).Spec.Template.Spec.Containers[0]
+	assert.Empty(t, c.Resources.Limits)
+	assert.Equal(t, v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")}, c.Resources.Requests)
+}
+
+func TestK8sObjectsEnvKeepsValueFrom(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("sancho.yaml", testyaml.SanchoYAML)
+	f.file("Tiltfile", `
+o = k8s_objects('sancho.yaml')[0]
+o.env = {'DEBUG': '1'}
+k8s_yaml(o)
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("sancho")
+	entities := f.entities(m.K8sTarget().YAML)
+	env := entities[0].Obj.(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Env
+	if assert.Equal(t, 2, len(env)) {
+		assert.Equal(t, "token", env[0].Name)
+		assert.NotNil(t, env[0].ValueFrom)
+		assert.Equal(t, v1.EnvVar{Name: "DEBUG", Value: "1"}, env[1])
+	}
+}
+
+func TestK8sObjectsRemoveContainer(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("sidecar.yaml", testyaml.SanchoSidecarYAML)
+	f.file("Tiltfile", `
+o = k8s_objects('sidecar.yaml')[0]
+if len(o.containers) != 2:
+  fail('expected 2 containers; got %s' % o.containers)
+o.containers = [c for c in o.containers if c.name == 'sancho']
+k8s_yaml(o)
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("sancho")
+	entities := f.entities(m.K8sTarget().YAML)
+	containers := entities[0].Obj.(*appsv1.Deployment).Spec.Template.Spec.Containers
+	if assert.Equal(t, 1, len(containers)) {
+		assert.Equal(t, "sancho", containers[0].Name)
+	}
+}
+
+func TestK8sObjectsDoNotChangeSource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+o = k8s_objects('foo.yaml')[0]
+k8s_yaml(o)
+o.name = 'bar'
+`)
+
+	f.load()
+
+	f.assertNextManifest("foo", deployment("foo"))
+}
+
+func TestK8sObjectsReplicasOnPod(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("pod.yaml", testyaml.PodYAML)
+	f.file("Tiltfile", `
+o = k8s_objects('pod.yaml')[0]
+if o.replicas != None:
+  fail('replicas: got %s' % o.replicas)
+o.replicas = 2
+`)
+
+	f.loadErrString(`Pod "sleep" has no replicas`)
+}
+
+func TestK8sObjectsKindIsReadOnly(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+o = k8s_objects('foo.yaml')[0]
+o.kind = 'StatefulSet'
+`)
+
+	f.loadErrString("k8s_object.kind can't be changed")
+}
+
+func TestK8sObjectsWrongType(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+o = k8s_objects('foo.yaml')[0]
+o.labels = ['app']
+`)
+
+	f.loadErrString("k8s_object.labels must be a dict; got list")
+}
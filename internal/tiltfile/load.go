@@ -0,0 +1,57 @@
+package tiltfile
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+// A module loaded with load(), cached by absolute path so that each file is
+// only executed once per Tiltfile load.
+type loadedModule struct {
+	globals starlark.StringDict
+	err     error
+}
+
+// Implements starlark.Thread.Load.
+//
+// Paths are resolved relative to the file that calls load(). The loaded
+// file gets the same builtins as the Tiltfile, and is watched for changes
+// like any other config file.
+func (s *tiltfileState) loadModule(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+	path := module
+	if !filepath.IsAbs(path) {
+		loadingFile := thread.TopFrame().Position().Filename()
+		path = filepath.Join(filepath.Dir(loadingFile), path)
+	}
+	path = filepath.Clean(path)
+
+	for i, p := range s.loadStack {
+		if p == path {
+			cycle := append(append([]string{}, s.loadStack[i:]...), path)
+			return nil, fmt.Errorf("cycle in load(): %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	if m, ok := s.loadCache[path]; ok {
+		return m.globals, m.err
+	}
+
+	s.recordConfigFile(path)
+
+	s.loadStack = append(s.loadStack, path)
+	globals, err := starlark.ExecFile(s.starlarkThread(), path, nil, s.builtins())
+	s.loadStack = s.loadStack[:len(s.loadStack)-1]
+
+	// Starlark only keeps the message of the error returned here, so keep the
+	// backtrace of the loaded file in the message.
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		err = errors.New(evalErr.Backtrace())
+	}
+
+	s.loadCache[path] = loadedModule{globals: globals, err: err}
+	return globals, err
+}
@@ -0,0 +1,117 @@
+package tiltfile
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+type localResource struct {
+	name           string
+	cmd            model.Cmd
+	serveCmd       model.Cmd
+	workdir        string
+	deps           []localPath
+	resourceDeps   []model.ManifestName
+	readinessProbe *model.Probe
+	triggerMode    triggerMode
+}
+
+func (s *tiltfileState) localResource(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name, cmd, serveCmd string
+	var deps, resourceDepsVal, readinessProbeVal, triggerModeVal starlark.Value
+
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"name", &name,
+		"cmd?", &cmd,
+		"serve_cmd?", &serveCmd,
+		"deps?", &deps,
+		"resource_deps?", &resourceDepsVal,
+		"readiness_probe?", &readinessProbeVal,
+		"trigger_mode?", &triggerModeVal,
+	); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("%s: name must not be empty", fn.Name())
+	}
+	if cmd == "" && serveCmd == "" {
+		return nil, fmt.Errorf("%s: must specify at least one of cmd and serve_cmd", fn.Name())
+	}
+	for _, r := range s.localResources {
+		if r.name == name {
+			return nil, fmt.Errorf("%s named %q already exists", fn.Name(), name)
+		}
+	}
+
+	var depPaths []localPath
+	for _, v := range starlarkValueOrSequenceToSlice(deps) {
+		p, err := s.localPathFromSkylarkValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: deps: %v", fn.Name(), err)
+		}
+		depPaths = append(depPaths, p)
+	}
+
+	resourceDeps, err := resourceDepsFromStarlarkValue(fn, resourceDepsVal)
+	if err != nil {
+		return nil, err
+	}
+
+	readinessProbe, err := probeFromStarlarkValue(fn, readinessProbeVal)
+	if err != nil {
+		return nil, err
+	}
+
+	triggerMode, err := triggerModeFromStarlarkValue(fn, triggerModeVal)
+	if err != nil {
+		return nil, err
+	}
+
+	s.localResources = append(s.localResources, localResource{
+		name:           name,
+		cmd:            model.ToShellCmd(cmd),
+		serveCmd:       model.ToShellCmd(serveCmd),
+		workdir:        s.absWorkingDir(),
+		deps:           depPaths,
+		resourceDeps:   resourceDeps,
+		readinessProbe: readinessProbe,
+		triggerMode:    triggerMode,
+	})
+
+	return starlark.None, nil
+}
+
+func (s *tiltfileState) translateLocal(existing []model.Manifest) ([]model.Manifest, error) {
+	names := make(map[model.ManifestName]bool, len(existing))
+	for _, m := range existing {
+		names[m.Name] = true
+	}
+
+	var result []model.Manifest
+	for _, r := range s.localResources {
+		mn := model.ManifestName(r.name)
+		if names[mn] {
+			return nil, fmt.Errorf("%s %q conflicts with another resource of the same name", localResourceN, r.name)
+		}
+
+		var deps []string
+		for _, d := range r.deps {
+			deps = append(deps, d.path)
+		}
+
+		lt := model.NewLocalTarget(model.TargetName(r.name), r.cmd, r.workdir, deps).
+			WithServeCmd(r.serveCmd).
+			WithRepos(reposForPaths(r.deps))
+		m := model.Manifest{Name: mn}.
+			WithDeployTarget(lt).
+			WithResourceDependencies(r.resourceDeps).
+			WithReadinessProbe(r.readinessProbe).
+			WithTriggerMode(s.triggerModeForResource(r.triggerMode))
+		result = append(result, m)
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package tiltfile
+
+import (
+	"fmt"
+	"sort"
+
+	"go.starlark.net/starlark"
+)
+
+// A namespace of builtins in the Tiltfile, like `config`.
+type module struct {
+	name    string
+	members starlark.StringDict
+}
+
+var _ starlark.HasAttrs = module{}
+
+func newModule(name string, members starlark.StringDict) module {
+	members.Freeze()
+	return module{name: name, members: members}
+}
+
+func (m module) String() string        { return fmt.Sprintf("<module %q>", m.name) }
+func (m module) Type() string          { return "module" }
+func (m module) Freeze()               {}
+func (m module) Truth() starlark.Bool  { return true }
+func (m module) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable type: module") }
+
+func (m module) Attr(name string) (starlark.Value, error) {
+	v, ok := m.members[name]
+	if !ok {
+		// Starlark turns a nil value into a "has no .name field" error.
+		return nil, nil
+	}
+	return v, nil
+}
+
+func (m module) AttrNames() []string {
+	names := make([]string, 0, len(m.members))
+	for name := range m.members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,26 @@
+package tiltfile
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+)
+
+func (s *tiltfileState) namespaceFn(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = k8s.ValidateNamespace(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fn.Name(), err)
+	}
+
+	s.k8sNamespace = k8s.Namespace(name)
+
+	return starlark.None, nil
+}
@@ -0,0 +1,49 @@
+package tiltfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/windmilleng/tilt/internal/k8s"
+)
+
+func TestNamespace(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	defer setenvForTest(t, "DEV_NAME", "jane")()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+namespace('dev-' + os.getenv('DEV_NAME', 'nobody'))
+`)
+
+	f.load()
+	assert.Equal(t, k8s.Namespace("dev-jane"), f.loadResult.K8sNamespace)
+}
+
+func TestNamespaceUnset(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+
+	f.load()
+	assert.Equal(t, k8s.Namespace(""), f.loadResult.K8sNamespace)
+}
+
+func TestNamespaceInvalid(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `namespace('Dev_Jane')`)
+
+	f.loadErrString(`namespace: invalid namespace "Dev_Jane"`)
+}
@@ -0,0 +1,82 @@
+package tiltfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+const (
+	osN        = "os"
+	osGetenvN  = "getenv"
+	osEnvironN = "environ"
+	osPathN    = "path"
+	osExistsN  = "exists"
+	osJoinN    = "join"
+)
+
+func (s *tiltfileState) osGetenv(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key string
+	var defaultValue starlark.Value = starlark.None
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs, "key", &key, "default?", &defaultValue)
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue, nil
+	}
+	return starlark.String(v), nil
+}
+
+// A snapshot of the environment when the Tiltfile is loaded, as a dict.
+func environ() *starlark.Dict {
+	result := &starlark.Dict{}
+	for _, kv := range os.Environ() {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		// SetKey only fails on frozen dicts and unhashable keys.
+		_ = result.SetKey(starlark.String(parts[0]), starlark.String(parts[1]))
+	}
+	return result
+}
+
+// Relative paths are relative to the Tiltfile, like every other path in the Tiltfile.
+func (s *tiltfileState) osPathExists(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var path string
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = os.Stat(s.absPath(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return starlark.False, nil
+		}
+		return nil, fmt.Errorf("%s: %v", fn.Name(), err)
+	}
+	return starlark.True, nil
+}
+
+func (s *tiltfileState) osPathJoin(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(kwargs) > 0 {
+		return nil, fmt.Errorf("%s: unexpected keyword arguments", fn.Name())
+	}
+
+	var parts []string
+	for i, arg := range args {
+		part, ok := arg.(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("%s: argument %d must be a string; got %s", fn.Name(), i, arg.Type())
+		}
+		parts = append(parts, part.GoString())
+	}
+	return starlark.String(filepath.Join(parts...)), nil
+}
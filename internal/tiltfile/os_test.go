@@ -0,0 +1,78 @@
+package tiltfile
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestOsGetenv(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	defer setenvForTest(t, "TILT_TEST_ENV", "staging")()
+	f.file("Tiltfile", `
+if os.getenv('TILT_TEST_ENV') != 'staging':
+  fail('TILT_TEST_ENV: got %s' % os.getenv('TILT_TEST_ENV'))
+if os.getenv('TILT_TEST_UNSET') != None:
+  fail('TILT_TEST_UNSET should be None')
+if os.getenv('TILT_TEST_UNSET', 'dev') != 'dev':
+  fail('TILT_TEST_UNSET should default to dev')
+`)
+
+	f.load()
+}
+
+func TestOsEnviron(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	defer setenvForTest(t, "TILT_TEST_ENV", "staging")()
+	f.file("Tiltfile", `
+if os.environ['TILT_TEST_ENV'] != 'staging':
+  fail('got %s' % os.environ.get('TILT_TEST_ENV'))
+if 'TILT_TEST_UNSET' in os.environ:
+  fail('TILT_TEST_UNSET should not be in os.environ')
+`)
+
+	f.load()
+}
+
+func TestOsPath(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("foo/Dockerfile", "FROM alpine")
+	f.file("Tiltfile", fmt.Sprintf(`
+if not os.path.exists('foo/Dockerfile'):
+  fail('foo/Dockerfile should exist')
+if not os.path.exists(%q):
+  fail('absolute path should exist')
+if os.path.exists('bar/Dockerfile'):
+  fail('bar/Dockerfile should not exist')
+if os.path.join('foo', 'bar', 'Dockerfile') != 'foo/bar/Dockerfile':
+  fail('got %%s' %% os.path.join('foo', 'bar', 'Dockerfile'))
+`, f.JoinPath("foo")))
+
+	f.load()
+}
+
+func TestOsUnknownAttr(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `os.remove('foo')`)
+
+	f.loadErrString("has no .remove field or method")
+}
+
+// Sets an env var and returns a func that unsets it.
+func setenvForTest(t *testing.T, key, value string) func() {
+	err := os.Setenv(key, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		_ = os.Unsetenv(key)
+	}
+}
@@ -0,0 +1,23 @@
+package tiltfile
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+)
+
+func (s *tiltfileState) maxParallelBuildsFn(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var n int
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs, "n", &n)
+	if err != nil {
+		return nil, err
+	}
+
+	if n < 1 {
+		return nil, fmt.Errorf("%s: n must be at least 1, got %d", fn.Name(), n)
+	}
+
+	s.maxParallelBuilds = n
+
+	return starlark.None, nil
+}
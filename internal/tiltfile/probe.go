@@ -0,0 +1,184 @@
+package tiltfile
+
+import (
+	"fmt"
+	"time"
+
+	"go.starlark.net/starlark"
+
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+type probe struct {
+	spec model.Probe
+}
+
+var _ starlark.Value = probe{}
+
+func (p probe) String() string        { return "probe" }
+func (p probe) Type() string          { return "probe" }
+func (p probe) Freeze()               {}
+func (p probe) Truth() starlark.Bool  { return true }
+func (p probe) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable type: probe") }
+
+type httpGetAction struct {
+	action model.HTTPGetAction
+}
+
+var _ starlark.Value = httpGetAction{}
+
+func (a httpGetAction) String() string       { return fmt.Sprintf("http_get_action: %s", a.action.URL()) }
+func (a httpGetAction) Type() string         { return "http_get_action" }
+func (a httpGetAction) Freeze()              {}
+func (a httpGetAction) Truth() starlark.Bool { return true }
+func (a httpGetAction) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: http_get_action")
+}
+
+type tcpSocketAction struct {
+	action model.TCPSocketAction
+}
+
+var _ starlark.Value = tcpSocketAction{}
+
+func (a tcpSocketAction) String() string {
+	return fmt.Sprintf("tcp_socket_action: %s:%d", a.action.Host, a.action.Port)
+}
+func (a tcpSocketAction) Type() string         { return "tcp_socket_action" }
+func (a tcpSocketAction) Freeze()              {}
+func (a tcpSocketAction) Truth() starlark.Bool { return true }
+func (a tcpSocketAction) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: tcp_socket_action")
+}
+
+type execAction struct {
+	action model.ExecAction
+}
+
+var _ starlark.Value = execAction{}
+
+func (a execAction) String() string        { return fmt.Sprintf("exec_action: %s", a.action.Command) }
+func (a execAction) Type() string          { return "exec_action" }
+func (a execAction) Freeze()               {}
+func (a execAction) Truth() starlark.Bool  { return true }
+func (a execAction) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable type: exec_action") }
+
+func (s *tiltfileState) probe(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var httpGetVal, tcpSocketVal, execVal starlark.Value
+	initialDelaySecs := 0
+	periodSecs := int(model.DefaultProbePeriod / time.Second)
+	timeoutSecs := int(model.DefaultProbeTimeout / time.Second)
+
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"http_get?", &httpGetVal,
+		"tcp_socket?", &tcpSocketVal,
+		"exec?", &execVal,
+		"initial_delay_secs?", &initialDelaySecs,
+		"period_secs?", &periodSecs,
+		"timeout_secs?", &timeoutSecs,
+	); err != nil {
+		return nil, err
+	}
+
+	spec := model.Probe{
+		InitialDelay: time.Duration(initialDelaySecs) * time.Second,
+		Period:       time.Duration(periodSecs) * time.Second,
+		Timeout:      time.Duration(timeoutSecs) * time.Second,
+	}
+
+	if httpGetVal != nil && httpGetVal != starlark.None {
+		a, ok := httpGetVal.(httpGetAction)
+		if !ok {
+			return nil, fmt.Errorf("%s: http_get must be an %s; got %s", fn.Name(), httpGetActionN, httpGetVal.Type())
+		}
+		spec.HTTPGet = &a.action
+	}
+	if tcpSocketVal != nil && tcpSocketVal != starlark.None {
+		a, ok := tcpSocketVal.(tcpSocketAction)
+		if !ok {
+			return nil, fmt.Errorf("%s: tcp_socket must be a %s; got %s", fn.Name(), tcpSocketActionN, tcpSocketVal.Type())
+		}
+		spec.TCPSocket = &a.action
+	}
+	if execVal != nil && execVal != starlark.None {
+		a, ok := execVal.(execAction)
+		if !ok {
+			return nil, fmt.Errorf("%s: exec must be an %s; got %s", fn.Name(), execActionN, execVal.Type())
+		}
+		spec.Exec = &a.action
+	}
+
+	if initialDelaySecs < 0 {
+		return nil, fmt.Errorf("%s: initial_delay_secs must not be negative; got %d", fn.Name(), initialDelaySecs)
+	}
+
+	err := spec.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fn.Name(), err)
+	}
+
+	return probe{spec: spec}, nil
+}
+
+func (s *tiltfileState) httpGetAction(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var port int
+	host := "localhost"
+	path := "/"
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"port", &port,
+		"host?", &host,
+		"path?", &path,
+	); err != nil {
+		return nil, err
+	}
+
+	if port <= 0 {
+		return nil, fmt.Errorf("%s: port must be positive; got %d", fn.Name(), port)
+	}
+
+	return httpGetAction{action: model.HTTPGetAction{Host: host, Port: port, Path: path}}, nil
+}
+
+func (s *tiltfileState) tcpSocketAction(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var port int
+	host := "localhost"
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"port", &port,
+		"host?", &host,
+	); err != nil {
+		return nil, err
+	}
+
+	if port <= 0 {
+		return nil, fmt.Errorf("%s: port must be positive; got %d", fn.Name(), port)
+	}
+
+	return tcpSocketAction{action: model.TCPSocketAction{Host: host, Port: port}}, nil
+}
+
+func (s *tiltfileState) execAction(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var command string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "command", &command); err != nil {
+		return nil, err
+	}
+
+	if command == "" {
+		return nil, fmt.Errorf("%s: command must not be empty", fn.Name())
+	}
+
+	return execAction{action: model.ExecAction{Command: model.ToShellCmd(command)}}, nil
+}
+
+func probeFromStarlarkValue(fn *starlark.Builtin, v starlark.Value) (*model.Probe, error) {
+	if v == nil || v == starlark.None {
+		return nil, nil
+	}
+
+	p, ok := v.(probe)
+	if !ok {
+		return nil, fmt.Errorf("%s: readiness_probe must be a %s; got %s", fn.Name(), probeN, v.Type())
+	}
+
+	spec := p.spec
+	return &spec, nil
+}
@@ -0,0 +1,62 @@
+package tiltfile
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+func resourceDepsFromStarlarkValue(fn *starlark.Builtin, v starlark.Value) ([]model.ManifestName, error) {
+	if v == nil || v == starlark.None {
+		return nil, nil
+	}
+
+	seq, ok := v.(starlark.Sequence)
+	if !ok {
+		return nil, fmt.Errorf("%s: resource_deps must be a list of strings; got %s", fn.Name(), v.Type())
+	}
+
+	var result []model.ManifestName
+	iter := seq.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		str, ok := item.(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("%s: resource_deps must be a list of strings; got element %s of type %s", fn.Name(), item.String(), item.Type())
+		}
+		result = append(result, model.ManifestName(str))
+	}
+	return result, nil
+}
+
+// Make sure that every resource dependency points at a real resource,
+// and that the dependencies don't form a cycle.
+func validateResourceDependencies(manifests []model.Manifest) error {
+	byName := make(map[model.ManifestName]bool, len(manifests))
+	for _, m := range manifests {
+		byName[m.Name] = true
+	}
+
+	var targets []model.TargetSpec
+	for _, m := range manifests {
+		for _, dep := range m.ResourceDependencies {
+			if !byName[dep] {
+				return fmt.Errorf("resource %s specified a dependency on unknown resource %s", m.Name, dep)
+			}
+		}
+
+		targets = append(targets, m)
+		for _, iTarget := range m.ImageTargets {
+			targets = append(targets, iTarget)
+		}
+		if m.DeployTarget() != nil {
+			targets = append(targets, m.DeployTarget())
+		}
+	}
+
+	_, err := model.TopologicalSort(targets)
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	gosync "sync"
 
 	"github.com/pkg/errors"
 	"go.starlark.net/resolve"
@@ -35,18 +36,35 @@ type TiltfileLoadResult struct {
 	ConfigFiles        []string
 	Warnings           []string
 	TiltIgnoreContents string
+	MaxParallelBuilds  int
+	K8sNamespace       k8s.Namespace
+
+	// The manifests that the Tiltfile declares, but that didn't match
+	// the resource names that Tilt was started with.
+	UnmatchedManifests []model.Manifest
+
+	// The non-local k8s contexts that the Tiltfile allows Tilt to change.
+	AllowedK8sContexts []k8s.KubeContext
 }
 
 type TiltfileLoader interface {
-	Load(ctx context.Context, filename string, matching map[string]bool) (TiltfileLoadResult, error)
+	Load(ctx context.Context, filename string, matching map[string]bool, args []string) (TiltfileLoadResult, error)
 }
 
 type FakeTiltfileLoader struct {
-	Manifests   []model.Manifest
-	Global      model.Manifest
-	ConfigFiles []string
-	Warnings    []string
-	Err         error
+	Manifests          []model.Manifest
+	Global             model.Manifest
+	ConfigFiles        []string
+	Warnings           []string
+	MaxParallelBuilds  int
+	K8sNamespace       k8s.Namespace
+	AllowedK8sContexts []k8s.KubeContext
+	Err                error
+
+	// The args passed to the last Load. Load runs on the ConfigsController's
+	// goroutine, so tests read them with LastArgs().
+	mu       gosync.Mutex
+	lastArgs []string
 }
 
 var _ TiltfileLoader = &FakeTiltfileLoader{}
@@ -55,22 +73,37 @@ func NewFakeTiltfileLoader() *FakeTiltfileLoader {
 	return &FakeTiltfileLoader{}
 }
 
-func (tfl *FakeTiltfileLoader) Load(ctx context.Context, filename string, matching map[string]bool) (TiltfileLoadResult, error) {
+func (tfl *FakeTiltfileLoader) Load(ctx context.Context, filename string, matching map[string]bool, args []string) (TiltfileLoadResult, error) {
+	tfl.mu.Lock()
+	tfl.lastArgs = args
+	tfl.mu.Unlock()
+
 	return TiltfileLoadResult{
-		Manifests:   tfl.Manifests,
-		Global:      tfl.Global,
-		ConfigFiles: tfl.ConfigFiles,
-		Warnings:    tfl.Warnings,
+		Manifests:          tfl.Manifests,
+		Global:             tfl.Global,
+		ConfigFiles:        tfl.ConfigFiles,
+		Warnings:           tfl.Warnings,
+		MaxParallelBuilds:  tfl.MaxParallelBuilds,
+		K8sNamespace:       tfl.K8sNamespace,
+		AllowedK8sContexts: tfl.AllowedK8sContexts,
 	}, tfl.Err
 }
 
-func ProvideTiltfileLoader(analytics analytics.Analytics, dcCli dockercompose.DockerComposeClient) TiltfileLoader {
-	return tiltfileLoader{analytics: analytics, dcCli: dcCli}
+func (tfl *FakeTiltfileLoader) LastArgs() []string {
+	tfl.mu.Lock()
+	defer tfl.mu.Unlock()
+	return tfl.lastArgs
+}
+
+func ProvideTiltfileLoader(analytics analytics.Analytics, dcCli dockercompose.DockerComposeClient, kubeContext k8s.KubeContext, env k8s.Env) TiltfileLoader {
+	return tiltfileLoader{analytics: analytics, dcCli: dcCli, kubeContext: kubeContext, env: env}
 }
 
 type tiltfileLoader struct {
-	analytics analytics.Analytics
-	dcCli     dockercompose.DockerComposeClient
+	analytics   analytics.Analytics
+	dcCli       dockercompose.DockerComposeClient
+	kubeContext k8s.KubeContext
+	env         k8s.Env
 }
 
 var _ TiltfileLoader = &tiltfileLoader{}
@@ -82,7 +115,8 @@ func printWarnings(s *tiltfileState) {
 }
 
 // Load loads the Tiltfile in `filename`, and returns the manifests matching `matching`.
-func (tfl tiltfileLoader) Load(ctx context.Context, filename string, matching map[string]bool) (tlr TiltfileLoadResult, err error) {
+// `args` are the Tiltfile's own args (after `--` on the command line), read with config.parse().
+func (tfl tiltfileLoader) Load(ctx context.Context, filename string, matching map[string]bool, args []string) (tlr TiltfileLoadResult, err error) {
 	absFilename, err := ospath.RealAbs(filename)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -92,7 +126,7 @@ func (tfl tiltfileLoader) Load(ctx context.Context, filename string, matching ma
 		return TiltfileLoadResult{ConfigFiles: []string{absFilename}}, err
 	}
 
-	s := newTiltfileState(ctx, tfl.dcCli, absFilename)
+	s := newTiltfileState(ctx, tfl.dcCli, absFilename, args)
 	printedWarnings := false
 	defer func() {
 		tlr.ConfigFiles = s.configFiles
@@ -110,30 +144,54 @@ func (tfl tiltfileLoader) Load(ctx context.Context, filename string, matching ma
 		return TiltfileLoadResult{}, err
 	}
 
+	s.checkUserConfigParsed()
+
 	resources, unresourced, err := s.assemble()
 	if err != nil {
 		return TiltfileLoadResult{}, err
 	}
 
-	var manifests []model.Manifest
+	manifests, err := s.translateK8s(resources.k8s)
+	if err != nil {
+		return TiltfileLoadResult{}, err
+	}
 
-	if len(resources.k8s) > 0 {
-		manifests, err = s.translateK8s(resources.k8s)
-		if err != nil {
-			return TiltfileLoadResult{}, err
-		}
-	} else {
-		manifests, err = s.translateDC(resources.dc)
-		if err != nil {
-			return TiltfileLoadResult{}, err
-		}
+	dcManifests, err := s.translateDC(resources.dc, manifests)
+	if err != nil {
+		return TiltfileLoadResult{}, err
 	}
+	manifests = append(manifests, dcManifests...)
+
+	localManifests, err := s.translateLocal(manifests)
+	if err != nil {
+		return TiltfileLoadResult{}, err
+	}
+	manifests = append(manifests, localManifests...)
 
 	err = s.checkForUnconsumedLiveUpdateSteps()
 	if err != nil {
 		return TiltfileLoadResult{}, err
 	}
 
+	err = validateResourceDependencies(manifests)
+	if err != nil {
+		return TiltfileLoadResult{}, err
+	}
+
+	err = s.validateK8sContext(tfl.kubeContext, tfl.env, manifests, unresourced)
+	if err != nil {
+		return TiltfileLoadResult{}, err
+	}
+
+	var unmatchedManifests []model.Manifest
+	if len(matching) > 0 {
+		for _, m := range manifests {
+			if !matching[m.Name.String()] {
+				unmatchedManifests = append(unmatchedManifests, m)
+			}
+		}
+	}
+
 	manifests, err = match(manifests, matching)
 	if err != nil {
 		return TiltfileLoadResult{}, err
@@ -164,7 +222,7 @@ func (tfl tiltfileLoader) Load(ctx context.Context, filename string, matching ma
 
 	// TODO(maia): `yamlManifest` should be processed just like any
 	// other manifest (i.e. get rid of "global yaml" concept)
-	return TiltfileLoadResult{manifests, yamlManifest, s.configFiles, s.warnings, string(tiltIgnoreContents)}, err
+	return TiltfileLoadResult{manifests, yamlManifest, s.configFiles, s.warnings, string(tiltIgnoreContents), s.maxParallelBuilds, s.k8sNamespace, unmatchedManifests, s.allowedK8sContexts}, err
 }
 
 // .tiltignore sits next to Tiltfile
@@ -152,7 +152,29 @@ docker_compose('docker-compose2.yml')`
 	f.loadErrString("already have a docker-compose resource declared")
 }
 
-func TestDockerComposeAndK8sNotSupported(t *testing.T) {
+func TestDockerComposeAndK8s(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.dockerfile("bar/Dockerfile")
+	f.file("docker-compose.yml", `version: '3'
+services:
+  bar:
+    build: ./bar
+    command: sleep 100`)
+	tf := `docker_compose('docker-compose.yml')
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')`
+	f.file("Tiltfile", tf)
+
+	f.load()
+	f.assertNumManifests(2)
+	f.assertNextManifest("foo", db(image("gcr.io/foo")), deployment("foo"))
+	f.assertDcManifest("bar", dcConfigPath(f.JoinPath("docker-compose.yml")))
+}
+
+func TestDockerComposeAndK8sNameConflict(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
 
@@ -163,8 +185,7 @@ docker_build('gcr.io/foo', 'foo')
 k8s_yaml('foo.yaml')`
 	f.file("Tiltfile", tf)
 
-	f.loadErrString("can't declare both k8s " +
-		"resources/entities and docker-compose resources")
+	f.loadErrString(`docker-compose service "foo" conflicts with a k8s resource of the same name`)
 }
 
 func TestDockerComposeResourceCreationFromAbsPath(t *testing.T) {
@@ -510,6 +531,49 @@ dc_resource('no-svc-with-this-name-eek', 'gcr.io/foo')
 	f.loadErrString("no Docker Compose service found with name")
 }
 
+func TestDockerComposeResourceDeps(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile("foo/Dockerfile")
+	f.dockerfile("bar/Dockerfile")
+	f.file("docker-compose.yml", twoServiceConfig)
+	f.file("Tiltfile", `docker_build('gcr.io/foo', './foo')
+docker_build('gcr.io/bar', './bar')
+docker_compose('docker-compose.yml')
+dc_resource('foo', 'gcr.io/foo')
+dc_resource('bar', 'gcr.io/bar', resource_deps=['foo'])
+`)
+
+	f.load()
+
+	foo := f.assertNextManifest("foo")
+	assert.Empty(t, foo.ResourceDependencies)
+
+	bar := f.assertNextManifest("bar")
+	assert.Equal(t, []model.ManifestName{"foo"}, bar.ResourceDependencies)
+}
+
+func TestDockerComposeTriggerMode(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile("foo/Dockerfile")
+	f.dockerfile("bar/Dockerfile")
+	f.file("docker-compose.yml", twoServiceConfig)
+	f.file("Tiltfile", `docker_build('gcr.io/foo', './foo')
+docker_build('gcr.io/bar', './bar')
+docker_compose('docker-compose.yml')
+dc_resource('foo', 'gcr.io/foo', trigger_mode=TRIGGER_MODE_MANUAL)
+dc_resource('bar', 'gcr.io/bar')
+`)
+
+	f.load()
+
+	assert.Equal(t, model.TriggerManual, f.assertNextManifest("foo").TriggerMode)
+	assert.Equal(t, model.TriggerAuto, f.assertNextManifest("bar").TriggerMode)
+}
+
 func (f *fixture) assertDcManifest(name string, opts ...interface{}) model.Manifest {
 	m := f.assertNextManifest(name)
 
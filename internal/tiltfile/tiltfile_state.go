@@ -38,6 +38,7 @@ type tiltfileState struct {
 	k8sUnresourced     []k8s.K8sEntity
 	dc                 dcResourceSet // currently only support one d-c.yml
 	k8sResourceOptions map[string]k8sResourceOptions
+	localResources     []localResource
 
 	// ensure that any pushed images are pushed instead to this registry, rewriting names if needed
 	defaultRegistryHost string
@@ -48,6 +49,25 @@ type tiltfileState struct {
 	k8sResourceAssemblyVersion int
 	workloadToResourceFunction workloadToResourceFunction
 
+	// how many manifests may be built at once; 0 means unset
+	maxParallelBuilds int
+
+	// the namespace that all k8s objects are deployed to; empty means unset
+	k8sNamespace k8s.Namespace
+
+	// non-local k8s contexts that we're allowed to deploy to
+	allowedK8sContexts []k8s.KubeContext
+
+	// the trigger mode of resources that don't set their own
+	triggerMode triggerMode
+
+	// settings declared with config.define_*
+	userConfig *userConfig
+
+	// files loaded with load(), and the files currently being loaded
+	loadCache map[string]loadedModule
+	loadStack []string
+
 	// for assembly
 	usedImages map[string]bool
 
@@ -66,7 +86,7 @@ type tiltfileState struct {
 	warnings []string
 }
 
-func newTiltfileState(ctx context.Context, dcCli dockercompose.DockerComposeClient, filename string) *tiltfileState {
+func newTiltfileState(ctx context.Context, dcCli dockercompose.DockerComposeClient, filename string, args []string) *tiltfileState {
 	lp := localPath{path: filename}
 	s := &tiltfileState{
 		ctx:                        ctx,
@@ -82,6 +102,9 @@ func newTiltfileState(ctx context.Context, dcCli dockercompose.DockerComposeClie
 		unconsumedLiveUpdateSteps:  make(map[string]liveUpdateStep),
 		k8sResourceAssemblyVersion: 1,
 		k8sResourceOptions:         make(map[string]k8sResourceOptions),
+		userConfig:                 newUserConfig(args),
+		loadCache:                  make(map[string]loadedModule),
+		loadStack:                  []string{filename},
 	}
 	s.filename = s.maybeAttachGitRepo(lp, filepath.Dir(lp.path))
 	return s
@@ -92,6 +115,7 @@ func (s *tiltfileState) starlarkThread() *starlark.Thread {
 		Print: func(_ *starlark.Thread, msg string) {
 			s.logger.Infof("%s", msg)
 		},
+		Load: s.loadModule,
 	}
 }
 
@@ -113,6 +137,9 @@ const (
 	dockerComposeN = "docker_compose"
 	dcResourceN    = "dc_resource"
 
+	// local resource functions
+	localResourceN = "local_resource"
+
 	// k8s functions
 	k8sResourceAssemblyVersionN = "k8s_resource_assembly_version"
 	k8sYamlN                    = "k8s_yaml"
@@ -140,9 +167,23 @@ const (
 	runN              = "run"
 	restartContainerN = "restart_container"
 
+	// probe functions
+	probeN           = "probe"
+	httpGetActionN   = "http_get_action"
+	tcpSocketActionN = "tcp_socket_action"
+	execActionN      = "exec_action"
+
 	// other functions
-	failN = "fail"
-	blobN = "blob"
+	failN              = "fail"
+	blobN              = "blob"
+	maxParallelBuildsN = "max_parallel_builds"
+	triggerModeN       = "trigger_mode"
+	namespaceN         = "namespace"
+	allowK8sContextsN  = "allow_k8s_contexts"
+
+	// constants
+	triggerModeAutoN   = "TRIGGER_MODE_AUTO"
+	triggerModeManualN = "TRIGGER_MODE_MANUAL"
 )
 
 // count how many times each builtin is called, for analytics
@@ -161,6 +202,10 @@ func (s *tiltfileState) builtins() starlark.StringDict {
 	addBuiltin := func(r starlark.StringDict, name string, fn func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error)) {
 		r[name] = starlark.NewBuiltin(name, s.makeBuiltinReporting(name, fn))
 	}
+	addModuleBuiltin := func(m starlark.StringDict, moduleName, name string, fn func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error)) {
+		fullName := fmt.Sprintf("%s.%s", moduleName, name)
+		m[name] = starlark.NewBuiltin(fullName, s.makeBuiltinReporting(fullName, fn))
+	}
 
 	r := make(starlark.StringDict)
 
@@ -174,9 +219,11 @@ func (s *tiltfileState) builtins() starlark.StringDict {
 	addBuiltin(r, defaultRegistryN, s.defaultRegistry)
 	addBuiltin(r, dockerComposeN, s.dockerCompose)
 	addBuiltin(r, dcResourceN, s.dcResource)
+	addBuiltin(r, localResourceN, s.localResource)
 	addBuiltin(r, k8sResourceAssemblyVersionN, s.k8sResourceAssemblyVersionFn)
 	addBuiltin(r, k8sYamlN, s.k8sYaml)
 	addBuiltin(r, filterYamlN, s.filterYaml)
+	addBuiltin(r, k8sObjectsN, s.k8sObjects)
 	addBuiltin(r, k8sResourceN, s.k8sResource)
 	addBuiltin(r, portForwardN, s.portForward)
 	addBuiltin(r, k8sKindN, s.k8sKind)
@@ -187,15 +234,48 @@ func (s *tiltfileState) builtins() starlark.StringDict {
 	addBuiltin(r, helmN, s.helm)
 	addBuiltin(r, failN, s.fail)
 	addBuiltin(r, blobN, s.blob)
+	addBuiltin(r, maxParallelBuildsN, s.maxParallelBuildsFn)
+	addBuiltin(r, triggerModeN, s.triggerModeFn)
+	addBuiltin(r, namespaceN, s.namespaceFn)
+	addBuiltin(r, allowK8sContextsN, s.allowK8sContexts)
+	addBuiltin(r, probeN, s.probe)
+	addBuiltin(r, httpGetActionN, s.httpGetAction)
+	addBuiltin(r, tcpSocketActionN, s.tcpSocketAction)
+	addBuiltin(r, execActionN, s.execAction)
 	addBuiltin(r, listdirN, s.listdir)
 	addBuiltin(r, decodeJSONN, s.decodeJSON)
 	addBuiltin(r, readJSONN, s.readJson)
+	addBuiltin(r, readYAMLN, s.readYAML)
+	addBuiltin(r, readYAMLStreamN, s.readYAMLStream)
+	addBuiltin(r, decodeYAMLN, s.decodeYAML)
+	addBuiltin(r, decodeYAMLStreamN, s.decodeYAMLStream)
+	addBuiltin(r, encodeYAMLN, s.encodeYAML)
+	addBuiltin(r, encodeYAMLStreamN, s.encodeYAMLStream)
 
 	addBuiltin(r, fallBackOnN, s.liveUpdateFallBackOn)
 	addBuiltin(r, syncN, s.liveUpdateSync)
 	addBuiltin(r, runN, s.liveUpdateRun)
 	addBuiltin(r, restartContainerN, s.liveUpdateRestartContainer)
 
+	config := make(starlark.StringDict)
+	addModuleBuiltin(config, configN, configDefineStringN, s.configDefineString)
+	addModuleBuiltin(config, configN, configDefineListN, s.configDefineStringList)
+	addModuleBuiltin(config, configN, configDefineBoolN, s.configDefineBool)
+	addModuleBuiltin(config, configN, configParseN, s.configParse)
+	r[configN] = newModule(configN, config)
+
+	osPath := make(starlark.StringDict)
+	addModuleBuiltin(osPath, osN+"."+osPathN, osExistsN, s.osPathExists)
+	addModuleBuiltin(osPath, osN+"."+osPathN, osJoinN, s.osPathJoin)
+	osModule := make(starlark.StringDict)
+	addModuleBuiltin(osModule, osN, osGetenvN, s.osGetenv)
+	osModule[osEnvironN] = environ()
+	osModule[osPathN] = newModule(osN+"."+osPathN, osPath)
+	r[osN] = newModule(osN, osModule)
+
+	r[triggerModeAutoN] = triggerModeAuto
+	r[triggerModeManualN] = triggerModeManual
+
 	s.builtinsMap = r
 
 	return r
@@ -222,11 +302,6 @@ func (s *tiltfileState) assemble() (resourceSet, []k8s.K8sEntity, error) {
 		return resourceSet{}, nil, err
 	}
 
-	if !s.dc.Empty() && (len(s.k8s) > 0 || len(s.k8sUnresourced) > 0) {
-		return resourceSet{}, nil, fmt.Errorf("can't declare both k8s " +
-			"resources/entities and docker-compose resources")
-	}
-
 	err = s.buildIndex.assertAllMatched()
 	if err != nil {
 		s.warnings = append(s.warnings, err.Error())
@@ -318,6 +393,9 @@ func (s *tiltfileState) assembleK8sV2() error {
 		if r, ok := s.k8sByName[workload]; ok {
 			r.extraPodSelectors = opts.extraPodSelectors
 			r.portForwards = opts.portForwards
+			r.resourceDeps = opts.resourceDeps
+			r.readinessProbe = opts.readinessProbe
+			r.triggerMode = opts.triggerMode
 			if opts.newName != "" && opts.newName != r.name {
 				if _, ok := s.k8sByName[opts.newName]; ok {
 					return fmt.Errorf("k8s_resource at %s specified to rename '%s' to '%s', but there is already a resource with that name", opts.tiltfilePosition.String(), r.name, opts.newName)
@@ -623,7 +701,10 @@ func (s *tiltfileState) translateK8s(resources []*k8sResource) ([]model.Manifest
 			return nil, errors.Wrapf(err, "getting image build info for %s", r.name)
 		}
 
-		m = m.WithImageTargets(iTargets)
+		m = m.WithImageTargets(iTargets).
+			WithResourceDependencies(r.resourceDeps).
+			WithReadinessProbe(r.readinessProbe).
+			WithTriggerMode(s.triggerModeForResource(r.triggerMode))
 
 		result = append(result, m)
 	}
@@ -716,9 +797,18 @@ func (s *tiltfileState) imgTargetsForDependencyIDsHelper(ids []model.TargetID, c
 	return iTargets, nil
 }
 
-func (s *tiltfileState) translateDC(dc dcResourceSet) ([]model.Manifest, error) {
+func (s *tiltfileState) translateDC(dc dcResourceSet, existing []model.Manifest) ([]model.Manifest, error) {
+	names := make(map[model.ManifestName]bool, len(existing))
+	for _, m := range existing {
+		names[m.Name] = true
+	}
+
 	var result []model.Manifest
 	for _, svc := range dc.services {
+		if names[model.ManifestName(svc.Name)] {
+			return nil, fmt.Errorf("docker-compose service %q conflicts with a k8s resource of the same name", svc.Name)
+		}
+
 		m, configFiles, err := s.dcServiceToManifest(svc, dc.configPath)
 		if err != nil {
 			return nil, err
@@ -728,7 +818,10 @@ func (s *tiltfileState) translateDC(dc dcResourceSet) ([]model.Manifest, error)
 		if err != nil {
 			return nil, errors.Wrapf(err, "getting image build info for %s", svc.Name)
 		}
-		m = m.WithImageTargets(iTargets)
+		m = m.WithImageTargets(iTargets).
+			WithResourceDependencies(svc.ResourceDeps).
+			WithReadinessProbe(svc.ReadinessProbe).
+			WithTriggerMode(s.triggerModeForResource(svc.TriggerMode))
 
 		result = append(result, m)
 
@@ -8,6 +8,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 
@@ -414,6 +415,130 @@ k8s_yaml(yaml)
 	f.assertConfigFiles("Tiltfile", ".tiltignore", "foo/Dockerfile", "foo.yaml")
 }
 
+func TestLoad(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("lib/lib.tilt", `
+def build_foo():
+  docker_build('gcr.io/foo', 'foo')
+  k8s_yaml('foo.yaml')
+`)
+	f.file("Tiltfile", `
+load('lib/lib.tilt', 'build_foo')
+build_foo()
+`)
+
+	f.load()
+
+	f.assertNextManifest("foo",
+		db(image("gcr.io/foo")),
+		deployment("foo"))
+	f.assertConfigFiles("Tiltfile", ".tiltignore", "lib/lib.tilt", "foo/Dockerfile", "foo.yaml")
+}
+
+func TestLoadRelativeToLoadingFile(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("lib/images.tilt", `
+def build_foo():
+  docker_build('gcr.io/foo', 'foo')
+`)
+	f.file("lib/lib.tilt", `
+load('images.tilt', 'build_foo')
+
+def setup():
+  build_foo()
+`)
+	f.file("Tiltfile", `
+load('lib/lib.tilt', 'setup')
+setup()
+k8s_yaml('foo.yaml')
+`)
+
+	f.load()
+
+	f.assertNextManifest("foo",
+		db(image("gcr.io/foo")),
+		deployment("foo"))
+	f.assertConfigFiles("Tiltfile", ".tiltignore", "lib/lib.tilt", "lib/images.tilt", "foo/Dockerfile", "foo.yaml")
+}
+
+func TestLoadExecutesEachFileOnce(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	// docker_build fails if the same image is built twice
+	f.file("lib/images.tilt", `
+docker_build('gcr.io/foo', 'foo')
+foo = 'gcr.io/foo'
+`)
+	f.file("lib/bar.tilt", `
+load('images.tilt', 'foo')
+docker_build('gcr.io/bar', 'bar')
+bar = 'gcr.io/bar'
+`)
+	f.file("Tiltfile", `
+load('lib/images.tilt', 'foo')
+load('lib/bar.tilt', 'bar')
+k8s_yaml(['foo.yaml', 'bar.yaml'])
+`)
+
+	f.load()
+
+	f.assertNextManifest("foo", db(image("gcr.io/foo")))
+	f.assertNextManifest("bar", db(image("gcr.io/bar")))
+}
+
+func TestLoadCycle(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("a.tilt", `load('b.tilt', 'b')
+a = 'a'`)
+	f.file("b.tilt", `load('a.tilt', 'a')
+b = 'b'`)
+	f.file("Tiltfile", `load('a.tilt', 'a')`)
+
+	f.loadErrString("cycle in load()", f.JoinPath("a.tilt")+" -> "+f.JoinPath("b.tilt")+" -> "+f.JoinPath("a.tilt"))
+}
+
+func TestLoadErrorBacktrace(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("lib.tilt", `
+def oops():
+  fail('oops')
+
+oops()
+`)
+	f.file("Tiltfile", `
+load('lib.tilt', 'oops')
+`)
+
+	f.loadErrString(
+		fmt.Sprintf("%s:2: in <toplevel>", f.JoinPath("Tiltfile")),
+		fmt.Sprintf("%s:5: in <toplevel>", f.JoinPath("lib.tilt")),
+		fmt.Sprintf("%s:3: in oops", f.JoinPath("lib.tilt")),
+		"Error: oops")
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+load('nope.tilt', 'fn')
+`)
+
+	f.loadErrString("cannot load nope.tilt", "nope.tilt")
+}
+
 func TestKustomize(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -866,6 +991,10 @@ k8s_yaml('bar.yaml')
 		deployment("foo"))
 
 	f.assertConfigFiles("Tiltfile", ".tiltignore", "foo/Dockerfile", "foo.yaml", "bar/Dockerfile", "bar.yaml")
+
+	if assert.Equal(t, 1, len(f.loadResult.UnmatchedManifests)) {
+		assert.Equal(t, model.ManifestName("bar"), f.loadResult.UnmatchedManifests[0].Name)
+	}
 }
 
 func TestLoadTypoManifest(t *testing.T) {
@@ -881,7 +1010,7 @@ docker_build('gcr.io/bar', 'bar')
 k8s_yaml('bar.yaml')
 `)
 
-	_, err := f.tfl.Load(f.ctx, f.JoinPath("Tiltfile"), matchMap("baz"))
+	_, err := f.tfl.Load(f.ctx, f.JoinPath("Tiltfile"), matchMap("baz"), nil)
 	if assert.Error(t, err) {
 		assert.Equal(t, "Could not find resources: baz. Existing resources in Tiltfile: foo, bar", err.Error())
 	}
@@ -1141,6 +1270,21 @@ docker_build("gcr.io/foo", "foo", cache='/path/to/cache')
 	f.loadErrString("no such file or directory")
 }
 
+func TestK8sJobResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("job.yaml", testyaml.JobYAML)
+	f.file("foo.yaml", testyaml.SanchoYAML)
+	f.file("Tiltfile", `
+k8s_yaml(['job.yaml', 'foo.yaml'])
+`)
+
+	f.load()
+	assert.True(t, f.assertNextManifest("pi").K8sTarget().IsJob)
+	assert.False(t, f.assertNextManifest("sancho").K8sTarget().IsJob)
+}
+
 func TestFilterYamlByLabel(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -2873,6 +3017,351 @@ k8s_resource('hello-foo', port_forwards=8000)
 	f.loadErrString("workload_to_resource_function arg must take 1 argument. wtrf takes 2")
 }
 
+func TestK8SResourceDeps(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("Tiltfile", `
+k8s_resource_assembly_version(2)
+docker_build('gcr.io/foo', 'foo')
+docker_build('gcr.io/bar', 'bar')
+k8s_yaml(['foo.yaml', 'bar.yaml'])
+k8s_resource('bar', resource_deps=['foo'])
+`)
+
+	f.load()
+	foo := f.assertNextManifest("foo")
+	assert.Empty(t, foo.ResourceDependencies)
+	bar := f.assertNextManifest("bar")
+	assert.Equal(t, []model.ManifestName{"foo"}, bar.ResourceDependencies)
+}
+
+func TestK8SResourceDepsUnknownResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("Tiltfile", `
+k8s_resource_assembly_version(2)
+docker_build('gcr.io/foo', 'foo')
+docker_build('gcr.io/bar', 'bar')
+k8s_yaml(['foo.yaml', 'bar.yaml'])
+k8s_resource('bar', resource_deps=['baz'])
+`)
+
+	f.loadErrString("resource bar specified a dependency on unknown resource baz")
+}
+
+func TestK8SResourceDepsCycle(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("Tiltfile", `
+k8s_resource_assembly_version(2)
+docker_build('gcr.io/foo', 'foo')
+docker_build('gcr.io/bar', 'bar')
+k8s_yaml(['foo.yaml', 'bar.yaml'])
+k8s_resource('foo', resource_deps=['bar'])
+k8s_resource('bar', resource_deps=['foo'])
+`)
+
+	f.loadErrString("Found a cycle at target: foo")
+}
+
+func TestK8SResourceDepsNotStrings(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("Tiltfile", `
+k8s_resource_assembly_version(2)
+docker_build('gcr.io/foo', 'foo')
+docker_build('gcr.io/bar', 'bar')
+k8s_yaml(['foo.yaml', 'bar.yaml'])
+k8s_resource('bar', resource_deps=[1])
+`)
+
+	f.loadErrString("resource_deps must be a list of strings")
+}
+
+func TestMaxParallelBuilds(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+max_parallel_builds(3)
+`)
+
+	f.load()
+	assert.Equal(t, 3, f.loadResult.MaxParallelBuilds)
+}
+
+func TestMaxParallelBuildsUnset(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+
+	f.load()
+	assert.Equal(t, 0, f.loadResult.MaxParallelBuilds)
+}
+
+func TestMaxParallelBuildsInvalid(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+max_parallel_builds(0)
+`)
+
+	f.loadErrString("max_parallel_builds: n must be at least 1, got 0")
+}
+
+func TestLocalResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+local_resource('codegen', 'make gen', deps=['foo/api', 'Makefile'], resource_deps=['foo'])
+`)
+
+	f.load()
+	f.assertNextManifest("foo")
+	m := f.assertNextManifest("codegen")
+	assert.True(t, m.IsLocal())
+	assert.Equal(t, []model.ManifestName{"foo"}, m.ResourceDependencies)
+
+	lt := m.LocalTarget()
+	assert.Equal(t, model.ToShellCmd("make gen"), lt.Cmd)
+	assert.Equal(t, f.Path(), lt.Workdir)
+	assert.Equal(t, []string{f.JoinPath("Makefile"), f.JoinPath("foo/api")}, lt.Dependencies())
+}
+
+func TestLocalResourceOnly(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource('hello', 'echo hello')
+`)
+
+	f.load()
+	m := f.assertNextManifest("hello")
+	assert.True(t, m.IsLocal())
+	assert.Empty(t, m.LocalTarget().Dependencies())
+}
+
+func TestLocalResourceDuplicateName(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource('hello', 'echo hello')
+local_resource('hello', 'echo goodbye')
+`)
+
+	f.loadErrString(`local_resource named "hello" already exists`)
+}
+
+func TestLocalResourceConflictsWithK8sResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+local_resource('foo', 'echo hello')
+`)
+
+	f.loadErrString(`local_resource "foo" conflicts with another resource of the same name`)
+}
+
+func TestLocalResourceServeCmd(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource('web', serve_cmd='yarn start', deps=['src'])
+`)
+
+	f.load()
+	m := f.assertNextManifest("web")
+	lt := m.LocalTarget()
+	assert.True(t, lt.Cmd.Empty())
+	assert.Equal(t, model.ToShellCmd("yarn start"), lt.ServeCmd)
+	assert.Equal(t, []string{f.JoinPath("src")}, lt.Dependencies())
+}
+
+func TestLocalResourceNoCmd(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource('web')
+`)
+
+	f.loadErrString("local_resource: must specify at least one of cmd and serve_cmd")
+}
+
+func TestK8SResourceReadinessProbe(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+k8s_resource_assembly_version(2)
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+k8s_resource('foo', readiness_probe=probe(
+  http_get=http_get_action(port=8000, path='/healthz'),
+  period_secs=5))
+`)
+
+	f.load()
+	m := f.assertNextManifest("foo")
+	assert.Equal(t, &model.Probe{
+		Period:  5 * time.Second,
+		Timeout: model.DefaultProbeTimeout,
+		HTTPGet: &model.HTTPGetAction{Host: "localhost", Port: 8000, Path: "/healthz"},
+	}, m.ReadinessProbe)
+}
+
+func TestLocalResourceReadinessProbe(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource('web', serve_cmd='yarn start',
+  readiness_probe=probe(exec=exec_action('curl localhost:3000'), initial_delay_secs=2))
+`)
+
+	f.load()
+	m := f.assertNextManifest("web")
+	assert.Equal(t, &model.Probe{
+		InitialDelay: 2 * time.Second,
+		Period:       model.DefaultProbePeriod,
+		Timeout:      model.DefaultProbeTimeout,
+		Exec:         &model.ExecAction{Command: model.ToShellCmd("curl localhost:3000")},
+	}, m.ReadinessProbe)
+}
+
+func TestProbeNoAction(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+probe(period_secs=5)
+`)
+
+	f.loadErrString("probe: probe must have exactly one action")
+}
+
+func TestProbeTwoActions(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+probe(tcp_socket=tcp_socket_action(8000), exec=exec_action('true'))
+`)
+
+	f.loadErrString("probe: probe must have exactly one action")
+}
+
+func TestReadinessProbeWrongType(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource('web', serve_cmd='yarn start', readiness_probe=http_get_action(8000))
+`)
+
+	f.loadErrString("local_resource: readiness_probe must be a probe; got http_get_action")
+}
+
+func TestTriggerModeDefaultsToAuto(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+
+	f.load()
+	m := f.assertNextManifest("foo")
+	assert.Equal(t, model.TriggerAuto, m.TriggerMode)
+}
+
+func TestTriggerModePerResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("Tiltfile", `
+k8s_resource_assembly_version(2)
+docker_build('gcr.io/foo', 'foo')
+docker_build('gcr.io/bar', 'bar')
+k8s_yaml(['foo.yaml', 'bar.yaml'])
+k8s_resource('foo', trigger_mode=TRIGGER_MODE_MANUAL)
+local_resource('web', serve_cmd='yarn start', trigger_mode=TRIGGER_MODE_MANUAL)
+`)
+
+	f.load()
+	assert.Equal(t, model.TriggerManual, f.assertNextManifest("foo").TriggerMode)
+	assert.Equal(t, model.TriggerAuto, f.assertNextManifest("bar").TriggerMode)
+	assert.Equal(t, model.TriggerManual, f.assertNextManifest("web").TriggerMode)
+}
+
+func TestTriggerModeGlobalDefault(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("Tiltfile", `
+trigger_mode(TRIGGER_MODE_MANUAL)
+k8s_resource_assembly_version(2)
+docker_build('gcr.io/foo', 'foo')
+docker_build('gcr.io/bar', 'bar')
+k8s_yaml(['foo.yaml', 'bar.yaml'])
+k8s_resource('bar', trigger_mode=TRIGGER_MODE_AUTO)
+`)
+
+	f.load()
+	assert.Equal(t, model.TriggerManual, f.assertNextManifest("foo").TriggerMode)
+	assert.Equal(t, model.TriggerAuto, f.assertNextManifest("bar").TriggerMode)
+}
+
+func TestTriggerModeWrongType(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+trigger_mode('manual')
+`)
+
+	f.loadErrString("trigger_mode: trigger_mode must be TRIGGER_MODE_AUTO or TRIGGER_MODE_MANUAL; got string")
+}
+
 type fixture struct {
 	ctx context.Context
 	t   *testing.T
@@ -2881,6 +3370,9 @@ type fixture struct {
 	tfl TiltfileLoader
 	an  *analytics.MemoryAnalytics
 
+	// the args after `--` on the command line
+	tiltfileArgs []string
+
 	loadResult TiltfileLoadResult
 }
 
@@ -2890,7 +3382,7 @@ func newFixture(t *testing.T) *fixture {
 	f := tempdir.NewTempDirFixture(t)
 	an := analytics.NewMemoryAnalytics()
 	dcc := dockercompose.NewDockerComposeClient(docker.Env{})
-	tfl := ProvideTiltfileLoader(an, dcc)
+	tfl := ProvideTiltfileLoader(an, dcc, "docker-for-desktop", k8s.EnvDockerDesktop)
 
 	r := &fixture{
 		ctx:            ctx,
@@ -3014,7 +3506,7 @@ func (f *fixture) load(names ...string) {
 }
 
 func (f *fixture) loadResourceAssemblyV1(names ...string) {
-	tlr, err := f.tfl.Load(f.ctx, f.JoinPath("Tiltfile"), matchMap(names...))
+	tlr, err := f.tfl.Load(f.ctx, f.JoinPath("Tiltfile"), matchMap(names...), f.tiltfileArgs)
 	if err != nil {
 		f.t.Fatal(err)
 	}
@@ -3025,7 +3517,7 @@ func (f *fixture) loadResourceAssemblyV1(names ...string) {
 // Load the manifests, expecting warnings.
 // Warnigns should be asserted later with assertWarnings
 func (f *fixture) loadAllowWarnings(names ...string) {
-	tlr, err := f.tfl.Load(f.ctx, f.JoinPath("Tiltfile"), matchMap(names...))
+	tlr, err := f.tfl.Load(f.ctx, f.JoinPath("Tiltfile"), matchMap(names...), f.tiltfileArgs)
 	if err != nil {
 		f.t.Fatal(err)
 	}
@@ -3050,7 +3542,7 @@ func (f *fixture) loadAssertWarnings(warnings ...string) {
 }
 
 func (f *fixture) loadErrString(msgs ...string) {
-	tlr, err := f.tfl.Load(f.ctx, f.JoinPath("Tiltfile"), nil)
+	tlr, err := f.tfl.Load(f.ctx, f.JoinPath("Tiltfile"), nil, f.tiltfileArgs)
 	if err == nil {
 		f.t.Fatalf("expected error but got nil")
 	}
@@ -0,0 +1,80 @@
+package tiltfile
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"github.com/windmilleng/tilt/internal/model"
+)
+
+// The value of the TRIGGER_MODE_* constants in the Tiltfile.
+type triggerMode int
+
+const (
+	// Not set on this resource; use the Tiltfile default.
+	triggerModeUnset triggerMode = iota
+	triggerModeAuto
+	triggerModeManual
+)
+
+var _ starlark.Value = triggerMode(0)
+
+func (m triggerMode) String() string {
+	switch m {
+	case triggerModeAuto:
+		return triggerModeAutoN
+	case triggerModeManual:
+		return triggerModeManualN
+	default:
+		return "TRIGGER_MODE_UNSET"
+	}
+}
+func (m triggerMode) Type() string          { return "trigger_mode" }
+func (m triggerMode) Freeze()               {}
+func (m triggerMode) Truth() starlark.Bool  { return m != triggerModeUnset }
+func (m triggerMode) Hash() (uint32, error) { return uint32(m), nil }
+
+func (s *tiltfileState) triggerModeFn(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var val starlark.Value
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs, "trigger_mode", &val)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := triggerModeFromStarlarkValue(fn, val)
+	if err != nil {
+		return nil, err
+	}
+	if mode == triggerModeUnset {
+		return nil, fmt.Errorf("%s: trigger_mode must be %s or %s", fn.Name(), triggerModeAutoN, triggerModeManualN)
+	}
+
+	s.triggerMode = mode
+
+	return starlark.None, nil
+}
+
+func triggerModeFromStarlarkValue(fn *starlark.Builtin, v starlark.Value) (triggerMode, error) {
+	switch v := v.(type) {
+	case nil, starlark.NoneType:
+		return triggerModeUnset, nil
+	case triggerMode:
+		return v, nil
+	default:
+		return triggerModeUnset, fmt.Errorf("%s: trigger_mode must be %s or %s; got %s",
+			fn.Name(), triggerModeAutoN, triggerModeManualN, v.Type())
+	}
+}
+
+// The trigger mode of a resource: its own, if it set one, or the
+// Tiltfile default otherwise.
+func (s *tiltfileState) triggerModeForResource(m triggerMode) model.TriggerMode {
+	if m == triggerModeUnset {
+		m = s.triggerMode
+	}
+	if m == triggerModeManual {
+		return model.TriggerManual
+	}
+	return model.TriggerAuto
+}
@@ -0,0 +1,305 @@
+package tiltfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	readYAMLN         = "read_yaml"
+	readYAMLStreamN   = "read_yaml_stream"
+	decodeYAMLN       = "decode_yaml"
+	decodeYAMLStreamN = "decode_yaml_stream"
+	encodeYAMLN       = "encode_yaml"
+	encodeYAMLStreamN = "encode_yaml_stream"
+)
+
+func (s *tiltfileState) readYAML(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return s.readYAMLHelper(fn, args, kwargs, false)
+}
+
+func (s *tiltfileState) readYAMLStream(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return s.readYAMLHelper(fn, args, kwargs, true)
+}
+
+func (s *tiltfileState) readYAMLHelper(fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, stream bool) (starlark.Value, error) {
+	var path starlark.String
+	var defaultValue starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &path, "default?", &defaultValue); err != nil {
+		return nil, err
+	}
+
+	localPath, err := s.localPathFromSkylarkValue(path)
+	if err != nil {
+		return nil, fmt.Errorf("Argument 0 (path): %v", err)
+	}
+
+	contents, err := s.readFile(localPath)
+	if err != nil {
+		// Return the default value if the file doesn't exist AND a default value was given
+		if os.IsNotExist(err) && defaultValue != nil {
+			return defaultValue, nil
+		}
+		return nil, err
+	}
+
+	v, err := decodeYAML(contents, stream)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v in %s", fn.Name(), err, path.GoString())
+	}
+	return v, nil
+}
+
+func (s *tiltfileState) decodeYAML(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return s.decodeYAMLHelper(fn, args, kwargs, false)
+}
+
+func (s *tiltfileState) decodeYAMLStream(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return s.decodeYAMLHelper(fn, args, kwargs, true)
+}
+
+func (s *tiltfileState) decodeYAMLHelper(fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, stream bool) (starlark.Value, error) {
+	var contents starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "yaml", &contents); err != nil {
+		return nil, err
+	}
+
+	var text string
+	switch contents := contents.(type) {
+	case starlark.String:
+		text = contents.GoString()
+	case *blob:
+		text = contents.text
+	default:
+		return nil, fmt.Errorf("%s: yaml must be a string or blob; got %s", fn.Name(), contents.Type())
+	}
+
+	v, err := decodeYAML([]byte(text), stream)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fn.Name(), err)
+	}
+	return v, nil
+}
+
+// Decodes a YAML document, or a list of documents if stream is true.
+func decodeYAML(contents []byte, stream bool) (starlark.Value, error) {
+	var docs []starlark.Value
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	for {
+		var doc orderedYAML
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("YAML parsing error: %v", err)
+		}
+		if doc.value == nil {
+			// an empty document, e.g., a trailing ---
+			continue
+		}
+
+		v, err := convertYAMLToStarlark(doc.value)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, v)
+	}
+
+	if stream {
+		return starlark.NewList(docs), nil
+	}
+
+	switch len(docs) {
+	case 0:
+		return starlark.None, nil
+	case 1:
+		return docs[0], nil
+	default:
+		return nil, fmt.Errorf("expected one YAML document; found %d (use %s for multiple documents)", len(docs), decodeYAMLStreamN)
+	}
+}
+
+// A YAML value whose maps are decoded as MapSlices, so that their keys stay
+// in document order (instead of Go's random map order).
+type orderedYAML struct {
+	value interface{}
+}
+
+func (y *orderedYAML) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	err := unmarshal(&y.value)
+	if err != nil {
+		return err
+	}
+
+	// Once the decoder is decoding into a MapSlice, it decodes all the maps
+	// nested inside as MapSlices too. So we only need to fix up the top level.
+	switch y.value.(type) {
+	case map[interface{}]interface{}:
+		var m yaml.MapSlice
+		err = unmarshal(&m)
+		y.value = m
+	case []interface{}:
+		var elems []orderedYAML
+		err = unmarshal(&elems)
+		values := make([]interface{}, len(elems))
+		for i, elem := range elems {
+			values[i] = elem.value
+		}
+		y.value = values
+	}
+	return err
+}
+
+func convertYAMLToStarlark(y interface{}) (starlark.Value, error) {
+	switch y := y.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(y), nil
+	case string:
+		return starlark.String(y), nil
+	case int:
+		return starlark.MakeInt(y), nil
+	case int64:
+		return starlark.MakeInt64(y), nil
+	case uint64:
+		return starlark.MakeUint64(y), nil
+	case float64:
+		return starlark.Float(y), nil
+	case []interface{}:
+		var values []starlark.Value
+		for _, elem := range y {
+			v, err := convertYAMLToStarlark(elem)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return starlark.NewList(values), nil
+	case yaml.MapSlice:
+		dict := &starlark.Dict{}
+		for _, item := range y {
+			key, err := convertYAMLToStarlark(item.Key)
+			if err != nil {
+				return nil, err
+			}
+			val, err := convertYAMLToStarlark(item.Value)
+			if err != nil {
+				return nil, err
+			}
+			err = dict.SetKey(key, val)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+
+	return nil, fmt.Errorf("Unable to convert YAML to starlark value, unexpected type %T", y)
+}
+
+func (s *tiltfileState) encodeYAML(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var obj starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "obj", &obj); err != nil {
+		return nil, err
+	}
+
+	text, err := encodeYAML(obj)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fn.Name(), err)
+	}
+	return newBlob(text, fmt.Sprintf("Tiltfile %s() call", fn.Name())), nil
+}
+
+// Encodes each element of a list as a YAML document, e.g., to pass a list
+// of Kubernetes objects to k8s_yaml.
+func (s *tiltfileState) encodeYAMLStream(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var objs *starlark.List
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "objs", &objs); err != nil {
+		return nil, err
+	}
+
+	var docs []string
+	for i := 0; i < objs.Len(); i++ {
+		text, err := encodeYAML(objs.Index(i))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fn.Name(), err)
+		}
+		docs = append(docs, text)
+	}
+	return newBlob(strings.Join(docs, "---\n"), fmt.Sprintf("Tiltfile %s() call", fn.Name())), nil
+}
+
+func encodeYAML(v starlark.Value) (string, error) {
+	obj, err := convertStarlarkToYAML(v)
+	if err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func convertStarlarkToYAML(v starlark.Value) (interface{}, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.String:
+		return v.GoString(), nil
+	case starlark.Int:
+		i, ok := v.Int64()
+		if !ok {
+			return nil, fmt.Errorf("int %s is too large", v.String())
+		}
+		return i, nil
+	case starlark.Float:
+		return float64(v), nil
+	case *starlark.List:
+		return convertStarlarkIterableToYAML(v)
+	case starlark.Tuple:
+		return convertStarlarkIterableToYAML(v)
+	case *starlark.Dict:
+		// A MapSlice keeps the keys in the order they were added to the dict.
+		var result yaml.MapSlice
+		for _, item := range v.Items() {
+			key, err := convertStarlarkToYAML(item[0])
+			if err != nil {
+				return nil, err
+			}
+			val, err := convertStarlarkToYAML(item[1])
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, yaml.MapItem{Key: key, Value: val})
+		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("can't convert %s to YAML", v.Type())
+}
+
+func convertStarlarkIterableToYAML(v starlark.Iterable) ([]interface{}, error) {
+	result := []interface{}{}
+	it := v.Iterate()
+	defer it.Done()
+	var elem starlark.Value
+	for it.Next(&elem) {
+		val, err := convertStarlarkToYAML(elem)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, val)
+	}
+	return result, nil
+}
@@ -0,0 +1,184 @@
+package tiltfile
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestReadYAMLAndEncode(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+d = read_yaml('foo.yaml')
+d['metadata']['name'] = 'foo-patched'
+k8s_yaml(encode_yaml(d))
+`)
+
+	f.load()
+
+	f.assertNextManifest("foo",
+		db(image("gcr.io/foo")),
+		deployment("foo-patched"))
+	f.assertConfigFiles("Tiltfile", ".tiltignore", "foo/Dockerfile", "foo.yaml")
+}
+
+func TestReadYAMLStreamAndEncode(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("all.yaml", f.readFile("foo.yaml")+"\n---\n"+f.readFile("bar.yaml")+"\n---\n")
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+docker_build('gcr.io/bar', 'bar')
+objs = read_yaml_stream('all.yaml')
+for o in objs:
+  o['metadata']['labels'] = {'team': 'platform'}
+k8s_yaml(encode_yaml_stream(objs))
+`)
+
+	f.load()
+
+	f.assertNextManifest("foo", deployment("foo"))
+	f.assertNextManifest("bar", deployment("bar"))
+	f.assertConfigFiles("Tiltfile", ".tiltignore", "foo/Dockerfile", "bar/Dockerfile", "all.yaml")
+}
+
+func TestReadYAMLDefault(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+d = read_yaml('missing.yaml', default={'a': 1})
+if d != {'a': 1}:
+  fail('got %s' % d)
+`)
+
+	f.load()
+}
+
+func TestReadYAMLMissing(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `read_yaml('missing.yaml')`)
+
+	f.loadErrString("missing.yaml", "no such file or directory")
+}
+
+func TestDecodeYAML(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+d = decode_yaml("""
+name: foo
+replicas: 3
+ratio: 1.5
+enabled: true
+nothing: null
+ports: [80, 443]
+""")
+expected = {
+  'name': 'foo',
+  'replicas': 3,
+  'enabled': True,
+  'nothing': None,
+  'ports': [80, 443],
+}
+ratio = d.pop('ratio')
+if str(ratio) != '1.5':
+  fail('ratio: expected 1.5; got %s' % ratio)
+if d != expected:
+  fail('expected %s; got %s' % (expected, d))
+`)
+
+	f.load()
+}
+
+func TestDecodeYAMLStream(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+docs = decode_yaml_stream('a: 1\n---\nb: 2\n---\n')
+if docs != [{'a': 1}, {'b': 2}]:
+  fail('got %s' % docs)
+`)
+
+	f.load()
+}
+
+func TestDecodeYAMLMultipleDocuments(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `decode_yaml('a: 1\n---\nb: 2\n')`)
+
+	f.loadErrString("expected one YAML document; found 2 (use decode_yaml_stream for multiple documents)")
+}
+
+func TestDecodeYAMLParseError(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `decode_yaml('a: [1')`)
+
+	f.loadErrString("decode_yaml: YAML parsing error")
+}
+
+func TestEncodeYAMLKeepsKeyOrder(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+y = str(encode_yaml({'kind': 'Service', 'apiVersion': 'v1', 'ports': [80]}))
+expected = 'kind: Service\napiVersion: v1\nports:\n- 80\n'
+if y != expected:
+  fail('expected %r; got %r' % (expected, y))
+`)
+
+	f.load()
+}
+
+func TestDecodeYAMLKeepsKeyOrder(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	// Decode and re-encode enough times that random map order would show up.
+	f.file("Tiltfile", `
+text = 'kind: Service\nmetadata:\n  name: foo\n  labels:\n    z: "1"\n    a: "2"\n    m: "3"\nspec:\n  ports:\n  - port: 80\n    name: http\n    protocol: TCP\napiVersion: v1\n'
+for i in range(20):
+  y = str(encode_yaml(decode_yaml(text)))
+  if y != text:
+    fail('expected %r; got %r' % (text, y))
+
+stream = [{'b': 1, 'a': 2}, {'d': 3, 'c': 4}]
+for i in range(20):
+  y = str(encode_yaml_stream(decode_yaml_stream(encode_yaml_stream(stream))))
+  if y != 'b: 1\na: 2\n---\nd: 3\nc: 4\n':
+    fail('got %r' % y)
+`)
+
+	f.load()
+}
+
+func TestEncodeYAMLUnsupportedType(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `encode_yaml({'f': len})`)
+
+	f.loadErrString("encode_yaml: can't convert builtin_function_or_method to YAML")
+}
+
+func (f *fixture) readFile(path string) string {
+	b, err := ioutil.ReadFile(f.JoinPath(path))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return string(b)
+}
@@ -1,6 +1,11 @@
 import HUD from "./HUD"
+import { applyViewDelta, ViewUpdate } from "./viewUpdate"
 
 // A Websocket that automatically retries.
+//
+// The server sends a snapshot of the view, then only what changed.
+// When we reconnect, we tell the server the last update we got,
+// so that it only sends what we missed.
 
 class AppController {
   url: string
@@ -12,6 +17,11 @@ class AppController {
   component: HUD
   disposed: boolean = false
 
+  // The view as of the last update, and where that update was in the stream.
+  view: any = null
+  streamID: string = ""
+  seq: number = 0
+
   /**
    * @param url The url of the websocket to pull data from
    * @param component The top-level component for the app.
@@ -34,8 +44,16 @@ class AppController {
 
   createNewSocket() {
     this.tryConnectCount++
-    this.socket = new WebSocket(this.url)
+    this.socket = new WebSocket(this.resumeURL())
     this.socket.addEventListener("close", this.onSocketClose.bind(this))
+    this.socket.addEventListener("open", () => {
+      // If nothing changed while we were disconnected, the server won't
+      // send anything, so show the view we already have.
+      if (this.view) {
+        // @ts-ignore
+        this.component.setAppState({ View: this.view })
+      }
+    })
     this.socket.addEventListener("message", event => {
       if (!this.liveSocket) {
         this.loadCount++
@@ -43,12 +61,53 @@ class AppController {
       this.liveSocket = true
       this.tryConnectCount = 0
 
-      let data = JSON.parse(event.data)
+      let update: ViewUpdate = JSON.parse(event.data)
+      let view = this.applyUpdate(update)
+      if (!view) {
+        // We missed an update. Start over from a snapshot.
+        this.view = null
+        this.streamID = ""
+        this.seq = 0
+        if (this.socket) {
+          this.socket.close()
+        }
+        return
+      }
+
       // @ts-ignore
-      this.component.setAppState({ View: data })
+      this.component.setAppState({ View: view })
     })
   }
 
+  resumeURL(): string {
+    if (!this.streamID) {
+      return this.url
+    }
+    let stream = encodeURIComponent(this.streamID)
+    return `${this.url}?stream=${stream}&since=${this.seq}`
+  }
+
+  // Returns the new view, or null if the update doesn't follow
+  // the last one that we got.
+  applyUpdate(update: ViewUpdate): any {
+    if (update.Snapshot) {
+      this.view = update.Snapshot
+    } else if (
+      update.Delta &&
+      this.view &&
+      update.StreamID === this.streamID &&
+      update.Seq === this.seq + 1
+    ) {
+      this.view = applyViewDelta(this.view, update.Delta)
+    } else {
+      return null
+    }
+
+    this.streamID = update.StreamID
+    this.seq = update.Seq
+    return this.view
+  }
+
   dispose() {
     this.disposed = true
     if (this.socket) {
@@ -7,6 +7,8 @@ import Statusbar, { StatusItem } from "./Statusbar"
 import LogPane from "./LogPane"
 import K8sViewPane from "./K8sViewPane"
 import PreviewPane from "./PreviewPane"
+import ResourceControls from "./ResourceControls"
+import { TriggerMode } from "./api"
 import { Map } from "immutable"
 import { Router, Route, Switch, RouteComponentProps } from "react-router-dom"
 import { createBrowserHistory, History, UnregisterCallback } from "history"
@@ -35,9 +37,12 @@ type Resource = {
     PodRestarts: number
     PodUpdateStartTime: string
     YAML: string
+    IsJob: boolean
+    JobStatus: string
   }
   RuntimeStatus: string
   ShowBuildStatus: boolean
+  TriggerMode: TriggerMode
 }
 
 export enum ResourceView {
@@ -155,11 +160,25 @@ class HUD extends Component<HudProps, HudState> {
     let logsRoute = (props: RouteComponentProps<any>) => {
       let name = props.match.params ? props.match.params.name : ""
       let logs = ""
+      let triggerMode = TriggerMode.Auto
+      let isTiltfile = false
       if (view && name !== "") {
         let r = view.Resources.find(r => r.Name === name)
         logs = r ? r.CombinedLog : ""
+        triggerMode = r ? r.TriggerMode : TriggerMode.Auto
+        isTiltfile = r ? r.IsTiltfile : false
       }
-      return <LogPane log={logs} isExpanded={isSidebarClosed} />
+      return (
+        <div className="HUD-pane">
+          <ResourceControls
+            name={name}
+            triggerMode={triggerMode}
+            isTiltfile={isTiltfile}
+            isExpanded={isSidebarClosed}
+          />
+          <LogPane log={logs} isExpanded={isSidebarClosed} />
+        </div>
+      )
     }
 
     let combinedLog = ""
@@ -192,7 +211,15 @@ class HUD extends Component<HudProps, HudState> {
               exact
               path="/"
               render={() => (
-                <LogPane log={combinedLog} isExpanded={isSidebarClosed} />
+                <div className="HUD-pane">
+                  <ResourceControls
+                    name=""
+                    triggerMode={TriggerMode.Auto}
+                    isTiltfile={false}
+                    isExpanded={isSidebarClosed}
+                  />
+                  <LogPane log={combinedLog} isExpanded={isSidebarClosed} />
+                </div>
               )}
             />
             <Route exact path="/r/:name" render={logsRoute} />
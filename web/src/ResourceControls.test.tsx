@@ -0,0 +1,56 @@
+import React from "react"
+import { mount } from "enzyme"
+import ResourceControls from "./ResourceControls"
+import { TriggerMode } from "./api"
+
+it("only shows reload for the all view", () => {
+  const controls = mount(
+    <ResourceControls
+      name=""
+      triggerMode={TriggerMode.Auto}
+      isTiltfile={false}
+      isExpanded={false}
+    />
+  )
+  expect(controls.find("button")).toHaveLength(1)
+  expect(controls.text()).toContain("Reload Tiltfile")
+})
+
+it("disables build in auto mode", () => {
+  const controls = mount(
+    <ResourceControls
+      name="vigoda"
+      triggerMode={TriggerMode.Auto}
+      isTiltfile={false}
+      isExpanded={false}
+    />
+  )
+  let build = controls.find("button").at(0)
+  expect(build.text()).toEqual("Build")
+  expect(build.prop("disabled")).toBe(true)
+})
+
+it("posts a trigger in manual mode", () => {
+  const fetchMock = jest.fn()
+  ;(window as any).fetch = fetchMock
+  const controls = mount(
+    <ResourceControls
+      name="vigoda"
+      triggerMode={TriggerMode.Manual}
+      isTiltfile={false}
+      isExpanded={false}
+    />
+  )
+  controls
+    .find("button")
+    .at(0)
+    .simulate("click")
+
+  let triggerCall = fetchMock.mock.calls.find(call =>
+    call[0].endsWith("/api/trigger")
+  )
+  expect(triggerCall).toBeTruthy()
+  expect(JSON.parse(triggerCall[1].body)).toEqual({
+    manifest_names: ["vigoda"],
+  })
+})
@@ -0,0 +1,81 @@
+import React, { PureComponent } from "react"
+import {
+  TriggerMode,
+  triggerBuild,
+  setTriggerMode,
+  restartResource,
+  reloadTiltfile,
+} from "./api"
+import { incr } from "./analytics"
+import "./ResourceControls.scss"
+
+type ResourceControlsProps = {
+  // The selected resource, if any. Empty for the "All" view.
+  name: string
+  triggerMode: TriggerMode
+  isTiltfile: boolean
+  isExpanded: boolean
+}
+
+class ResourceControls extends PureComponent<ResourceControlsProps> {
+  render() {
+    let classes = `ResourceControls ${
+      this.props.isExpanded ? "ResourceControls--expanded" : ""
+    }`
+    let name = this.props.name
+
+    let reload = (
+      <button
+        className="ResourceControls-button"
+        onClick={() => {
+          incr("ui.interactions.reload_tiltfile")
+          reloadTiltfile()
+        }}
+      >
+        Reload Tiltfile
+      </button>
+    )
+    if (!name || this.props.isTiltfile) {
+      return <nav className={classes}>{reload}</nav>
+    }
+
+    let isManual = this.props.triggerMode === TriggerMode.Manual
+    let nextMode = isManual ? TriggerMode.Auto : TriggerMode.Manual
+
+    return (
+      <nav className={classes}>
+        <button
+          className="ResourceControls-button"
+          disabled={!isManual}
+          onClick={() => {
+            incr("ui.interactions.trigger")
+            triggerBuild(name)
+          }}
+        >
+          Build
+        </button>
+        <button
+          className="ResourceControls-button"
+          onClick={() => {
+            incr("ui.interactions.trigger_mode")
+            setTriggerMode(name, nextMode)
+          }}
+        >
+          {isManual ? "Manual" : "Auto"}
+        </button>
+        <button
+          className="ResourceControls-button"
+          onClick={() => {
+            incr("ui.interactions.restart")
+            restartResource(name)
+          }}
+        >
+          Restart
+        </button>
+        {reload}
+      </nav>
+    )
+  }
+}
+
+export default ResourceControls
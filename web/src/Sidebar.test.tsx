@@ -46,6 +46,26 @@ describe("sidebar", () => {
     expect(sidebar.find("li Link.has-warnings")).toHaveLength(1)
   })
 
+  it("renders job status", () => {
+    let items = oneResourceView().Resources.map((res: any) => {
+      res.BuildHistory[0].Error = ""
+      res.ResourceInfo = { IsJob: true, JobStatus: "Completed" }
+      return new SidebarItem(res)
+    })
+    let sidebar = mount(
+      <MemoryRouter initialEntries={["/"]}>
+        <Sidebar
+          isClosed={false}
+          items={items}
+          selected=""
+          toggleSidebar={null}
+          resourceView={ResourceView.Log}
+        />
+      </MemoryRouter>
+    )
+    expect(sidebar.find("li .resLink-jobStatus").text()).toEqual("completed")
+  })
+
   it("renders list of resources", () => {
     let items = twoResourceView().Resources.map((res: any) => {
       res.BuildHistory[0].Error = ""
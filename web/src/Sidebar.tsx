@@ -10,6 +10,7 @@ class SidebarItem {
   status: string
   hasWarnings: boolean
   hasEndpoints: boolean
+  jobStatus: string
 
   /**
    * Create a pared down SidebarItem from a ResourceView
@@ -19,6 +20,7 @@ class SidebarItem {
     this.status = combinedStatus(res)
     this.hasWarnings = warnings(res).length > 0
     this.hasEndpoints = (res.Endpoints || []).length
+    this.jobStatus = ((res.ResourceInfo || {}).JobStatus || "").toLowerCase()
   }
 }
 
@@ -67,6 +69,9 @@ class Sidebar extends PureComponent<SidebarProps> {
         <li key={item.name}>
           <Link className={classes} to={link}>
             {item.name}
+            {item.jobStatus ? (
+              <span className="resLink-jobStatus">{item.jobStatus}</span>
+            ) : null}
           </Link>
         </li>
       )
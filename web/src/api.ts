@@ -0,0 +1,36 @@
+// Fire-and-forget requests that ask Tilt to act on resources.
+// Tilt picks up the change on its next state update, which
+// we receive over the websocket like any other change.
+
+export enum TriggerMode {
+  Auto = 0,
+  Manual = 1,
+}
+
+const post = (path: string, body?: object): void => {
+  let url = `http://${window.location.host}${path}`
+
+  fetch(url, {
+    method: "post",
+    headers: { "Content-Type": "application/json" },
+    body: body ? JSON.stringify(body) : undefined,
+  })
+}
+
+const triggerBuild = (name: string): void => {
+  post("/api/trigger", { manifest_names: [name] })
+}
+
+const setTriggerMode = (name: string, mode: TriggerMode): void => {
+  post("/api/trigger_mode", { manifest_name: name, trigger_mode: mode })
+}
+
+const restartResource = (name: string): void => {
+  post("/api/restart", { manifest_names: [name] })
+}
+
+const reloadTiltfile = (): void => {
+  post("/api/reload_tiltfile")
+}
+
+export { triggerBuild, setTriggerMode, restartResource, reloadTiltfile }
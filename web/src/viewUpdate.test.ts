@@ -0,0 +1,76 @@
+import { applyFieldDelta, applyViewDelta } from "./viewUpdate"
+
+describe("applyFieldDelta", () => {
+  it("replaces values", () => {
+    expect(applyFieldDelta("pending", { Value: "ok" })).toEqual("ok")
+    expect(applyFieldDelta(["a"], { Value: null })).toEqual(null)
+  })
+
+  it("appends to strings", () => {
+    let result = applyFieldDelta("line 1\n", { Append: "line 2\n" })
+    expect(result).toEqual("line 1\nline 2\n")
+  })
+
+  it("trims strings in UTF-16 code units", () => {
+    // The emoji takes two UTF-16 code units, like the server counts them.
+    let old = "🚀 line 1\nline 2\n"
+    let result = applyFieldDelta(old, { TrimStart: 10, Append: "line 3\n" })
+    expect(result).toEqual("line 2\nline 3\n")
+  })
+
+  it("changes nested fields", () => {
+    let old = { PodName: "fe-abc", PodStatus: "Pending", PodLog: "hi\n" }
+    let result = applyFieldDelta(old, {
+      Fields: {
+        PodName: { Removed: true },
+        PodStatus: { Value: "Running" },
+        PodLog: { Append: "there\n" },
+      },
+    })
+    expect(result).toEqual({ PodStatus: "Running", PodLog: "hi\nthere\n" })
+    expect(old.PodStatus).toEqual("Pending")
+  })
+})
+
+describe("applyViewDelta", () => {
+  let view = {
+    Log: "a\n",
+    LogTimestamps: false,
+    Resources: [
+      { Name: "(Tiltfile)", CombinedLog: "" },
+      { Name: "fe", CombinedLog: "fe 1\n" },
+      { Name: "be", CombinedLog: "" },
+    ],
+  }
+
+  it("changes resources by name", () => {
+    let result = applyViewDelta(view, {
+      Fields: { Log: { Append: "b\n" } },
+      Resources: { fe: { CombinedLog: { Append: "fe 2\n" } } },
+    })
+    expect(result.Log).toEqual("a\nb\n")
+    expect(result.Resources.map((r: any) => r.CombinedLog)).toEqual([
+      "",
+      "fe 1\nfe 2\n",
+      "",
+    ])
+
+    // Unchanged resources keep their identity, so React can skip them.
+    expect(result.Resources[0]).toBe(view.Resources[0])
+    expect(view.Resources[1].CombinedLog).toEqual("fe 1\n")
+  })
+
+  it("adds, removes, and reorders resources", () => {
+    let result = applyViewDelta(view, {
+      ResourceNames: ["(Tiltfile)", "be", "db"],
+      Resources: {
+        db: { Name: { Value: "db" }, CombinedLog: { Value: "db 1\n" } },
+      },
+    })
+    expect(result.Resources).toEqual([
+      { Name: "(Tiltfile)", CombinedLog: "" },
+      { Name: "be", CombinedLog: "" },
+      { Name: "db", CombinedLog: "db 1\n" },
+    ])
+  })
+})
@@ -0,0 +1,73 @@
+// Applies the view updates that the server sends over the websocket.
+// Mirrors the Go types in internal/hud/webview/delta.go.
+
+export type FieldDelta = {
+  Value?: any
+  Removed?: boolean
+  Fields?: { [key: string]: FieldDelta }
+  TrimStart?: number
+  Append?: string
+}
+
+export type ViewDelta = {
+  Fields?: { [key: string]: FieldDelta }
+  ResourceNames?: string[]
+  Resources?: { [name: string]: { [key: string]: FieldDelta } }
+}
+
+export type ViewUpdate = {
+  StreamID: string
+  Seq: number
+  Snapshot?: any
+  Delta?: ViewDelta
+}
+
+function applyFieldDelta(old: any, delta: FieldDelta): any {
+  if ("Value" in delta) {
+    return delta.Value
+  }
+
+  if (delta.Fields) {
+    return applyFields(old || {}, delta.Fields)
+  }
+
+  let oldString = typeof old === "string" ? old : ""
+  return oldString.slice(delta.TrimStart || 0) + (delta.Append || "")
+}
+
+// Returns a copy of obj with the deltas applied, so that React sees the change.
+function applyFields(obj: any, deltas: { [key: string]: FieldDelta }): any {
+  let result = Object.assign({}, obj)
+  Object.keys(deltas).forEach(key => {
+    let delta = deltas[key]
+    if (delta.Removed) {
+      delete result[key]
+    } else {
+      result[key] = applyFieldDelta(result[key], delta)
+    }
+  })
+  return result
+}
+
+// Applies a delta to the view, and returns the new view.
+// Doesn't modify the old view.
+function applyViewDelta(view: any, delta: ViewDelta): any {
+  let result = applyFields(view, delta.Fields || {})
+
+  let oldResources: any[] = view.Resources || []
+  let byName: { [name: string]: any } = {}
+  oldResources.forEach(r => {
+    byName[r.Name] = r
+  })
+
+  let names = delta.ResourceNames || oldResources.map(r => r.Name)
+  let resourceDeltas = delta.Resources || {}
+  result.Resources = names.map(name => {
+    let resource = byName[name] || {}
+    let fields = resourceDeltas[name]
+    return fields ? applyFields(resource, fields) : resource
+  })
+  return result
+}
+
+export { applyFieldDelta, applyViewDelta }